@@ -3,12 +3,17 @@ package pack
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/buildpacks/imgutil"
@@ -17,7 +22,10 @@ import (
 	"github.com/buildpacks/lifecycle/platform"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/volume/mounts"
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/pkg/errors"
 	ignore "github.com/sabhiram/go-gitignore"
 
@@ -27,19 +35,43 @@ import (
 	"github.com/buildpacks/pack/internal/builder"
 	"github.com/buildpacks/pack/internal/buildpack"
 	"github.com/buildpacks/pack/internal/buildpackage"
+	"github.com/buildpacks/pack/internal/cache"
 	internalConfig "github.com/buildpacks/pack/internal/config"
 	"github.com/buildpacks/pack/internal/dist"
+	"github.com/buildpacks/pack/internal/ephemeral"
+	"github.com/buildpacks/pack/internal/gitsource"
 	"github.com/buildpacks/pack/internal/image"
 	"github.com/buildpacks/pack/internal/layer"
 	pname "github.com/buildpacks/pack/internal/name"
+	"github.com/buildpacks/pack/internal/provenance"
+	"github.com/buildpacks/pack/internal/sshagent"
 	"github.com/buildpacks/pack/internal/stack"
 	"github.com/buildpacks/pack/internal/stringset"
 	"github.com/buildpacks/pack/internal/style"
 	"github.com/buildpacks/pack/logging"
 	"github.com/buildpacks/pack/pkg/archive"
+	"github.com/buildpacks/pack/pkg/events"
 	"github.com/buildpacks/pack/project"
 )
 
+const (
+	// baseBuilderNameLabel is set on ephemeral builders to the name of the builder they were created from.
+	baseBuilderNameLabel = "io.buildpacks.pack.base-builder.name"
+
+	// baseBuilderIDLabel is set on ephemeral builders to the identifier of the builder they were created from.
+	baseBuilderIDLabel = "io.buildpacks.pack.base-builder.id"
+)
+
+const (
+	// reservedLabelPrefix is the namespace of the labels the lifecycle sets on app images.
+	reservedLabelPrefix = "io.buildpacks."
+
+	ociTitleLabel    = "org.opencontainers.image.title"
+	ociVersionLabel  = "org.opencontainers.image.version"
+	ociSourceLabel   = "org.opencontainers.image.source"
+	ociLicensesLabel = "org.opencontainers.image.licenses"
+)
+
 const (
 	minLifecycleVersionSupportingCreator = "0.7.4"
 	prevLifecycleVersionSupportingImage  = "0.6.1"
@@ -106,10 +138,23 @@ type BuildOptions struct {
 	// Buildpacks may both read and overwrite these values.
 	Env map[string]string
 
-	// Option only valid if Publish is true
 	// Create an additional image that contains cache=true layers and push it to the registry.
+	// When Publish is false the image is only read from, unless WriteCacheImage is set.
 	CacheImage string
 
+	// When Publish is false, push the cache image given by CacheImage or BuildCache back to the registry
+	// once the build completes. Published builds always write the cache image.
+	WriteCacheImage bool
+
+	// BuildCache selects where build layers are cached, for example a host directory persisted by a CI system
+	// or a volume shared by several apps. When nil, a volume named after Image is used, or CacheImage if it is set.
+	// A build cache declared in the project descriptor is used unless one is provided here.
+	BuildCache *CacheConfig
+
+	// LaunchCache selects where the layers of the previous image are cached for daemon builds.
+	// A launch cache declared in the project descriptor is used unless one is provided here.
+	LaunchCache *CacheConfig
+
 	// Option passed directly to the lifecycle.
 	// If true, publishes Image directly to a registry.
 	// Assumes Image contains a valid registry with credentials
@@ -125,6 +170,10 @@ type BuildOptions struct {
 	// Only trust builders from reputable sources.
 	TrustBuilder bool
 
+	// BuilderTrust limits TrustBuilder to builder images with a trusted digest or signature.
+	// The zero value trusts whichever image the builder name resolves to.
+	BuilderTrust BuilderTrust
+
 	// List of buildpack images or archives to add to a builder.
 	// These buildpacks may overwrite those on the builder if they
 	// share both an ID and Version with a buildpack on the builder.
@@ -165,6 +214,104 @@ type BuildOptions struct {
 
 	// A previous image to set to a particular tag reference, digest reference, or (when performing a daemon build) image ID;
 	PreviousImage string
+
+	// EventSink, when set, receives structured events as the build progresses:
+	// phase boundaries and durations, the detected group, layers restored, reused and exported,
+	// the exported image digest and warnings.
+	EventSink events.Sink
+
+	// DryRun when true resolves the builder, run image and buildpacks and runs detection only.
+	// No image is built or exported, and a builder created to add buildpacks is removed once done.
+	// The resolved configuration, detected group and build plan are reported to EventSink,
+	// with the platform set on the events of each platform when building for several.
+	DryRun bool
+
+	// DebugOnFailure when true keeps the volumes of a failed build and starts an interactive shell
+	// with the mounts, environment and user of the phase that failed.
+	DebugOnFailure bool
+
+	// DebugShellIn and DebugShellOut are the terminal the shell started by DebugOnFailure is connected to.
+	// Both are required with DebugOnFailure.
+	DebugShellIn  io.Reader
+	DebugShellOut io.Writer
+
+	// Platforms are the platforms to build for, in the form os/arch[/variant], e.g. linux/arm64.
+	// The builder and run images for each platform are selected from their manifest lists.
+	// When more than one platform is given Publish is required: the image for each platform is
+	// published under Image suffixed with the platform, e.g. my/app:latest-linux-arm64, and an
+	// image index referencing them is published under Image and AdditionalTags.
+	Platforms []string
+
+	// Output, when set, writes the app image to an OCI image layout instead of leaving it in the daemon.
+	// The image is built with the daemon as usual, so the build cache is kept across builds of the same Image,
+	// and is then removed from the daemon once written. Cannot be combined with Publish.
+	Output *ImageOutput
+
+	// Executor selects how the lifecycle is run. When empty, the lifecycle is run in containers by the docker daemon.
+	Executor ExecutorType
+
+	// Labels are added to the app image once it is exported, along with the labels of the project descriptor
+	// and the org.opencontainers.image.* labels describing its project. Labels given here take precedence.
+	// Labels in the io.buildpacks namespace are reserved for the lifecycle.
+	Labels map[string]string
+
+	// SkipGitMetadata when true does not record the git commit of the app in the project metadata of the app image.
+	// Otherwise, when AppPath is within a git work tree, its remote URL, commit, branch and whether it has
+	// uncommitted changes are recorded, unless the project descriptor declares them.
+	SkipGitMetadata bool
+
+	// CreationTime, when set, is recorded as the creation time of the app image instead of the fixed
+	// time used for reproducible builds. It requires Platform API 0.9 or later and is ignored otherwise.
+	CreationTime *time.Time
+
+	// SignKey is the path to a cosign private key the published image is signed with. The signature is pushed
+	// to the repository of each tag of the image, under the tag cosign uses. Requires Publish.
+	SignKey string
+
+	// SignKeyPassword decrypts SignKey when it is an encrypted cosign key.
+	SignKeyPassword string
+
+	// Provenance configures the in-toto provenance statement recorded for the build.
+	// It is not recorded for dry runs.
+	Provenance ProvenanceOptions
+
+	// Lock configures the project lock, which pins the builder, run image, lifecycle image and buildpacks
+	// of the build. It is not written for dry runs.
+	Lock LockOptions
+}
+
+// ExecutorType selects how the lifecycle is run during a build.
+type ExecutorType string
+
+const (
+	// DockerExecutor runs the lifecycle in containers created from the builder by the docker daemon.
+	DockerExecutor ExecutorType = "docker"
+
+	// LocalExecutor runs the lifecycle installed on the host as a local process, without a docker daemon.
+	// It is meant for builds within a build image, such as in a CI job, and requires Publish.
+	// Builder is ignored: the buildpacks, order and stack of the build image are used.
+	LocalExecutor ExecutorType = "local"
+)
+
+// ImageOutputFormat is a format in which the app image is written to the file system.
+type ImageOutputFormat string
+
+const (
+	// OCILayoutOutput writes the app image to an OCI image layout directory.
+	OCILayoutOutput ImageOutputFormat = "oci-layout"
+
+	// OCIArchiveOutput writes the app image to a tar archive of an OCI image layout.
+	OCIArchiveOutput ImageOutputFormat = "oci-archive"
+)
+
+// ImageOutput is the location on the file system to which the app image is written.
+type ImageOutput struct {
+	Format ImageOutputFormat
+
+	// Path is the layout directory or archive file to write.
+	// An existing layout directory is added to, replacing any image with the same name.
+	// Relative paths are resolved against RelativeBaseDir.
+	Path string
 }
 
 // ProxyConfig specifies proxy setting to be set as environment variables in a container.
@@ -194,6 +341,81 @@ type ContainerConfig struct {
 	// - /layers
 	// - anything below /cnb/**
 	Volumes []string
+
+	// Secrets are files made available, read-only, to the detect and build phases at
+	// /run/secrets/<id>. They are never written to the app image, the build volumes or logs.
+	// Secrets declared in the project descriptor are added unless a secret with the same ID is provided here.
+	Secrets []Secret
+
+	// SSH, when set, forwards an SSH agent to the detect and build phases, with SSH_AUTH_SOCK pointing at its socket.
+	// Private keys are never copied into the build containers.
+	SSH *SSHConfig
+
+	// CPUs limits the number of CPUs available to each lifecycle phase, for example 1.5.
+	// Zero means no limit.
+	CPUs float64
+
+	// Memory limits the memory, in bytes, available to each lifecycle phase.
+	// A phase that exceeds it is killed. Zero means no limit.
+	Memory int64
+
+	// PidsLimit limits the number of processes that may run at once in each lifecycle phase.
+	// Zero means no limit.
+	PidsLimit int64
+
+	// PhaseTimeout stops any lifecycle phase that runs for longer, failing the build.
+	// When the builder is trusted the phases run in a single creator container, and the timeout applies to it as a whole.
+	// Zero means no timeout.
+	PhaseTimeout time.Duration
+}
+
+// CacheFormat is the storage backing a cache.
+type CacheFormat string
+
+const (
+	// VolumeCacheFormat stores the cache in a docker volume.
+	VolumeCacheFormat CacheFormat = "volume"
+
+	// ImageCacheFormat stores the cache as an image in a registry. It is only supported by build caches of published images.
+	ImageCacheFormat CacheFormat = "image"
+
+	// BindCacheFormat stores the cache in a directory on the host.
+	BindCacheFormat CacheFormat = "bind"
+)
+
+// CacheConfig selects the storage of a cache.
+type CacheConfig struct {
+	// Format of the cache, defaults to VolumeCacheFormat.
+	Format CacheFormat
+
+	// Name of the volume or image holding the cache.
+	// For the volume format it defaults to a volume named after the app image.
+	Name string
+
+	// Source is the host directory of a bind cache, it is created if it does not exist.
+	// Relative paths are resolved against RelativeBaseDir.
+	Source string
+}
+
+// Secret is a file containing sensitive data needed during a build, such as a package registry token.
+type Secret struct {
+	// ID names the secret and determines the path at which it is mounted.
+	ID string
+
+	// Source is the path to the file holding the secret.
+	// Relative paths are resolved against RelativeBaseDir.
+	Source string
+}
+
+// SSHConfig selects the SSH agent forwarded to the detect and build phases.
+type SSHConfig struct {
+	// Keys are paths to private keys that are served by an agent running for the duration of the build.
+	// Relative paths are resolved against RelativeBaseDir.
+	Keys []string
+
+	// AgentSocket is the socket of the agent to forward when no Keys are provided.
+	// Defaults to the value of SSH_AUTH_SOCK.
+	AgentSocket string
 }
 
 // Build configures settings for the build container(s) and lifecycle.
@@ -201,10 +423,506 @@ type ContainerConfig struct {
 // If any configuration is deemed invalid, or if any lifecycle phases fail,
 // an error will be returned and no image produced.
 func (c *Client) Build(ctx context.Context, opts BuildOptions) error {
+	if err := validateResourceLimits(opts.ContainerConfig); err != nil {
+		return err
+	}
+	if err := validateLabels(opts); err != nil {
+		return err
+	}
+	if err := validateProvenance(opts); err != nil {
+		return err
+	}
+	if err := validateLock(opts); err != nil {
+		return err
+	}
+	if opts.DebugOnFailure && (opts.DebugShellIn == nil || opts.DebugShellOut == nil) {
+		return errors.New("debug on failure requires a reader and writer for the debug shell")
+	}
+	if err := c.loadPolicy(); err != nil {
+		return err
+	}
+	if err := c.policy.CheckPublish(opts.Publish, append([]string{opts.Image}, opts.AdditionalTags...)...); err != nil {
+		return err
+	}
+
+	var err error
+	if opts.BuildCache, opts.LaunchCache, err = processCaches(opts); err != nil {
+		return err
+	}
+
+	signKey, err := loadSignKey(opts.SignKey, opts.SignKeyPassword, opts.Publish)
+	if err != nil {
+		return err
+	}
+
+	// the image is signed by the digest it was exported with, the last image exported being the image itself
+	// rather than the image of a single platform
+	var exportedDigest string
+	if signKey != nil {
+		digestSink := events.SinkFunc(func(e events.Event) {
+			if e.Type == events.ImageExported {
+				exportedDigest = e.Digest
+			}
+		})
+		if opts.EventSink == nil {
+			opts.EventSink = digestSink
+		} else {
+			opts.EventSink = events.MultiSink{opts.EventSink, digestSink}
+		}
+	}
+
+	if err := c.dispatchBuild(ctx, opts); err != nil {
+		return err
+	}
+
+	if signKey == nil || opts.DryRun {
+		return nil
+	}
+	return c.signImage(ctx, signKey, opts.Image, exportedDigest, opts.AdditionalTags)
+}
+
+// dispatchBuild builds the image with the executor and for the platforms requested.
+func (c *Client) dispatchBuild(ctx context.Context, opts BuildOptions) error {
+	switch opts.Executor {
+	case "", DockerExecutor:
+	case LocalExecutor:
+		return c.buildLocal(ctx, opts)
+	default:
+		return errors.Errorf("executor %s is not supported", style.Symbol(string(opts.Executor)))
+	}
+
+	if opts.Output != nil {
+		return c.buildToOutput(ctx, opts)
+	}
+
+	switch len(opts.Platforms) {
+	case 0:
+		return c.build(ctx, opts, "", "")
+	case 1:
+		return c.build(ctx, opts, opts.Platforms[0], "")
+	default:
+		return c.buildIndex(ctx, opts)
+	}
+}
+
+// buildToOutput builds the image with the daemon and then moves it to the requested output.
+func (c *Client) buildToOutput(ctx context.Context, opts BuildOptions) error {
+	switch {
+	case opts.Publish:
+		return errors.New("output cannot be combined with publish")
+	case len(opts.Platforms) > 1:
+		return errors.New("output does not support building for multiple platforms")
+	case len(opts.AdditionalTags) > 0:
+		return errors.New("output cannot be combined with additional tags")
+	}
+
+	outputPath := opts.Output.Path
+	if outputPath == "" {
+		return errors.New("output path must be specified")
+	}
+	if !filepath.IsAbs(outputPath) {
+		outputPath = filepath.Join(opts.RelativeBaseDir, outputPath)
+	}
+
+	switch opts.Output.Format {
+	case OCILayoutOutput, OCIArchiveOutput:
+	default:
+		return errors.Errorf("output format %s is not supported", style.Symbol(string(opts.Output.Format)))
+	}
+
+	imageRef, err := c.parseTagReference(opts.Image)
+	if err != nil {
+		return errors.Wrapf(err, "invalid image name '%s'", opts.Image)
+	}
+
+	var targetPlatform string
+	if len(opts.Platforms) == 1 {
+		targetPlatform = opts.Platforms[0]
+	}
+
+	// the image is exported to the daemon under a name of its own, so that an image of the daemon is never replaced
+	// or removed, and the caches are named after the image so that they are kept across builds
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return errors.Wrap(err, "generating image name")
+	}
+	exportName := fmt.Sprintf("pack.local/output/%x:latest", suffix)
+	if opts.CacheImage == "" {
+		opts.BuildCache = imageVolumeCache(opts.BuildCache, imageRef, "build")
+	}
+	opts.LaunchCache = imageVolumeCache(opts.LaunchCache, imageRef, "launch")
+
+	if !opts.DryRun {
+		defer c.docker.ImageRemove(context.Background(), exportName, types.ImageRemoveOptions{Force: true, PruneChildren: true})
+	}
+	if err := c.build(ctx, opts, targetPlatform, exportName); err != nil {
+		return err
+	}
+	if opts.DryRun {
+		return nil
+	}
+
+	if err := c.saveDaemonImage(ctx, exportName, imageRef, opts.Output.Format, outputPath); err != nil {
+		return errors.Wrapf(err, "writing image to %s", style.Symbol(outputPath))
+	}
+
+	c.logger.Infof("Saved image %s to %s", style.Symbol(imageRef.Name()), style.Symbol(string(opts.Output.Format)+":"+outputPath))
+	return nil
+}
+
+// dockerHost returns the daemon address lifecycle containers are given access to.
+// Without an explicit address, the socket of the resolved daemon is used if it is not the default one.
+func (c *Client) dockerHost(dockerHost string) string {
+	if dockerHost == "" {
+		return c.daemonAccessHost
+	}
+	return dockerHost
+}
+
+// buildLocal runs the lifecycle of the build image pack runs in and publishes the image, without using the docker daemon.
+func (c *Client) buildLocal(ctx context.Context, opts BuildOptions) error {
+	if err := validateLocalBuild(opts); err != nil {
+		return err
+	}
+
+	imageRef, err := c.parseTagReference(opts.Image)
+	if err != nil {
+		return errors.Wrapf(err, "invalid image name '%s'", opts.Image)
+	}
+
+	appPath, err := c.processAppPath(opts.AppPath)
+	if err != nil {
+		return errors.Wrapf(err, "invalid app path '%s'", opts.AppPath)
+	}
+
+	fileFilter, err := getFileFilter(opts.ProjectDescriptor)
+	if err != nil {
+		return err
+	}
+
+	buildCache, err := lifecycleCache(opts.BuildCache)
+	if err != nil {
+		return err
+	}
+	if buildCache != nil && buildCache.Type == cache.Bind {
+		fileFilter = excludePaths(fileFilter, appPath, buildCache.Source)
+	}
+
+	runImageName, err := c.checkLocalBuildPolicy(ctx, imageRef, opts.RunImage, opts.AdditionalMirrors)
+	if err != nil {
+		return err
+	}
+	if runImageName != "" {
+		if runImageName, err = pname.TranslateRegistry(runImageName, c.registryMirrors, c.logger); err != nil {
+			return err
+		}
+	}
+
+	env := map[string]string{}
+	for _, envVar := range opts.ProjectDescriptor.Build.Env {
+		env[envVar.Name] = envVar.Value
+	}
+	for k, v := range opts.Env {
+		env[k] = v
+	}
+
+	proxyConfig := c.processProxyConfig(opts.ProxyConfig)
+	lifecycleOpts := build.LifecycleOptions{
+		AppPath:            appPath,
+		Image:              imageRef,
+		RunImage:           runImageName,
+		ProjectMetadata:    c.projectMetadata(appPath, opts),
+		ClearCache:         opts.ClearCache,
+		Publish:            true,
+		CacheImage:         opts.CacheImage,
+		BuildCache:         buildCache,
+		HTTPProxy:          proxyConfig.HTTPProxy,
+		HTTPSProxy:         proxyConfig.HTTPSProxy,
+		NoProxy:            proxyConfig.NoProxy,
+		AdditionalTags:     opts.AdditionalTags,
+		DefaultProcessType: opts.DefaultProcessType,
+		FileFilter:         fileFilter,
+		GID:                opts.GroupID,
+		PreviousImage:      opts.PreviousImage,
+		EventSink:          opts.EventSink,
+		PhaseTimeout:       opts.ContainerConfig.PhaseTimeout,
+		Env:                env,
+		CreationTime:       opts.CreationTime,
+	}
+
+	if err := c.localExecutor.Execute(ctx, lifecycleOpts); err != nil {
+		return errors.Wrap(err, "executing lifecycle")
+	}
+
+	labelledDigest, err := c.labelImage(ctx, true, imageRef, opts.AdditionalTags, imageLabels(opts))
+	if err != nil {
+		return err
+	}
+	return c.logImageNameAndSha(ctx, true, imageRef, imageRef, opts.EventSink, labelledDigest)
+}
+
+// validateLocalBuild rejects the options which require the docker daemon or a builder image.
+func validateLocalBuild(opts BuildOptions) error {
+	if !opts.Publish {
+		return errors.New("local executor requires publishing the image")
+	}
+
+	unsupported := map[string]bool{
+		"output":                     opts.Output != nil,
+		"platforms":                  len(opts.Platforms) > 0,
+		"dry run":                    opts.DryRun,
+		"debug on failure":           opts.DebugOnFailure,
+		"additional buildpacks":      len(opts.Buildpacks) > 0 || len(opts.ProjectDescriptor.Build.Buildpacks) > 0,
+		"launch cache":               opts.LaunchCache != nil,
+		"volumes":                    len(opts.ContainerConfig.Volumes) > 0,
+		"network":                    opts.ContainerConfig.Network != "",
+		"secrets":                    len(opts.ContainerConfig.Secrets) > 0,
+		"ssh":                        opts.ContainerConfig.SSH != nil,
+		"cpu, memory and pid limits": opts.ContainerConfig.CPUs > 0 || opts.ContainerConfig.Memory > 0 || opts.ContainerConfig.PidsLimit > 0,
+		"provenance":                 opts.Provenance.requested(),
+		"project lock":               opts.Lock.File != "",
+	}
+	var names []string
+	for name, set := range unsupported {
+		if set {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return errors.Errorf("local executor does not support %s", strings.Join(names, ", "))
+	}
+
+	if opts.BuildCache != nil && opts.BuildCache.Format == VolumeCacheFormat {
+		return errors.New("local executor does not support volume caches")
+	}
+	return nil
+}
+
+// imageVolumeCache returns cfg, or a volume cache named after imageRef when cfg is a volume cache without a name.
+func imageVolumeCache(cfg *CacheConfig, imageRef name.Reference, suffix string) *CacheConfig {
+	named := CacheConfig{Format: VolumeCacheFormat}
+	if cfg != nil {
+		if cfg.Format != VolumeCacheFormat || cfg.Name != "" {
+			return cfg
+		}
+		named = *cfg
+	}
+	named.Name = cache.NewVolumeCache(imageRef, suffix, nil).Name()
+	return &named
+}
+
+// saveDaemonImage writes the daemon image daemonName to path in the given format, naming it after ref.
+func (c *Client) saveDaemonImage(ctx context.Context, daemonName string, ref name.Reference, format ImageOutputFormat, path string) error {
+	return c.withDaemonImage(ctx, daemonName, func(img v1.Image) error {
+		if format == OCIArchiveOutput {
+			return image.WriteLayoutArchive(path, img, ref.Name())
+		}
+		return image.WriteLayout(path, img, ref.Name())
+	})
+}
+
+// withDaemonImage calls fn with the daemon image daemonName.
+func (c *Client) withDaemonImage(ctx context.Context, daemonName string, fn func(img v1.Image) error) error {
+	tmpDir, err := ioutil.TempDir("", "pack-output")
+	if err != nil {
+		return errors.Wrap(err, "creating temp dir")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// the image is saved to a file first, rather than being buffered in memory
+	rc, err := c.docker.ImageSave(ctx, []string{daemonName})
+	if err != nil {
+		return errors.Wrap(err, "saving image")
+	}
+	defer rc.Close()
+
+	tarPath := filepath.Join(tmpDir, "image.tar")
+	f, err := os.Create(tarPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, rc); err != nil {
+		return errors.Wrap(err, "saving image")
+	}
+
+	img, err := tarball.ImageFromPath(tarPath, nil)
+	if err != nil {
+		return errors.Wrap(err, "reading saved image")
+	}
+
+	return fn(img)
+}
+
+// buildIndex builds the image for each of the requested platforms, publishes each by digest
+// and publishes an image index referencing them under the image name and additional tags.
+func (c *Client) buildIndex(ctx context.Context, opts BuildOptions) error {
+	if !opts.Publish && !opts.DryRun {
+		return errors.New("building for multiple platforms requires publishing the image")
+	}
+
+	imageRef, err := c.parseTagReference(opts.Image)
+	if err != nil {
+		return errors.Wrapf(err, "invalid image name '%s'", opts.Image)
+	}
+
+	var additionalTags []name.Tag
+	for _, tag := range opts.AdditionalTags {
+		tagRef, err := name.NewTag(tag, name.WeakValidation)
+		if err != nil {
+			return errors.Wrapf(err, "invalid additional tag '%s'", tag)
+		}
+		additionalTags = append(additionalTags, tagRef)
+	}
+
+	// each platform is exported to the daemon under a name of its own and published by digest,
+	// so that the tags of the index are the only tags published
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return errors.Wrap(err, "generating image name")
+	}
+
+	seen := map[string]bool{}
+	var manifests []image.IndexManifest
+	for _, platform := range opts.Platforms {
+		p, err := image.ParsePlatform(platform)
+		if err != nil {
+			return err
+		}
+		if seen[image.PlatformSuffix(p)] {
+			return errors.Errorf("platform %s is specified more than once", style.Symbol(platform))
+		}
+		seen[image.PlatformSuffix(p)] = true
+
+		// the caches of each platform are named after the platform, so that those of one platform are never used for another
+		platformRef, err := name.ParseReference(image.PlatformTag(imageRef, p), name.WeakValidation)
+		if err != nil {
+			return err
+		}
+
+		platformOpts := opts
+		platformOpts.Publish = false
+		platformOpts.WriteCacheImage = opts.WriteCacheImage || opts.Publish
+		platformOpts.AdditionalTags = nil
+		if opts.CacheImage != "" {
+			cacheRef, err := name.ParseReference(opts.CacheImage, name.WeakValidation)
+			if err != nil {
+				return errors.Wrapf(err, "invalid cache image name '%s'", opts.CacheImage)
+			}
+			platformOpts.CacheImage = image.PlatformTag(cacheRef, p)
+		}
+		if platformOpts.BuildCache, err = platformCache(opts.BuildCache, p); err != nil {
+			return err
+		}
+		if platformOpts.LaunchCache, err = platformCache(opts.LaunchCache, p); err != nil {
+			return err
+		}
+		if opts.CacheImage == "" {
+			platformOpts.BuildCache = imageVolumeCache(platformOpts.BuildCache, platformRef, "build")
+		}
+		platformOpts.LaunchCache = imageVolumeCache(platformOpts.LaunchCache, platformRef, "launch")
+
+		if opts.EventSink != nil {
+			platformOpts.EventSink = platformSink(opts.EventSink, platform)
+		}
+
+		exportName := fmt.Sprintf("pack.local/index/%x:%s", suffix, image.PlatformSuffix(p))
+		if !opts.DryRun {
+			defer c.docker.ImageRemove(context.Background(), exportName, types.ImageRemoveOptions{Force: true, PruneChildren: true})
+		}
+
+		c.logger.Infof("Building for platform %s", style.Symbol(platform))
+		if err := c.build(ctx, platformOpts, platform, exportName); err != nil {
+			return errors.Wrapf(err, "building for platform %s", style.Symbol(platform))
+		}
+
+		if opts.DryRun {
+			continue
+		}
+
+		var digest name.Digest
+		if err := c.withDaemonImage(ctx, exportName, func(img v1.Image) error {
+			digest, err = image.WriteManifest(ctx, authn.DefaultKeychain, imageRef.Context(), img)
+			return err
+		}); err != nil {
+			return errors.Wrapf(err, "publishing image for platform %s", style.Symbol(platform))
+		}
+		manifests = append(manifests, image.IndexManifest{Ref: digest, Platform: p})
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+
+	digest, err := image.WriteIndex(ctx, authn.DefaultKeychain, imageRef, manifests, additionalTags...)
+	if err != nil {
+		return err
+	}
+
+	events.Emit(opts.EventSink, events.Event{Type: events.ImageExported, Image: imageRef.Name(), Digest: digest.String()})
+	imgNameAndSha := fmt.Sprintf("%s@%s", imageRef.Context().Name(), digest)
+	if !logging.IsQuiet(c.logger) {
+		c.logger.Infof("Published image index %s", style.Symbol(imgNameAndSha))
+		return nil
+	}
+	if events.WritesOutput(opts.EventSink) {
+		return nil
+	}
+
+	// Access the logger's Writer directly to bypass ReportSuccessfulQuietBuild mode
+	_, err = c.logger.Writer().Write([]byte(imgNameAndSha + "\n"))
+	return err
+}
+
+// platformSink returns a sink which sends the events to sink with their platform set.
+func platformSink(sink events.Sink, platform string) events.Sink {
+	return events.SinkFunc(func(e events.Event) {
+		e.Platform = platform
+		sink.Emit(e)
+	})
+}
+
+// platformCache returns the cache to use when building for platform, so that the layers cached
+// for one platform are never restored for another. Volumes named after the image are named after the platform by buildIndex.
+func platformCache(cfg *CacheConfig, platform v1.Platform) (*CacheConfig, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	platformCfg := *cfg
+	switch cfg.Format {
+	case ImageCacheFormat:
+		ref, err := name.ParseReference(cfg.Name, name.WeakValidation)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cache image name '%s'", cfg.Name)
+		}
+		platformCfg.Name = image.PlatformTag(ref, platform)
+	case BindCacheFormat:
+		platformCfg.Source = filepath.Join(cfg.Source, image.PlatformSuffix(platform))
+	default:
+		if cfg.Name != "" {
+			platformCfg.Name = cfg.Name + "-" + image.PlatformSuffix(platform)
+		}
+	}
+	return &platformCfg, nil
+}
+
+// build builds the image for a single platform. When targetPlatform is empty, the platform of the builder is used.
+// When exportName is set, the image is exported under that name rather than opts.Image.
+func (c *Client) build(ctx context.Context, opts BuildOptions, targetPlatform, exportName string) error {
+	startedOn := time.Now()
 	imageRef, err := c.parseTagReference(opts.Image)
 	if err != nil {
 		return errors.Wrapf(err, "invalid image name '%s'", opts.Image)
 	}
+	exportRef := imageRef
+	if exportName != "" {
+		if exportRef, err = name.ParseReference(exportName, name.WeakValidation); err != nil {
+			return errors.Wrapf(err, "invalid image name '%s'", exportName)
+		}
+	}
 
 	appPath, err := c.processAppPath(opts.AppPath)
 	if err != nil {
@@ -218,32 +936,102 @@ func (c *Client) Build(ctx context.Context, opts BuildOptions) error {
 		return errors.Wrapf(err, "invalid builder '%s'", opts.Builder)
 	}
 
-	rawBuilderImage, err := c.imageFetcher.Fetch(ctx, builderRef.Name(), image.FetchOptions{Daemon: true, PullPolicy: opts.PullPolicy})
+	lock, err := c.readLock(opts)
+	if err != nil {
+		return err
+	}
+	if lock.replaying() {
+		if builderRef, err = lock.pinImage("builder", lock.locked.Builder, builderRef); err != nil {
+			return err
+		}
+	}
+
+	rawBuilderImage, err := c.imageFetcher.Fetch(ctx, builderRef.Name(), image.FetchOptions{Daemon: true, PullPolicy: opts.PullPolicy, Platform: targetPlatform})
 	if err != nil {
 		return errors.Wrapf(err, "failed to fetch builder image '%s'", builderRef.Name())
 	}
 
+	if err := validatePlatform(rawBuilderImage, targetPlatform); err != nil {
+		return errors.Wrapf(err, "invalid builder %s", style.Symbol(opts.Builder))
+	}
+
+	if lock.recording() {
+		if lock.resolved.Builder, err = c.lockedImage(ctx, builderRef, rawBuilderImage); err != nil {
+			return err
+		}
+	}
+
+	if err := c.policy.CheckBuilder(builderRef, c.imageDigests(ctx, rawBuilderImage)); err != nil {
+		return err
+	}
+
+	trustBuilder, err := c.isBuilderTrusted(ctx, opts, builderRef, rawBuilderImage)
+	if err != nil {
+		return err
+	}
+
 	bldr, err := c.getBuilder(rawBuilderImage)
 	if err != nil {
 		return errors.Wrapf(err, "invalid builder %s", style.Symbol(opts.Builder))
 	}
 
+	if err := c.policy.CheckLifecycle(semverOf(bldr.LifecycleDescriptor().Info.Version)); err != nil {
+		return err
+	}
+
 	runImageName := c.resolveRunImage(opts.RunImage, imageRef.Context().RegistryStr(), builderRef.Context().RegistryStr(), bldr.Stack(), opts.AdditionalMirrors, opts.Publish)
-	runImage, err := c.validateRunImage(ctx, runImageName, opts.PullPolicy, opts.Publish, bldr.StackID)
+	if lock.replaying() {
+		if runImageName, err = lock.pinImageName("run image", lock.locked.RunImage, runImageName); err != nil {
+			return err
+		}
+	}
+
+	runImage, err := c.validateRunImage(ctx, runImageName, opts.PullPolicy, opts.Publish, bldr.StackID, targetPlatform)
 	if err != nil {
 		return errors.Wrapf(err, "invalid run-image '%s'", runImageName)
 	}
 
+	if lock.recording() {
+		runImageRef, err := name.ParseReference(runImageName, name.WeakValidation)
+		if err != nil {
+			return errors.Wrapf(err, "invalid run-image '%s'", runImageName)
+		}
+		if lock.resolved.RunImage, err = c.lockedImage(ctx, runImageRef, runImage); err != nil {
+			return err
+		}
+	}
+
+	if err := c.checkRunImagePolicy(ctx, runImageName, runImage); err != nil {
+		return err
+	}
+
+	// when publishing, the exporter resolves the run image itself, so the image for the platform is pinned by digest
+	if targetPlatform != "" && opts.Publish {
+		if id, err := runImage.Identifier(); err == nil {
+			if digestID, ok := id.(remote.DigestIdentifier); ok {
+				runImageName = digestID.Digest.String()
+			}
+		}
+	}
+	// in the daemon, the image for another platform than that of the image of the same name is kept under a name of its own
+	if targetPlatform != "" && !opts.Publish {
+		runImageName = runImage.Name()
+	}
+
 	var runMixins []string
 	if _, err := dist.GetLabel(runImage, stack.MixinsLabel, &runMixins); err != nil {
 		return err
 	}
 
-	fetchedBPs, order, err := c.processBuildpacks(ctx, bldr.Image(), bldr.Buildpacks(), bldr.Order(), bldr.StackID, opts)
+	fetchedBPs, order, err := c.processBuildpacks(ctx, bldr.Image(), bldr.Buildpacks(), bldr.Order(), bldr.StackID, opts, lock)
 	if err != nil {
 		return err
 	}
 
+	if err := c.policy.CheckBuildpacks(buildpackIDs(bldr.Buildpacks(), fetchedBPs...)...); err != nil {
+		return err
+	}
+
 	if err := c.validateMixins(fetchedBPs, bldr, runImageName, runMixins); err != nil {
 		return errors.Wrap(err, "validating stack mixins")
 	}
@@ -257,11 +1045,14 @@ func (c *Client) Build(ctx context.Context, opts BuildOptions) error {
 		buildEnvs[k] = v
 	}
 
-	ephemeralBuilder, err := c.createEphemeralBuilder(rawBuilderImage, buildEnvs, order, fetchedBPs)
+	// a dry run does not leave a builder behind
+	ephemeralBuilder, created, err := c.createEphemeralBuilder(ctx, rawBuilderImage, order, fetchedBPs, !opts.DryRun)
 	if err != nil {
 		return err
 	}
-	defer c.docker.ImageRemove(context.Background(), ephemeralBuilder.Name(), types.ImageRemoveOptions{Force: true})
+	if created {
+		defer c.docker.ImageRemove(context.Background(), ephemeralBuilder.Name(), types.ImageRemoveOptions{Force: true, PruneChildren: true})
+	}
 
 	builderPlatformAPIs := append(
 		ephemeralBuilder.LifecycleDescriptor().APIs.Platform.Deprecated,
@@ -286,6 +1077,7 @@ func (c *Client) Build(ctx context.Context, opts BuildOptions) error {
 
 	for _, warning := range warnings {
 		c.logger.Warn(warning)
+		events.Emit(opts.EventSink, events.Event{Type: events.Warning, Message: warning})
 	}
 
 	fileFilter, err := getFileFilter(opts.ProjectDescriptor)
@@ -293,31 +1085,76 @@ func (c *Client) Build(ctx context.Context, opts BuildOptions) error {
 		return err
 	}
 
-	version := opts.ProjectDescriptor.Project.Version
-	sourceURL := opts.ProjectDescriptor.Project.SourceURL
+	secrets, err := processSecrets(imgOS, opts)
+	if err != nil {
+		return err
+	}
+	buildCache, err := lifecycleCache(opts.BuildCache)
+	if err != nil {
+		return err
+	}
+	launchCache, err := lifecycleCache(opts.LaunchCache)
+	if err != nil {
+		return err
+	}
+
+	var excluded []string
+	for _, secret := range secrets {
+		excluded = append(excluded, secret.Source)
+	}
+	for _, c := range []*build.CacheOptions{buildCache, launchCache} {
+		if c != nil && c.Type == cache.Bind {
+			excluded = append(excluded, c.Source)
+		}
+	}
+	fileFilter = excludePaths(fileFilter, appPath, excluded...)
+
+	sshAgent, err := startSSHAgent(imgOS, opts)
+	if err != nil {
+		return err
+	}
+	if sshAgent != nil {
+		defer sshAgent.Close()
+	}
+
 	runImageName, err = pname.TranslateRegistry(runImageName, c.registryMirrors, c.logger)
 	if err != nil {
 		return err
 	}
 
+	projectMetadata := c.projectMetadata(appPath, opts)
+
+	events.Emit(opts.EventSink, events.Event{
+		Type:             events.BuildResolved,
+		Builder:          builderRef.Name(),
+		RunImage:         runImageName,
+		RunImageMirrors:  append(append([]string{}, bldr.Stack().RunImage.Mirrors...), opts.AdditionalMirrors[bldr.Stack().RunImage.Image]...),
+		Order:            orderForEvent(ephemeralBuilder.Order()),
+		Buildpacks:       buildpacksForEvent(fetchedBPs),
+		CreationTime:     opts.CreationTime,
+		BuilderDigest:    imageDigest(rawBuilderImage),
+		RunImageDigest:   imageDigest(runImage),
+		LifecycleVersion: ephemeralBuilder.LifecycleDescriptor().Info.Version.String(),
+		Tags:             opts.AdditionalTags,
+	})
+
 	lifecycleOpts := build.LifecycleOptions{
-		AppPath:        appPath,
-		Image:          imageRef,
-		Builder:        ephemeralBuilder,
-		LifecycleImage: ephemeralBuilder.Name(),
-		RunImage:       runImageName,
-		ProjectMetadata: platform.ProjectMetadata{Source: &platform.ProjectSource{
-			Type:     "project",
-			Version:  map[string]interface{}{"declared": version},
-			Metadata: map[string]interface{}{"url": sourceURL},
-		}},
+		AppPath:            appPath,
+		Image:              exportRef,
+		Builder:            ephemeralBuilder,
+		LifecycleImage:     ephemeralBuilder.Name(),
+		RunImage:           runImageName,
+		ProjectMetadata:    projectMetadata,
 		ProjectPath:        "",
 		ClearCache:         opts.ClearCache,
 		Publish:            opts.Publish,
-		TrustBuilder:       opts.TrustBuilder,
+		TrustBuilder:       trustBuilder,
 		UseCreator:         false,
-		DockerHost:         opts.DockerHost,
+		DockerHost:         c.dockerHost(opts.DockerHost),
 		CacheImage:         opts.CacheImage,
+		CacheImageReadOnly: !opts.Publish && !opts.WriteCacheImage,
+		BuildCache:         buildCache,
+		LaunchCache:        launchCache,
 		HTTPProxy:          proxyConfig.HTTPProxy,
 		HTTPSProxy:         proxyConfig.HTTPSProxy,
 		NoProxy:            proxyConfig.NoProxy,
@@ -329,6 +1166,52 @@ func (c *Client) Build(ctx context.Context, opts BuildOptions) error {
 		Workspace:          opts.Workspace,
 		GID:                opts.GroupID,
 		PreviousImage:      opts.PreviousImage,
+		EventSink:          opts.EventSink,
+		DebugOnFailure:     opts.DebugOnFailure,
+		DebugShellIn:       opts.DebugShellIn,
+		DebugShellOut:      opts.DebugShellOut,
+		Secrets:            secrets,
+		CPUs:               opts.ContainerConfig.CPUs,
+		Memory:             opts.ContainerConfig.Memory,
+		PidsLimit:          opts.ContainerConfig.PidsLimit,
+		PhaseTimeout:       opts.ContainerConfig.PhaseTimeout,
+		Env:                buildEnvs,
+		CreationTime:       opts.CreationTime,
+	}
+
+	if sshAgent != nil {
+		lifecycleOpts.SSHAuthSock = sshAgent.Socket()
+	}
+
+	invoker := opts.Provenance.Invoker
+	if invoker == "" {
+		invoker = currentInvoker()
+	}
+	provenanceInputs := provenance.Inputs{
+		Image:            imageRef.Name(),
+		AdditionalTags:   opts.AdditionalTags,
+		Publish:          opts.Publish,
+		Platform:         targetPlatform,
+		Builder:          provenance.Image{Name: builderRef.Name(), Digest: imageDigest(rawBuilderImage)},
+		RunImage:         provenance.Image{Name: runImageName, Digest: imageDigest(runImage)},
+		LifecycleVersion: ephemeralBuilder.LifecycleDescriptor().Info.Version.String(),
+		Order:            orderForProvenance(ephemeralBuilder),
+		Source:           sourceForProvenance(projectMetadata),
+		EnvKeys:          envKeys(buildEnvs),
+		Invoker:          invoker,
+		PackVersion:      Version,
+		StartedOn:        startedOn,
+	}
+
+	if opts.DryRun {
+		lifecycleOpts.DryRun = true
+		// detection runs in the builder, so there is no need to fetch a lifecycle image
+		if err := c.lifecycleExecutor.Execute(ctx, lifecycleOpts); err != nil {
+			return errors.Wrap(err, "executing lifecycle")
+		}
+
+		c.logger.Info("Dry run complete, skipping build and export")
+		return nil
 	}
 
 	lifecycleVersion := ephemeralBuilder.LifecycleDescriptor().Info.Version
@@ -336,22 +1219,29 @@ func (c *Client) Build(ctx context.Context, opts BuildOptions) error {
 	// have bugs that make using the creator problematic.
 	lifecycleSupportsCreator := !lifecycleVersion.LessThan(semver.MustParse(minLifecycleVersionSupportingCreator))
 
-	if lifecycleSupportsCreator && opts.TrustBuilder {
+	if lifecycleSupportsCreator && trustBuilder {
 		lifecycleOpts.UseCreator = true
 		// no need to fetch a lifecycle image, it won't be used
 		if err := c.lifecycleExecutor.Execute(ctx, lifecycleOpts); err != nil {
 			return errors.Wrap(err, "executing lifecycle")
 		}
-
-		return c.logImageNameAndSha(ctx, opts.Publish, imageRef)
+		return c.completeBuild(ctx, opts, imageRef, exportRef, provenanceInputs, lock)
 	}
 
-	if !opts.TrustBuilder {
+	if !trustBuilder {
 		if lifecycleImageSupported(imgOS, lifecycleVersion) {
 			lifecycleImageName := opts.LifecycleImage
 			if lifecycleImageName == "" {
 				lifecycleImageName = fmt.Sprintf("%s:%s", internalConfig.DefaultLifecycleImageRepo, lifecycleVersion.String())
 			}
+			if lock.replaying() {
+				// the lifecycle image is only used for untrusted builders, so a lock written for a trusted builder has none
+				if lock.locked.LifecycleImage == nil {
+					c.logger.Warnf("Lifecycle image %s is not in the project lock %s", style.Symbol(lifecycleImageName), style.Symbol(lock.path))
+				} else if lifecycleImageName, err = lock.pinImageName("lifecycle image", lock.locked.LifecycleImage, lifecycleImageName); err != nil {
+					return err
+				}
+			}
 
 			imgArch, err := rawBuilderImage.Architecture()
 			if err != nil {
@@ -367,6 +1257,16 @@ func (c *Client) Build(ctx context.Context, opts BuildOptions) error {
 				return errors.Wrap(err, "fetching lifecycle image")
 			}
 
+			if lock.recording() {
+				lifecycleImageRef, err := name.ParseReference(lifecycleImageName, name.WeakValidation)
+				if err != nil {
+					return errors.Wrapf(err, "invalid lifecycle image %s", style.Symbol(lifecycleImageName))
+				}
+				if lock.resolved.LifecycleImage, err = c.lockedImage(ctx, lifecycleImageRef, lifecycleImage); err != nil {
+					return err
+				}
+			}
+
 			lifecycleOpts.LifecycleImage = lifecycleImage.Name()
 		} else {
 			return errors.Errorf("Lifecycle %s does not have an associated lifecycle image. Builder must be trusted.", lifecycleVersion.String())
@@ -376,8 +1276,26 @@ func (c *Client) Build(ctx context.Context, opts BuildOptions) error {
 	if err := c.lifecycleExecutor.Execute(ctx, lifecycleOpts); err != nil {
 		return errors.Wrap(err, "executing lifecycle. This may be the result of using an untrusted builder")
 	}
+	return c.completeBuild(ctx, opts, imageRef, exportRef, provenanceInputs, lock)
+}
 
-	return c.logImageNameAndSha(ctx, opts.Publish, imageRef)
+// completeBuild labels the image exported as exportRef, reports its name and digest, records its provenance and writes
+// the project lock. Once labelled, the image is reported, recorded and signed by the digest it was saved with.
+func (c *Client) completeBuild(ctx context.Context, opts BuildOptions, imageRef, exportRef name.Reference, provenanceInputs provenance.Inputs, lock *buildLock) error {
+	labelledDigest, err := c.labelImage(ctx, opts.Publish, exportRef, opts.AdditionalTags, imageLabels(opts))
+	if err != nil {
+		return err
+	}
+	// the image of a platform is exported under a name of its own, and only the index referencing it is reported
+	if exportRef.Name() == imageRef.Name() {
+		if err := c.logImageNameAndSha(ctx, opts.Publish, imageRef, exportRef, opts.EventSink, labelledDigest); err != nil {
+			return err
+		}
+	}
+	if err := c.recordProvenance(ctx, opts, imageRef, exportRef, labelledDigest, provenanceInputs); err != nil {
+		return err
+	}
+	return c.writeLock(lock)
 }
 
 func getFileFilter(descriptor project.Descriptor) (func(string) bool, error) {
@@ -395,6 +1313,241 @@ func getFileFilter(descriptor project.Descriptor) (func(string) bool, error) {
 	return nil, nil
 }
 
+var secretIDExp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// processCaches merges the caches declared in the project descriptor with those provided in opts,
+// validates them and resolves the sources of bind caches to absolute paths.
+func processCaches(opts BuildOptions) (buildCache, launchCache *CacheConfig, err error) {
+	if opts.CacheImage != "" && opts.BuildCache != nil {
+		return nil, nil, errors.New("cache image cannot be combined with a build cache")
+	}
+
+	caches := map[string]*CacheConfig{}
+	add := func(cacheType string, cfg CacheConfig, baseDir string) error {
+		if cacheType != "build" && cacheType != "launch" {
+			return errors.Errorf("cache type %s is not supported", style.Symbol(cacheType))
+		}
+
+		if cfg.Format == "" {
+			cfg.Format = VolumeCacheFormat
+		}
+		switch cfg.Format {
+		case VolumeCacheFormat:
+		case ImageCacheFormat:
+			if cacheType == "launch" {
+				return errors.New("launch cache does not support the image format")
+			}
+			if cfg.Name == "" {
+				return errors.New("image cache requires a name")
+			}
+		case BindCacheFormat:
+			if cfg.Source == "" {
+				return errors.New("bind cache requires a source")
+			}
+			if !filepath.IsAbs(cfg.Source) {
+				cfg.Source = filepath.Join(baseDir, cfg.Source)
+			}
+			source, err := filepath.Abs(cfg.Source)
+			if err != nil {
+				return errors.Wrapf(err, "resolving source of %s cache", cacheType)
+			}
+			cfg.Source = source
+		default:
+			return errors.Errorf("cache format %s is not supported", style.Symbol(string(cfg.Format)))
+		}
+
+		caches[cacheType] = &cfg
+		return nil
+	}
+
+	for _, c := range opts.ProjectDescriptor.Build.Caches {
+		// a cache image provided in opts replaces the build cache of the project
+		if c.Type == "build" && opts.CacheImage != "" {
+			continue
+		}
+		if err := add(c.Type, CacheConfig{Format: CacheFormat(c.Format), Name: c.Name, Source: c.Source}, opts.ProjectDescriptorBaseDir); err != nil {
+			return nil, nil, errors.Wrap(err, "project.toml")
+		}
+	}
+	if opts.BuildCache != nil {
+		if err := add("build", *opts.BuildCache, opts.RelativeBaseDir); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.LaunchCache != nil {
+		if err := add("launch", *opts.LaunchCache, opts.RelativeBaseDir); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if opts.WriteCacheImage && opts.CacheImage == "" && (caches["build"] == nil || caches["build"].Format != ImageCacheFormat) {
+		return nil, nil, errors.New("writing the cache image requires a cache image")
+	}
+
+	return caches["build"], caches["launch"], nil
+}
+
+func validateResourceLimits(cfg ContainerConfig) error {
+	switch {
+	case cfg.CPUs < 0:
+		return errors.New("cpus must not be negative")
+	case cfg.Memory < 0:
+		return errors.New("memory must not be negative")
+	case cfg.PidsLimit < 0:
+		return errors.New("pids limit must not be negative")
+	case cfg.PhaseTimeout < 0:
+		return errors.New("phase timeout must not be negative")
+	}
+	return nil
+}
+
+// lifecycleCache returns the lifecycle options for cfg, creating the directory of a bind cache so that
+// it is not created by the daemon.
+func lifecycleCache(cfg *CacheConfig) (*build.CacheOptions, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	switch cfg.Format {
+	case ImageCacheFormat:
+		return &build.CacheOptions{Type: cache.Image, Name: cfg.Name}, nil
+	case BindCacheFormat:
+		if err := os.MkdirAll(cfg.Source, 0755); err != nil {
+			return nil, errors.Wrapf(err, "creating cache directory %s", style.Symbol(cfg.Source))
+		}
+		return &build.CacheOptions{Type: cache.Bind, Source: cfg.Source}, nil
+	default:
+		return &build.CacheOptions{Type: cache.Volume, Name: cfg.Name}, nil
+	}
+}
+
+// processSecrets merges the secrets declared in the project descriptor with those provided in opts,
+// resolves their sources to absolute paths and validates them.
+func processSecrets(imgOS string, opts BuildOptions) ([]build.Secret, error) {
+	var secrets []build.Secret
+	index := map[string]int{}
+	add := func(id, source, baseDir string) error {
+		if !secretIDExp.MatchString(id) {
+			return errors.Errorf("invalid secret id %s", style.Symbol(id))
+		}
+
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(baseDir, source)
+		}
+		source, err := filepath.EvalSymlinks(source)
+		if err != nil {
+			return errors.Wrapf(err, "reading secret %s", style.Symbol(id))
+		}
+		if source, err = filepath.Abs(source); err != nil {
+			return errors.Wrapf(err, "resolving source of secret %s", style.Symbol(id))
+		}
+
+		fi, err := os.Stat(source)
+		if err != nil {
+			return errors.Wrapf(err, "reading secret %s", style.Symbol(id))
+		}
+		if !fi.Mode().IsRegular() {
+			return errors.Errorf("source of secret %s must be a file", style.Symbol(id))
+		}
+
+		secret := build.Secret{ID: id, Source: source}
+		if i, ok := index[id]; ok {
+			secrets[i] = secret
+			return nil
+		}
+		index[id] = len(secrets)
+		secrets = append(secrets, secret)
+		return nil
+	}
+
+	for _, secret := range opts.ProjectDescriptor.Build.Secrets {
+		if err := add(secret.ID, secret.Source, opts.ProjectDescriptorBaseDir); err != nil {
+			return nil, err
+		}
+	}
+	for _, secret := range opts.ContainerConfig.Secrets {
+		if err := add(secret.ID, secret.Source, opts.RelativeBaseDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(secrets) > 0 && imgOS == "windows" {
+		return nil, errors.New("secrets are not supported for Windows builds")
+	}
+
+	return secrets, nil
+}
+
+// startSSHAgent starts the agent that is forwarded to the build containers, if one is requested.
+// Rather than mounting the agent at SSH_AUTH_SOCK directly it is proxied, so that its socket is accessible to the build user.
+func startSSHAgent(imgOS string, opts BuildOptions) (*sshagent.Agent, error) {
+	sshConfig := opts.ContainerConfig.SSH
+	if sshConfig == nil {
+		return nil, nil
+	}
+
+	if imgOS == "windows" {
+		return nil, errors.New("SSH agent forwarding is not supported for Windows builds")
+	}
+
+	if len(sshConfig.Keys) > 0 {
+		var keys []string
+		for _, key := range sshConfig.Keys {
+			if !filepath.IsAbs(key) {
+				key = filepath.Join(opts.RelativeBaseDir, key)
+			}
+			keys = append(keys, key)
+		}
+
+		agent, err := sshagent.WithKeys(keys...)
+		if err != nil {
+			return nil, errors.Wrap(err, "starting SSH agent")
+		}
+		return agent, nil
+	}
+
+	socket := sshConfig.AgentSocket
+	if socket == "" {
+		socket = os.Getenv("SSH_AUTH_SOCK")
+	}
+	if socket == "" {
+		return nil, errors.New("SSH_AUTH_SOCK is not set, start an SSH agent or provide SSH keys")
+	}
+
+	agent, err := sshagent.Forward(socket)
+	if err != nil {
+		return nil, errors.Wrap(err, "forwarding SSH agent")
+	}
+	return agent, nil
+}
+
+// excludePaths extends fileFilter so that files and directories within the app directory, such as secrets
+// and bind caches, are not copied into the app volume.
+func excludePaths(fileFilter func(string) bool, appPath string, paths ...string) func(string) bool {
+	var excluded []string
+	for _, p := range paths {
+		rel, err := filepath.Rel(appPath, p)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		excluded = append(excluded, rel)
+	}
+
+	if len(excluded) == 0 {
+		return fileFilter
+	}
+
+	return func(fileName string) bool {
+		fileName = filepath.Clean(fileName)
+		for _, rel := range excluded {
+			if fileName == rel || strings.HasPrefix(fileName, rel+string(filepath.Separator)) {
+				return false
+			}
+		}
+		return fileFilter == nil || fileFilter(fileName)
+	}
+}
+
 func lifecycleImageSupported(builderOS string, lifecycleVersion *builder.Version) bool {
 	return lifecycleVersion.Equal(builder.VersionMustParse(prevLifecycleVersionSupportingImage)) ||
 		!lifecycleVersion.LessThan(semver.MustParse(minLifecycleVersionSupportingImage))
@@ -444,14 +1597,17 @@ func (c *Client) getBuilder(img imgutil.Image) (*builder.Builder, error) {
 	return bldr, nil
 }
 
-func (c *Client) validateRunImage(context context.Context, name string, pullPolicy config.PullPolicy, publish bool, expectedStack string) (imgutil.Image, error) {
+func (c *Client) validateRunImage(context context.Context, name string, pullPolicy config.PullPolicy, publish bool, expectedStack string, platform string) (imgutil.Image, error) {
 	if name == "" {
 		return nil, errors.New("run image must be specified")
 	}
-	img, err := c.imageFetcher.Fetch(context, name, image.FetchOptions{Daemon: !publish, PullPolicy: pullPolicy})
+	img, err := c.imageFetcher.Fetch(context, name, image.FetchOptions{Daemon: !publish, PullPolicy: pullPolicy, Platform: platform})
 	if err != nil {
 		return nil, err
 	}
+	if err := validatePlatform(img, platform); err != nil {
+		return nil, err
+	}
 	stackID, err := img.Label("io.buildpacks.stack.id")
 	if err != nil {
 		return nil, err
@@ -462,6 +1618,32 @@ func (c *Client) validateRunImage(context context.Context, name string, pullPoli
 	return img, nil
 }
 
+// validatePlatform checks that img was built for platform, if one is given.
+func validatePlatform(img imgutil.Image, platform string) error {
+	if platform == "" {
+		return nil
+	}
+
+	p, err := image.ParsePlatform(platform)
+	if err != nil {
+		return err
+	}
+
+	imgOS, err := img.OS()
+	if err != nil {
+		return errors.Wrap(err, "getting image OS")
+	}
+	imgArch, err := img.Architecture()
+	if err != nil {
+		return errors.Wrap(err, "getting image architecture")
+	}
+
+	if imgOS != p.OS || imgArch != p.Architecture {
+		return errors.Errorf("image is for platform %s, not %s", style.Symbol(imgOS+"/"+imgArch), style.Symbol(platform))
+	}
+	return nil
+}
+
 func (c *Client) validateMixins(additionalBuildpacks []dist.Buildpack, bldr *builder.Builder, runImageName string, runMixins []string) error {
 	if err := stack.ValidateMixins(bldr.Image().Name(), bldr.Mixins(), runImageName, runMixins); err != nil {
 		return err
@@ -584,6 +1766,55 @@ func (c *Client) processAppPath(appPath string) (string, error) {
 	return resolvedAppPath, nil
 }
 
+// projectMetadata returns the metadata of the project the app is built from, which the lifecycle records on the app image.
+// When the app is a directory within a git work tree its commit is recorded, with the values declared in the project descriptor
+// taking precedence over the ones detected. Whether the app has uncommitted changes is only detected when it is not declared.
+func (c *Client) projectMetadata(appPath string, opts BuildOptions) platform.ProjectMetadata {
+	proj := opts.ProjectDescriptor.Project
+	source := &platform.ProjectSource{
+		Type:     "project",
+		Version:  map[string]interface{}{"declared": proj.Version},
+		Metadata: map[string]interface{}{"url": proj.SourceURL},
+	}
+
+	detected := &gitsource.Source{}
+	if fi, err := os.Stat(appPath); err == nil && fi.IsDir() && c.gitSource != nil && !opts.SkipGitMetadata {
+		gitSource, err := c.gitSource(appPath, proj.SourceDirty == nil)
+		if err != nil {
+			c.logger.Warnf("Unable to read git metadata of %s: %s", style.Symbol(appPath), err)
+		}
+		if gitSource != nil {
+			detected = gitSource
+			source.Metadata["dirty"] = gitSource.Dirty
+		}
+	}
+	if proj.SourceDirty != nil {
+		source.Metadata["dirty"] = *proj.SourceDirty
+	}
+
+	commit := firstNonEmpty(proj.SourceCommit, detected.Commit)
+	if commit == "" {
+		return platform.ProjectMetadata{Source: source}
+	}
+
+	source.Type = "git"
+	source.Version["commit"] = commit
+	source.Metadata["url"] = firstNonEmpty(proj.SourceURL, detected.URL)
+	if branch := firstNonEmpty(proj.SourceBranch, detected.Branch); branch != "" {
+		source.Metadata["refs"] = []string{branch}
+	}
+	return platform.ProjectMetadata{Source: source}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 func (c *Client) processProxyConfig(config *ProxyConfig) ProxyConfig {
 	var (
 		httpProxy, httpsProxy, noProxy string
@@ -652,7 +1883,7 @@ func (c *Client) processProxyConfig(config *ProxyConfig) ProxyConfig {
 // 	----------
 // 	- group:
 //		- A
-func (c *Client) processBuildpacks(ctx context.Context, builderImage imgutil.Image, builderBPs []dist.BuildpackInfo, builderOrder dist.Order, stackID string, opts BuildOptions) (fetchedBPs []dist.Buildpack, order dist.Order, err error) {
+func (c *Client) processBuildpacks(ctx context.Context, builderImage imgutil.Image, builderBPs []dist.BuildpackInfo, builderOrder dist.Order, stackID string, opts BuildOptions, lock *buildLock) (fetchedBPs []dist.Buildpack, order dist.Order, err error) {
 	pullPolicy := opts.PullPolicy
 	publish := opts.Publish
 	registry := opts.Registry
@@ -721,7 +1952,11 @@ func (c *Client) processBuildpacks(ctx context.Context, builderImage imgutil.Ima
 			if err != nil {
 				return fetchedBPs, order, errors.Wrapf(err, "getting OS from %s", style.Symbol(builderImage.Name()))
 			}
-			mainBP, depBPs, err := c.BuildpackDownloader.Download(ctx, bp, BuildpackDownloadOptions{
+			pinnedBP, err := c.pinBuildpack(ctx, lock, bp, locatorType, registry)
+			if err != nil {
+				return fetchedBPs, order, err
+			}
+			mainBP, depBPs, err := c.BuildpackDownloader.Download(ctx, pinnedBP, BuildpackDownloadOptions{
 				RegistryName:    registry,
 				ImageOS:         imageOS,
 				RelativeBaseDir: relativeBaseDir,
@@ -731,6 +1966,9 @@ func (c *Client) processBuildpacks(ctx context.Context, builderImage imgutil.Ima
 			if err != nil {
 				return fetchedBPs, order, errors.Wrap(err, "downloading buildpack")
 			}
+			if err := c.recordBuildpack(ctx, lock, bp, pinnedBP, mainBP.Descriptor().Info, relativeBaseDir, publish); err != nil {
+				return fetchedBPs, order, err
+			}
 			fetchedBPs = append(append(fetchedBPs, mainBP), depBPs...)
 			order = appendBuildpackToOrder(order, mainBP.Descriptor().Info)
 		}
@@ -779,41 +2017,139 @@ func decomposeBuildpack(blob blob.Blob, imageOS string) (mainBP dist.Buildpack,
 	return mainBP, depBPs, nil
 }
 
-func (c *Client) createEphemeralBuilder(rawBuilderImage imgutil.Image, env map[string]string, order dist.Order, buildpacks []dist.Buildpack) (*builder.Builder, error) {
+// createEphemeralBuilder returns a builder with the given order and buildpacks added to rawBuilderImage, or the builder
+// itself when there is nothing to add. The builder is named after a digest of its inputs, so that an existing image
+// is reused instead of rebuilding it. The build env is never added to it, as it is kept between builds.
+// When keep is false, a builder which does not exist yet is created under a name of its own and labelled as ephemeral,
+// and created reports that it is for the caller to remove.
+func (c *Client) createEphemeralBuilder(ctx context.Context, rawBuilderImage imgutil.Image, order dist.Order, buildpacks []dist.Buildpack, keep bool) (bldr *builder.Builder, created bool, err error) {
 	origBuilderName := rawBuilderImage.Name()
-	bldr, err := builder.New(rawBuilderImage, fmt.Sprintf("pack.local/builder/%x:latest", randString(10)))
+	customOrder := len(order) > 0 && len(order[0].Group) > 0
+	if len(buildpacks) == 0 && !customOrder {
+		bldr, err := builder.FromImage(rawBuilderImage)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "invalid builder %s", style.Symbol(origBuilderName))
+		}
+		return bldr, false, nil
+	}
+
+	baseID, err := imageID(rawBuilderImage)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "invalid builder %s", style.Symbol(origBuilderName))
+	}
+
+	digest, err := ephemeralBuilderDigest(baseID, order, buildpacks)
+	if err != nil {
+		return nil, false, err
+	}
+	builderName := fmt.Sprintf("pack.local/builder/%s:latest", digest)
+
+	existing, err := c.imageFetcher.Fetch(ctx, builderName, image.FetchOptions{Daemon: true, PullPolicy: config.PullNever})
+	if err == nil {
+		if bldr, err := builder.FromImage(existing); err == nil {
+			c.logger.Debugf("Using existing ephemeral builder %s", style.Symbol(builderName))
+			return bldr, false, nil
+		}
+	} else if !errors.Is(err, image.ErrNotFound) {
+		return nil, false, err
+	}
+
+	// the labels let pack system prune find a kept builder once its base builder changes, or a builder which was not
+	// kept once the build that created it is no longer running
+	labels := map[string]string{
+		baseBuilderNameLabel: origBuilderName,
+		baseBuilderIDLabel:   baseID,
+	}
+	if !keep {
+		suffix := make([]byte, 6)
+		if _, err := rand.Read(suffix); err != nil {
+			return nil, false, errors.Wrap(err, "generating builder name")
+		}
+		builderName = fmt.Sprintf("pack.local/builder/%s:%x", digest, suffix)
+		labels = ephemeral.Labels()
+	}
+
+	bldr, err = builder.New(rawBuilderImage, builderName)
 	if err != nil {
-		return nil, errors.Wrapf(err, "invalid builder %s", style.Symbol(origBuilderName))
+		return nil, false, errors.Wrapf(err, "invalid builder %s", style.Symbol(origBuilderName))
 	}
 
-	bldr.SetEnv(env)
 	for _, bp := range buildpacks {
 		bpInfo := bp.Descriptor().Info
 		c.logger.Debugf("Adding buildpack %s version %s to builder", style.Symbol(bpInfo.ID), style.Symbol(bpInfo.Version))
 		bldr.AddBuildpack(bp)
 	}
-	if len(order) > 0 && len(order[0].Group) > 0 {
+	if customOrder {
 		c.logger.Debug("Setting custom order")
 		bldr.SetOrder(order)
 	}
 
+	for k, v := range labels {
+		if err := bldr.Image().SetLabel(k, v); err != nil {
+			return nil, false, errors.Wrapf(err, "setting label %s", k)
+		}
+	}
+
 	if err := bldr.Save(c.logger, builder.CreatorMetadata{Version: Version}); err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	return bldr, nil
+	return bldr, !keep, nil
+}
+
+// ephemeralBuilderDigest returns a digest of everything that goes into an ephemeral builder.
+// Buildpacks are identified by the digest of their contents, as the same version may be rebuilt from source.
+func ephemeralBuilderDigest(baseID string, order dist.Order, buildpacks []dist.Buildpack) (string, error) {
+	key := struct {
+		Version    string     `json:"version"`
+		Builder    string     `json:"builder"`
+		Buildpacks []string   `json:"buildpacks"`
+		Order      dist.Order `json:"order"`
+	}{
+		Version: Version,
+		Builder: baseID,
+		Order:   order,
+	}
+
+	for _, bp := range buildpacks {
+		bpDigest, err := buildpackDigest(bp)
+		if err != nil {
+			return "", err
+		}
+		key.Buildpacks = append(key.Buildpacks, bpDigest)
+	}
+
+	contents, err := json.Marshal(key)
+	if err != nil {
+		return "", errors.Wrap(err, "encoding ephemeral builder key")
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(contents)), nil
+}
+
+func buildpackDigest(bp dist.Buildpack) (string, error) {
+	bpInfo := bp.Descriptor().Info
+	rc, err := bp.Open()
+	if err != nil {
+		return "", errors.Wrapf(err, "reading buildpack %s", style.Symbol(bpInfo.FullName()))
+	}
+	defer rc.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, rc); err != nil {
+		return "", errors.Wrapf(err, "reading buildpack %s", style.Symbol(bpInfo.FullName()))
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
-// Returns a string iwith lowercase a-z, of length n
-func randString(n int) string {
-	b := make([]byte, n)
-	_, err := rand.Read(b)
+// imageID returns the identifier of img, or an empty string if it has none.
+func imageID(img imgutil.Image) (string, error) {
+	id, err := img.Identifier()
 	if err != nil {
-		panic(err)
+		return "", errors.Wrap(err, "getting image identifier")
 	}
-	for i := range b {
-		b[i] = 'a' + (b[i] % 26)
+	if id == nil {
+		return "", nil
 	}
-	return string(b)
+	return id.String(), nil
 }
 
 func processVolumes(imgOS string, volumes []string) (processed []string, warnings []string, err error) {
@@ -851,31 +2187,180 @@ func processMode(mode string) string {
 	return mode
 }
 
-func (c *Client) logImageNameAndSha(ctx context.Context, publish bool, imageRef name.Reference) error {
-	// The image name and sha are printed in the lifecycle logs, and there is no need to print it again, unless output is suppressed.
-	if !logging.IsQuiet(c.logger) {
-		return nil
+// validateLabels rejects labels which would overwrite the metadata the lifecycle stores on the app image.
+func validateLabels(opts BuildOptions) error {
+	for _, labels := range []map[string]string{opts.ProjectDescriptor.Build.Labels, opts.Labels} {
+		for key := range labels {
+			if key == "" {
+				return errors.New("label key must not be empty")
+			}
+			if strings.HasPrefix(key, reservedLabelPrefix) {
+				return errors.Errorf("label %s is reserved for the lifecycle", style.Symbol(key))
+			}
+		}
+	}
+	return nil
+}
+
+// imageLabels returns the labels to add to the app image. The OCI labels describing the project are overridden
+// by the labels of the project descriptor, which are in turn overridden by the labels of opts.
+func imageLabels(opts BuildOptions) map[string]string {
+	labels := map[string]string{}
+	proj := opts.ProjectDescriptor.Project
+	for key, value := range map[string]string{
+		ociTitleLabel:   proj.Name,
+		ociVersionLabel: proj.Version,
+		ociSourceLabel:  proj.SourceURL,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+
+	// licenses without an SPDX identifier cannot be expressed in the label, which holds an SPDX expression
+	var licenses []string
+	for _, license := range proj.Licenses {
+		if license.Type != "" {
+			licenses = append(licenses, license.Type)
+		}
+	}
+	if len(licenses) > 0 {
+		labels[ociLicensesLabel] = strings.Join(licenses, " AND ")
+	}
+
+	for key, value := range opts.ProjectDescriptor.Build.Labels {
+		labels[key] = value
+	}
+	for key, value := range opts.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// labelImage adds labels to the exported image and saves it again under its name and additional tags.
+// The lifecycle cannot add labels itself, so the image is fetched from the daemon or registry it was exported to.
+// As this changes the digest of the image, it returns the digest the image was saved with, or an empty string when the
+// image was not saved again. When publishing, the manifest pushed by the exporter is left untagged in the registry.
+func (c *Client) labelImage(ctx context.Context, publish bool, imageRef name.Reference, additionalTags []string, labels map[string]string) (string, error) {
+	if len(labels) == 0 {
+		return "", nil
 	}
 
 	img, err := c.imageFetcher.Fetch(ctx, imageRef.Name(), image.FetchOptions{Daemon: !publish, PullPolicy: config.PullNever})
 	if err != nil {
-		return errors.Wrap(err, "fetching built image")
+		return "", errors.Wrap(err, "fetching built image")
+	}
+
+	var keys []string
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c.logger.Debugf("Adding label %s=%s", key, labels[key])
+		if err := img.SetLabel(key, labels[key]); err != nil {
+			return "", errors.Wrapf(err, "setting label %s", style.Symbol(key))
+		}
+	}
+
+	if err := img.Save(additionalTags...); err != nil {
+		return "", errors.Wrapf(err, "saving labels of image %s", style.Symbol(imageRef.Name()))
+	}
+
+	id, err := img.Identifier()
+	if err != nil {
+		return "", errors.Wrap(err, "reading image sha")
+	}
+	return parseDigestFromImageID(id), nil
+}
+
+// builtImageDigest returns the digest of the image exported as exportRef.
+func (c *Client) builtImageDigest(ctx context.Context, publish bool, exportRef name.Reference) (string, error) {
+	img, err := c.imageFetcher.Fetch(ctx, exportRef.Name(), image.FetchOptions{Daemon: !publish, PullPolicy: config.PullNever})
+	if err != nil {
+		return "", errors.Wrap(err, "fetching built image")
 	}
 
 	id, err := img.Identifier()
 	if err != nil {
-		return errors.Wrap(err, "reading image sha")
+		return "", errors.Wrap(err, "reading image sha")
+	}
+	return parseDigestFromImageID(id), nil
+}
+
+func (c *Client) logImageNameAndSha(ctx context.Context, publish bool, imageRef, exportRef name.Reference, sink events.Sink, labelledDigest string) error {
+	// The image name and sha are printed in the lifecycle logs, and there is no need to print it again, unless output is
+	// suppressed or the image was saved again with labels, which leaves the digest printed by the exporter stale.
+	relabeled := labelledDigest != ""
+	quiet := logging.IsQuiet(c.logger)
+	if !quiet && !relabeled && sink == nil {
+		return nil
+	}
+
+	digest := labelledDigest
+	if !relabeled {
+		var err error
+		if digest, err = c.builtImageDigest(ctx, publish, exportRef); err != nil {
+			return err
+		}
 	}
 
 	// Remove tag, if it exists, from the image name
 	imgName := strings.TrimSuffix(imageRef.String(), imageRef.Identifier())
-	imgNameAndSha := fmt.Sprintf("%s@%s\n", imgName, parseDigestFromImageID(id))
+
+	if sink != nil {
+		events.Emit(sink, events.Event{Type: events.ImageExported, Image: imageRef.Name(), Digest: digest})
+	}
+
+	if !quiet {
+		if relabeled {
+			c.logger.Infof("Saved labels to %s, its digest is now %s", style.Symbol(imageRef.Name()), style.Symbol(digest))
+		}
+		return nil
+	}
+
+	// the image and digest are part of the events written to the output
+	if events.WritesOutput(sink) {
+		return nil
+	}
+
+	imgNameAndSha := fmt.Sprintf("%s@%s\n", imgName, digest)
 
 	// Access the logger's Writer directly to bypass ReportSuccessfulQuietBuild mode
-	_, err = c.logger.Writer().Write([]byte(imgNameAndSha))
+	_, err := c.logger.Writer().Write([]byte(imgNameAndSha))
 	return err
 }
 
+func orderForEvent(order dist.Order) [][]events.Buildpack {
+	var groups [][]events.Buildpack
+	for _, entry := range order {
+		var group []events.Buildpack
+		for _, bp := range entry.Group {
+			group = append(group, events.Buildpack{ID: bp.ID, Version: bp.Version, Optional: bp.Optional})
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func buildpacksForEvent(bps []dist.Buildpack) []events.Buildpack {
+	var infos []events.Buildpack
+	for _, bp := range bps {
+		info := bp.Descriptor().Info
+		infos = append(infos, events.Buildpack{ID: info.ID, Version: info.Version})
+	}
+	return infos
+}
+
+// imageDigest returns the digest (or, for daemon images, the ID) of img, or an empty string when it is unknown.
+func imageDigest(img imgutil.Image) string {
+	id, err := img.Identifier()
+	if err != nil || id == nil {
+		return ""
+	}
+	return parseDigestFromImageID(id)
+}
+
 func parseDigestFromImageID(id imgutil.Identifier) string {
 	var digest string
 	switch v := id.(type) {
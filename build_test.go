@@ -3,16 +3,25 @@ package pack
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	cryptorand "crypto/rand"
+	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -23,8 +32,19 @@ import (
 	"github.com/buildpacks/imgutil/local"
 	"github.com/buildpacks/imgutil/remote"
 	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/platform"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	ggcrremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/heroku/color"
 	"github.com/onsi/gomega/ghttp"
 	"github.com/pkg/errors"
@@ -36,14 +56,23 @@ import (
 	"github.com/buildpacks/pack/internal/build"
 	"github.com/buildpacks/pack/internal/builder"
 	"github.com/buildpacks/pack/internal/buildpackage"
+	"github.com/buildpacks/pack/internal/cache"
 	cfg "github.com/buildpacks/pack/internal/config"
 	"github.com/buildpacks/pack/internal/dist"
+	"github.com/buildpacks/pack/internal/ephemeral"
 	ifakes "github.com/buildpacks/pack/internal/fakes"
+	"github.com/buildpacks/pack/internal/gitsource"
 	ilogging "github.com/buildpacks/pack/internal/logging"
+	"github.com/buildpacks/pack/internal/policy"
+	"github.com/buildpacks/pack/internal/provenance"
 	rg "github.com/buildpacks/pack/internal/registry"
+	"github.com/buildpacks/pack/internal/sshagent"
 	"github.com/buildpacks/pack/internal/style"
+	"github.com/buildpacks/pack/pkg/archive"
+	"github.com/buildpacks/pack/pkg/events"
 	"github.com/buildpacks/pack/project"
 	h "github.com/buildpacks/pack/testhelpers"
+	"github.com/buildpacks/pack/testmocks"
 )
 
 func TestBuild(t *testing.T) {
@@ -670,6 +699,34 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 				}))
 				h.AssertEq(t, fakeLifecycle.Opts.CacheImage, "")
 			})
+
+			it("is read-only for daemon builds", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					CacheImage: "some-cache-image",
+				}))
+				h.AssertTrue(t, fakeLifecycle.Opts.CacheImageReadOnly)
+			})
+
+			it("is written by daemon builds when WriteCacheImage is set", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:           "some/app",
+					Builder:         defaultBuilderName,
+					CacheImage:      "some-cache-image",
+					WriteCacheImage: true,
+				}))
+				h.AssertFalse(t, fakeLifecycle.Opts.CacheImageReadOnly)
+			})
+
+			it("errors when WriteCacheImage is set without a cache image", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:           "some/app",
+					Builder:         defaultBuilderName,
+					WriteCacheImage: true,
+				})
+				h.AssertError(t, err, "writing the cache image requires a cache image")
+			})
 		})
 
 		when("Buildpacks option", func() {
@@ -1101,6 +1158,33 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 					})
 				})
 
+				it("records the package in the project lock", func() {
+					for _, img := range []*fakes.Image{defaultBuilderImage, fakeDefaultRunImage, fakePackage} {
+						digest, err := name.NewDigest(img.Name() + "@sha256:" + strings.Repeat("a", 64))
+						h.AssertNil(t, err)
+						img.SetIdentifier(remote.DigestIdentifier{Digest: digest})
+					}
+					lockPath := filepath.Join(tmpDir, project.LockFileName)
+
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:        "some/app",
+						Builder:      defaultBuilderName,
+						TrustBuilder: true,
+						Buildpacks:   []string{"example.com/some/package"},
+						Lock:         LockOptions{File: lockPath},
+					}))
+
+					lock, err := project.ReadLock(lockPath)
+					h.AssertNil(t, err)
+					h.AssertEq(t, lock.Buildpacks, []project.LockedBuildpack{{
+						URI:     "example.com/some/package",
+						ID:      "meta.buildpack.id",
+						Version: "meta.buildpack.version",
+						Image:   "example.com/some/package",
+						Digest:  "sha256:" + strings.Repeat("a", 64),
+					}})
+				})
+
 				it("fails when no metadata label on package", func() {
 					h.AssertNil(t, fakePackage.SetLabel("io.buildpacks.buildpackage.metadata", ""))
 
@@ -1527,7 +1611,7 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 		})
 
 		when("Env option", func() {
-			it("should set the env on the ephemeral builder", func() {
+			it("should pass the env to the lifecycle rather than the builder", func() {
 				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
 					Image:   "some/app",
 					Builder: defaultBuilderName,
@@ -1536,320 +1620,2030 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 						"key2": "value2",
 					},
 				}))
-				layerTar, err := defaultBuilderImage.FindLayerWithPath("/platform/env/key1")
-				h.AssertNil(t, err)
-				h.AssertTarFileContents(t, layerTar, "/platform/env/key1", `value1`)
-				h.AssertTarFileContents(t, layerTar, "/platform/env/key2", `value2`)
+				h.AssertEq(t, fakeLifecycle.Opts.Env, map[string]string{"key1": "value1", "key2": "value2"})
+				_, err := defaultBuilderImage.FindLayerWithPath("/platform/env/key1")
+				h.AssertNotNil(t, err)
 			})
 		})
 
-		when("Publish option", func() {
-			var remoteRunImage, builderWithoutLifecycleImageOrCreator *fakes.Image
+		when("Labels option", func() {
+			var builtImage *fakes.Image
 
 			it.Before(func() {
-				remoteRunImage = fakes.NewImage("default/run", "", nil)
-				h.AssertNil(t, remoteRunImage.SetLabel("io.buildpacks.stack.id", defaultBuilderStackID))
-				h.AssertNil(t, remoteRunImage.SetLabel("io.buildpacks.stack.mixins", `["mixinA", "mixinX", "run:mixinZ"]`))
-				fakeImageFetcher.RemoteImages[remoteRunImage.Name()] = remoteRunImage
-
-				builderWithoutLifecycleImageOrCreator = newFakeBuilderImage(
-					t,
-					tmpDir,
-					"example.com/supportscreator/builder:tag",
-					"some.stack.id",
-					defaultRunImageName,
-					"0.3.0",
-					newLinuxImage,
-				)
-				h.AssertNil(t, builderWithoutLifecycleImageOrCreator.SetLabel("io.buildpacks.stack.mixins", `["mixinA", "build:mixinB", "mixinX", "build:mixinY"]`))
-				fakeImageFetcher.LocalImages[builderWithoutLifecycleImageOrCreator.Name()] = builderWithoutLifecycleImageOrCreator
+				builtImage = fakes.NewImage("index.docker.io/some/app:latest", "", local.IDIdentifier{
+					ImageID: "363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4",
+				})
+				fakeImageFetcher.LocalImages[builtImage.Name()] = builtImage
 			})
 
 			it.After(func() {
-				remoteRunImage.Cleanup()
-				builderWithoutLifecycleImageOrCreator.Cleanup()
+				h.AssertNilE(t, builtImage.Cleanup())
 			})
 
-			when("true", func() {
-				it("uses a remote run image", func() {
-					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
-						Image:   "some/app",
-						Builder: defaultBuilderName,
-						Publish: true,
-					}))
-					h.AssertEq(t, fakeLifecycle.Opts.Publish, true)
-
-					args := fakeImageFetcher.FetchCalls["default/run"]
-					h.AssertEq(t, args.Daemon, false)
-
-					args = fakeImageFetcher.FetchCalls[defaultBuilderName]
-					h.AssertEq(t, args.Daemon, true)
-				})
+			it("adds the labels to the built image", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:          "some/app",
+					Builder:        defaultBuilderName,
+					AdditionalTags: []string{"some/app:other"},
+					Labels:         map[string]string{"com.example.team": "payments"},
+				}))
 
-				when("builder is untrusted", func() {
-					when("lifecycle image is available", func() {
-						it("uses the 5 phases with the lifecycle image", func() {
-							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
-								Image:        "some/app",
-								Builder:      defaultBuilderName,
-								Publish:      true,
-								TrustBuilder: false,
-							}))
-							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, false)
-							h.AssertEq(t, fakeLifecycle.Opts.LifecycleImage, fakeLifecycleImage.Name())
+				h.AssertEq(t, fakeImageFetcher.FetchCalls[builtImage.Name()].Daemon, true)
+				label, err := builtImage.Label("com.example.team")
+				h.AssertNil(t, err)
+				h.AssertEq(t, label, "payments")
+				h.AssertSliceContains(t, builtImage.SavedNames(), "index.docker.io/some/app:latest", "some/app:other")
+				h.AssertContains(t, outBuf.String(), "Saved labels to 'index.docker.io/some/app:latest', its digest is now 'sha256:363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4'")
+			})
 
-							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
-							h.AssertEq(t, args.Daemon, true)
-							h.AssertEq(t, args.PullPolicy, config.PullAlways)
-							h.AssertEq(t, args.Platform, "linux/amd64")
-						})
-					})
+			it("adds the OCI labels of the project and the labels of the project descriptor", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					ProjectDescriptor: project.Descriptor{
+						Project: project.Project{
+							Name:      "some-app",
+							Version:   "1.2.3",
+							SourceURL: "https://github.com/example/some-app",
+							Licenses:  []dist.License{{Type: "MIT"}, {URI: "https://example.com/license"}, {Type: "Apache-2.0"}},
+						},
+						Build: project.Build{
+							Labels: map[string]string{
+								"com.example.team":             "payments",
+								"org.opencontainers.image.url": "https://example.com",
+							},
+						},
+					},
+					Labels: map[string]string{"com.example.team": "checkout"},
+				}))
 
-					when("lifecycle image is not available", func() {
-						it("errors", func() {
-							h.AssertNotNil(t, subject.Build(context.TODO(), BuildOptions{
-								Image:        "some/app",
-								Builder:      builderWithoutLifecycleImageOrCreator.Name(),
-								Publish:      true,
-								TrustBuilder: false,
-							}))
-						})
-					})
+				labels, err := builtImage.Labels()
+				h.AssertNil(t, err)
+				h.AssertEq(t, labels, map[string]string{
+					"org.opencontainers.image.title":    "some-app",
+					"org.opencontainers.image.version":  "1.2.3",
+					"org.opencontainers.image.source":   "https://github.com/example/some-app",
+					"org.opencontainers.image.licenses": "MIT AND Apache-2.0",
+					"org.opencontainers.image.url":      "https://example.com",
+					"com.example.team":                  "checkout",
 				})
+			})
 
-				when("builder is trusted", func() {
-					when("lifecycle supports creator", func() {
-						it("uses the creator with the provided builder", func() {
-							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
-								Image:        "some/app",
-								Builder:      defaultBuilderName,
-								Publish:      true,
-								TrustBuilder: true,
-							}))
-							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, true)
+			it("does not save the image without labels", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+				}))
 
-							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
-							h.AssertNil(t, args)
-						})
-					})
+				h.AssertEq(t, builtImage.IsSaved(), false)
+				h.AssertNotContains(t, outBuf.String(), "Saved labels")
+			})
 
-					when("lifecycle doesn't support creator", func() {
-						// the default test builder (example.com/default/builder:tag) has lifecycle version 0.3.0, so creator is not supported
-						it("uses the 5 phases with the provided builder", func() {
-							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
-								Image:        "some/app",
-								Builder:      builderWithoutLifecycleImageOrCreator.Name(),
-								Publish:      true,
-								TrustBuilder: true,
-							}))
-							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, false)
-							h.AssertEq(t, fakeLifecycle.Opts.LifecycleImage, builderWithoutLifecycleImageOrCreator.Name())
+			when("publishing", func() {
+				var remoteImage *fakes.Image
 
-							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
-							h.AssertNil(t, args)
-						})
-					})
+				it.Before(func() {
+					digest, err := name.NewDigest("example.io/some/app@sha256:363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4", name.WeakValidation)
+					h.AssertNil(t, err)
+					remoteImage = fakes.NewImage("example.io/some/app:latest", "", remote.DigestIdentifier{Digest: digest})
+					fakeImageFetcher.RemoteImages[remoteImage.Name()] = remoteImage
+					fakeImageFetcher.RemoteImages[fakeDefaultRunImage.Name()] = fakeDefaultRunImage
 				})
-			})
 
-			when("false", func() {
-				it("uses a local run image", func() {
+				it.After(func() {
+					h.AssertNilE(t, remoteImage.Cleanup())
+				})
+
+				it("adds the labels to the published image", func() {
 					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
-						Image:   "some/app",
+						Image:   "example.io/some/app",
 						Builder: defaultBuilderName,
-						Publish: false,
+						Publish: true,
+						Labels:  map[string]string{"com.example.team": "payments"},
 					}))
-					h.AssertEq(t, fakeLifecycle.Opts.Publish, false)
-
-					args := fakeImageFetcher.FetchCalls["default/run"]
-					h.AssertEq(t, args.Daemon, true)
-					h.AssertEq(t, args.PullPolicy, config.PullAlways)
 
-					args = fakeImageFetcher.FetchCalls[defaultBuilderName]
-					h.AssertEq(t, args.Daemon, true)
-					h.AssertEq(t, args.PullPolicy, config.PullAlways)
+					h.AssertEq(t, fakeImageFetcher.FetchCalls[remoteImage.Name()].Daemon, false)
+					label, err := remoteImage.Label("com.example.team")
+					h.AssertNil(t, err)
+					h.AssertEq(t, label, "payments")
+					h.AssertEq(t, remoteImage.IsSaved(), true)
 				})
+			})
 
-				when("builder is untrusted", func() {
-					when("lifecycle image is available", func() {
-						it("uses the 5 phases with the lifecycle image", func() {
-							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
-								Image:        "some/app",
-								Builder:      defaultBuilderName,
-								Publish:      false,
-								TrustBuilder: false,
-							}))
-							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, false)
-							h.AssertEq(t, fakeLifecycle.Opts.LifecycleImage, fakeLifecycleImage.Name())
+			when("publishing to a registry", func() {
+				var (
+					server       *httptest.Server
+					imageName    string
+					pushedDigest string
+					keyPath      string
+					pubKeyPath   string
+				)
 
-							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
-							h.AssertEq(t, args.Daemon, true)
-							h.AssertEq(t, args.PullPolicy, config.PullAlways)
-							h.AssertEq(t, args.Platform, "linux/amd64")
-						})
+				it.Before(func() {
+					server = httptest.NewServer(registry.New())
+					imageName = strings.TrimPrefix(server.URL, "http://") + "/some/app:latest"
 
-						it("suggests that being untrusted may be the root of a failure", func() {
-							subject.lifecycleExecutor = &executeFailsLifecycle{}
-							err := subject.Build(context.TODO(), BuildOptions{
-								Image:        "some/app",
-								Builder:      defaultBuilderName,
-								Publish:      false,
-								TrustBuilder: false,
-							})
+					subject.lifecycleExecutor = lifecycleExecutorFunc(func(ctx context.Context, opts build.LifecycleOptions) error {
+						img, err := random.Image(10, 1)
+						if err != nil {
+							return err
+						}
+						if img, err = mutate.ConfigFile(img, &v1.ConfigFile{OS: "linux", Architecture: "amd64"}); err != nil {
+							return err
+						}
+						if err := ggcrremote.Write(opts.Image, img); err != nil {
+							return err
+						}
+						digest, err := img.Digest()
+						if err != nil {
+							return err
+						}
+						pushedDigest = digest.String()
 
-							h.AssertError(t, err, "may be the result of using an untrusted builder")
-						})
+						exported, err := remote.NewImage(opts.Image.Name(), authn.DefaultKeychain, remote.FromBaseImage(opts.Image.Name()))
+						if err != nil {
+							return err
+						}
+						fakeImageFetcher.RemoteImages[opts.Image.Name()] = exported
+						return nil
 					})
+					fakeImageFetcher.RemoteImages[fakeDefaultRunImage.Name()] = fakeDefaultRunImage
 
-					when("lifecycle image is not available", func() {
-						it("errors", func() {
-							h.AssertNotNil(t, subject.Build(context.TODO(), BuildOptions{
-								Image:        "some/app",
-								Builder:      builderWithoutLifecycleImageOrCreator.Name(),
-								Publish:      false,
-								TrustBuilder: false,
-							}))
-						})
-					})
+					key, err := ecdsa.GenerateKey(elliptic.P256(), cryptorand.Reader)
+					h.AssertNil(t, err)
+					der, err := x509.MarshalECPrivateKey(key)
+					h.AssertNil(t, err)
+					keyPath = filepath.Join(tmpDir, "cosign.key")
+					h.AssertNil(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+					pubDer, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+					h.AssertNil(t, err)
+					pubKeyPath = filepath.Join(tmpDir, "cosign.pub")
+					h.AssertNil(t, ioutil.WriteFile(pubKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}), 0644))
 				})
 
-				when("builder is trusted", func() {
-					when("lifecycle supports creator", func() {
-						it("uses the creator with the provided builder", func() {
-							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
-								Image:        "some/app",
-								Builder:      defaultBuilderName,
-								Publish:      false,
-								TrustBuilder: true,
-							}))
-							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, true)
-
-							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
-							h.AssertNil(t, args)
-						})
-					})
-
-					when("lifecycle doesn't support creator", func() {
-						// the default test builder (example.com/default/builder:tag) has lifecycle version 0.3.0, so creator is not supported
-						it("uses the 5 phases with the provided builder", func() {
-							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
-								Image:        "some/app",
-								Builder:      builderWithoutLifecycleImageOrCreator.Name(),
-								Publish:      false,
-								TrustBuilder: true,
-							}))
-							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, false)
-							h.AssertEq(t, fakeLifecycle.Opts.LifecycleImage, builderWithoutLifecycleImageOrCreator.Name())
-
-							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
-							h.AssertNil(t, args)
-						})
-					})
+				it.After(func() {
+					server.Close()
 				})
-			})
-		})
 
-		when("PullPolicy", func() {
-			when("never", func() {
-				it("uses the local builder and run images without updating", func() {
+				it("reports, records and signs the digest of the labelled image", func() {
+					var exported []events.Event
+					provenancePath := filepath.Join(tmpDir, "provenance.json")
 					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
-						Image:      "some/app",
+						Image:      imageName,
 						Builder:    defaultBuilderName,
-						PullPolicy: config.PullNever,
+						Publish:    true,
+						Labels:     map[string]string{"com.example.team": "payments"},
+						SignKey:    keyPath,
+						Provenance: ProvenanceOptions{File: provenancePath},
+						EventSink: events.SinkFunc(func(e events.Event) {
+							if e.Type == events.ImageExported {
+								exported = append(exported, e)
+							}
+						}),
 					}))
 
-					args := fakeImageFetcher.FetchCalls["default/run"]
-					h.AssertEq(t, args.Daemon, true)
-					h.AssertEq(t, args.PullPolicy, config.PullNever)
+					ref, err := name.ParseReference(imageName)
+					h.AssertNil(t, err)
+					desc, err := ggcrremote.Head(ref)
+					h.AssertNil(t, err)
+					labelledDigest := desc.Digest.String()
+					h.AssertNotEq(t, labelledDigest, pushedDigest)
 
-					args = fakeImageFetcher.FetchCalls[defaultBuilderName]
-					h.AssertEq(t, args.Daemon, true)
-					h.AssertEq(t, args.PullPolicy, config.PullNever)
+					h.AssertEq(t, len(exported), 1)
+					h.AssertEq(t, exported[0].Digest, labelledDigest)
+					h.AssertContains(t, outBuf.String(), fmt.Sprintf("its digest is now '%s'", labelledDigest))
 
-					args = fakeImageFetcher.FetchCalls["buildpacksio/lifecycle:0.11.3"]
-					h.AssertEq(t, args.Daemon, true)
+					contents, err := ioutil.ReadFile(provenancePath)
+					h.AssertNil(t, err)
+					var statement provenance.Statement
+					h.AssertNil(t, json.Unmarshal(contents, &statement))
+					h.AssertEq(t, statement.Subject[0].Digest, map[string]string{"sha256": strings.TrimPrefix(labelledDigest, "sha256:")})
+
+					verified, err := subject.VerifyImage(context.TODO(), VerifyImageOptions{Image: imageName, Key: pubKeyPath})
+					h.AssertNil(t, err)
+					h.AssertEq(t, verified.DigestStr(), labelledDigest)
+				})
+			})
+
+			it("errors for labels in the io.buildpacks namespace", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					Labels:  map[string]string{"io.buildpacks.build.metadata": "{}"},
+				})
+				h.AssertError(t, err, "label 'io.buildpacks.build.metadata' is reserved for the lifecycle")
+			})
+		})
+
+		when("ProjectMetadata", func() {
+			var builtImage *fakes.Image
+
+			it.Before(func() {
+				// the project descriptor adds OCI labels to the built image
+				builtImage = fakes.NewImage("index.docker.io/some/app:latest", "", local.IDIdentifier{
+					ImageID: "363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4",
+				})
+				fakeImageFetcher.LocalImages[builtImage.Name()] = builtImage
+			})
+
+			it.After(func() {
+				h.AssertNilE(t, builtImage.Cleanup())
+			})
+
+			when("the app is in a git work tree", func() {
+				it.Before(func() {
+					subject.gitSource = func(dir string, checkDirty bool) (*gitsource.Source, error) {
+						return &gitsource.Source{
+							URL:    "https://github.com/example/some-app.git",
+							Commit: "0123456789abcdef0123456789abcdef01234567",
+							Branch: "main",
+							Dirty:  true,
+						}, nil
+					}
+				})
+
+				it("records the commit in the project metadata", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+						ProjectDescriptor: project.Descriptor{
+							Project: project.Project{Version: "1.2.3"},
+						},
+					}))
+
+					h.AssertEq(t, fakeLifecycle.Opts.ProjectMetadata.Source, &platform.ProjectSource{
+						Type:    "git",
+						Version: map[string]interface{}{"declared": "1.2.3", "commit": "0123456789abcdef0123456789abcdef01234567"},
+						Metadata: map[string]interface{}{
+							"url":   "https://github.com/example/some-app.git",
+							"refs":  []string{"main"},
+							"dirty": true,
+						},
+					})
+				})
+
+				it("prefers the values of the project descriptor", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+						ProjectDescriptor: project.Descriptor{
+							Project: project.Project{
+								SourceURL:    "https://example.com/some-app.git",
+								SourceCommit: "fedcba9876543210fedcba9876543210fedcba98",
+								SourceBranch: "release",
+							},
+						},
+					}))
+
+					source := fakeLifecycle.Opts.ProjectMetadata.Source
+					h.AssertEq(t, source.Version["commit"], "fedcba9876543210fedcba9876543210fedcba98")
+					h.AssertEq(t, source.Metadata["url"], "https://example.com/some-app.git")
+					h.AssertEq(t, source.Metadata["refs"], []string{"release"})
+				})
+
+				it("records whether the app is dirty as declared instead of detecting it", func() {
+					subject.gitSource = func(dir string, checkDirty bool) (*gitsource.Source, error) {
+						h.AssertFalse(t, checkDirty)
+						return &gitsource.Source{Commit: "0123456789abcdef0123456789abcdef01234567"}, nil
+					}
+					dirty := false
+
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+						ProjectDescriptor: project.Descriptor{
+							Project: project.Project{SourceDirty: &dirty},
+						},
+					}))
+
+					h.AssertEq(t, fakeLifecycle.Opts.ProjectMetadata.Source.Metadata["dirty"], false)
+				})
+
+				it("does not record the commit when SkipGitMetadata is set", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:           "some/app",
+						Builder:         defaultBuilderName,
+						SkipGitMetadata: true,
+					}))
+
+					h.AssertEq(t, fakeLifecycle.Opts.ProjectMetadata.Source, &platform.ProjectSource{
+						Type:     "project",
+						Version:  map[string]interface{}{"declared": ""},
+						Metadata: map[string]interface{}{"url": ""},
+					})
+				})
+			})
+
+			when("the app is not in a git work tree", func() {
+				it.Before(func() {
+					subject.gitSource = func(dir string, checkDirty bool) (*gitsource.Source, error) {
+						return nil, nil
+					}
+				})
+
+				it("records the commit declared in the project descriptor", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+						ProjectDescriptor: project.Descriptor{
+							Project: project.Project{
+								SourceURL:    "https://example.com/some-app.git",
+								SourceCommit: "fedcba9876543210fedcba9876543210fedcba98",
+							},
+						},
+					}))
+
+					h.AssertEq(t, fakeLifecycle.Opts.ProjectMetadata.Source, &platform.ProjectSource{
+						Type:     "git",
+						Version:  map[string]interface{}{"declared": "", "commit": "fedcba9876543210fedcba9876543210fedcba98"},
+						Metadata: map[string]interface{}{"url": "https://example.com/some-app.git"},
+					})
+				})
+			})
+		})
+
+		when("CreationTime option", func() {
+			it("passes the creation time to the lifecycle", func() {
+				creationTime := time.Unix(1600000000, 0)
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:        "some/app",
+					Builder:      defaultBuilderName,
+					CreationTime: &creationTime,
+				}))
+
+				h.AssertEq(t, fakeLifecycle.Opts.CreationTime, &creationTime)
+			})
+		})
+
+		when("SignKey option", func() {
+			it("errors when the image is not published", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					SignKey: "cosign.key",
+				})
+				h.AssertError(t, err, "signing requires publishing the image")
+			})
+
+			it("errors for a missing key before building", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					Publish: true,
+					SignKey: filepath.Join(tmpDir, "missing.key"),
+				})
+				h.AssertError(t, err, "reading key")
+				h.AssertNil(t, fakeLifecycle.Opts.Image)
+			})
+		})
+
+		when("Provenance option", func() {
+			var builtImage *fakes.Image
+
+			it.Before(func() {
+				builtImage = fakes.NewImage("index.docker.io/some/app:latest", "", local.IDIdentifier{
+					ImageID: "363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4",
+				})
+				fakeImageFetcher.LocalImages[builtImage.Name()] = builtImage
+			})
+
+			it.After(func() {
+				h.AssertNilE(t, builtImage.Cleanup())
+			})
+
+			it("writes the provenance of the image to the file", func() {
+				path := filepath.Join(tmpDir, "provenance.json")
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					Env:     map[string]string{"SOME_KEY": "some-secret-value"},
+					Provenance: ProvenanceOptions{
+						File:    path,
+						Invoker: "some-user",
+					},
+				}))
+
+				contents, err := ioutil.ReadFile(path)
+				h.AssertNil(t, err)
+				var statement provenance.Statement
+				h.AssertNil(t, json.Unmarshal(contents, &statement))
+
+				h.AssertEq(t, statement.Subject[0].Name, "index.docker.io/some/app:latest")
+				h.AssertEq(t, statement.Subject[0].Digest, map[string]string{"sha256": "363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4"})
+				h.AssertEq(t, statement.Predicate.BuildConfig.Builder.Name, "example.com/default/builder:tag")
+				h.AssertEq(t, statement.Predicate.BuildConfig.LifecycleVersion, builder.DefaultLifecycleVersion)
+				h.AssertEq(t, statement.Predicate.BuildConfig.Order, [][]provenance.Buildpack{
+					{{ID: "buildpack.1.id", Version: "buildpack.1.version"}},
+					{{ID: "buildpack.2.id", Version: "buildpack.2.version"}},
+				})
+				h.AssertEq(t, statement.Predicate.Invocation.Parameters.EnvKeys, []string{"SOME_KEY"})
+				h.AssertEq(t, statement.Predicate.Invocation.Environment["invoker"], "some-user")
+				h.AssertNotContains(t, string(contents), "some-secret-value")
+			})
+
+			it("does not write the file for dry runs", func() {
+				path := filepath.Join(tmpDir, "provenance.json")
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					DryRun:     true,
+					Provenance: ProvenanceOptions{File: path},
+				}))
+
+				_, err := os.Stat(path)
+				h.AssertTrue(t, os.IsNotExist(err))
+			})
+
+			it("errors when attaching without publishing", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					Provenance: ProvenanceOptions{Attach: true},
+				})
+				h.AssertError(t, err, "attaching provenance requires publishing the image")
+			})
+
+			it("errors when writing a file for multiple platforms", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					Publish:    true,
+					Platforms:  []string{"linux/amd64", "linux/arm64"},
+					Provenance: ProvenanceOptions{File: "provenance.json"},
+				})
+				h.AssertError(t, err, "provenance file does not support building for multiple platforms")
+			})
+
+			it("errors when attaching for multiple platforms", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					Publish:    true,
+					Platforms:  []string{"linux/amd64", "linux/arm64"},
+					Provenance: ProvenanceOptions{Attach: true},
+				})
+				h.AssertError(t, err, "attaching provenance does not support building for multiple platforms")
+			})
+		})
+
+		when("Lock option", func() {
+			var (
+				lockPath        string
+				builderDigest   name.Digest
+				runImageDigest  name.Digest
+				lifecycleDigest name.Digest
+			)
+
+			newDigest := func(image, hex string) name.Digest {
+				t.Helper()
+				digest, err := name.NewDigest(image + "@sha256:" + strings.Repeat(hex, 64))
+				h.AssertNil(t, err)
+				return digest
+			}
+
+			it.Before(func() {
+				lockPath = filepath.Join(tmpDir, project.LockFileName)
+				builderDigest = newDigest("example.com/default/builder", "a")
+				runImageDigest = newDigest("index.docker.io/default/run", "b")
+				lifecycleDigest = newDigest(fakeLifecycleImage.Name(), "c")
+
+				defaultBuilderImage.SetIdentifier(remote.DigestIdentifier{Digest: builderDigest})
+				fakeDefaultRunImage.SetIdentifier(remote.DigestIdentifier{Digest: runImageDigest})
+				fakeLifecycleImage.SetIdentifier(remote.DigestIdentifier{Digest: lifecycleDigest})
+			})
+
+			it("writes the images the build resolved to the lock", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					Lock:    LockOptions{File: lockPath},
+				}))
+
+				lock, err := project.ReadLock(lockPath)
+				h.AssertNil(t, err)
+				h.AssertEq(t, lock.Builder, &project.LockedImage{Image: "example.com/default/builder", Digest: builderDigest.DigestStr()})
+				h.AssertEq(t, lock.RunImage, &project.LockedImage{Image: "index.docker.io/default/run", Digest: runImageDigest.DigestStr()})
+				h.AssertEq(t, lock.LifecycleImage, &project.LockedImage{Image: lifecycleDigest.Context().Name(), Digest: lifecycleDigest.DigestStr()})
+				h.AssertContains(t, outBuf.String(), "Wrote project lock to '"+lockPath+"'")
+			})
+
+			it("does not write the lock for dry runs", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					DryRun:  true,
+					Lock:    LockOptions{File: lockPath},
+				}))
+
+				_, err := os.Stat(lockPath)
+				h.AssertTrue(t, os.IsNotExist(err))
+			})
+
+			it("errors for images without a digest in a registry", func() {
+				fakeDefaultRunImage.SetIdentifier(nil)
+
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					Lock:    LockOptions{File: lockPath},
+				})
+				h.AssertError(t, err, "cannot lock 'default/run', as it has no digest in a registry")
+			})
+
+			it("errors for multiple platforms", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:     "some/app",
+					Builder:   defaultBuilderName,
+					Publish:   true,
+					Platforms: []string{"linux/amd64", "linux/arm64"},
+					Lock:      LockOptions{File: lockPath},
+				})
+				h.AssertError(t, err, "project lock does not support building for multiple platforms")
+			})
+
+			when("the lock exists", func() {
+				it.Before(func() {
+					h.AssertNil(t, project.WriteLock(lockPath, project.Lock{
+						Builder:        &project.LockedImage{Image: "example.com/default/builder", Digest: builderDigest.DigestStr()},
+						RunImage:       &project.LockedImage{Image: "index.docker.io/default/run", Digest: runImageDigest.DigestStr()},
+						LifecycleImage: &project.LockedImage{Image: lifecycleDigest.Context().Name(), Digest: lifecycleDigest.DigestStr()},
+					}))
+					fakeImageFetcher.LocalImages[builderDigest.Name()] = defaultBuilderImage
+					fakeImageFetcher.LocalImages[runImageDigest.Name()] = fakeDefaultRunImage
+					fakeImageFetcher.LocalImages[lifecycleDigest.Name()] = fakeLifecycleImage
+				})
+
+				it("builds with the images in the lock", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+						Lock:    LockOptions{File: lockPath},
+					}))
+
+					h.AssertNotNil(t, fakeImageFetcher.FetchCalls[builderDigest.Name()])
+					h.AssertNil(t, fakeImageFetcher.FetchCalls[defaultBuilderName])
+					h.AssertEq(t, fakeLifecycle.Opts.RunImage, runImageDigest.Name())
+					h.AssertNotNil(t, fakeImageFetcher.FetchCalls[lifecycleDigest.Name()])
+					h.AssertNotContains(t, outBuf.String(), "Wrote project lock")
+				})
+
+				it("errors when the builder is not the builder in the lock", func() {
+					err := subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: "example.com/other/builder:tag",
+						Lock:    LockOptions{File: lockPath},
+					})
+					h.AssertError(t, err, "builder 'example.com/other/builder:tag' differs from the builder 'example.com/default/builder' in the project lock, update the project lock '"+lockPath+"' to use it")
+				})
+
+				it("resolves the images again when updating the lock", func() {
+					otherRunImageDigest := newDigest("index.docker.io/default/run", "d")
+					fakeDefaultRunImage.SetIdentifier(remote.DigestIdentifier{Digest: otherRunImageDigest})
+
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+						Lock:    LockOptions{File: lockPath, Update: true},
+					}))
+
+					h.AssertEq(t, fakeLifecycle.Opts.RunImage, "default/run")
+					lock, err := project.ReadLock(lockPath)
+					h.AssertNil(t, err)
+					h.AssertEq(t, lock.RunImage.Digest, otherRunImageDigest.DigestStr())
+				})
+			})
+		})
+
+		when("Policy", func() {
+			usePolicy := func(contents string) {
+				subject.policy = readPolicy(t, tmpDir, contents)
+			}
+
+			it("builds with builders in the allowed builders", func() {
+				usePolicy(`[builders]
+allowed = ["example.com/default/builder"]`)
+
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+				}))
+			})
+
+			it("errors for builders not in the allowed builders", func() {
+				usePolicy(`[builders]
+allowed = ["example.com/other/builder"]`)
+
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+				})
+				h.AssertError(t, err, "builder 'example.com/default/builder:tag' is not in the allowed builders, as required by policy")
+				h.AssertNil(t, fakeLifecycle.Opts.Image)
+			})
+
+			when("the allowed builder is pinned by digest", func() {
+				digest := "sha256:363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4"
+
+				it.Before(func() {
+					usePolicy(`[builders]
+allowed = ["example.com/default/builder@` + digest + `"]`)
+				})
+
+				it("builds with the builder with that digest", func() {
+					builderDigest, err := name.NewDigest("example.com/default/builder@" + digest)
+					h.AssertNil(t, err)
+					defaultBuilderImage.SetIdentifier(remote.DigestIdentifier{Digest: builderDigest})
+
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+					}))
+				})
+
+				it("errors for the builder with another digest", func() {
+					err := subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+					})
+					h.AssertError(t, err, "builder 'example.com/default/builder:tag' must have digest "+digest)
+				})
+			})
+
+			it("errors for run images not in the allowed run images", func() {
+				usePolicy(`[run-images]
+allowed = ["registry.example.com/run/base"]`)
+
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+				})
+				h.AssertError(t, err, "run image 'default/run' is not in the allowed run images")
+			})
+
+			it("errors for forbidden buildpacks", func() {
+				usePolicy(`[buildpacks]
+forbidden = ["buildpack.2.id"]`)
+
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+				})
+				h.AssertError(t, err, "buildpack 'buildpack.2.id' is forbidden")
+			})
+
+			it("errors for lifecycles older than the minimum version", func() {
+				usePolicy(`[lifecycle]
+minimum-version = "99.0.0"`)
+
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+				})
+				h.AssertError(t, err, "lifecycle '"+builder.DefaultLifecycleVersion+"' is older than the minimum version '99.0.0'")
+			})
+
+			when("images must be published to a registry", func() {
+				it.Before(func() {
+					usePolicy(`[publish]
+registry = "registry.example.com"`)
+				})
+
+				it("errors when not publishing", func() {
+					err := subject.Build(context.TODO(), BuildOptions{
+						Image:   "registry.example.com/some/app",
+						Builder: defaultBuilderName,
+					})
+					h.AssertError(t, err, "images must be published to 'registry.example.com'")
+				})
+
+				it("errors for tags in other registries", func() {
+					err := subject.Build(context.TODO(), BuildOptions{
+						Image:          "registry.example.com/some/app",
+						AdditionalTags: []string{"other.example.com/some/app"},
+						Builder:        defaultBuilderName,
+						Publish:        true,
+					})
+					h.AssertError(t, err, "image 'other.example.com/some/app' must be published to 'registry.example.com'")
+				})
+			})
+		})
+
+		when("DockerHost option", func() {
+			it("defaults to the socket of the resolved daemon", func() {
+				subject.daemonAccessHost = "unix:///run/user/1000/podman/podman.sock"
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+				}))
+				h.AssertEq(t, fakeLifecycle.Opts.DockerHost, "unix:///run/user/1000/podman/podman.sock")
+			})
+
+			it("prefers the given host", func() {
+				subject.daemonAccessHost = "unix:///run/user/1000/podman/podman.sock"
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					DockerHost: "inherit",
+				}))
+				h.AssertEq(t, fakeLifecycle.Opts.DockerHost, "inherit")
+			})
+		})
+
+		when("Executor option", func() {
+			var localLifecycle *ifakes.FakeLifecycle
+
+			it.Before(func() {
+				localLifecycle = &ifakes.FakeLifecycle{}
+				subject.localExecutor = localLifecycle
+			})
+
+			when("local", func() {
+				it("runs the lifecycle of the build image without fetching a builder", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:    "some/app",
+						Executor: LocalExecutor,
+						Publish:  true,
+						RunImage: "some/run",
+						Env:      map[string]string{"key1": "value1"},
+						ProjectDescriptor: project.Descriptor{
+							Build: project.Build{Env: []project.EnvVar{{Name: "key2", Value: "value2"}}},
+						},
+					}))
+
+					h.AssertEq(t, localLifecycle.Opts.Image.Name(), "index.docker.io/some/app:latest")
+					h.AssertEq(t, localLifecycle.Opts.Publish, true)
+					h.AssertEq(t, localLifecycle.Opts.RunImage, "some/run")
+					h.AssertEq(t, localLifecycle.Opts.Env, map[string]string{"key1": "value1", "key2": "value2"})
+					h.AssertNil(t, localLifecycle.Opts.Builder)
+					h.AssertEq(t, len(fakeImageFetcher.FetchCalls), 0)
+					h.AssertNil(t, fakeLifecycle.Opts.Image)
+				})
+
+				it("errors without publish", func() {
+					err := subject.Build(context.TODO(), BuildOptions{
+						Image:    "some/app",
+						Executor: LocalExecutor,
+					})
+					h.AssertError(t, err, "local executor requires publishing the image")
+				})
+
+				it("errors for options that require the docker daemon", func() {
+					err := subject.Build(context.TODO(), BuildOptions{
+						Image:           "some/app",
+						Executor:        LocalExecutor,
+						Publish:         true,
+						Buildpacks:      []string{"example/foo@1.0.0"},
+						ContainerConfig: ContainerConfig{Network: "host"},
+					})
+					h.AssertError(t, err, "local executor does not support additional buildpacks, network")
+				})
+
+				when("a policy is set", func() {
+					it.Before(func() {
+						subject.cnbDir = filepath.Join(tmpDir, "cnb")
+						for path, contents := range map[string]string{
+							"lifecycle/lifecycle.toml":                   "[lifecycle]\nversion = \"0.10.2\"\n",
+							"buildpacks/example_bp/1.0.0/buildpack.toml": "api = \"0.4\"\n[buildpack]\nid = \"example/bp\"\nversion = \"1.0.0\"\n",
+							"stack.toml": "[run-image]\nimage = \"some/stack-run\"\nmirrors = [\"registry.example.com/some/stack-run\"]\n",
+						} {
+							path = filepath.Join(subject.cnbDir, path)
+							h.AssertNil(t, os.MkdirAll(filepath.Dir(path), 0755))
+							h.AssertNil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+						}
+					})
+
+					it("builds with the run image of the stack it checked", func() {
+						subject.policy = readPolicy(t, tmpDir, `[run-images]
+allowed = ["registry.example.com/some/stack-run"]`)
+
+						h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+							Image:    "registry.example.com/some/app",
+							Executor: LocalExecutor,
+							Publish:  true,
+						}))
+						h.AssertEq(t, localLifecycle.Opts.RunImage, "registry.example.com/some/stack-run")
+					})
+
+					it("errors for run images not allowed", func() {
+						subject.policy = readPolicy(t, tmpDir, `[run-images]
+allowed = ["registry.example.com/some/stack-run"]`)
+
+						err := subject.Build(context.TODO(), BuildOptions{
+							Image:    "some/app",
+							Executor: LocalExecutor,
+							Publish:  true,
+						})
+						h.AssertError(t, err, "run image 'some/stack-run' is not in the allowed run images")
+						h.AssertNil(t, localLifecycle.Opts.Image)
+					})
+
+					it("errors for forbidden buildpacks of the build image", func() {
+						subject.policy = readPolicy(t, tmpDir, `[buildpacks]
+forbidden = ["example/bp"]`)
+
+						err := subject.Build(context.TODO(), BuildOptions{
+							Image:    "some/app",
+							Executor: LocalExecutor,
+							Publish:  true,
+						})
+						h.AssertError(t, err, "buildpack 'example/bp' is forbidden")
+					})
+
+					it("errors for lifecycles older than the minimum version", func() {
+						subject.policy = readPolicy(t, tmpDir, `[lifecycle]
+minimum-version = "0.11.0"`)
+
+						err := subject.Build(context.TODO(), BuildOptions{
+							Image:    "some/app",
+							Executor: LocalExecutor,
+							Publish:  true,
+						})
+						h.AssertError(t, err, "lifecycle '0.10.2' is older than the minimum version '0.11.0'")
+					})
+
+					it("errors when the policy restricts builders", func() {
+						subject.policy = readPolicy(t, tmpDir, `[builders]
+allowed = ["some/builder"]`)
+
+						err := subject.Build(context.TODO(), BuildOptions{
+							Image:    "some/app",
+							Executor: LocalExecutor,
+							Publish:  true,
+						})
+						h.AssertError(t, err, "the build image of the local executor cannot be checked against the allowed builders, as required by policy")
+					})
+				})
+
+				it("errors for volume caches", func() {
+					err := subject.Build(context.TODO(), BuildOptions{
+						Image:      "some/app",
+						Executor:   LocalExecutor,
+						Publish:    true,
+						BuildCache: &CacheConfig{Format: VolumeCacheFormat},
+					})
+					h.AssertError(t, err, "local executor does not support volume caches")
+				})
+			})
+
+			it("errors for unknown executors", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:    "some/app",
+					Builder:  defaultBuilderName,
+					Executor: "kubernetes",
+				})
+				h.AssertError(t, err, "executor 'kubernetes' is not supported")
+			})
+		})
+
+		when("ephemeral builder", func() {
+			build := func(env map[string]string, buildpacks ...string) string {
+				t.Helper()
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					Env:        env,
+					Buildpacks: buildpacks,
+				}))
+				return fakeLifecycle.Opts.Builder.Name()
+			}
+
+			it("is not created when nothing is added to the builder", func() {
+				h.AssertEq(t, build(map[string]string{"key1": "value1"}), defaultBuilderName)
+				h.AssertEq(t, defaultBuilderImage.IsSaved(), false)
+			})
+
+			it("is named after the builder, buildpacks and order", func() {
+				name := build(map[string]string{"key1": "value1"}, "buildpack.1.id@buildpack.1.version")
+				h.AssertContainsMatch(t, name, `^pack.local/builder/[0-9a-f]{64}:latest$`)
+				h.AssertEq(t, build(map[string]string{"key1": "other-value"}, "buildpack.1.id@buildpack.1.version"), name)
+				h.AssertNotEq(t, build(nil, "buildpack.2.id@buildpack.2.version"), name)
+			})
+
+			it("does not contain the build env", func() {
+				build(map[string]string{"key1": "value1"}, "buildpack.1.id@buildpack.1.version")
+
+				bldrImage, ok := fakeLifecycle.Opts.Builder.Image().(*fakes.Image)
+				h.AssertTrue(t, ok)
+				_, err := bldrImage.FindLayerWithPath("/platform/env/key1")
+				h.AssertNotNil(t, err)
+			})
+
+			it("is labeled with the builder it was created from", func() {
+				build(nil, "buildpack.1.id@buildpack.1.version")
+				bldrImage := fakeLifecycle.Opts.Builder.Image()
+
+				label, err := bldrImage.Label("io.buildpacks.pack.base-builder.name")
+				h.AssertNil(t, err)
+				h.AssertEq(t, label, defaultBuilderName)
+				_, err = bldrImage.Label("io.buildpacks.pack.base-builder.id")
+				h.AssertNil(t, err)
+			})
+
+			it("is reused when it exists", func() {
+				name := build(nil, "buildpack.1.id@buildpack.1.version")
+
+				existingImage := newFakeBuilderImage(t, tmpDir, name, defaultBuilderStackID, defaultRunImageName, builder.DefaultLifecycleVersion, newLinuxImage)
+				fakeImageFetcher.LocalImages[name] = existingImage
+
+				h.AssertEq(t, build(nil, "buildpack.1.id@buildpack.1.version"), name)
+				h.AssertEq(t, fakeImageFetcher.FetchCalls[name].PullPolicy, config.PullNever)
+				h.AssertEq(t, existingImage.IsSaved(), false)
+			})
+		})
+
+		when("Publish option", func() {
+			var remoteRunImage, builderWithoutLifecycleImageOrCreator *fakes.Image
+
+			it.Before(func() {
+				remoteRunImage = fakes.NewImage("default/run", "", nil)
+				h.AssertNil(t, remoteRunImage.SetLabel("io.buildpacks.stack.id", defaultBuilderStackID))
+				h.AssertNil(t, remoteRunImage.SetLabel("io.buildpacks.stack.mixins", `["mixinA", "mixinX", "run:mixinZ"]`))
+				fakeImageFetcher.RemoteImages[remoteRunImage.Name()] = remoteRunImage
+
+				builderWithoutLifecycleImageOrCreator = newFakeBuilderImage(
+					t,
+					tmpDir,
+					"example.com/supportscreator/builder:tag",
+					"some.stack.id",
+					defaultRunImageName,
+					"0.3.0",
+					newLinuxImage,
+				)
+				h.AssertNil(t, builderWithoutLifecycleImageOrCreator.SetLabel("io.buildpacks.stack.mixins", `["mixinA", "build:mixinB", "mixinX", "build:mixinY"]`))
+				fakeImageFetcher.LocalImages[builderWithoutLifecycleImageOrCreator.Name()] = builderWithoutLifecycleImageOrCreator
+			})
+
+			it.After(func() {
+				remoteRunImage.Cleanup()
+				builderWithoutLifecycleImageOrCreator.Cleanup()
+			})
+
+			when("true", func() {
+				it("uses a remote run image", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+						Publish: true,
+					}))
+					h.AssertEq(t, fakeLifecycle.Opts.Publish, true)
+
+					args := fakeImageFetcher.FetchCalls["default/run"]
+					h.AssertEq(t, args.Daemon, false)
+
+					args = fakeImageFetcher.FetchCalls[defaultBuilderName]
+					h.AssertEq(t, args.Daemon, true)
+				})
+
+				when("builder is untrusted", func() {
+					when("lifecycle image is available", func() {
+						it("uses the 5 phases with the lifecycle image", func() {
+							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+								Image:        "some/app",
+								Builder:      defaultBuilderName,
+								Publish:      true,
+								TrustBuilder: false,
+							}))
+							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, false)
+							h.AssertEq(t, fakeLifecycle.Opts.LifecycleImage, fakeLifecycleImage.Name())
+
+							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
+							h.AssertEq(t, args.Daemon, true)
+							h.AssertEq(t, args.PullPolicy, config.PullAlways)
+							h.AssertEq(t, args.Platform, "linux/amd64")
+						})
+					})
+
+					when("lifecycle image is not available", func() {
+						it("errors", func() {
+							h.AssertNotNil(t, subject.Build(context.TODO(), BuildOptions{
+								Image:        "some/app",
+								Builder:      builderWithoutLifecycleImageOrCreator.Name(),
+								Publish:      true,
+								TrustBuilder: false,
+							}))
+						})
+					})
+				})
+
+				when("builder is trusted", func() {
+					when("lifecycle supports creator", func() {
+						it("uses the creator with the provided builder", func() {
+							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+								Image:        "some/app",
+								Builder:      defaultBuilderName,
+								Publish:      true,
+								TrustBuilder: true,
+							}))
+							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, true)
+
+							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
+							h.AssertNil(t, args)
+						})
+
+						when("the trust is pinned to digests", func() {
+							digest := "sha256:363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4"
+
+							it("uses the creator when the builder resolves to a trusted digest", func() {
+								builderDigest, err := name.NewDigest("example.com/default/builder@" + digest)
+								h.AssertNil(t, err)
+								defaultBuilderImage.SetIdentifier(remote.DigestIdentifier{Digest: builderDigest})
+
+								h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+									Image:        "some/app",
+									Builder:      defaultBuilderName,
+									Publish:      true,
+									TrustBuilder: true,
+									BuilderTrust: BuilderTrust{Digests: []string{"sha256:other", digest}},
+								}))
+								h.AssertEq(t, fakeLifecycle.Opts.UseCreator, true)
+								h.AssertEq(t, fakeLifecycle.Opts.TrustBuilder, true)
+							})
+
+							it("uses the 5 phases when the builder resolves to another digest", func() {
+								otherDigest := "sha256:" + strings.Repeat("a", 64)
+								builderDigest, err := name.NewDigest("example.com/default/builder@" + otherDigest)
+								h.AssertNil(t, err)
+								defaultBuilderImage.SetIdentifier(remote.DigestIdentifier{Digest: builderDigest})
+
+								h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+									Image:        "some/app",
+									Builder:      defaultBuilderName,
+									Publish:      true,
+									TrustBuilder: true,
+									BuilderTrust: BuilderTrust{Digests: []string{digest}},
+								}))
+								h.AssertEq(t, fakeLifecycle.Opts.UseCreator, false)
+								h.AssertEq(t, fakeLifecycle.Opts.TrustBuilder, false)
+								h.AssertEq(t, fakeLifecycle.Opts.LifecycleImage, fakeLifecycleImage.Name())
+								h.AssertContains(t, outBuf.String(), "Not trusting builder 'example.com/default/builder:tag': it resolved to '"+otherDigest+"', which is not trusted")
+							})
+
+							it("uses the 5 phases when the digest of the builder is unknown", func() {
+								h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+									Image:        "some/app",
+									Builder:      defaultBuilderName,
+									Publish:      true,
+									TrustBuilder: true,
+									BuilderTrust: BuilderTrust{Digests: []string{digest}},
+								}))
+								h.AssertEq(t, fakeLifecycle.Opts.UseCreator, false)
+								h.AssertContains(t, outBuf.String(), "Not trusting builder 'example.com/default/builder:tag': its digest could not be determined")
+							})
+						})
+					})
+
+					when("lifecycle doesn't support creator", func() {
+						// the default test builder (example.com/default/builder:tag) has lifecycle version 0.3.0, so creator is not supported
+						it("uses the 5 phases with the provided builder", func() {
+							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+								Image:        "some/app",
+								Builder:      builderWithoutLifecycleImageOrCreator.Name(),
+								Publish:      true,
+								TrustBuilder: true,
+							}))
+							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, false)
+							h.AssertEq(t, fakeLifecycle.Opts.LifecycleImage, builderWithoutLifecycleImageOrCreator.Name())
+
+							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
+							h.AssertNil(t, args)
+						})
+					})
+				})
+			})
+
+			when("false", func() {
+				it("uses a local run image", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+						Publish: false,
+					}))
+					h.AssertEq(t, fakeLifecycle.Opts.Publish, false)
+
+					args := fakeImageFetcher.FetchCalls["default/run"]
+					h.AssertEq(t, args.Daemon, true)
+					h.AssertEq(t, args.PullPolicy, config.PullAlways)
+
+					args = fakeImageFetcher.FetchCalls[defaultBuilderName]
+					h.AssertEq(t, args.Daemon, true)
+					h.AssertEq(t, args.PullPolicy, config.PullAlways)
+				})
+
+				when("builder is untrusted", func() {
+					when("lifecycle image is available", func() {
+						it("uses the 5 phases with the lifecycle image", func() {
+							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+								Image:        "some/app",
+								Builder:      defaultBuilderName,
+								Publish:      false,
+								TrustBuilder: false,
+							}))
+							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, false)
+							h.AssertEq(t, fakeLifecycle.Opts.LifecycleImage, fakeLifecycleImage.Name())
+
+							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
+							h.AssertEq(t, args.Daemon, true)
+							h.AssertEq(t, args.PullPolicy, config.PullAlways)
+							h.AssertEq(t, args.Platform, "linux/amd64")
+						})
+
+						it("suggests that being untrusted may be the root of a failure", func() {
+							subject.lifecycleExecutor = &executeFailsLifecycle{}
+							err := subject.Build(context.TODO(), BuildOptions{
+								Image:        "some/app",
+								Builder:      defaultBuilderName,
+								Publish:      false,
+								TrustBuilder: false,
+							})
+
+							h.AssertError(t, err, "may be the result of using an untrusted builder")
+						})
+					})
+
+					when("lifecycle image is not available", func() {
+						it("errors", func() {
+							h.AssertNotNil(t, subject.Build(context.TODO(), BuildOptions{
+								Image:        "some/app",
+								Builder:      builderWithoutLifecycleImageOrCreator.Name(),
+								Publish:      false,
+								TrustBuilder: false,
+							}))
+						})
+					})
+				})
+
+				when("builder is trusted", func() {
+					when("lifecycle supports creator", func() {
+						it("uses the creator with the provided builder", func() {
+							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+								Image:        "some/app",
+								Builder:      defaultBuilderName,
+								Publish:      false,
+								TrustBuilder: true,
+							}))
+							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, true)
+
+							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
+							h.AssertNil(t, args)
+						})
+					})
+
+					when("lifecycle doesn't support creator", func() {
+						// the default test builder (example.com/default/builder:tag) has lifecycle version 0.3.0, so creator is not supported
+						it("uses the 5 phases with the provided builder", func() {
+							h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+								Image:        "some/app",
+								Builder:      builderWithoutLifecycleImageOrCreator.Name(),
+								Publish:      false,
+								TrustBuilder: true,
+							}))
+							h.AssertEq(t, fakeLifecycle.Opts.UseCreator, false)
+							h.AssertEq(t, fakeLifecycle.Opts.LifecycleImage, builderWithoutLifecycleImageOrCreator.Name())
+
+							args := fakeImageFetcher.FetchCalls[fakeLifecycleImage.Name()]
+							h.AssertNil(t, args)
+						})
+					})
+				})
+			})
+		})
+
+		when("PullPolicy", func() {
+			when("never", func() {
+				it("uses the local builder and run images without updating", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:      "some/app",
+						Builder:    defaultBuilderName,
+						PullPolicy: config.PullNever,
+					}))
+
+					args := fakeImageFetcher.FetchCalls["default/run"]
+					h.AssertEq(t, args.Daemon, true)
+					h.AssertEq(t, args.PullPolicy, config.PullNever)
+
+					args = fakeImageFetcher.FetchCalls[defaultBuilderName]
+					h.AssertEq(t, args.Daemon, true)
+					h.AssertEq(t, args.PullPolicy, config.PullNever)
+
+					args = fakeImageFetcher.FetchCalls["buildpacksio/lifecycle:0.11.3"]
+					h.AssertEq(t, args.Daemon, true)
 					h.AssertEq(t, args.PullPolicy, config.PullNever)
 					h.AssertEq(t, args.Platform, "linux/amd64")
 				})
 			})
 
-			when("always", func() {
-				it("uses pulls the builder and run image before using them", func() {
+			when("always", func() {
+				it("uses pulls the builder and run image before using them", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:      "some/app",
+						Builder:    defaultBuilderName,
+						PullPolicy: config.PullAlways,
+					}))
+
+					args := fakeImageFetcher.FetchCalls["default/run"]
+					h.AssertEq(t, args.Daemon, true)
+					h.AssertEq(t, args.PullPolicy, config.PullAlways)
+
+					args = fakeImageFetcher.FetchCalls[defaultBuilderName]
+					h.AssertEq(t, args.Daemon, true)
+					h.AssertEq(t, args.PullPolicy, config.PullAlways)
+				})
+			})
+		})
+
+		when("ProxyConfig option", func() {
+			when("ProxyConfig is nil", func() {
+				it.Before(func() {
+					h.AssertNil(t, os.Setenv("http_proxy", "other-http-proxy"))
+					h.AssertNil(t, os.Setenv("https_proxy", "other-https-proxy"))
+					h.AssertNil(t, os.Setenv("no_proxy", "other-no-proxy"))
+				})
+
+				when("*_PROXY env vars are set", func() {
+					it.Before(func() {
+						h.AssertNil(t, os.Setenv("HTTP_PROXY", "some-http-proxy"))
+						h.AssertNil(t, os.Setenv("HTTPS_PROXY", "some-https-proxy"))
+						h.AssertNil(t, os.Setenv("NO_PROXY", "some-no-proxy"))
+					})
+
+					it.After(func() {
+						h.AssertNilE(t, os.Unsetenv("HTTP_PROXY"))
+						h.AssertNilE(t, os.Unsetenv("HTTPS_PROXY"))
+						h.AssertNilE(t, os.Unsetenv("NO_PROXY"))
+					})
+
+					it("defaults to the *_PROXY environment variables", func() {
+						h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+							Image:   "some/app",
+							Builder: defaultBuilderName,
+						}))
+						h.AssertEq(t, fakeLifecycle.Opts.HTTPProxy, "some-http-proxy")
+						h.AssertEq(t, fakeLifecycle.Opts.HTTPSProxy, "some-https-proxy")
+						h.AssertEq(t, fakeLifecycle.Opts.NoProxy, "some-no-proxy")
+					})
+				})
+
+				it("falls back to the *_proxy environment variables", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+					}))
+					h.AssertEq(t, fakeLifecycle.Opts.HTTPProxy, "other-http-proxy")
+					h.AssertEq(t, fakeLifecycle.Opts.HTTPSProxy, "other-https-proxy")
+					h.AssertEq(t, fakeLifecycle.Opts.NoProxy, "other-no-proxy")
+				})
+			}, spec.Sequential())
+
+			when("ProxyConfig is not nil", func() {
+				it("passes the values through", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+						ProxyConfig: &ProxyConfig{
+							HTTPProxy:  "custom-http-proxy",
+							HTTPSProxy: "custom-https-proxy",
+							NoProxy:    "custom-no-proxy",
+						},
+					}))
+					h.AssertEq(t, fakeLifecycle.Opts.HTTPProxy, "custom-http-proxy")
+					h.AssertEq(t, fakeLifecycle.Opts.HTTPSProxy, "custom-https-proxy")
+					h.AssertEq(t, fakeLifecycle.Opts.NoProxy, "custom-no-proxy")
+				})
+			})
+		})
+
+		when("Secrets option", func() {
+			var (
+				appDir     string
+				secretPath string
+			)
+
+			it.Before(func() {
+				var err error
+				appDir, err = ioutil.TempDir(tmpDir, "secrets-app")
+				h.AssertNil(t, err)
+				appDir, err = filepath.EvalSymlinks(appDir)
+				h.AssertNil(t, err)
+				secretPath = filepath.Join(appDir, ".npmrc")
+				h.AssertNil(t, ioutil.WriteFile(secretPath, []byte("some-token"), 0600))
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(appDir, "app.js"), []byte("app"), 0600))
+			})
+
+			it("passes the secrets with absolute sources to the lifecycle", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:           "some/app",
+					Builder:         defaultBuilderName,
+					AppPath:         appDir,
+					RelativeBaseDir: appDir,
+					ContainerConfig: ContainerConfig{
+						Secrets: []Secret{{ID: "npmrc", Source: ".npmrc"}},
+					},
+				}))
+
+				h.AssertEq(t, fakeLifecycle.Opts.Secrets, []build.Secret{{ID: "npmrc", Source: secretPath}})
+			})
+
+			it("does not copy secrets within the app dir into the app volume", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					AppPath: appDir,
+					ContainerConfig: ContainerConfig{
+						Secrets: []Secret{{ID: "npmrc", Source: secretPath}},
+					},
+				}))
+
+				h.AssertNotNil(t, fakeLifecycle.Opts.FileFilter)
+				h.AssertFalse(t, fakeLifecycle.Opts.FileFilter(".npmrc"))
+				h.AssertTrue(t, fakeLifecycle.Opts.FileFilter("app.js"))
+			})
+
+			it("adds secrets from the project descriptor unless overridden", func() {
+				otherSecretPath := filepath.Join(appDir, "other")
+				h.AssertNil(t, ioutil.WriteFile(otherSecretPath, []byte("other-token"), 0600))
+
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:                    "some/app",
+					Builder:                  defaultBuilderName,
+					AppPath:                  appDir,
+					ProjectDescriptorBaseDir: appDir,
+					ProjectDescriptor: project.Descriptor{
+						Build: project.Build{
+							Secrets: []project.Secret{
+								{ID: "npmrc", Source: ".npmrc"},
+								{ID: "other", Source: "other"},
+							},
+						},
+					},
+					ContainerConfig: ContainerConfig{
+						Secrets: []Secret{{ID: "other", Source: secretPath}},
+					},
+				}))
+
+				h.AssertEq(t, fakeLifecycle.Opts.Secrets, []build.Secret{
+					{ID: "npmrc", Source: secretPath},
+					{ID: "other", Source: secretPath},
+				})
+			})
+
+			it("errors when the secret does not exist", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					ContainerConfig: ContainerConfig{
+						Secrets: []Secret{{ID: "npmrc", Source: filepath.Join(appDir, "missing")}},
+					},
+				})
+				h.AssertError(t, err, "reading secret 'npmrc'")
+			})
+
+			it("errors when the secret id is invalid", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					ContainerConfig: ContainerConfig{
+						Secrets: []Secret{{ID: "../npmrc", Source: secretPath}},
+					},
+				})
+				h.AssertError(t, err, "invalid secret id '../npmrc'")
+			})
+
+			it("errors for Windows builds", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultWindowsBuilderName,
+					ContainerConfig: ContainerConfig{
+						Secrets: []Secret{{ID: "npmrc", Source: secretPath}},
+					},
+				})
+				h.AssertError(t, err, "secrets are not supported for Windows builds")
+			})
+		})
+
+		when("resource limits are provided", func() {
+			it("passes them through to the lifecycle", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					ContainerConfig: ContainerConfig{
+						CPUs:         2,
+						Memory:       1024 * 1024 * 1024,
+						PidsLimit:    256,
+						PhaseTimeout: 15 * time.Minute,
+					},
+				}))
+
+				h.AssertEq(t, fakeLifecycle.Opts.CPUs, float64(2))
+				h.AssertEq(t, fakeLifecycle.Opts.Memory, int64(1024*1024*1024))
+				h.AssertEq(t, fakeLifecycle.Opts.PidsLimit, int64(256))
+				h.AssertEq(t, fakeLifecycle.Opts.PhaseTimeout, 15*time.Minute)
+			})
+
+			it("errors when a limit is negative", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:           "some/app",
+					Builder:         defaultBuilderName,
+					ContainerConfig: ContainerConfig{Memory: -1},
+				})
+				h.AssertError(t, err, "memory must not be negative")
+			})
+		})
+
+		when("Cache options", func() {
+			var appDir string
+
+			it.Before(func() {
+				var err error
+				appDir, err = ioutil.TempDir(tmpDir, "cache-app")
+				h.AssertNil(t, err)
+				appDir, err = filepath.EvalSymlinks(appDir)
+				h.AssertNil(t, err)
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(appDir, "app.js"), []byte("app"), 0600))
+			})
+
+			it("creates the directory of a bind cache and passes it to the lifecycle", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:           "some/app",
+					Builder:         defaultBuilderName,
+					AppPath:         appDir,
+					RelativeBaseDir: appDir,
+					BuildCache:      &CacheConfig{Format: BindCacheFormat, Source: "cache"},
+				}))
+
+				cacheDir := filepath.Join(appDir, "cache")
+				h.AssertEq(t, fakeLifecycle.Opts.BuildCache, &build.CacheOptions{Type: cache.Bind, Source: cacheDir})
+				h.AssertNil(t, fakeLifecycle.Opts.LaunchCache)
+
+				info, err := os.Stat(cacheDir)
+				h.AssertNil(t, err)
+				h.AssertTrue(t, info.IsDir())
+			})
+
+			it("does not copy a bind cache within the app dir into the app volume", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					AppPath:    appDir,
+					BuildCache: &CacheConfig{Format: BindCacheFormat, Source: filepath.Join(appDir, "cache")},
+				}))
+
+				h.AssertNotNil(t, fakeLifecycle.Opts.FileFilter)
+				h.AssertFalse(t, fakeLifecycle.Opts.FileFilter("cache"))
+				h.AssertFalse(t, fakeLifecycle.Opts.FileFilter(filepath.Join("cache", "committed")))
+				h.AssertTrue(t, fakeLifecycle.Opts.FileFilter("app.js"))
+			})
+
+			it("passes a named volume cache to the lifecycle", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:       "some/app",
+					Builder:     defaultBuilderName,
+					LaunchCache: &CacheConfig{Name: "shared-launch-cache"},
+				}))
+
+				h.AssertNil(t, fakeLifecycle.Opts.BuildCache)
+				h.AssertEq(t, fakeLifecycle.Opts.LaunchCache, &build.CacheOptions{Type: cache.Volume, Name: "shared-launch-cache"})
+			})
+
+			it("adds caches from the project descriptor unless overridden", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:                    "some/app",
+					Builder:                  defaultBuilderName,
+					AppPath:                  appDir,
+					ProjectDescriptorBaseDir: appDir,
+					ProjectDescriptor: project.Descriptor{
+						Build: project.Build{
+							Caches: []project.Cache{
+								{Type: "build", Format: "bind", Source: "build-cache"},
+								{Type: "launch", Format: "volume", Name: "project-launch-cache"},
+							},
+						},
+					},
+					LaunchCache: &CacheConfig{Format: VolumeCacheFormat, Name: "other-launch-cache"},
+				}))
+
+				h.AssertEq(t, fakeLifecycle.Opts.BuildCache, &build.CacheOptions{Type: cache.Bind, Source: filepath.Join(appDir, "build-cache")})
+				h.AssertEq(t, fakeLifecycle.Opts.LaunchCache, &build.CacheOptions{Type: cache.Volume, Name: "other-launch-cache"})
+			})
+
+			it("errors for an invalid cache in the project descriptor", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					ProjectDescriptor: project.Descriptor{
+						Build: project.Build{
+							Caches: []project.Cache{{Type: "other"}},
+						},
+					},
+				})
+				h.AssertError(t, err, "project.toml: cache type 'other' is not supported")
+			})
+
+			it("errors when a cache image is combined with a build cache", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					Publish:    true,
+					CacheImage: "some/cache",
+					BuildCache: &CacheConfig{Format: VolumeCacheFormat},
+				})
+				h.AssertError(t, err, "cache image cannot be combined with a build cache")
+			})
+
+			it("passes an image cache of a daemon build to the lifecycle as read-only", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					BuildCache: &CacheConfig{Format: ImageCacheFormat, Name: "some/cache"},
+				}))
+
+				h.AssertEq(t, fakeLifecycle.Opts.BuildCache, &build.CacheOptions{Type: cache.Image, Name: "some/cache"})
+				h.AssertTrue(t, fakeLifecycle.Opts.CacheImageReadOnly)
+			})
+
+			it("allows writing an image cache from a daemon build", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:           "some/app",
+					Builder:         defaultBuilderName,
+					BuildCache:      &CacheConfig{Format: ImageCacheFormat, Name: "some/cache"},
+					WriteCacheImage: true,
+				}))
+
+				h.AssertFalse(t, fakeLifecycle.Opts.CacheImageReadOnly)
+			})
+
+			it("errors when writing the cache image without an image cache", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:           "some/app",
+					Builder:         defaultBuilderName,
+					BuildCache:      &CacheConfig{Format: VolumeCacheFormat},
+					WriteCacheImage: true,
+				})
+				h.AssertError(t, err, "writing the cache image requires a cache image")
+			})
+
+			it("errors when the launch cache is an image", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:       "some/app",
+					Builder:     defaultBuilderName,
+					Publish:     true,
+					LaunchCache: &CacheConfig{Format: ImageCacheFormat, Name: "some/cache"},
+				})
+				h.AssertError(t, err, "launch cache does not support the image format")
+			})
+
+			it("errors when a bind cache has no source", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					BuildCache: &CacheConfig{Format: BindCacheFormat},
+				})
+				h.AssertError(t, err, "bind cache requires a source")
+			})
+		})
+
+		when("SSH option", func() {
+			var keyDir string
+
+			it.Before(func() {
+				if runtime.GOOS == "windows" {
+					t.Skip("SSH agent forwarding is not supported on Windows")
+				}
+
+				var err error
+				keyDir, err = ioutil.TempDir(tmpDir, "ssh-keys")
+				h.AssertNil(t, err)
+
+				key, err := rsa.GenerateKey(cryptorand.Reader, 2048)
+				h.AssertNil(t, err)
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(keyDir, "id_rsa"), pem.EncodeToMemory(&pem.Block{
+					Type:  "RSA PRIVATE KEY",
+					Bytes: x509.MarshalPKCS1PrivateKey(key),
+				}), 0600))
+			})
+
+			it("serves the keys on a socket passed to the lifecycle and removes it afterwards", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:           "some/app",
+					Builder:         defaultBuilderName,
+					RelativeBaseDir: keyDir,
+					ContainerConfig: ContainerConfig{
+						SSH: &SSHConfig{Keys: []string{"id_rsa"}},
+					},
+				}))
+
+				h.AssertNotEq(t, fakeLifecycle.Opts.SSHAuthSock, "")
+				_, err := os.Stat(fakeLifecycle.Opts.SSHAuthSock)
+				h.AssertTrue(t, os.IsNotExist(err))
+			})
+
+			it("forwards the agent at the given socket", func() {
+				upstream, err := sshagent.WithKeys(filepath.Join(keyDir, "id_rsa"))
+				h.AssertNil(t, err)
+				defer upstream.Close()
+
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					ContainerConfig: ContainerConfig{
+						SSH: &SSHConfig{AgentSocket: upstream.Socket()},
+					},
+				}))
+
+				h.AssertNotEq(t, fakeLifecycle.Opts.SSHAuthSock, "")
+				h.AssertNotEq(t, fakeLifecycle.Opts.SSHAuthSock, upstream.Socket())
+			})
+
+			it("does not forward an agent when not set", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+				}))
+
+				h.AssertEq(t, fakeLifecycle.Opts.SSHAuthSock, "")
+			})
+
+			it("errors when the agent cannot be reached", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					ContainerConfig: ContainerConfig{
+						SSH: &SSHConfig{AgentSocket: filepath.Join(keyDir, "missing.sock")},
+					},
+				})
+				h.AssertError(t, err, "forwarding SSH agent")
+			})
+
+			it("errors when a key cannot be read", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					ContainerConfig: ContainerConfig{
+						SSH: &SSHConfig{Keys: []string{filepath.Join(keyDir, "missing")}},
+					},
+				})
+				h.AssertError(t, err, "reading SSH key")
+			})
+
+			it("errors for Windows builds", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultWindowsBuilderName,
+					ContainerConfig: ContainerConfig{
+						SSH: &SSHConfig{Keys: []string{filepath.Join(keyDir, "id_rsa")}},
+					},
+				})
+				h.AssertError(t, err, "SSH agent forwarding is not supported for Windows builds")
+			})
+		})
+
+		when("Platforms option", func() {
+			when("a single platform is given", func() {
+				it("fetches the builder and run image for the platform", func() {
 					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
-						Image:      "some/app",
-						Builder:    defaultBuilderName,
-						PullPolicy: config.PullAlways,
+						Image:     "some/app",
+						Builder:   defaultBuilderName,
+						Platforms: []string{"linux/amd64"},
 					}))
 
-					args := fakeImageFetcher.FetchCalls["default/run"]
-					h.AssertEq(t, args.Daemon, true)
-					h.AssertEq(t, args.PullPolicy, config.PullAlways)
+					h.AssertEq(t, fakeImageFetcher.FetchCalls[defaultBuilderName].Platform, "linux/amd64")
+					h.AssertEq(t, fakeImageFetcher.FetchCalls["default/run"].Platform, "linux/amd64")
+				})
 
-					args = fakeImageFetcher.FetchCalls[defaultBuilderName]
-					h.AssertEq(t, args.Daemon, true)
-					h.AssertEq(t, args.PullPolicy, config.PullAlways)
+				it("errors when the builder is for another platform", func() {
+					err := subject.Build(context.TODO(), BuildOptions{
+						Image:     "some/app",
+						Builder:   defaultBuilderName,
+						Platforms: []string{"linux/arm64"},
+					})
+					h.AssertError(t, err, "image is for platform 'linux/amd64', not 'linux/arm64'")
+				})
+
+				it("errors when the platform is invalid", func() {
+					err := subject.Build(context.TODO(), BuildOptions{
+						Image:     "some/app",
+						Builder:   defaultBuilderName,
+						Platforms: []string{"arm64"},
+					})
+					h.AssertError(t, err, "invalid platform 'arm64'")
 				})
 			})
-		})
 
-		when("ProxyConfig option", func() {
-			when("ProxyConfig is nil", func() {
+			when("multiple platforms are given", func() {
+				var (
+					server         *httptest.Server
+					repoName       string
+					mockController *gomock.Controller
+					mockDocker     *testmocks.MockCommonAPIClient
+					exportedOpts   []build.LifecycleOptions
+					exportedImages map[string]v1.Image
+					removedNames   []string
+				)
+
 				it.Before(func() {
-					h.AssertNil(t, os.Setenv("http_proxy", "other-http-proxy"))
-					h.AssertNil(t, os.Setenv("https_proxy", "other-https-proxy"))
-					h.AssertNil(t, os.Setenv("no_proxy", "other-no-proxy"))
+					server = httptest.NewServer(registry.New())
+					repoName = strings.TrimPrefix(server.URL, "http://") + "/some/app"
+
+					mockController = gomock.NewController(t)
+					mockDocker = testmocks.NewMockCommonAPIClient(mockController)
+					subject.docker = mockDocker
+
+					exportedOpts, exportedImages, removedNames = nil, map[string]v1.Image{}, nil
+					subject.lifecycleExecutor = lifecycleExecutorFunc(func(ctx context.Context, opts build.LifecycleOptions) error {
+						exportedOpts = append(exportedOpts, opts)
+						img, err := random.Image(10, 1)
+						if err != nil {
+							return err
+						}
+						exportedImages[opts.Image.Name()] = img
+						return nil
+					})
+
+					mockDocker.EXPECT().
+						ImageSave(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, names []string) (io.ReadCloser, error) {
+							tag, err := name.NewTag(names[0])
+							h.AssertNil(t, err)
+							saved := &bytes.Buffer{}
+							h.AssertNil(t, tarball.Write(tag, exportedImages[names[0]], saved))
+							return ioutil.NopCloser(saved), nil
+						}).AnyTimes()
+					mockDocker.EXPECT().
+						ImageRemove(gomock.Any(), gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, name string, _ types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error) {
+							removedNames = append(removedNames, name)
+							return nil, nil
+						}).AnyTimes()
 				})
 
-				when("*_PROXY env vars are set", func() {
-					it.Before(func() {
-						h.AssertNil(t, os.Setenv("HTTP_PROXY", "some-http-proxy"))
-						h.AssertNil(t, os.Setenv("HTTPS_PROXY", "some-https-proxy"))
-						h.AssertNil(t, os.Setenv("NO_PROXY", "some-no-proxy"))
-					})
+				it.After(func() {
+					mockController.Finish()
+					server.Close()
+				})
 
-					it.After(func() {
-						h.AssertNilE(t, os.Unsetenv("HTTP_PROXY"))
-						h.AssertNilE(t, os.Unsetenv("HTTPS_PROXY"))
-						h.AssertNilE(t, os.Unsetenv("NO_PROXY"))
+				it("publishes the image of each platform by digest and an index referencing them", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:          repoName + ":latest",
+						Builder:        defaultBuilderName,
+						Publish:        true,
+						AdditionalTags: []string{repoName + ":other"},
+						CacheImage:     repoName + ":cache",
+						Platforms:      []string{"linux/amd64", "linux/amd64/v3"},
+					}))
+
+					h.AssertEq(t, len(exportedOpts), 2)
+					h.AssertContainsMatch(t, exportedOpts[0].Image.Name(), `^pack.local/index/[0-9a-f]+:linux-amd64$`)
+					h.AssertEq(t, exportedOpts[0].Publish, false)
+					h.AssertEq(t, exportedOpts[0].CacheImage, repoName+":cache-linux-amd64")
+					h.AssertEq(t, exportedOpts[0].CacheImageReadOnly, false)
+					h.AssertEq(t, len(exportedOpts[0].AdditionalTags), 0)
+					h.AssertContainsMatch(t, exportedOpts[1].Image.Name(), `^pack.local/index/[0-9a-f]+:linux-amd64-v3$`)
+					h.AssertSliceContains(t, removedNames, exportedOpts[0].Image.Name(), exportedOpts[1].Image.Name())
+
+					repo, err := name.NewRepository(repoName)
+					h.AssertNil(t, err)
+					tags, err := ggcrremote.List(repo)
+					h.AssertNil(t, err)
+					sort.Strings(tags)
+					h.AssertEq(t, tags, []string{"latest", "other"})
+
+					for _, tag := range []string{repoName + ":latest", repoName + ":other"} {
+						ref, err := name.ParseReference(tag)
+						h.AssertNil(t, err)
+						index, err := ggcrremote.Index(ref)
+						h.AssertNil(t, err)
+						manifest, err := index.IndexManifest()
+						h.AssertNil(t, err)
+						h.AssertEq(t, len(manifest.Manifests), 2)
+						h.AssertEq(t, manifest.Manifests[1].Platform.Variant, "v3")
+					}
+					h.AssertContains(t, outBuf.String(), "Published image index")
+				})
+
+				it("emits a single exported event with the digest of the index", func() {
+					var exported []events.Event
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:     repoName + ":latest",
+						Builder:   defaultBuilderName,
+						Publish:   true,
+						Platforms: []string{"linux/amd64", "linux/amd64/v3"},
+						EventSink: events.SinkFunc(func(e events.Event) {
+							if e.Type == events.ImageExported {
+								exported = append(exported, e)
+							}
+						}),
+					}))
+
+					ref, err := name.ParseReference(repoName + ":latest")
+					h.AssertNil(t, err)
+					index, err := ggcrremote.Index(ref)
+					h.AssertNil(t, err)
+					digest, err := index.Digest()
+					h.AssertNil(t, err)
+
+					h.AssertEq(t, len(exported), 1)
+					h.AssertEq(t, exported[0].Image, ref.Name())
+					h.AssertEq(t, exported[0].Digest, digest.String())
+					h.AssertEq(t, exported[0].Platform, "")
+				})
+
+				it("names the caches after each platform", func() {
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:     repoName + ":latest",
+						Builder:   defaultBuilderName,
+						Publish:   true,
+						Platforms: []string{"linux/amd64", "linux/amd64/v3"},
+					}))
+
+					platformRef, err := name.ParseReference(repoName + ":latest-linux-amd64-v3")
+					h.AssertNil(t, err)
+					h.AssertEq(t, exportedOpts[1].BuildCache.Name, cache.NewVolumeCache(platformRef, "build", nil).Name())
+					h.AssertEq(t, exportedOpts[1].LaunchCache.Name, cache.NewVolumeCache(platformRef, "launch", nil).Name())
+				})
+
+				it("sets the platform of the events of each platform", func() {
+					var platforms []string
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:     repoName + ":latest",
+						Builder:   defaultBuilderName,
+						Platforms: []string{"linux/amd64", "linux/amd64/v3"},
+						DryRun:    true,
+						EventSink: events.SinkFunc(func(e events.Event) {
+							if e.Type == events.BuildResolved {
+								platforms = append(platforms, e.Platform)
+							}
+						}),
+					}))
+
+					h.AssertEq(t, platforms, []string{"linux/amd64", "linux/amd64/v3"})
+				})
+
+				it("requires publish", func() {
+					err := subject.Build(context.TODO(), BuildOptions{
+						Image:     repoName + ":latest",
+						Builder:   defaultBuilderName,
+						Platforms: []string{"linux/amd64", "linux/arm64"},
 					})
+					h.AssertError(t, err, "building for multiple platforms requires publishing the image")
+				})
 
-					it("defaults to the *_PROXY environment variables", func() {
-						h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
-							Image:   "some/app",
-							Builder: defaultBuilderName,
-						}))
-						h.AssertEq(t, fakeLifecycle.Opts.HTTPProxy, "some-http-proxy")
-						h.AssertEq(t, fakeLifecycle.Opts.HTTPSProxy, "some-https-proxy")
-						h.AssertEq(t, fakeLifecycle.Opts.NoProxy, "some-no-proxy")
+				it("errors when a platform is given twice", func() {
+					err := subject.Build(context.TODO(), BuildOptions{
+						Image:     repoName + ":latest",
+						Builder:   defaultBuilderName,
+						Publish:   true,
+						Platforms: []string{"linux/amd64", "linux/amd64"},
 					})
+					h.AssertError(t, err, "platform 'linux/amd64' is specified more than once")
 				})
+			})
+		})
+
+		when("Output option", func() {
+			var (
+				mockController *gomock.Controller
+				mockDocker     *testmocks.MockCommonAPIClient
+				outputDir      string
+				savedImage     v1.Image
+				savedNames     []string
+				removedNames   []string
+			)
+
+			it.Before(func() {
+				mockController = gomock.NewController(t)
+				mockDocker = testmocks.NewMockCommonAPIClient(mockController)
+				subject.docker = mockDocker
+
+				var err error
+				outputDir, err = ioutil.TempDir(tmpDir, "output")
+				h.AssertNil(t, err)
+
+				savedImage, err = random.Image(10, 1)
+				h.AssertNil(t, err)
+				tag, err := name.NewTag("some/app")
+				h.AssertNil(t, err)
+				saved := &bytes.Buffer{}
+				h.AssertNil(t, tarball.Write(tag, savedImage, saved))
+
+				savedNames, removedNames = nil, nil
+				mockDocker.EXPECT().
+					ImageSave(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, names []string) (io.ReadCloser, error) {
+						savedNames = append(savedNames, names...)
+						return ioutil.NopCloser(bytes.NewReader(saved.Bytes())), nil
+					}).AnyTimes()
+				mockDocker.EXPECT().
+					ImageRemove(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, name string, _ types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error) {
+						removedNames = append(removedNames, name)
+						return nil, nil
+					}).AnyTimes()
+			})
+
+			it.After(func() {
+				mockController.Finish()
+			})
+
+			assertOnlyExportedImageRemoved := func() {
+				t.Helper()
+				h.AssertEq(t, len(savedNames), 1)
+				h.AssertContainsMatch(t, savedNames[0], `^pack.local/output/[0-9a-f]+:latest$`)
+				h.AssertEq(t, fakeLifecycle.Opts.Image.Name(), savedNames[0])
+				h.AssertEq(t, removedNames, savedNames)
+			}
+
+			readLayoutIndex := func(dir string) *v1.IndexManifest {
+				t.Helper()
+				p, err := layout.FromPath(dir)
+				h.AssertNil(t, err)
+				index, err := p.ImageIndex()
+				h.AssertNil(t, err)
+				manifest, err := index.IndexManifest()
+				h.AssertNil(t, err)
+				return manifest
+			}
+
+			when("oci-layout", func() {
+				it("writes the image to the layout and removes only the image it exported from the daemon", func() {
+					layoutDir := filepath.Join(outputDir, "layout")
 
-				it("falls back to the *_proxy environment variables", func() {
 					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
 						Image:   "some/app",
 						Builder: defaultBuilderName,
+						Output:  &ImageOutput{Format: OCILayoutOutput, Path: layoutDir},
 					}))
-					h.AssertEq(t, fakeLifecycle.Opts.HTTPProxy, "other-http-proxy")
-					h.AssertEq(t, fakeLifecycle.Opts.HTTPSProxy, "other-https-proxy")
-					h.AssertEq(t, fakeLifecycle.Opts.NoProxy, "other-no-proxy")
+
+					manifest := readLayoutIndex(layoutDir)
+					h.AssertEq(t, len(manifest.Manifests), 1)
+					h.AssertEq(t, manifest.Manifests[0].Annotations["org.opencontainers.image.ref.name"], "index.docker.io/some/app:latest")
+					h.AssertContains(t, outBuf.String(), "Saved image 'index.docker.io/some/app:latest' to 'oci-layout:"+layoutDir+"'")
+					assertOnlyExportedImageRemoved()
 				})
-			}, spec.Sequential())
 
-			when("ProxyConfig is not nil", func() {
-				it("passes the values through", func() {
+				it("keeps the caches named after the image", func() {
 					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
 						Image:   "some/app",
 						Builder: defaultBuilderName,
-						ProxyConfig: &ProxyConfig{
-							HTTPProxy:  "custom-http-proxy",
-							HTTPSProxy: "custom-https-proxy",
-							NoProxy:    "custom-no-proxy",
-						},
+						Output:  &ImageOutput{Format: OCILayoutOutput, Path: filepath.Join(outputDir, "layout")},
 					}))
-					h.AssertEq(t, fakeLifecycle.Opts.HTTPProxy, "custom-http-proxy")
-					h.AssertEq(t, fakeLifecycle.Opts.HTTPSProxy, "custom-https-proxy")
-					h.AssertEq(t, fakeLifecycle.Opts.NoProxy, "custom-no-proxy")
+
+					imageRef, err := name.ParseReference("some/app")
+					h.AssertNil(t, err)
+					h.AssertEq(t, fakeLifecycle.Opts.BuildCache.Name, cache.NewVolumeCache(imageRef, "build", nil).Name())
+					h.AssertEq(t, fakeLifecycle.Opts.LaunchCache.Name, cache.NewVolumeCache(imageRef, "launch", nil).Name())
+				})
+
+				it("replaces the image with the same name on subsequent builds", func() {
+					for i := 0; i < 2; i++ {
+						h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+							Image:           "some/app",
+							Builder:         defaultBuilderName,
+							RelativeBaseDir: outputDir,
+							Output:          &ImageOutput{Format: OCILayoutOutput, Path: "layout"},
+						}))
+					}
+
+					manifest := readLayoutIndex(filepath.Join(outputDir, "layout"))
+					h.AssertEq(t, len(manifest.Manifests), 1)
+				})
+			})
+
+			when("oci-archive", func() {
+				it("writes the image to a layout archive", func() {
+					archivePath := filepath.Join(outputDir, "app.tar")
+
+					h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+						Image:   "some/app",
+						Builder: defaultBuilderName,
+						Output:  &ImageOutput{Format: OCIArchiveOutput, Path: archivePath},
+					}))
+
+					f, err := os.Open(archivePath)
+					h.AssertNil(t, err)
+					defer f.Close()
+					_, contents, err := archive.ReadTarEntry(f, "/index.json")
+					h.AssertNil(t, err)
+					h.AssertContains(t, string(contents), "index.docker.io/some/app:latest")
+					assertOnlyExportedImageRemoved()
+				})
+			})
+
+			it("errors when combined with publish", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					Publish: true,
+					Output:  &ImageOutput{Format: OCILayoutOutput, Path: outputDir},
+				})
+				h.AssertError(t, err, "output cannot be combined with publish")
+			})
+
+			it("errors for unsupported formats", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					Output:  &ImageOutput{Format: "docker-archive", Path: outputDir},
 				})
+				h.AssertError(t, err, "output format 'docker-archive' is not supported")
 			})
 		})
 
@@ -1866,6 +3660,188 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("EventSink option", func() {
+			var builtImage *fakes.Image
+
+			it.Before(func() {
+				builtImage = fakes.NewImage("index.docker.io/some/app:latest", "", local.IDIdentifier{
+					ImageID: "363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4",
+				})
+				fakeImageFetcher.LocalImages[builtImage.Name()] = builtImage
+			})
+
+			it.After(func() {
+				h.AssertNilE(t, builtImage.Cleanup())
+			})
+
+			it("passes the sink through to the lifecycle", func() {
+				sink := events.SinkFunc(func(events.Event) {})
+
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:     "some/app",
+					Builder:   defaultBuilderName,
+					EventSink: sink,
+				}))
+
+				h.AssertNotNil(t, fakeLifecycle.Opts.EventSink)
+			})
+
+			it("emits the exported image", func() {
+				var received []events.Event
+				sink := events.SinkFunc(func(e events.Event) { received = append(received, e) })
+
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:     "some/app",
+					Builder:   defaultBuilderName,
+					EventSink: sink,
+				}))
+
+				h.AssertEq(t, len(received), 2)
+				h.AssertEq(t, received[1].Type, events.ImageExported)
+				h.AssertEq(t, received[1].Image, "index.docker.io/some/app:latest")
+				h.AssertEq(t, received[1].Digest, "sha256:363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4")
+			})
+
+			it("still prints the image and digest in quiet mode", func() {
+				logger.WantQuiet(true)
+				defer logger.WantQuiet(false)
+
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:     "some/app",
+					Builder:   defaultBuilderName,
+					EventSink: events.SinkFunc(func(events.Event) {}),
+				}))
+
+				h.AssertEq(t, strings.TrimSpace(outBuf.String()), "some/app@sha256:363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4")
+			})
+
+			it("does not print the image and digest in quiet mode when the events are written to the output", func() {
+				logger.WantQuiet(true)
+				defer logger.WantQuiet(false)
+
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:     "some/app",
+					Builder:   defaultBuilderName,
+					EventSink: events.NewJSONSink(ioutil.Discard),
+				}))
+
+				h.AssertNotContains(t, outBuf.String(), "some/app@sha256")
+			})
+
+			it("emits the resolved builder and run image", func() {
+				var received []events.Event
+				sink := events.SinkFunc(func(e events.Event) { received = append(received, e) })
+
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:     "some/app",
+					Builder:   defaultBuilderName,
+					EventSink: sink,
+				}))
+
+				h.AssertEq(t, received[0].Type, events.BuildResolved)
+				h.AssertEq(t, received[0].Builder, "example.com/default/builder:tag")
+				h.AssertEq(t, received[0].RunImage, "default/run")
+				h.AssertEq(t, received[0].RunImageMirrors, []string{"registry1.example.com/run/mirror", "registry2.example.com/run/mirror"})
+				h.AssertEq(t, received[0].LifecycleVersion, builder.DefaultLifecycleVersion)
+			})
+
+			it("emits the digest of the run image", func() {
+				runImage := newLinuxImage("default/run", "", local.IDIdentifier{ImageID: "some-run-image-id"})
+				h.AssertNil(t, runImage.SetLabel("io.buildpacks.stack.id", defaultBuilderStackID))
+				h.AssertNil(t, runImage.SetLabel("io.buildpacks.stack.mixins", `["mixinA", "run:mixinC", "mixinX", "run:mixinZ"]`))
+				fakeImageFetcher.LocalImages[runImage.Name()] = runImage
+
+				var received []events.Event
+				sink := events.SinkFunc(func(e events.Event) { received = append(received, e) })
+
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:     "some/app",
+					Builder:   defaultBuilderName,
+					EventSink: sink,
+				}))
+
+				h.AssertEq(t, received[0].RunImageDigest, "sha256:some-run-image-id")
+				h.AssertEq(t, received[0].BuilderDigest, "")
+			})
+		})
+
+		when("DebugOnFailure option", func() {
+			it("passes the value and the terminal of the shell through", func() {
+				in, out := &bytes.Buffer{}, &bytes.Buffer{}
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:          "some/app",
+					Builder:        defaultBuilderName,
+					DebugOnFailure: true,
+					DebugShellIn:   in,
+					DebugShellOut:  out,
+				}))
+				h.AssertEq(t, fakeLifecycle.Opts.DebugOnFailure, true)
+				h.AssertTrue(t, fakeLifecycle.Opts.DebugShellIn == in)
+				h.AssertTrue(t, fakeLifecycle.Opts.DebugShellOut == out)
+			})
+
+			it("errors without the terminal of the shell", func() {
+				err := subject.Build(context.TODO(), BuildOptions{
+					Image:          "some/app",
+					Builder:        defaultBuilderName,
+					DebugOnFailure: true,
+				})
+				h.AssertError(t, err, "debug on failure requires a reader and writer for the debug shell")
+			})
+		})
+
+		when("DryRun option", func() {
+			it("runs the lifecycle in dry run mode", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:   "some/app",
+					Builder: defaultBuilderName,
+					DryRun:  true,
+				}))
+
+				h.AssertEq(t, fakeLifecycle.Opts.DryRun, true)
+			})
+
+			it("does not fetch a lifecycle image", func() {
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:          "some/app",
+					Builder:        defaultBuilderName,
+					LifecycleImage: "some-lifecycle-image",
+					DryRun:         true,
+				}))
+
+				h.AssertEq(t, fakeLifecycle.Opts.LifecycleImage, fakeLifecycle.Opts.Builder.Name())
+			})
+
+			it("removes the builder it creates once done", func() {
+				mockController := gomock.NewController(t)
+				defer mockController.Finish()
+				mockDocker := testmocks.NewMockCommonAPIClient(mockController)
+				subject.docker = mockDocker
+
+				var removed string
+				mockDocker.EXPECT().
+					ImageRemove(gomock.Any(), gomock.Any(), types.ImageRemoveOptions{Force: true, PruneChildren: true}).
+					DoAndReturn(func(_ context.Context, name string, _ types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error) {
+						removed = name
+						return nil, nil
+					})
+
+				h.AssertNil(t, subject.Build(context.TODO(), BuildOptions{
+					Image:      "some/app",
+					Builder:    defaultBuilderName,
+					Buildpacks: []string{"buildpack.1.id@buildpack.1.version"},
+					DryRun:     true,
+				}))
+
+				builderName := fakeLifecycle.Opts.Builder.Name()
+				h.AssertContainsMatch(t, builderName, `^pack.local/builder/[0-9a-f]{64}:[0-9a-f]{12}$`)
+				h.AssertEq(t, removed, builderName)
+				label, err := fakeLifecycle.Opts.Builder.Image().Label(ephemeral.Label)
+				h.AssertNil(t, err)
+				h.AssertEq(t, label, "true")
+			})
+		})
+
 		when("Lifecycle option", func() {
 			when("Platform API", func() {
 				for _, supportedPlatformAPI := range []string{"0.3", "0.4"} {
@@ -2408,6 +4384,22 @@ func diffIDForFile(t *testing.T, path string) string {
 	return "sha256:" + hex.EncodeToString(hasher.Sum(make([]byte, 0, hasher.Size())))
 }
 
+type lifecycleExecutorFunc func(ctx context.Context, opts build.LifecycleOptions) error
+
+func (f lifecycleExecutorFunc) Execute(ctx context.Context, opts build.LifecycleOptions) error {
+	return f(ctx, opts)
+}
+
+// readPolicy writes a policy with the given contents to dir and reads it.
+func readPolicy(t *testing.T, dir, contents string) *policy.Policy {
+	t.Helper()
+	path := filepath.Join(dir, "policy.toml")
+	h.AssertNil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	p, err := policy.Read(path)
+	h.AssertNil(t, err)
+	return p
+}
+
 func newLinuxImage(name, topLayerSha string, identifier imgutil.Identifier) *fakes.Image {
 	return fakes.NewImage(name, topLayerSha, identifier)
 }
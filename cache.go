@@ -0,0 +1,290 @@
+package pack
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"time"
+
+	lcache "github.com/buildpacks/lifecycle/cache"
+	"github.com/buildpacks/lifecycle/platform"
+	"github.com/docker/docker/api/types"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/cache"
+	"github.com/buildpacks/pack/internal/style"
+)
+
+// CacheInfo describes a volume in which layers are cached between builds of an image.
+type CacheInfo struct {
+	// Name of the volume.
+	Name string
+
+	// Image the cache is used for. When no local image matches the cache,
+	// this is the sanitized reference from the volume name, for example my_app_latest.
+	Image string
+
+	// Type of the cache, build or launch.
+	Type string
+
+	// Size of the volume in bytes, -1 if the daemon cannot report it.
+	Size int64
+
+	// LastUsed is the last time a build used the cache.
+	LastUsed time.Time
+
+	// InUse is set when a container is using the cache.
+	InUse bool
+}
+
+// CacheDetails describes the caches of an image along with the layers cached for each buildpack.
+type CacheDetails struct {
+	Image      string
+	Caches     []CacheInfo
+	Buildpacks []CachedBuildpack
+}
+
+// CachedBuildpack lists the layers a buildpack restores from the build cache.
+type CachedBuildpack struct {
+	ID      string
+	Version string
+	Layers  []CachedLayer
+}
+
+// CachedLayer describes a layer in the build cache.
+type CachedLayer struct {
+	Name   string
+	SHA    string
+	Build  bool
+	Launch bool
+	Cache  bool
+}
+
+// PruneCachesOptions is a configuration struct that controls which caches are removed by PruneCaches.
+type PruneCachesOptions struct {
+	// Only remove caches which have not been used for at least this long.
+	// When zero, all caches which are not in use are removed.
+	OlderThan time.Duration
+}
+
+var cacheMetadataPath = path.Join("committed", lcache.MetadataLabel)
+
+// ListCaches returns the build and launch volume caches created by pack, sorted by image and type.
+// Caches given a name or a bind source through CacheConfig are not named after an image and are not included.
+func (c *Client) ListCaches(ctx context.Context) ([]CacheInfo, error) {
+	caches, err := c.listCaches(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.readLastUsed(ctx, caches); err != nil {
+		return nil, err
+	}
+	return caches, nil
+}
+
+// InspectCache returns the volume caches of imageName and the layers cached by each buildpack.
+func (c *Client) InspectCache(ctx context.Context, imageName string) (*CacheDetails, error) {
+	imageRef, err := name.ParseReference(imageName, name.WeakValidation)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid image name %s", style.Symbol(imageName))
+	}
+
+	names := map[string]bool{}
+	for _, cacheType := range []string{"build", "launch"} {
+		names[cache.NewVolumeCache(imageRef, cacheType, c.docker).Name()] = true
+	}
+
+	caches, err := c.listCaches(ctx, func(volume string) bool { return names[volume] })
+	if err != nil {
+		return nil, err
+	}
+	if len(caches) == 0 {
+		return nil, errors.Errorf("no caches found for image %s", style.Symbol(imageName))
+	}
+
+	details := &CacheDetails{Image: imageName}
+	err = c.withVolumeReader(ctx, caches, func(reader *cache.VolumeReader) error {
+		for i := range caches {
+			lastUsed, err := reader.LastModified(ctx, caches[i].Name)
+			if err != nil {
+				return err
+			}
+			caches[i].Image = imageName
+			caches[i].LastUsed = lastUsed
+
+			if caches[i].Type != "build" {
+				continue
+			}
+			buildpacks, err := readCachedBuildpacks(ctx, reader, caches[i].Name)
+			if err != nil {
+				return err
+			}
+			details.Buildpacks = buildpacks
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	details.Caches = caches
+	return details, nil
+}
+
+// PruneCaches removes volume caches which are not in use and have not been used within opts.OlderThan,
+// and returns the caches removed. Only the caches returned by ListCaches are considered.
+func (c *Client) PruneCaches(ctx context.Context, opts PruneCachesOptions) ([]CacheInfo, error) {
+	caches, err := c.ListCaches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-opts.OlderThan)
+	var pruned []CacheInfo
+	for _, info := range caches {
+		if info.InUse || info.LastUsed.After(cutoff) {
+			continue
+		}
+
+		if err := c.docker.VolumeRemove(ctx, info.Name, false); err != nil {
+			return pruned, errors.Wrapf(err, "removing cache %s", style.Symbol(info.Name))
+		}
+		pruned = append(pruned, info)
+	}
+	return pruned, nil
+}
+
+func (c *Client) listCaches(ctx context.Context, include func(volume string) bool) ([]CacheInfo, error) {
+	usage, err := c.docker.DiskUsage(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing volumes")
+	}
+
+	var caches []CacheInfo
+	for _, volume := range usage.Volumes {
+		ref, cacheType, ok := cache.ParseVolumeName(volume.Name)
+		if !ok || (include != nil && !include(volume.Name)) {
+			continue
+		}
+
+		info := CacheInfo{Name: volume.Name, Image: ref, Type: cacheType, Size: -1}
+		if volume.UsageData != nil {
+			info.Size = volume.UsageData.Size
+			info.InUse = volume.UsageData.RefCount > 0
+		}
+		caches = append(caches, info)
+	}
+
+	if include == nil && len(caches) > 0 {
+		if err := c.resolveCacheImages(ctx, caches); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(caches, func(i, j int) bool {
+		if caches[i].Image != caches[j].Image {
+			return caches[i].Image < caches[j].Image
+		}
+		return caches[i].Type < caches[j].Type
+	})
+	return caches, nil
+}
+
+// resolveCacheImages replaces the sanitized reference of each cache with the name of a local image using it, if any.
+func (c *Client) resolveCacheImages(ctx context.Context, caches []CacheInfo) error {
+	images, err := c.docker.ImageList(ctx, types.ImageListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "listing images")
+	}
+
+	imageByVolume := map[string]string{}
+	for _, img := range images {
+		for _, tag := range img.RepoTags {
+			ref, err := name.ParseReference(tag, name.WeakValidation)
+			if err != nil {
+				continue
+			}
+			for _, info := range caches {
+				if cache.NewVolumeCache(ref, info.Type, c.docker).Name() == info.Name {
+					imageByVolume[info.Name] = tag
+				}
+			}
+		}
+	}
+
+	for i := range caches {
+		if img, ok := imageByVolume[caches[i].Name]; ok {
+			caches[i].Image = img
+		}
+	}
+	return nil
+}
+
+func (c *Client) readLastUsed(ctx context.Context, caches []CacheInfo) error {
+	if len(caches) == 0 {
+		return nil
+	}
+
+	return c.withVolumeReader(ctx, caches, func(reader *cache.VolumeReader) error {
+		for i := range caches {
+			lastUsed, err := reader.LastModified(ctx, caches[i].Name)
+			if err != nil {
+				return err
+			}
+			caches[i].LastUsed = lastUsed
+		}
+		return nil
+	})
+}
+
+func (c *Client) withVolumeReader(ctx context.Context, caches []CacheInfo, f func(reader *cache.VolumeReader) error) error {
+	var volumes []string
+	for _, info := range caches {
+		volumes = append(volumes, info.Name)
+	}
+
+	reader, err := cache.NewVolumeReader(ctx, c.docker, volumes...)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return f(reader)
+}
+
+func readCachedBuildpacks(ctx context.Context, reader *cache.VolumeReader, volume string) ([]CachedBuildpack, error) {
+	contents, err := reader.ReadFile(ctx, volume, cacheMetadataPath)
+	if err != nil {
+		// the cache has not been committed by a successful build yet
+		if dockerClient.IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var metadata platform.CacheMetadata
+	if err := json.Unmarshal(contents, &metadata); err != nil {
+		return nil, errors.Wrapf(err, "parsing metadata of cache %s", style.Symbol(volume))
+	}
+
+	var buildpacks []CachedBuildpack
+	for _, bp := range metadata.Buildpacks {
+		cached := CachedBuildpack{ID: bp.ID, Version: bp.Version}
+		for layerName, layer := range bp.Layers {
+			cached.Layers = append(cached.Layers, CachedLayer{
+				Name:   layerName,
+				SHA:    layer.SHA,
+				Build:  layer.Build,
+				Launch: layer.Launch,
+				Cache:  layer.Cache,
+			})
+		}
+		sort.Slice(cached.Layers, func(i, j int) bool { return cached.Layers[i].Name < cached.Layers[j].Name })
+		buildpacks = append(buildpacks, cached)
+	}
+	return buildpacks, nil
+}
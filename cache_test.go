@@ -0,0 +1,229 @@
+package pack
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/cache"
+	ilogging "github.com/buildpacks/pack/internal/logging"
+	h "github.com/buildpacks/pack/testhelpers"
+	"github.com/buildpacks/pack/testmocks"
+)
+
+func TestCache(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "Cache", testCache, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testCache(t *testing.T, when spec.G, it spec.S) {
+	var (
+		subject        *Client
+		mockController *gomock.Controller
+		mockDocker     *testmocks.MockCommonAPIClient
+		out            bytes.Buffer
+		buildVolume    string
+		launchVolume   string
+		lastUsed       map[string]time.Time
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockDocker = testmocks.NewMockCommonAPIClient(mockController)
+
+		var err error
+		subject, err = NewClient(WithLogger(ilogging.NewLogWithWriters(&out, &out)), WithDockerClient(mockDocker))
+		h.AssertNil(t, err)
+
+		ref, err := name.ParseReference("my/app")
+		h.AssertNil(t, err)
+		buildVolume = cache.NewVolumeCache(ref, "build", mockDocker).Name()
+		launchVolume = cache.NewVolumeCache(ref, "launch", mockDocker).Name()
+		lastUsed = map[string]time.Time{}
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	volume := func(name string, size, refCount int64) *types.Volume {
+		return &types.Volume{Name: name, UsageData: &types.VolumeUsageData{Size: size, RefCount: refCount}}
+	}
+
+	expectVolumes := func(volumes ...*types.Volume) {
+		mockDocker.EXPECT().DiskUsage(gomock.Any()).Return(types.DiskUsage{Volumes: volumes}, nil)
+	}
+
+	expectReader := func(volumes ...string) {
+		var binds []string
+		for _, v := range volumes {
+			binds = append(binds, v+":/caches/"+v+":ro")
+		}
+
+		mockDocker.EXPECT().
+			ImageImport(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(ioutil.NopCloser(&bytes.Buffer{}), nil)
+		mockDocker.EXPECT().
+			ContainerCreate(gomock.Any(), gomock.Any(), &container.HostConfig{Binds: binds}, nil, nil, "").
+			Return(container.ContainerCreateCreatedBody{ID: "reader-id"}, nil)
+		mockDocker.EXPECT().
+			ContainerStatPath(gomock.Any(), "reader-id", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, path string) (types.ContainerPathStat, error) {
+				return types.ContainerPathStat{Mtime: lastUsed[path[len("/caches/"):]]}, nil
+			}).AnyTimes()
+		mockDocker.EXPECT().ContainerRemove(gomock.Any(), "reader-id", types.ContainerRemoveOptions{Force: true}).Return(nil)
+		mockDocker.EXPECT().ImageRemove(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	}
+
+	when("#ListCaches", func() {
+		it("lists the volume caches with the image using them", func() {
+			orphan := "pack-cache-other_app_latest-0123456789ab.build"
+			lastUsed[buildVolume] = time.Unix(100, 0)
+			lastUsed[launchVolume] = time.Unix(200, 0)
+			lastUsed[orphan] = time.Unix(300, 0)
+
+			expectVolumes(volume(launchVolume, 50, 0), volume("some-volume", 10, 0), volume(buildVolume, 100, 1), volume(orphan, 20, 0))
+			mockDocker.EXPECT().ImageList(gomock.Any(), gomock.Any()).Return([]types.ImageSummary{
+				{RepoTags: []string{"my/app:latest", "other/image:latest"}},
+			}, nil)
+			expectReader(buildVolume, launchVolume, orphan)
+
+			caches, err := subject.ListCaches(context.TODO())
+			h.AssertNil(t, err)
+			h.AssertEq(t, caches, []CacheInfo{
+				{Name: buildVolume, Image: "my/app:latest", Type: "build", Size: 100, LastUsed: time.Unix(100, 0), InUse: true},
+				{Name: launchVolume, Image: "my/app:latest", Type: "launch", Size: 50, LastUsed: time.Unix(200, 0)},
+				{Name: orphan, Image: "other_app_latest", Type: "build", Size: 20, LastUsed: time.Unix(300, 0)},
+			})
+		})
+
+		it("does not read volumes when there are no caches", func() {
+			expectVolumes(volume("some-volume", 10, 0))
+
+			caches, err := subject.ListCaches(context.TODO())
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(caches), 0)
+		})
+
+		it("errors when volumes cannot be listed", func() {
+			mockDocker.EXPECT().DiskUsage(gomock.Any()).Return(types.DiskUsage{}, errors.New("some error"))
+
+			_, err := subject.ListCaches(context.TODO())
+			h.AssertError(t, err, "listing volumes: some error")
+		})
+	})
+
+	when("#InspectCache", func() {
+		cacheMetadata := func(contents string) io.ReadCloser {
+			buf := &bytes.Buffer{}
+			tw := tar.NewWriter(buf)
+			h.AssertNil(t, tw.WriteHeader(&tar.Header{Name: "io.buildpacks.lifecycle.cache.metadata", Mode: 0644, Size: int64(len(contents))}))
+			_, err := tw.Write([]byte(contents))
+			h.AssertNil(t, err)
+			h.AssertNil(t, tw.Close())
+			return ioutil.NopCloser(buf)
+		}
+
+		it("returns the caches and the layers cached by each buildpack", func() {
+			lastUsed[buildVolume] = time.Unix(100, 0)
+			expectVolumes(volume(buildVolume, 100, 0), volume("pack-cache-other_app_latest-0123456789ab.build", 20, 0))
+			expectReader(buildVolume)
+			mockDocker.EXPECT().
+				CopyFromContainer(gomock.Any(), "reader-id", "/caches/"+buildVolume+"/committed/io.buildpacks.lifecycle.cache.metadata").
+				Return(cacheMetadata(`{"buildpacks":[{"key":"some/buildpack","version":"1.2.3","layers":{
+					"runtime":{"sha":"sha256:def","launch":true,"cache":true},
+					"deps":{"sha":"sha256:abc","build":true,"cache":true}
+				}}]}`), types.ContainerPathStat{}, nil)
+
+			details, err := subject.InspectCache(context.TODO(), "my/app")
+			h.AssertNil(t, err)
+			h.AssertEq(t, details, &CacheDetails{
+				Image: "my/app",
+				Caches: []CacheInfo{
+					{Name: buildVolume, Image: "my/app", Type: "build", Size: 100, LastUsed: time.Unix(100, 0)},
+				},
+				Buildpacks: []CachedBuildpack{
+					{
+						ID:      "some/buildpack",
+						Version: "1.2.3",
+						Layers: []CachedLayer{
+							{Name: "deps", SHA: "sha256:abc", Build: true, Cache: true},
+							{Name: "runtime", SHA: "sha256:def", Launch: true, Cache: true},
+						},
+					},
+				},
+			})
+		})
+
+		it("returns no buildpacks when the cache has not been committed", func() {
+			expectVolumes(volume(buildVolume, 100, 0), volume(launchVolume, 50, 0))
+			expectReader(buildVolume, launchVolume)
+			mockDocker.EXPECT().
+				CopyFromContainer(gomock.Any(), "reader-id", gomock.Any()).
+				Return(nil, types.ContainerPathStat{}, errdefs.NotFound(errors.New("not found")))
+
+			details, err := subject.InspectCache(context.TODO(), "my/app")
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(details.Caches), 2)
+			h.AssertEq(t, len(details.Buildpacks), 0)
+		})
+
+		it("errors when the image has no caches", func() {
+			expectVolumes(volume("pack-cache-other_app_latest-0123456789ab.build", 20, 0))
+
+			_, err := subject.InspectCache(context.TODO(), "my/app")
+			h.AssertError(t, err, "no caches found for image 'my/app'")
+		})
+
+		it("errors for invalid image names", func() {
+			_, err := subject.InspectCache(context.TODO(), "my/App")
+			h.AssertError(t, err, "invalid image name 'my/App'")
+		})
+	})
+
+	when("#PruneCaches", func() {
+		it("removes caches which are not in use and were not used recently", func() {
+			recent := "pack-cache-recent_latest-0123456789ab.build"
+			lastUsed[buildVolume] = time.Now().Add(-200 * time.Hour)
+			lastUsed[launchVolume] = time.Now().Add(-200 * time.Hour)
+			lastUsed[recent] = time.Now().Add(-time.Hour)
+
+			expectVolumes(volume(buildVolume, 100, 0), volume(launchVolume, 50, 1), volume(recent, 20, 0))
+			mockDocker.EXPECT().ImageList(gomock.Any(), gomock.Any()).Return(nil, nil)
+			expectReader(buildVolume, launchVolume, recent)
+			mockDocker.EXPECT().VolumeRemove(gomock.Any(), buildVolume, false).Return(nil)
+
+			pruned, err := subject.PruneCaches(context.TODO(), PruneCachesOptions{OlderThan: 168 * time.Hour})
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(pruned), 1)
+			h.AssertEq(t, pruned[0].Name, buildVolume)
+		})
+
+		it("returns the caches removed before an error", func() {
+			expectVolumes(volume(buildVolume, 100, 0), volume(launchVolume, 50, 0))
+			mockDocker.EXPECT().ImageList(gomock.Any(), gomock.Any()).Return(nil, nil)
+			expectReader(buildVolume, launchVolume)
+			mockDocker.EXPECT().VolumeRemove(gomock.Any(), buildVolume, false).Return(nil)
+			mockDocker.EXPECT().VolumeRemove(gomock.Any(), launchVolume, false).Return(errors.New("some error"))
+
+			pruned, err := subject.PruneCaches(context.TODO(), PruneCachesOptions{})
+			h.AssertError(t, err, "removing cache '"+launchVolume+"': some error")
+			h.AssertEq(t, len(pruned), 1)
+		})
+	})
+}
@@ -14,7 +14,10 @@ import (
 	"github.com/buildpacks/pack/internal/build"
 	"github.com/buildpacks/pack/internal/config"
 	"github.com/buildpacks/pack/internal/dist"
+	"github.com/buildpacks/pack/internal/dockerhost"
+	"github.com/buildpacks/pack/internal/gitsource"
 	"github.com/buildpacks/pack/internal/image"
+	"github.com/buildpacks/pack/internal/policy"
 	"github.com/buildpacks/pack/logging"
 )
 
@@ -70,11 +73,19 @@ type Client struct {
 	imageFetcher        ImageFetcher
 	downloader          Downloader
 	lifecycleExecutor   LifecycleExecutor
+	localExecutor       LifecycleExecutor
 	docker              dockerClient.CommonAPIClient
+	daemonAccessHost    string
+	gitSource           func(dir string, checkDirty bool) (*gitsource.Source, error)
 	imageFactory        ImageFactory
 	BuildpackDownloader BuildpackDownloader
 	experimental        bool
 	registryMirrors     map[string]string
+	cnbDir              string
+	policyPath          string
+	policyLoaded        bool
+	policy              *policy.Policy
+	policyErr           error
 }
 
 // ClientOption is a type of function that mutate settings on the client.
@@ -149,6 +160,15 @@ func WithRegistryMirrors(registryMirrors map[string]string) ClientOption {
 	}
 }
 
+// WithPolicyFile sets the path of an organisation policy restricting the builders, run images, buildpacks and lifecycles
+// used, and the registry images are published to. It is enforced by Build, Rebase, CreateBuilder and PackageBuildpack,
+// which read it when they are first called.
+func WithPolicyFile(path string) ClientOption {
+	return func(c *Client) {
+		c.policyPath = path
+	}
+}
+
 // NewClient allocates and returns a Client configured with the specified options.
 func NewClient(opts ...ClientOption) (*Client, error) {
 	var client Client
@@ -162,14 +182,19 @@ func NewClient(opts ...ClientOption) (*Client, error) {
 	}
 
 	if client.docker == nil {
-		var err error
-		client.docker, err = dockerClient.NewClientWithOpts(
+		endpoint, err := dockerhost.Resolve()
+		if err != nil {
+			return nil, errors.Wrap(err, "resolving docker host")
+		}
+
+		client.docker, err = dockerClient.NewClientWithOpts(append([]dockerClient.Opt{
 			dockerClient.FromEnv,
 			dockerClient.WithVersion("1.38"),
-		)
+		}, endpoint.ClientOpts()...)...)
 		if err != nil {
 			return nil, errors.Wrap(err, "creating docker client")
 		}
+		client.daemonAccessHost = endpoint.DaemonAccessHost()
 	}
 
 	if client.downloader == nil {
@@ -192,7 +217,10 @@ func NewClient(opts ...ClientOption) (*Client, error) {
 		client.BuildpackDownloader = NewBuildpackDownloader(client.logger, client.imageFetcher, client.downloader)
 	}
 
+	client.cnbDir = build.DefaultCNBDir
 	client.lifecycleExecutor = build.NewLifecycleExecutor(client.logger, client.docker)
+	client.localExecutor = build.NewLocalLifecycleExecutor(client.logger, client.cnbDir)
+	client.gitSource = gitsource.Detect
 
 	return &client, nil
 }
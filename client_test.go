@@ -2,7 +2,10 @@ package pack
 
 import (
 	"bytes"
+	"context"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	dockerClient "github.com/docker/docker/client"
@@ -122,4 +125,39 @@ func testClient(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, cl.registryMirrors, registryMirrors)
 		})
 	})
+
+	when("#WithPolicyFile", func() {
+		var tmpDir string
+
+		it.Before(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "client-policy")
+			h.AssertNil(t, err)
+		})
+
+		it.After(func() {
+			h.AssertNil(t, os.RemoveAll(tmpDir))
+		})
+
+		it("reads the policy when it is first needed", func() {
+			path := filepath.Join(tmpDir, "policy.toml")
+			h.AssertNil(t, ioutil.WriteFile(path, []byte(`[buildpacks]
+forbidden = ["some/bp"]`), 0644))
+
+			cl, err := NewClient(WithPolicyFile(path))
+			h.AssertNil(t, err)
+			h.AssertNil(t, cl.policy)
+
+			h.AssertNil(t, cl.loadPolicy())
+			h.AssertEq(t, cl.policy.Buildpacks.Forbidden, []string{"some/bp"})
+		})
+
+		it("errors when a command governed by the policy cannot read it", func() {
+			cl, err := NewClient(WithPolicyFile(filepath.Join(tmpDir, "missing.toml")))
+			h.AssertNil(t, err)
+
+			err = cl.Build(context.TODO(), BuildOptions{Image: "some/app", Builder: "some/builder"})
+			h.AssertError(t, err, "reading policy")
+		})
+	})
 }
@@ -43,6 +43,13 @@ type CreateBuilderOptions struct {
 // CreateBuilder creates and saves a builder image to a registry with the provided options.
 // If any configuration is invalid, it will error and exit without creating any images.
 func (c *Client) CreateBuilder(ctx context.Context, opts CreateBuilderOptions) error {
+	if err := c.loadPolicy(); err != nil {
+		return err
+	}
+	if err := c.policy.CheckPublish(opts.Publish, opts.BuilderName); err != nil {
+		return err
+	}
+
 	if err := c.validateConfig(ctx, opts); err != nil {
 		return err
 	}
@@ -71,7 +78,16 @@ func (c *Client) validateConfig(ctx context.Context, opts CreateBuilderOptions)
 		return errors.Wrap(err, "invalid run image config")
 	}
 
-	return nil
+	var ids []string
+	for _, bp := range opts.Config.Buildpacks {
+		ids = append(ids, bp.ID)
+	}
+	for _, entry := range opts.Config.Order {
+		for _, bp := range entry.Group {
+			ids = append(ids, bp.ID)
+		}
+	}
+	return c.policy.CheckBuildpacks(ids...)
 }
 
 func (c *Client) validateRunImageConfig(ctx context.Context, opts CreateBuilderOptions) error {
@@ -101,6 +117,10 @@ func (c *Client) validateRunImageConfig(ctx context.Context, opts CreateBuilderO
 	}
 
 	for _, img := range runImages {
+		if err := c.checkRunImagePolicy(ctx, img.Name(), img); err != nil {
+			return err
+		}
+
 		stackID, err := img.Label("io.buildpacks.stack.id")
 		if err != nil {
 			return errors.Wrap(err, "failed to label image")
@@ -155,6 +175,10 @@ func (c *Client) createBaseBuilder(ctx context.Context, opts CreateBuilderOption
 		return nil, errors.Wrap(err, "fetch lifecycle")
 	}
 
+	if err := c.policy.CheckLifecycle(semverOf(lifecycle.Descriptor().Info.Version)); err != nil {
+		return nil, err
+	}
+
 	bldr.SetLifecycle(lifecycle)
 
 	return bldr, nil
@@ -234,6 +258,10 @@ func (c *Client) addBuildpacksToBuilder(ctx context.Context, opts CreateBuilderO
 			}
 		}
 
+		if err := c.policy.CheckBuildpacks(buildpackIDs(nil, append([]dist.Buildpack{mainBP}, depBPs...)...)...); err != nil {
+			return err
+		}
+
 		for _, bp := range append([]dist.Buildpack{mainBP}, depBPs...) {
 			bldr.AddBuildpack(bp)
 		}
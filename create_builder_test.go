@@ -545,6 +545,63 @@ func testCreateBuilder(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("a policy is set", func() {
+			var usePolicy = func(contents string) {
+				t.Helper()
+				path := filepath.Join(tmpDir, "policy.toml")
+				h.AssertNil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+
+				var err error
+				subject, err = pack.NewClient(
+					pack.WithLogger(logger),
+					pack.WithDownloader(mockDownloader),
+					pack.WithImageFactory(mockImageFactory),
+					pack.WithFetcher(mockImageFetcher),
+					pack.WithDockerClient(mockDockerClient),
+					pack.WithBuildpackDownloader(mockBuildpackDownloader),
+					pack.WithPolicyFile(path),
+				)
+				h.AssertNil(t, err)
+			}
+
+			it("should fail when the builder is not published to the required registry", func() {
+				usePolicy(`[publish]
+registry = "registry.example.com"`)
+
+				err := subject.CreateBuilder(context.TODO(), opts)
+				h.AssertError(t, err, "images must be published to 'registry.example.com'")
+			})
+
+			it("should fail when a buildpack is forbidden", func() {
+				usePolicy(`[buildpacks]
+forbidden = ["bp.one"]`)
+				prepareFetcherWithRunImages()
+
+				err := subject.CreateBuilder(context.TODO(), opts)
+				h.AssertError(t, err, "buildpack 'bp.one' is forbidden")
+			})
+
+			it("should fail when a run image is not allowed", func() {
+				usePolicy(`[run-images]
+allowed = ["some/run-image"]`)
+				prepareFetcherWithRunImages()
+
+				err := subject.CreateBuilder(context.TODO(), opts)
+				h.AssertError(t, err, "run image 'localhost:5000/some/run-image' is not in the allowed run images")
+			})
+
+			it("should fail when the lifecycle is older than the minimum version", func() {
+				usePolicy(`[lifecycle]
+minimum-version = "0.11.0"`)
+				prepareFetcherWithBuildImage()
+				prepareFetcherWithRunImages()
+
+				err := subject.CreateBuilder(context.TODO(), opts)
+				h.AssertError(t, err, "lifecycle '0.0.0' is older than the minimum version '0.11.0'")
+				h.AssertEq(t, fakeBuildImage.IsSaved(), false)
+			})
+		})
+
 		when("creation succeeds", func() {
 			it("should set basic metadata", func() {
 				prepareFetcherWithBuildImage()
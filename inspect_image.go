@@ -8,6 +8,7 @@ import (
 	"github.com/buildpacks/lifecycle/buildpack"
 	"github.com/buildpacks/lifecycle/launch"
 	"github.com/buildpacks/lifecycle/platform"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/pkg/errors"
 
 	"github.com/buildpacks/pack/config"
@@ -51,6 +52,9 @@ type ImageInfo struct {
 
 	// Processes lists all processes contributed by buildpacks.
 	Processes ProcessDetails
+
+	// Digest of the image manifest, or the image ID for images in the daemon.
+	Digest string
 }
 
 // ProcessDetails is a collection of all start command metadata
@@ -94,6 +98,73 @@ func (c *Client) InspectImage(name string, daemon bool) (*ImageInfo, error) {
 		return nil, err
 	}
 
+	info, err := inspectImageMetadata(img)
+	if err != nil {
+		return nil, err
+	}
+
+	if id, err := img.Identifier(); err == nil && id != nil {
+		info.Digest = parseDigestFromImageID(id)
+	}
+	return info, nil
+}
+
+// InspectLayoutImage reads the Label metadata of the image named refName in the OCI image layout at dir,
+// or of the only image in the layout when refName is empty.
+func (c *Client) InspectLayoutImage(dir, refName string) (*ImageInfo, error) {
+	img, err := image.ReadLayout(dir, refName)
+	if err != nil {
+		return nil, err
+	}
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading image config")
+	}
+
+	info, err := inspectImageMetadata(layoutImage{config: configFile.Config})
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading image digest")
+	}
+	info.Digest = digest.String()
+	return info, nil
+}
+
+// imageMetadata is the part of an image the ImageInfo is read from.
+type imageMetadata interface {
+	dist.Labeled
+	Env(key string) (string, error)
+	Entrypoint() ([]string, error)
+}
+
+// layoutImage reads the metadata of an image in an OCI image layout from its config.
+type layoutImage struct {
+	config v1.Config
+}
+
+func (i layoutImage) Label(key string) (string, error) {
+	return i.config.Labels[key], nil
+}
+
+func (i layoutImage) Env(key string) (string, error) {
+	for _, env := range i.config.Env {
+		if strings.HasPrefix(env, key+"=") {
+			return strings.TrimPrefix(env, key+"="), nil
+		}
+	}
+	return "", nil
+}
+
+func (i layoutImage) Entrypoint() ([]string, error) {
+	return i.config.Entrypoint, nil
+}
+
+func inspectImageMetadata(img imageMetadata) (*ImageInfo, error) {
 	var layersMd layersMetadata
 	if _, err := dist.GetLabel(img, platform.LayerMetadataLabel, &layersMd); err != nil {
 		return nil, err
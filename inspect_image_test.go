@@ -5,12 +5,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/buildpacks/imgutil/fakes"
+	"github.com/buildpacks/imgutil/local"
 	"github.com/buildpacks/lifecycle/launch"
 	"github.com/buildpacks/lifecycle/platform"
 	"github.com/golang/mock/gomock"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/heroku/color"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -555,6 +561,71 @@ func testInspectImage(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("the image has an identifier", func() {
+		it("returns the digest", func() {
+			img := fakes.NewImage("some/image", "", local.IDIdentifier{ImageID: "some-image-id"})
+			mockImageFetcher.EXPECT().Fetch(gomock.Any(), "some/image", image.FetchOptions{Daemon: true, PullPolicy: config.PullNever}).Return(img, nil)
+
+			info, err := subject.InspectImage("some/image", true)
+			h.AssertNil(t, err)
+			h.AssertEq(t, info.Digest, "sha256:some-image-id")
+		})
+	})
+
+	when("#InspectLayoutImage", func() {
+		var (
+			layoutDir string
+			layoutImg v1.Image
+		)
+
+		it.Before(func() {
+			var err error
+			layoutDir, err = ioutil.TempDir("", "inspect-layout-image")
+			h.AssertNil(t, err)
+
+			labels := map[string]string{}
+			for _, key := range []string{"io.buildpacks.stack.id", "io.buildpacks.lifecycle.metadata", "io.buildpacks.build.metadata"} {
+				labels[key], err = mockImage.Label(key)
+				h.AssertNil(t, err)
+			}
+			layoutImg, err = mutate.Config(empty.Image, v1.Config{
+				Labels:     labels,
+				Env:        []string{"CNB_PLATFORM_API=0.4"},
+				Entrypoint: []string{"/cnb/process/other-process"},
+			})
+			h.AssertNil(t, err)
+			h.AssertNil(t, image.WriteLayout(layoutDir, layoutImg, "index.docker.io/some/image:latest"))
+		})
+
+		it.After(func() {
+			h.AssertNil(t, os.RemoveAll(layoutDir))
+		})
+
+		it("reads the image from the labels of its config", func() {
+			info, err := subject.InspectLayoutImage(layoutDir, "index.docker.io/some/image:latest")
+			h.AssertNil(t, err)
+
+			digest, err := layoutImg.Digest()
+			h.AssertNil(t, err)
+			h.AssertEq(t, info.Digest, digest.String())
+			h.AssertEq(t, info.StackID, "test.stack.id")
+			h.AssertEq(t, info.Stack.RunImage.Image, "some-run-image")
+			h.AssertEq(t, len(info.BOM), 1)
+			h.AssertEq(t, info.Processes.DefaultProcess.Type, "other-process")
+		})
+
+		it("reads the only image without a name", func() {
+			info, err := subject.InspectLayoutImage(layoutDir, "")
+			h.AssertNil(t, err)
+			h.AssertEq(t, info.StackID, "test.stack.id")
+		})
+
+		it("errors when the layout has no image with the name", func() {
+			_, err := subject.InspectLayoutImage(layoutDir, "some/other-image")
+			h.AssertError(t, err, "has no image named 'some/other-image'")
+		})
+	})
+
 	when("the image is missing labels", func() {
 		it("returns empty data", func() {
 			mockImageFetcher.EXPECT().
@@ -7,7 +7,9 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path"
 	"runtime"
+	"sort"
 
 	"github.com/BurntSushi/toml"
 	"github.com/buildpacks/lifecycle/platform"
@@ -18,6 +20,7 @@ import (
 
 	"github.com/buildpacks/pack/internal/builder"
 	"github.com/buildpacks/pack/internal/container"
+	"github.com/buildpacks/pack/internal/ephemeral"
 	"github.com/buildpacks/pack/internal/paths"
 	"github.com/buildpacks/pack/pkg/archive"
 )
@@ -103,6 +106,7 @@ func copyDirWindows(ctx context.Context, ctrClient client.CommonAPIClient, conta
 			},
 			WorkingDir: "/",
 			User:       windowsContainerAdmin,
+			Labels:     ephemeral.Labels(),
 		},
 		&dcontainer.HostConfig{
 			Binds:     []string{fmt.Sprintf("%s:%s", mnt.Name, mnt.Destination)},
@@ -129,6 +133,28 @@ func copyDirWindows(ctx context.Context, ctrClient client.CommonAPIClient, conta
 	)
 }
 
+// CopyOut copies each path (src) out of the container and passes the resulting tar stream to handler.
+func CopyOut(handler func(io.Reader) error, srcs ...string) ContainerOperation {
+	return func(ctrClient client.CommonAPIClient, ctx context.Context, containerID string, stdout, stderr io.Writer) error {
+		for _, src := range srcs {
+			if err := copyOut(ctx, ctrClient, containerID, src, handler); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func copyOut(ctx context.Context, ctrClient client.CommonAPIClient, containerID, src string, handler func(io.Reader) error) error {
+	reader, _, err := ctrClient.CopyFromContainer(ctx, containerID, src)
+	if err != nil {
+		return errors.Wrapf(err, "copying '%s' from container", src)
+	}
+	defer reader.Close()
+
+	return handler(reader)
+}
+
 func findMount(info types.ContainerJSON, dst string) (types.MountPoint, error) {
 	for _, m := range info.Mounts {
 		if m.Destination == dst {
@@ -167,6 +193,32 @@ func WriteProjectMetadata(p string, metadata platform.ProjectMetadata, os string
 	}
 }
 
+// WritePlatformEnv writes each variable in env to a file named after it in dir, where the lifecycle reads the
+// build-time environment from.
+func WritePlatformEnv(dir string, env map[string]string, os string) ContainerOperation {
+	return func(ctrClient client.CommonAPIClient, ctx context.Context, containerID string, stdout, stderr io.Writer) error {
+		tarDir := dir
+		if os == "windows" {
+			tarDir = paths.WindowsToSlash(dir)
+		}
+
+		names := make([]string, 0, len(env))
+		for name := range env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		tarBuilder := archive.TarBuilder{}
+		for _, name := range names {
+			tarBuilder.AddFile(path.Join(tarDir, name), 0644, archive.NormalizedDateTime, []byte(env[name]))
+		}
+		reader := tarBuilder.Reader(archive.DefaultTarWriterFactory())
+		defer reader.Close()
+
+		return ctrClient.CopyToContainer(ctx, containerID, "/", reader, types.CopyToContainerOptions{})
+	}
+}
+
 // WriteStackToml writes a `stack.toml` based on the StackMetadata provided to the destination path.
 func WriteStackToml(dstPath string, stack builder.StackMetadata, os string) ContainerOperation {
 	return func(ctrClient client.CommonAPIClient, ctx context.Context, containerID string, stdout, stderr io.Writer) error {
@@ -256,6 +308,7 @@ func EnsureVolumeAccess(uid, gid int, os string, volumeNames ...string) Containe
 				Cmd:        []string{"cmd", "/c", cmd},
 				WorkingDir: "/",
 				User:       windowsContainerAdmin,
+				Labels:     ephemeral.Labels(),
 			},
 			&dcontainer.HostConfig{
 				Binds:     binds,
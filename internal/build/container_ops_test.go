@@ -422,6 +422,30 @@ drwsrwsrwt    2 123      456 (.*) some-vol
 `)
 		})
 	})
+
+	when("#WritePlatformEnv", func() {
+		it("writes a file for each variable", func() {
+			h.SkipIf(t, osType == "windows", "linux only")
+
+			ctx := context.Background()
+			ctr, err := createContainer(ctx, imageName, "/layers-vol", osType, "sh", "-c", "cat /platform/env/KEY_1 /platform/env/KEY_2")
+			h.AssertNil(t, err)
+			defer cleanupContainer(ctx, ctr.ID)
+
+			writeOp := build.WritePlatformEnv("/platform/env", map[string]string{"KEY_1": "value-1", "KEY_2": "value-2"}, osType)
+
+			var outBuf, errBuf bytes.Buffer
+			err = writeOp(ctrClient, ctx, ctr.ID, &outBuf, &errBuf)
+			h.AssertNil(t, err)
+
+			err = container.Run(ctx, ctrClient, ctr.ID, &outBuf, &errBuf)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, errBuf.String(), "")
+			h.AssertEq(t, outBuf.String(), "value-1value-2")
+		})
+	})
+
 	when("#EnsureVolumeAccess", func() {
 		it("changes owner of volume", func() {
 			h.SkipIf(t, osType != "windows", "no-op for linux")
@@ -0,0 +1,117 @@
+package build
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/container"
+	"github.com/buildpacks/pack/internal/ephemeral"
+	"github.com/buildpacks/pack/internal/style"
+)
+
+// DebugShellConfig returns the configuration of a container that runs an interactive shell in the builder image
+// with the mounts, environment and user of the last phase that was run.
+// The container is labelled as kept, so that it and the volumes it mounts are not removed by PruneSystem.
+// As it may be resumed after the build, it does not mount the secrets or the SSH agent socket of the build.
+func (l *LifecycleExecution) DebugShellConfig() (*dcontainer.Config, *dcontainer.HostConfig, error) {
+	if l.lastPhase == nil {
+		return nil, nil, errors.New("no phase has been run")
+	}
+
+	shell := []string{"/bin/sh"}
+	if l.os == "windows" {
+		shell = []string{"cmd"}
+	}
+
+	phaseConf := l.lastPhase.ContainerConfig()
+	ctrConf := &dcontainer.Config{
+		Image:        l.opts.Builder.Name(),
+		Entrypoint:   strslice.StrSlice(shell),
+		Env:          debugShellEnv(phaseConf.Env),
+		User:         phaseConf.User,
+		WorkingDir:   l.mountPaths.appDir(),
+		Labels:       ephemeral.Keep(phaseConf.Labels),
+		Tty:          true,
+		OpenStdin:    true,
+		StdinOnce:    true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+
+	phaseHostConf := l.lastPhase.HostConfig()
+	hostConf := &dcontainer.HostConfig{
+		Binds:       append([]string{}, phaseHostConf.Binds...),
+		Mounts:      debugShellMounts(phaseHostConf.Mounts),
+		NetworkMode: phaseHostConf.NetworkMode,
+		Isolation:   phaseHostConf.Isolation,
+		SecurityOpt: phaseHostConf.SecurityOpt,
+	}
+
+	return ctrConf, hostConf, nil
+}
+
+// debugShellMounts returns mounts without the secrets and the SSH agent socket, which are only available during the build.
+func debugShellMounts(mounts []mount.Mount) []mount.Mount {
+	kept := []mount.Mount{}
+	for _, m := range mounts {
+		if m.Target == SSHAuthSock || m.Target == SecretsDir || strings.HasPrefix(m.Target, SecretsDir+"/") {
+			continue
+		}
+		kept = append(kept, m)
+	}
+	return kept
+}
+
+// debugShellEnv returns env without SSH_AUTH_SOCK, as the SSH agent socket is not mounted in the debug shell.
+func debugShellEnv(env []string) []string {
+	kept := []string{}
+	for _, e := range env {
+		if !strings.HasPrefix(e, "SSH_AUTH_SOCK=") {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// StartDebugShell runs an interactive shell for the last phase that was run, connected to in and out.
+// The shell container and the volumes of the build are kept, and instructions on how to resume or clean up are printed.
+func (l *LifecycleExecution) StartDebugShell(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctrConf, hostConf, err := l.DebugShellConfig()
+	if err != nil {
+		return err
+	}
+
+	ctr, err := l.docker.ContainerCreate(ctx, ctrConf, hostConf, nil, nil, "")
+	if err != nil {
+		return errors.Wrap(err, "creating debug shell container")
+	}
+
+	if len(l.opts.Env) > 0 {
+		if err := WritePlatformEnv(l.mountPaths.platformEnvDir(), l.opts.Env, l.os)(l.docker, ctx, ctr.ID, ioutil.Discard, ioutil.Discard); err != nil {
+			_ = l.docker.ContainerRemove(context.Background(), ctr.ID, types.ContainerRemoveOptions{Force: true})
+			return errors.Wrap(err, "writing build env to debug shell container")
+		}
+	}
+
+	l.logger.Infof("Starting a debug shell with the mounts, environment and user of the failed %s", style.Symbol(l.lastPhase.Name()))
+	l.logger.Infof("The layers are mounted at %s and the app at %s. Exit the shell to finish.", style.Symbol(l.mountPaths.layersDir()), style.Symbol(l.mountPaths.appDir()))
+
+	if err := container.RunInteractive(ctx, l.docker, ctr.ID, in, out); err != nil {
+		_ = l.docker.ContainerRemove(context.Background(), ctr.ID, types.ContainerRemoveOptions{Force: true})
+		return errors.Wrap(err, "running debug shell")
+	}
+
+	l.logger.Info("The debug shell container and the volumes of the failed build have been kept.")
+	l.logger.Infof("To resume debugging, run:\n  docker start --attach --interactive %s", ctr.ID)
+	l.logger.Infof("To clean up, run:\n  docker rm --force %s && docker volume rm %s %s", ctr.ID, l.layersVolume, l.appVolume)
+	return nil
+}
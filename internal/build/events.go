@@ -0,0 +1,263 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/platform"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/pkg/archive"
+	"github.com/buildpacks/pack/pkg/events"
+)
+
+var (
+	ansiExp          = regexp.MustCompile("\x1b\\[[0-9;]*m")
+	phaseStepExp     = regexp.MustCompile(`^===> ([A-Z]+)$`)
+	layerRestoredExp = regexp.MustCompile(`^Restoring data for "(.+)" from cache$`)
+	layerReusedExp   = regexp.MustCompile(`^Reusing layer '(.+)'$`)
+	layerExportedExp = regexp.MustCompile(`^Adding layer '(.+)'$`)
+	warningPrefix    = "Warning: "
+
+	// creatorSteps maps the steps announced by the creator onto the names of the equivalent phases.
+	creatorSteps = map[string]string{
+		"DETECTING": "detector",
+		"ANALYZING": "analyzer",
+		"RESTORING": "restorer",
+		"BUILDING":  "builder",
+		"EXPORTING": "exporter",
+	}
+)
+
+// runPhase surrounds run with PhaseStarted and PhaseFinished events.
+func (l *LifecycleExecution) runPhase(name string, run func() error) error {
+	start := time.Now()
+	events.Emit(l.opts.EventSink, events.Event{Type: events.PhaseStarted, Time: start, Phase: name})
+
+	err := run()
+
+	finished := events.Event{Type: events.PhaseFinished, Phase: name, Duration: time.Since(start)}
+	if err != nil {
+		finished.Error = err.Error()
+	}
+	events.Emit(l.opts.EventSink, finished)
+
+	return err
+}
+
+// withDetectEvents reads group.toml and plan.toml out of the layers directory once the phase has run
+// and emits GroupDetected and PlanDetected events.
+func (l *LifecycleExecution) withDetectEvents() PhaseConfigProviderOperation {
+	if l.opts.EventSink == nil {
+		return NullOp()
+	}
+
+	return WithPostContainerRunOperations(
+		CopyOut(l.emitGroup, l.mountPaths.groupPath()),
+		CopyOut(l.emitPlan, l.mountPaths.planPath()),
+	)
+}
+
+func (l *LifecycleExecution) emitGroup(reader io.Reader) error {
+	_, contents, err := archive.ReadTarEntry(reader, "group.toml")
+	if err != nil {
+		return errors.Wrap(err, "reading group")
+	}
+
+	var group buildpack.Group
+	if _, err := toml.Decode(string(contents), &group); err != nil {
+		return errors.Wrap(err, "decoding group")
+	}
+
+	var bps []events.Buildpack
+	for _, bp := range group.Group {
+		bps = append(bps, events.Buildpack{ID: bp.ID, Version: bp.Version})
+	}
+	events.Emit(l.opts.EventSink, events.Event{Type: events.GroupDetected, Buildpacks: bps})
+	return nil
+}
+
+func (l *LifecycleExecution) emitPlan(reader io.Reader) error {
+	_, contents, err := archive.ReadTarEntry(reader, "plan.toml")
+	if err != nil {
+		return errors.Wrap(err, "reading plan")
+	}
+
+	var plan platform.BuildPlan
+	if _, err := toml.Decode(string(contents), &plan); err != nil {
+		return errors.Wrap(err, "decoding plan")
+	}
+
+	var entries []events.PlanEntry
+	for _, entry := range plan.Entries {
+		var planEntry events.PlanEntry
+		for _, bp := range entry.Providers {
+			planEntry.Providers = append(planEntry.Providers, events.Buildpack{ID: bp.ID, Version: bp.Version})
+		}
+		for _, req := range entry.Requires {
+			planEntry.Requires = append(planEntry.Requires, events.Require{Name: req.Name, Version: req.Version, Metadata: req.Metadata})
+		}
+		entries = append(entries, planEntry)
+	}
+	events.Emit(l.opts.EventSink, events.Event{Type: events.PlanDetected, Plan: entries})
+	return nil
+}
+
+// minPlatformAPIWithReportInLayers is the first Platform API whose exporter writes report.toml to the layers directory
+// rather than to its working directory.
+var minPlatformAPIWithReportInLayers = api.MustParse("0.5")
+
+// withExportEvents reads the processes and report.toml out of the layers directory once the phase has run
+// and emits an ExportReported event. The report is only read on Platform APIs writing it to the layers directory.
+func (l *LifecycleExecution) withExportEvents() PhaseConfigProviderOperation {
+	if l.opts.EventSink == nil {
+		return NullOp()
+	}
+
+	report := events.Event{Type: events.ExportReported, PlatformAPI: l.platformAPI.String()}
+	ops := []ContainerOperation{
+		CopyOut(func(reader io.Reader) error {
+			processes, err := readProcesses(reader)
+			report.Processes = processes
+			return err
+		}, l.mountPaths.metadataPath()),
+	}
+	if l.platformAPI.Compare(minPlatformAPIWithReportInLayers) >= 0 {
+		ops = append(ops, CopyOut(func(reader io.Reader) error {
+			tags, err := readReportTags(reader)
+			report.Tags = tags
+			return err
+		}, l.mountPaths.reportPath()))
+	}
+	ops = append(ops, func(client.CommonAPIClient, context.Context, string, io.Writer, io.Writer) error {
+		events.Emit(l.opts.EventSink, report)
+		return nil
+	})
+
+	return WithPostContainerRunOperations(ops...)
+}
+
+func readProcesses(reader io.Reader) ([]events.Process, error) {
+	_, contents, err := archive.ReadTarEntry(reader, "metadata.toml")
+	if err != nil {
+		return nil, errors.Wrap(err, "reading build metadata")
+	}
+	return parseProcesses(contents)
+}
+
+func parseProcesses(contents []byte) ([]events.Process, error) {
+	var metadata platform.BuildMetadata
+	if _, err := toml.Decode(string(contents), &metadata); err != nil {
+		return nil, errors.Wrap(err, "decoding build metadata")
+	}
+
+	var processes []events.Process
+	for _, p := range metadata.Processes {
+		processes = append(processes, events.Process{Type: p.Type, Command: p.Command, Args: p.Args, Direct: p.Direct})
+	}
+	return processes, nil
+}
+
+func readReportTags(reader io.Reader) ([]string, error) {
+	_, contents, err := archive.ReadTarEntry(reader, "report.toml")
+	if err != nil {
+		return nil, errors.Wrap(err, "reading export report")
+	}
+	return parseReportTags(contents)
+}
+
+func parseReportTags(contents []byte) ([]string, error) {
+	var report platform.ExportReport
+	if _, err := toml.Decode(string(contents), &report); err != nil {
+		return nil, errors.Wrap(err, "decoding export report")
+	}
+	return report.Image.Tags, nil
+}
+
+// eventWriter passes lifecycle output through unchanged while scanning each line for
+// messages that correspond to build events.
+type eventWriter struct {
+	out           io.Writer
+	buf           bytes.Buffer
+	lifecycleExec *LifecycleExecution
+
+	// step and stepStart track the phase the creator is currently running.
+	step      string
+	stepStart time.Time
+}
+
+func newEventWriter(out io.Writer, lifecycleExec *LifecycleExecution) *eventWriter {
+	return &eventWriter{out: out, lifecycleExec: lifecycleExec}
+}
+
+func (w *eventWriter) Write(data []byte) (int, error) {
+	w.buf.Write(data)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// incomplete line, keep it for the next write
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			break
+		}
+		w.scan(line)
+	}
+
+	return w.out.Write(data)
+}
+
+// Close scans any remaining output, finishes the current creator step and closes the underlying writer.
+func (w *eventWriter) Close() error {
+	if w.buf.Len() > 0 {
+		w.scan(w.buf.String())
+		w.buf.Reset()
+	}
+	w.finishStep()
+
+	if closer, ok := w.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (w *eventWriter) scan(line string) {
+	line = strings.TrimSpace(ansiExp.ReplaceAllString(line, ""))
+
+	if m := phaseStepExp.FindStringSubmatch(line); m != nil {
+		if phase, ok := creatorSteps[m[1]]; ok {
+			w.finishStep()
+			w.step = phase
+			w.stepStart = time.Now()
+			events.Emit(w.lifecycleExec.opts.EventSink, events.Event{Type: events.PhaseStarted, Time: w.stepStart, Phase: phase})
+		}
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(line, warningPrefix):
+		events.Emit(w.lifecycleExec.opts.EventSink, events.Event{Type: events.Warning, Message: strings.TrimPrefix(line, warningPrefix)})
+	case layerRestoredExp.MatchString(line):
+		events.Emit(w.lifecycleExec.opts.EventSink, events.Event{Type: events.LayerRestored, Layer: layerRestoredExp.FindStringSubmatch(line)[1]})
+	case layerReusedExp.MatchString(line):
+		events.Emit(w.lifecycleExec.opts.EventSink, events.Event{Type: events.LayerReused, Layer: layerReusedExp.FindStringSubmatch(line)[1]})
+	case layerExportedExp.MatchString(line):
+		events.Emit(w.lifecycleExec.opts.EventSink, events.Event{Type: events.LayerExported, Layer: layerExportedExp.FindStringSubmatch(line)[1]})
+	}
+}
+
+func (w *eventWriter) finishStep() {
+	if w.step == "" {
+		return
+	}
+
+	events.Emit(w.lifecycleExec.opts.EventSink, events.Event{Type: events.PhaseFinished, Phase: w.step, Duration: time.Since(w.stepStart)})
+	w.step = ""
+}
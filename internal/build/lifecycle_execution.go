@@ -8,6 +8,9 @@ import (
 
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/auth"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -15,16 +18,24 @@ import (
 
 	"github.com/buildpacks/pack/internal/builder"
 	"github.com/buildpacks/pack/internal/cache"
+	"github.com/buildpacks/pack/internal/ephemeral"
 	"github.com/buildpacks/pack/internal/paths"
 	"github.com/buildpacks/pack/internal/style"
 	"github.com/buildpacks/pack/logging"
+	"github.com/buildpacks/pack/pkg/events"
 )
 
 const (
 	defaultProcessType = "web"
 	overrideGID        = 0
+
+	// sourceDateEpochEnv sets the creation time of the app image, as a unix timestamp.
+	sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
 )
 
+// minPlatformAPISupportingCreationTime is the first Platform API whose exporter reads the creation time from SOURCE_DATE_EPOCH.
+var minPlatformAPISupportingCreationTime = api.MustParse("0.9")
+
 type LifecycleExecution struct {
 	logger       logging.Logger
 	docker       client.CommonAPIClient
@@ -34,6 +45,8 @@ type LifecycleExecution struct {
 	os           string
 	mountPaths   mountPaths
 	opts         LifecycleOptions
+	lastPhase    *PhaseConfigProvider
+	redactions   []string
 }
 
 func NewLifecycleExecution(logger logging.Logger, docker client.CommonAPIClient, opts LifecycleOptions) (*LifecycleExecution, error) {
@@ -50,6 +63,11 @@ func NewLifecycleExecution(logger logging.Logger, docker client.CommonAPIClient,
 		return nil, err
 	}
 
+	redactions, err := secretRedactions(opts.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
 	exec := &LifecycleExecution{
 		logger:       logger,
 		docker:       docker,
@@ -59,6 +77,7 @@ func NewLifecycleExecution(logger logging.Logger, docker client.CommonAPIClient,
 		opts:         opts,
 		os:           osType,
 		mountPaths:   mountPathsForOS(osType, opts.Workspace),
+		redactions:   redactions,
 	}
 
 	return exec, nil
@@ -110,6 +129,17 @@ func (l *LifecycleExecution) PlatformAPI() *api.Version {
 
 func (l *LifecycleExecution) Run(ctx context.Context, phaseFactoryCreator PhaseFactoryCreator) error {
 	phaseFactory := phaseFactoryCreator(l)
+	if l.opts.DryRun {
+		l.logger.Info(style.Step("DETECTING"))
+		return l.runPhase("detector", func() error {
+			return l.Detect(ctx, l.opts.Network, l.opts.Volumes, phaseFactory)
+		})
+	}
+
+	if l.opts.CreationTime != nil && !supportsCreationTime(l.PlatformAPI()) {
+		l.warnCreationTimeUnsupported()
+	}
+
 	var buildCache Cache
 	if l.opts.CacheImage != "" {
 		cacheImage, err := name.ParseReference(l.opts.CacheImage, name.WeakValidation)
@@ -118,10 +148,13 @@ func (l *LifecycleExecution) Run(ctx context.Context, phaseFactoryCreator PhaseF
 		}
 		buildCache = cache.NewImageCache(cacheImage, l.docker)
 	} else {
-		buildCache = cache.NewVolumeCache(l.opts.Image, "build", l.docker)
+		var err error
+		if buildCache, err = l.newCache(l.opts.BuildCache, "build"); err != nil {
+			return err
+		}
 	}
 
-	l.logger.Debugf("Using build cache volume %s", style.Symbol(buildCache.Name()))
+	l.logger.Debugf("Using build cache %s %s", buildCache.Type(), style.Symbol(buildCache.Name()))
 	if l.opts.ClearCache {
 		if err := buildCache.Clear(ctx); err != nil {
 			return errors.Wrap(err, "clearing build cache")
@@ -129,41 +162,83 @@ func (l *LifecycleExecution) Run(ctx context.Context, phaseFactoryCreator PhaseF
 		l.logger.Debugf("Build cache %s cleared", style.Symbol(buildCache.Name()))
 	}
 
-	launchCache := cache.NewVolumeCache(l.opts.Image, "launch", l.docker)
+	launchCache, err := l.newCache(l.opts.LaunchCache, "launch")
+	if err != nil {
+		return err
+	}
+	if launchCache.Type() == cache.Image {
+		return errors.New("launch cache cannot be stored in an image")
+	}
+
+	useCreator := l.opts.UseCreator
+	if useCreator && !writesCache(buildCache, l.opts.CacheImageReadOnly) {
+		// the creator always exports to the cache it restores from
+		l.logger.Debugf("Running each phase separately to avoid writing to cache image %s", style.Symbol(buildCache.Name()))
+		useCreator = false
+	}
 
-	if !l.opts.UseCreator {
+	if !useCreator {
 		l.logger.Info(style.Step("DETECTING"))
-		if err := l.Detect(ctx, l.opts.Network, l.opts.Volumes, phaseFactory); err != nil {
+		if err := l.runPhase("detector", func() error {
+			return l.Detect(ctx, l.opts.Network, l.opts.Volumes, phaseFactory)
+		}); err != nil {
 			return err
 		}
 
 		l.logger.Info(style.Step("ANALYZING"))
-		if err := l.Analyze(ctx, l.opts.Image.String(), l.opts.Network, l.opts.Publish, l.opts.DockerHost, l.opts.ClearCache, buildCache, phaseFactory); err != nil {
+		if err := l.runPhase("analyzer", func() error {
+			return l.Analyze(ctx, l.opts.Image.String(), l.opts.Network, l.opts.Publish, l.opts.DockerHost, l.opts.ClearCache, buildCache, phaseFactory)
+		}); err != nil {
 			return err
 		}
 
 		l.logger.Info(style.Step("RESTORING"))
 		if l.opts.ClearCache {
 			l.logger.Info("Skipping 'restore' due to clearing cache")
-		} else if err := l.Restore(ctx, l.opts.Network, buildCache, phaseFactory); err != nil {
+		} else if err := l.runPhase("restorer", func() error {
+			return l.Restore(ctx, l.opts.Network, buildCache, phaseFactory)
+		}); err != nil {
 			return err
 		}
 
 		l.logger.Info(style.Step("BUILDING"))
 
-		if err := l.Build(ctx, l.opts.Network, l.opts.Volumes, phaseFactory); err != nil {
+		if err := l.runPhase("builder", func() error {
+			return l.Build(ctx, l.opts.Network, l.opts.Volumes, phaseFactory)
+		}); err != nil {
 			return err
 		}
 
 		l.logger.Info(style.Step("EXPORTING"))
-		return l.Export(ctx, l.opts.Image.String(), l.opts.RunImage, l.opts.Publish, l.opts.DockerHost, l.opts.Network, buildCache, launchCache, l.opts.AdditionalTags, phaseFactory)
+		return l.runPhase("exporter", func() error {
+			return l.Export(ctx, l.opts.Image.String(), l.opts.RunImage, l.opts.Publish, l.opts.DockerHost, l.opts.Network, buildCache, launchCache, l.opts.AdditionalTags, phaseFactory)
+		})
 	}
 
-	return l.Create(ctx, l.opts.Publish, l.opts.DockerHost, l.opts.ClearCache, l.opts.RunImage, l.opts.Image.String(), l.opts.Network, buildCache, launchCache, l.opts.AdditionalTags, l.opts.Volumes, phaseFactory)
+	return l.runPhase("creator", func() error {
+		return l.Create(ctx, l.opts.Publish, l.opts.DockerHost, l.opts.ClearCache, l.opts.RunImage, l.opts.Image.String(), l.opts.Network, buildCache, launchCache, l.opts.AdditionalTags, l.opts.Volumes, phaseFactory)
+	})
+}
+
+// createVolumes creates the layers and app volumes labelled as ephemeral, rather than leaving the daemon
+// to create them unlabelled when the first phase mounts them, so that pack system prune finds them
+// should Cleanup never run.
+func (l *LifecycleExecution) createVolumes(ctx context.Context) error {
+	for _, name := range []string{l.layersVolume, l.appVolume} {
+		if _, err := l.docker.VolumeCreate(ctx, volume.VolumeCreateBody{Name: name, Labels: ephemeral.Labels()}); err != nil {
+			return errors.Wrapf(err, "creating volume %s", style.Symbol(name))
+		}
+	}
+	return nil
 }
 
+// Cleanup removes the volumes of the build. It does not depend on the context of the build,
+// so that it also cleans up after a build that was cancelled.
 func (l *LifecycleExecution) Cleanup() error {
 	var reterr error
+	if err := l.removeContainers(); err != nil {
+		reterr = err
+	}
 	if err := l.docker.VolumeRemove(context.Background(), l.layersVolume, true); err != nil {
 		reterr = errors.Wrapf(err, "failed to clean up layers volume %s", l.layersVolume)
 	}
@@ -173,6 +248,50 @@ func (l *LifecycleExecution) Cleanup() error {
 	return reterr
 }
 
+// removeContainers removes any container still using the volumes of the build, which a phase leaves behind
+// when its context is cancelled after the daemon created its container but before its ID was returned.
+func (l *LifecycleExecution) removeContainers() error {
+	for _, name := range []string{l.layersVolume, l.appVolume} {
+		ctrs, err := l.docker.ContainerList(context.Background(), types.ContainerListOptions{
+			All:     true,
+			Filters: filters.NewArgs(filters.Arg("volume", name)),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "listing containers using volume %s", name)
+		}
+
+		for _, ctr := range ctrs {
+			if err := l.docker.ContainerRemove(context.Background(), ctr.ID, types.ContainerRemoveOptions{Force: true}); err != nil && !client.IsErrNotFound(err) {
+				return errors.Wrapf(err, "failed to clean up container %s", ctr.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// newCache returns the cache selected by opts, or a volume named after the app image with the given suffix.
+func (l *LifecycleExecution) newCache(opts *CacheOptions, suffix string) (Cache, error) {
+	if opts == nil {
+		return cache.NewVolumeCache(l.opts.Image, suffix, l.docker), nil
+	}
+
+	switch opts.Type {
+	case cache.Image:
+		cacheImage, err := name.ParseReference(opts.Name, name.WeakValidation)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache image name: %s", err)
+		}
+		return cache.NewImageCache(cacheImage, l.docker), nil
+	case cache.Bind:
+		return cache.NewBindCache(opts.Source), nil
+	default:
+		if opts.Name != "" {
+			return cache.NewNamedVolumeCache(opts.Name, l.docker), nil
+		}
+		return cache.NewVolumeCache(l.opts.Image, suffix, l.docker), nil
+	}
+}
+
 func (l *LifecycleExecution) Create(ctx context.Context, publish bool, dockerHost string, clearCache bool, runImage, repoName, networkMode string, buildCache, launchCache Cache, additionalTags, volumes []string, phaseFactory PhaseFactory) error {
 	flags := addTags([]string{
 		"-app", l.mountPaths.appDir(),
@@ -223,7 +342,7 @@ func (l *LifecycleExecution) Create(ctx context.Context, publish bool, dockerHos
 	case cache.Image:
 		flags = append(flags, "-cache-image", buildCache.Name())
 		cacheOpts = WithBinds(volumes...)
-	case cache.Volume:
+	case cache.Volume, cache.Bind:
 		cacheOpts = WithBinds(append(volumes, fmt.Sprintf("%s:%s", buildCache.Name(), l.mountPaths.cacheDir()))...)
 	}
 
@@ -234,17 +353,29 @@ func (l *LifecycleExecution) Create(ctx context.Context, publish bool, dockerHos
 		cacheOpts,
 		WithContainerOperations(WriteProjectMetadata(l.mountPaths.projectPath(), l.opts.ProjectMetadata, l.os)),
 		WithContainerOperations(CopyDir(l.opts.AppPath, l.mountPaths.appDir(), l.opts.Builder.UID(), l.opts.Builder.GID(), l.os, true, l.opts.FileFilter)),
+		l.withPlatformEnv(),
+		WithSecrets(l.opts.Secrets...),
+		WithSSHAgent(l.opts.SSHAuthSock),
+		l.withDetectEvents(),
+		l.withExportEvents(),
+		l.withCreationTime(),
 	}
 
 	if publish {
-		authConfig, err := auth.BuildEnvVar(authn.DefaultKeychain, repoName)
+		authConfig, err := auth.BuildEnvVar(authn.DefaultKeychain, registryImages(buildCache, repoName)...)
 		if err != nil {
 			return err
 		}
 
 		opts = append(opts, WithRoot(), WithRegistryAccess(authConfig))
 	} else {
+		cacheAccessOpt, err := withCacheImageAccess(buildCache)
+		if err != nil {
+			return err
+		}
+
 		opts = append(opts,
+			cacheAccessOpt,
 			WithDaemonAccess(dockerHost),
 			WithFlags("-daemon", "-launch-cache", l.mountPaths.launchCacheDir()),
 			WithBinds(fmt.Sprintf("%s:%s", launchCache.Name(), l.mountPaths.launchCacheDir())),
@@ -271,7 +402,11 @@ func (l *LifecycleExecution) Detect(ctx context.Context, networkMode string, vol
 			EnsureVolumeAccess(l.opts.Builder.UID(), l.opts.Builder.GID(), l.os, l.layersVolume, l.appVolume),
 			CopyDir(l.opts.AppPath, l.mountPaths.appDir(), l.opts.Builder.UID(), l.opts.Builder.GID(), l.os, true, l.opts.FileFilter),
 		),
+		l.withPlatformEnv(),
 		WithFlags(flags...),
+		WithSecrets(l.opts.Secrets...),
+		WithSSHAgent(l.opts.SSHAuthSock),
+		l.withDetectEvents(),
 	)
 
 	detect := phaseFactory.New(configProvider)
@@ -280,16 +415,21 @@ func (l *LifecycleExecution) Detect(ctx context.Context, networkMode string, vol
 }
 
 func (l *LifecycleExecution) Restore(ctx context.Context, networkMode string, buildCache Cache, phaseFactory PhaseFactory) error {
-	flagsOpt := NullOp()
+	cacheAccessOpt, err := withCacheImageAccess(buildCache)
+	if err != nil {
+		return err
+	}
+
+	var flags []string
 	cacheOpt := NullOp()
 	switch buildCache.Type() {
 	case cache.Image:
-		flagsOpt = WithFlags("-cache-image", buildCache.Name())
-	case cache.Volume:
+		flags = append(flags, "-cache-image", buildCache.Name())
+	case cache.Volume, cache.Bind:
 		cacheOpt = WithBinds(fmt.Sprintf("%s:%s", buildCache.Name(), l.mountPaths.cacheDir()))
 	}
 	if l.opts.GID >= overrideGID {
-		flagsOpt = WithFlags("-gid", strconv.Itoa(l.opts.GID))
+		flags = append(flags, "-gid", strconv.Itoa(l.opts.GID))
 	}
 
 	configProvider := NewPhaseConfigProvider(
@@ -305,8 +445,9 @@ func (l *LifecycleExecution) Restore(ctx context.Context, networkMode string, bu
 			)...,
 		),
 		WithNetwork(networkMode),
-		flagsOpt,
+		WithFlags(flags...),
 		cacheOpt,
+		cacheAccessOpt,
 	)
 
 	restore := phaseFactory.New(configProvider)
@@ -333,23 +474,23 @@ func (l *LifecycleExecution) newAnalyze(repoName, networkMode string, publish bo
 		args = append([]string{"-cache-dir", l.mountPaths.cacheDir()}, args...)
 	}
 
+	var flags []string
 	cacheOpt := NullOp()
-	flagsOpt := NullOp()
 	switch buildCache.Type() {
 	case cache.Image:
 		if !clearCache {
-			flagsOpt = WithFlags("-cache-image", buildCache.Name())
+			flags = append(flags, "-cache-image", buildCache.Name())
 		}
-	case cache.Volume:
+	case cache.Volume, cache.Bind:
 		cacheOpt = WithBinds(fmt.Sprintf("%s:%s", buildCache.Name(), l.mountPaths.cacheDir()))
 	}
 
 	if l.opts.GID >= overrideGID {
-		flagsOpt = WithFlags("-gid", strconv.Itoa(l.opts.GID))
+		flags = append(flags, "-gid", strconv.Itoa(l.opts.GID))
 	}
 
 	if publish {
-		authConfig, err := auth.BuildEnvVar(authn.DefaultKeychain, repoName)
+		authConfig, err := auth.BuildEnvVar(authn.DefaultKeychain, registryImages(buildCache, repoName)...)
 		if err != nil {
 			return nil, err
 		}
@@ -364,13 +505,18 @@ func (l *LifecycleExecution) newAnalyze(repoName, networkMode string, publish bo
 			WithRoot(),
 			WithArgs(l.withLogLevel(args...)...),
 			WithNetwork(networkMode),
-			flagsOpt,
+			WithFlags(flags...),
 			cacheOpt,
 		)
 
 		return phaseFactory.New(configProvider), nil
 	}
 
+	cacheAccessOpt, err := withCacheImageAccess(buildCache)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO: when platform API 0.2 is no longer supported we can delete this code: https://github.com/buildpacks/pack/issues/629.
 	configProvider := NewPhaseConfigProvider(
 		"analyzer",
@@ -390,9 +536,10 @@ func (l *LifecycleExecution) newAnalyze(repoName, networkMode string, publish bo
 				)...,
 			)...,
 		),
-		flagsOpt,
+		WithFlags(flags...),
 		WithNetwork(networkMode),
 		cacheOpt,
+		cacheAccessOpt,
 	)
 
 	return phaseFactory.New(configProvider), nil
@@ -408,6 +555,9 @@ func (l *LifecycleExecution) Build(ctx context.Context, networkMode string, volu
 		WithNetwork(networkMode),
 		WithBinds(volumes...),
 		WithFlags(flags...),
+		l.withPlatformEnv(),
+		WithSecrets(l.opts.Secrets...),
+		WithSSHAgent(l.opts.SSHAuthSock),
 	)
 
 	build := phaseFactory.New(configProvider)
@@ -426,12 +576,17 @@ func determineDefaultProcessType(platformAPI *api.Version, providedValue string)
 }
 
 func (l *LifecycleExecution) newExport(repoName, runImage string, publish bool, dockerHost, networkMode string, buildCache, launchCache Cache, additionalTags []string, phaseFactory PhaseFactory) (RunnerCleaner, error) {
-	flags := []string{
-		"-app", l.mountPaths.appDir(),
-		"-cache-dir", l.mountPaths.cacheDir(),
+	writeCache := writesCache(buildCache, l.opts.CacheImageReadOnly)
+
+	// the exporter skips caching when it is given neither a cache directory nor a cache image
+	flags := []string{"-app", l.mountPaths.appDir()}
+	if writeCache {
+		flags = append(flags, "-cache-dir", l.mountPaths.cacheDir())
+	}
+	flags = append(flags,
 		"-stack", l.mountPaths.stackPath(),
 		"-run-image", runImage,
-	}
+	)
 
 	processType := determineDefaultProcessType(l.platformAPI, l.opts.DefaultProcessType)
 	if processType != "" {
@@ -444,8 +599,10 @@ func (l *LifecycleExecution) newExport(repoName, runImage string, publish bool,
 	cacheOpt := NullOp()
 	switch buildCache.Type() {
 	case cache.Image:
-		flags = append(flags, "-cache-image", buildCache.Name())
-	case cache.Volume:
+		if writeCache {
+			flags = append(flags, "-cache-image", buildCache.Name())
+		}
+	case cache.Volume, cache.Bind:
 		cacheOpt = WithBinds(fmt.Sprintf("%s:%s", buildCache.Name(), l.mountPaths.cacheDir()))
 	}
 
@@ -465,10 +622,16 @@ func (l *LifecycleExecution) newExport(repoName, runImage string, publish bool,
 		cacheOpt,
 		WithContainerOperations(WriteStackToml(l.mountPaths.stackPath(), l.opts.Builder.Stack(), l.os)),
 		WithContainerOperations(WriteProjectMetadata(l.mountPaths.projectPath(), l.opts.ProjectMetadata, l.os)),
+		l.withExportEvents(),
+		l.withCreationTime(),
 	}
 
 	if publish {
-		authConfig, err := auth.BuildEnvVar(authn.DefaultKeychain, repoName, runImage)
+		images := []string{repoName, runImage}
+		if writeCache {
+			images = registryImages(buildCache, images...)
+		}
+		authConfig, err := auth.BuildEnvVar(authn.DefaultKeychain, images...)
 		if err != nil {
 			return nil, err
 		}
@@ -479,8 +642,17 @@ func (l *LifecycleExecution) newExport(repoName, runImage string, publish bool,
 			WithRoot(),
 		)
 	} else {
+		cacheAccessOpt := NullOp()
+		if writeCache {
+			var err error
+			if cacheAccessOpt, err = withCacheImageAccess(buildCache); err != nil {
+				return nil, err
+			}
+		}
+
 		opts = append(
 			opts,
+			cacheAccessOpt,
 			WithDaemonAccess(dockerHost),
 			WithFlags("-daemon", "-launch-cache", l.mountPaths.launchCacheDir()),
 			WithBinds(fmt.Sprintf("%s:%s", launchCache.Name(), l.mountPaths.launchCacheDir())),
@@ -499,6 +671,28 @@ func (l *LifecycleExecution) Export(ctx context.Context, repoName, runImage stri
 	return export.Run(ctx)
 }
 
+// withCreationTime provides the creation time of the app image to the exporting phase, when its Platform API supports it.
+func (l *LifecycleExecution) withCreationTime() PhaseConfigProviderOperation {
+	if l.opts.CreationTime == nil || !supportsCreationTime(l.PlatformAPI()) {
+		return NullOp()
+	}
+	return WithEnv(fmt.Sprintf("%s=%d", sourceDateEpochEnv, l.opts.CreationTime.Unix()))
+}
+
+func (l *LifecycleExecution) warnCreationTimeUnsupported() {
+	warning := creationTimeUnsupportedWarning(l.PlatformAPI())
+	l.logger.Warn(warning)
+	events.Emit(l.opts.EventSink, events.Event{Type: events.Warning, Message: warning})
+}
+
+func creationTimeUnsupportedWarning(platformAPI *api.Version) string {
+	return fmt.Sprintf("Ignoring the creation time, it requires Platform API %s or later but the build uses Platform API %s", minPlatformAPISupportingCreationTime, platformAPI)
+}
+
+func supportsCreationTime(platformAPI *api.Version) bool {
+	return platformAPI.Compare(minPlatformAPISupportingCreationTime) >= 0
+}
+
 func (l *LifecycleExecution) withLogLevel(args ...string) []string {
 	if l.logger.IsVerbose() {
 		return append([]string{"-log-level", "debug"}, args...)
@@ -506,6 +700,43 @@ func (l *LifecycleExecution) withLogLevel(args ...string) []string {
 	return args
 }
 
+// withPlatformEnv provides the build-time environment to the phases that run buildpacks. It is written to each
+// container rather than to the builder, so that it never ends up in an image.
+func (l *LifecycleExecution) withPlatformEnv() PhaseConfigProviderOperation {
+	if len(l.opts.Env) == 0 {
+		return NullOp()
+	}
+	return WithContainerOperations(WritePlatformEnv(l.mountPaths.platformEnvDir(), l.opts.Env, l.os))
+}
+
+// writesCache reports whether the exporter stores layers in buildCache. A cache image may be restored from without
+// being written to, for example to start a local build from the cache of a CI system.
+func writesCache(buildCache Cache, cacheImageReadOnly bool) bool {
+	return buildCache.Type() != cache.Image || !cacheImageReadOnly
+}
+
+// registryImages returns images along with the cache image, if buildCache is stored in one.
+func registryImages(buildCache Cache, images ...string) []string {
+	if buildCache.Type() == cache.Image {
+		return append(images, buildCache.Name())
+	}
+	return images
+}
+
+// withCacheImageAccess provides the registry credentials for buildCache when it is stored in an image,
+// as phases that export to the daemon are otherwise given no registry access.
+func withCacheImageAccess(buildCache Cache) (PhaseConfigProviderOperation, error) {
+	if buildCache.Type() != cache.Image {
+		return NullOp(), nil
+	}
+
+	authConfig, err := auth.BuildEnvVar(authn.DefaultKeychain, buildCache.Name())
+	if err != nil {
+		return nil, err
+	}
+	return WithRegistryAccess(authConfig), nil
+}
+
 func prependArg(arg string, args []string) []string {
 	return append([]string{arg}, args...)
 }
@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/apex/log"
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
 	"github.com/heroku/color"
 	"github.com/sclevine/spec"
@@ -25,7 +27,10 @@ import (
 
 	"github.com/buildpacks/pack/internal/build"
 	"github.com/buildpacks/pack/internal/build/fakes"
+	"github.com/buildpacks/pack/internal/ephemeral"
 	ilogging "github.com/buildpacks/pack/internal/logging"
+	"github.com/buildpacks/pack/logging"
+	"github.com/buildpacks/pack/pkg/events"
 	h "github.com/buildpacks/pack/testhelpers"
 )
 
@@ -184,6 +189,152 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 				})
 			})
 		})
+		when("Run with a creation time", func() {
+			it("warns that the Platform API does not support it", func() {
+				creationTime := time.Unix(1600000000, 0)
+				opts := build.LifecycleOptions{
+					RunImage:     "test",
+					Image:        imageName,
+					Builder:      fakeBuilder,
+					UseCreator:   true,
+					BuildCache:   &build.CacheOptions{Type: cache.Bind, Source: "/some/cache/dir"},
+					CreationTime: &creationTime,
+				}
+
+				lifecycle, err := build.NewLifecycleExecution(logger, docker, opts)
+				h.AssertNil(t, err)
+
+				err = lifecycle.Run(context.Background(), func(execution *build.LifecycleExecution) build.PhaseFactory {
+					return fakePhaseFactory
+				})
+				h.AssertNil(t, err)
+
+				h.AssertContains(t, outBuf.String(), "Ignoring the creation time, it requires Platform API 0.9 or later but the build uses Platform API 0.3")
+				h.AssertEq(t, len(fakePhaseFactory.NewCalledWithProvider), 1)
+				for _, env := range fakePhaseFactory.NewCalledWithProvider[0].ContainerConfig().Env {
+					h.AssertEq(t, strings.HasPrefix(env, "SOURCE_DATE_EPOCH="), false)
+				}
+			})
+		})
+
+		when("Run with cache options", func() {
+			it("mounts a bind build cache and a named launch cache", func() {
+				opts := build.LifecycleOptions{
+					RunImage:    "test",
+					Image:       imageName,
+					Builder:     fakeBuilder,
+					UseCreator:  true,
+					BuildCache:  &build.CacheOptions{Type: cache.Bind, Source: "/some/cache/dir"},
+					LaunchCache: &build.CacheOptions{Type: cache.Volume, Name: "shared-launch-cache"},
+				}
+
+				lifecycle, err := build.NewLifecycleExecution(logger, docker, opts)
+				h.AssertNil(t, err)
+
+				err = lifecycle.Run(context.Background(), func(execution *build.LifecycleExecution) build.PhaseFactory {
+					return fakePhaseFactory
+				})
+				h.AssertNil(t, err)
+
+				h.AssertEq(t, len(fakePhaseFactory.NewCalledWithProvider), 1)
+				binds := fakePhaseFactory.NewCalledWithProvider[0].HostConfig().Binds
+				h.AssertSliceContains(t, binds, "/some/cache/dir:/cache")
+				h.AssertSliceContains(t, binds, "shared-launch-cache:/launch-cache")
+			})
+
+			it("uses a cache image for the build cache", func() {
+				opts := build.LifecycleOptions{
+					RunImage:   "test",
+					Image:      imageName,
+					Builder:    fakeBuilder,
+					UseCreator: true,
+					Publish:    true,
+					BuildCache: &build.CacheOptions{Type: cache.Image, Name: "some/cache-image"},
+				}
+
+				lifecycle, err := build.NewLifecycleExecution(logger, docker, opts)
+				h.AssertNil(t, err)
+
+				err = lifecycle.Run(context.Background(), func(execution *build.LifecycleExecution) build.PhaseFactory {
+					return fakePhaseFactory
+				})
+				h.AssertNil(t, err)
+
+				h.AssertSliceContainsInOrder(t, fakePhaseFactory.NewCalledWithProvider[0].ContainerConfig().Cmd, "-cache-image", "index.docker.io/some/cache-image:latest")
+			})
+
+			it("runs each phase when the cache image is read-only", func() {
+				opts := build.LifecycleOptions{
+					RunImage:           "test",
+					Image:              imageName,
+					Builder:            fakeBuilder,
+					UseCreator:         true,
+					CacheImage:         "some/cache-image",
+					CacheImageReadOnly: true,
+				}
+
+				lifecycle, err := build.NewLifecycleExecution(logger, docker, opts)
+				h.AssertNil(t, err)
+
+				err = lifecycle.Run(context.Background(), func(execution *build.LifecycleExecution) build.PhaseFactory {
+					return fakePhaseFactory
+				})
+				h.AssertNil(t, err)
+
+				h.AssertEq(t, len(fakePhaseFactory.NewCalledWithProvider), 5)
+				for _, entry := range fakePhaseFactory.NewCalledWithProvider {
+					switch entry.Name() {
+					case "analyzer", "restorer":
+						h.AssertSliceContainsInOrder(t, entry.ContainerConfig().Cmd, "-cache-image", "index.docker.io/some/cache-image:latest")
+					case "exporter":
+						h.AssertSliceNotContains(t, entry.ContainerConfig().Cmd, "-cache-image")
+						h.AssertSliceNotContains(t, entry.ContainerConfig().Cmd, "-cache-dir")
+					}
+				}
+			})
+
+			it("errors for a launch cache image", func() {
+				opts := build.LifecycleOptions{
+					RunImage:    "test",
+					Image:       imageName,
+					Builder:     fakeBuilder,
+					UseCreator:  true,
+					LaunchCache: &build.CacheOptions{Type: cache.Image, Name: "some/cache-image"},
+				}
+
+				lifecycle, err := build.NewLifecycleExecution(logger, docker, opts)
+				h.AssertNil(t, err)
+
+				err = lifecycle.Run(context.Background(), func(execution *build.LifecycleExecution) build.PhaseFactory {
+					return fakePhaseFactory
+				})
+				h.AssertError(t, err, "launch cache cannot be stored in an image")
+			})
+		})
+
+		when("Run with dry run", func() {
+			it("only runs the detector", func() {
+				opts := build.LifecycleOptions{
+					RunImage:     "test",
+					Image:        imageName,
+					Builder:      fakeBuilder,
+					TrustBuilder: true,
+					UseCreator:   true,
+					DryRun:       true,
+				}
+
+				lifecycle, err := build.NewLifecycleExecution(logger, docker, opts)
+				h.AssertNil(t, err)
+
+				err = lifecycle.Run(context.Background(), func(execution *build.LifecycleExecution) build.PhaseFactory {
+					return fakePhaseFactory
+				})
+				h.AssertNil(t, err)
+
+				h.AssertEq(t, len(fakePhaseFactory.NewCalledWithProvider), 1)
+				h.AssertEq(t, fakePhaseFactory.NewCalledWithProvider[0].Name(), "detector")
+			})
+		})
 		when("Run without using creator", func() {
 			it("succeeds", func() {
 				opts := build.LifecycleOptions{
@@ -215,6 +366,38 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 					}
 				}
 			})
+			when("an event sink is provided", func() {
+				it("emits the start and finish of each phase", func() {
+					var received []events.Event
+					opts := build.LifecycleOptions{
+						RunImage:  "test",
+						Image:     imageName,
+						Builder:   fakeBuilder,
+						EventSink: events.SinkFunc(func(e events.Event) { received = append(received, e) }),
+					}
+
+					lifecycle, err := build.NewLifecycleExecution(logger, docker, opts)
+					h.AssertNil(t, err)
+
+					err = lifecycle.Run(context.Background(), func(execution *build.LifecycleExecution) build.PhaseFactory {
+						return fakePhaseFactory
+					})
+					h.AssertNil(t, err)
+
+					var phases []string
+					for _, e := range received {
+						phases = append(phases, fmt.Sprintf("%s:%s", e.Type, e.Phase))
+						h.AssertFalse(t, e.Time.IsZero())
+					}
+					h.AssertEq(t, phases, []string{
+						"phase_started:detector", "phase_finished:detector",
+						"phase_started:analyzer", "phase_finished:analyzer",
+						"phase_started:restorer", "phase_finished:restorer",
+						"phase_started:builder", "phase_finished:builder",
+						"phase_started:exporter", "phase_finished:exporter",
+					})
+				})
+			})
 			when("Run with workspace dir", func() {
 				it("succeeds", func() {
 					opts := build.LifecycleOptions{
@@ -343,6 +526,20 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, configProvider.HostConfig().NetworkMode, container.NetworkMode(expectedNetworkMode))
 		})
 
+		it("configures the phase to write the build env", func() {
+			lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+				options.Env = map[string]string{"some-key": "some-value"}
+			})
+			fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+			err := lifecycle.Create(context.Background(), false, "", false, "test", "test", "test", fakeBuildCache, fakeLaunchCache, []string{}, []string{}, fakePhaseFactory)
+			h.AssertNil(t, err)
+
+			configProvider := fakePhaseFactory.NewCalledWithProvider[len(fakePhaseFactory.NewCalledWithProvider)-1]
+			h.AssertEq(t, len(configProvider.ContainerOps()), 3)
+			h.AssertFunctionName(t, configProvider.ContainerOps()[2], "WritePlatformEnv")
+		})
+
 		when("clear cache", func() {
 			it("configures the phase with the expected arguments", func() {
 				verboseLifecycle := newTestLifecycleExec(t, true)
@@ -408,6 +605,21 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 
 				h.AssertSliceNotContains(t, configProvider.HostConfig().Binds, ":/cache")
 			})
+
+			it("configures the phase with registry access", func() {
+				lifecycle := newTestLifecycleExec(t, false)
+				fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+				err := lifecycle.Create(context.Background(), false, "", false, "test", "test", "test", fakeBuildCache, fakeLaunchCache, []string{}, []string{}, fakePhaseFactory)
+				h.AssertNil(t, err)
+
+				lastCallIndex := len(fakePhaseFactory.NewCalledWithProvider) - 1
+				h.AssertNotEq(t, lastCallIndex, -1)
+
+				configProvider := fakePhaseFactory.NewCalledWithProvider[lastCallIndex]
+				h.AssertSliceContains(t, configProvider.ContainerConfig().Env, "CNB_REGISTRY_AUTH={}")
+				h.AssertSliceContains(t, configProvider.HostConfig().Binds, "some-launch-cache:/launch-cache")
+			})
 		})
 
 		when("additional tags are specified", func() {
@@ -934,7 +1146,95 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("#DebugShellConfig", func() {
+		it("errors when no phase has been run", func() {
+			lifecycle := newTestLifecycleExec(t, false)
+
+			_, _, err := lifecycle.DebugShellConfig()
+			h.AssertError(t, err, "no phase has been run")
+		})
+
+		it("uses the mounts, environment and user of the last phase", func() {
+			lifecycle := newTestLifecycleExec(t, false)
+			fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+			err := lifecycle.Build(context.Background(), "some-network", []string{"some-volume:/some-path"}, fakePhaseFactory)
+			h.AssertNil(t, err)
+			phaseProvider := fakePhaseFactory.NewCalledWithProvider[len(fakePhaseFactory.NewCalledWithProvider)-1]
+
+			ctrConf, hostConf, err := lifecycle.DebugShellConfig()
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, ctrConf.Image, lifecycle.Builder().Name())
+			h.AssertEq(t, []string(ctrConf.Entrypoint), []string{"/bin/sh"})
+			h.AssertEq(t, ctrConf.Env, phaseProvider.ContainerConfig().Env)
+			h.AssertEq(t, ctrConf.User, phaseProvider.ContainerConfig().User)
+			h.AssertEq(t, ctrConf.Tty, true)
+			h.AssertEq(t, ctrConf.OpenStdin, true)
+			h.AssertEq(t, hostConf.Binds, phaseProvider.HostConfig().Binds)
+			h.AssertSliceContains(t, hostConf.Binds, "some-volume:/some-path")
+			h.AssertEq(t, hostConf.NetworkMode, container.NetworkMode("some-network"))
+		})
+
+		it("does not mount the secrets or the SSH agent socket", func() {
+			tmpDir, err := ioutil.TempDir("", "debug-shell-test")
+			h.AssertNil(t, err)
+			defer os.RemoveAll(tmpDir)
+			secretFile := filepath.Join(tmpDir, "token")
+			h.AssertNil(t, ioutil.WriteFile(secretFile, []byte("some-token"), 0600))
+			lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+				options.SSHAuthSock = "/tmp/some-agent.sock"
+				options.Secrets = []build.Secret{{ID: "token", Source: secretFile}}
+			})
+			fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+			err = lifecycle.Build(context.Background(), "some-network", []string{}, fakePhaseFactory)
+			h.AssertNil(t, err)
+			phaseProvider := fakePhaseFactory.NewCalledWithProvider[len(fakePhaseFactory.NewCalledWithProvider)-1]
+			h.AssertEq(t, len(phaseProvider.HostConfig().Mounts), 3)
+
+			ctrConf, hostConf, err := lifecycle.DebugShellConfig()
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, len(hostConf.Mounts), 0)
+			h.AssertSliceNotContains(t, ctrConf.Env, "SSH_AUTH_SOCK=/run/ssh/agent.sock")
+		})
+
+		it("labels the container as kept instead of ephemeral", func() {
+			lifecycle := newTestLifecycleExec(t, false)
+			fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+			err := lifecycle.Build(context.Background(), "some-network", []string{}, fakePhaseFactory)
+			h.AssertNil(t, err)
+
+			ctrConf, _, err := lifecycle.DebugShellConfig()
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, ctrConf.Labels[ephemeral.KeepLabel], "true")
+			_, ok := ctrConf.Labels[ephemeral.Label]
+			h.AssertFalse(t, ok)
+			_, ok = ctrConf.Labels[ephemeral.OwnerLabel]
+			h.AssertFalse(t, ok)
+		})
+	})
+
 	when("#Detect", func() {
+		it("forwards the SSH agent socket when provided", func() {
+			lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+				options.SSHAuthSock = "/tmp/some-agent.sock"
+			})
+			fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+			err := lifecycle.Detect(context.Background(), "test", []string{}, fakePhaseFactory)
+			h.AssertNil(t, err)
+
+			configProvider := fakePhaseFactory.NewCalledWithProvider[len(fakePhaseFactory.NewCalledWithProvider)-1]
+			h.AssertEq(t, configProvider.HostConfig().Mounts, []mount.Mount{
+				{Type: mount.TypeBind, Source: "/tmp/some-agent.sock", Target: "/run/ssh/agent.sock"},
+			})
+			h.AssertSliceContains(t, configProvider.ContainerConfig().Env, "SSH_AUTH_SOCK=/run/ssh/agent.sock")
+		})
+
 		it("creates a phase and then runs it", func() {
 			lifecycle := newTestLifecycleExec(t, false)
 			fakePhase := &fakes.FakePhase{}
@@ -997,6 +1297,41 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, len(configProvider.ContainerOps()), 2)
 			h.AssertFunctionName(t, configProvider.ContainerOps()[0], "EnsureVolumeAccess")
 			h.AssertFunctionName(t, configProvider.ContainerOps()[1], "CopyDir")
+			h.AssertEq(t, len(configProvider.PostContainerRunOps()), 0)
+		})
+
+		it("configures the phase to write the build env", func() {
+			lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+				options.Env = map[string]string{"some-key": "some-value"}
+			})
+			fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+			err := lifecycle.Detect(context.Background(), "test", []string{}, fakePhaseFactory)
+			h.AssertNil(t, err)
+
+			configProvider := fakePhaseFactory.NewCalledWithProvider[len(fakePhaseFactory.NewCalledWithProvider)-1]
+			h.AssertEq(t, len(configProvider.ContainerOps()), 3)
+			h.AssertFunctionName(t, configProvider.ContainerOps()[2], "WritePlatformEnv")
+		})
+
+		when("an event sink is provided", func() {
+			it("configures the phase to read the detected group and plan", func() {
+				lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+					options.EventSink = events.SinkFunc(func(events.Event) {})
+				})
+				fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+				err := lifecycle.Detect(context.Background(), "test", []string{}, fakePhaseFactory)
+				h.AssertNil(t, err)
+
+				lastCallIndex := len(fakePhaseFactory.NewCalledWithProvider) - 1
+				h.AssertNotEq(t, lastCallIndex, -1)
+
+				configProvider := fakePhaseFactory.NewCalledWithProvider[lastCallIndex]
+				h.AssertEq(t, len(configProvider.PostContainerRunOps()), 2)
+				h.AssertFunctionName(t, configProvider.PostContainerRunOps()[0], "CopyOut")
+				h.AssertFunctionName(t, configProvider.PostContainerRunOps()[1], "CopyOut")
+			})
 		})
 	})
 
@@ -1091,6 +1426,17 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 					[]string{"-cache-dir", "/cache"},
 				)
 			})
+			it("configures the phase with registry access", func() {
+				err := lifecycle.Analyze(context.Background(), expectedRepoName, "", false, "", false, fakeCache, fakePhaseFactory)
+				h.AssertNil(t, err)
+
+				lastCallIndex := len(fakePhaseFactory.NewCalledWithProvider) - 1
+				h.AssertNotEq(t, lastCallIndex, -1)
+
+				configProvider := fakePhaseFactory.NewCalledWithProvider[lastCallIndex]
+				h.AssertSliceContains(t, configProvider.ContainerConfig().Env, "CNB_REGISTRY_AUTH={}")
+				h.AssertSliceContains(t, configProvider.ContainerConfig().Cmd, "-daemon")
+			})
 			when("clear-cache", func() {
 				it("cache is omitted from Analyze", func() {
 					err := lifecycle.Analyze(context.Background(), expectedRepoName, "", false, "", true, fakeCache, fakePhaseFactory)
@@ -1516,6 +1862,22 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 			h.AssertSliceContains(t, configProvider.HostConfig().Binds, expectedBind)
 		})
 
+		it("configures the phase with a bind cache", func() {
+			fakeCache.ReturnForType = cache.Bind
+			fakeCache.ReturnForName = "/some/cache/dir"
+			lifecycle := newTestLifecycleExec(t, false)
+			fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+			err := lifecycle.Restore(context.Background(), "test", fakeCache, fakePhaseFactory)
+			h.AssertNil(t, err)
+
+			lastCallIndex := len(fakePhaseFactory.NewCalledWithProvider) - 1
+			h.AssertNotEq(t, lastCallIndex, -1)
+
+			configProvider := fakePhaseFactory.NewCalledWithProvider[lastCallIndex]
+			h.AssertSliceContains(t, configProvider.HostConfig().Binds, "/some/cache/dir:/cache")
+		})
+
 		when("using cache image", func() {
 			var (
 				lifecycle        *build.LifecycleExecution
@@ -1531,6 +1893,16 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 				})
 				fakePhaseFactory = fakes.NewFakePhaseFactory()
 			})
+			it("configures the phase with registry access", func() {
+				err := lifecycle.Restore(context.Background(), "test", fakeCache, fakePhaseFactory)
+				h.AssertNil(t, err)
+
+				lastCallIndex := len(fakePhaseFactory.NewCalledWithProvider) - 1
+				h.AssertNotEq(t, lastCallIndex, -1)
+
+				configProvider := fakePhaseFactory.NewCalledWithProvider[lastCallIndex]
+				h.AssertSliceContains(t, configProvider.ContainerConfig().Env, "CNB_REGISTRY_AUTH={}")
+			})
 			it("configures the phase with a cache image", func() {
 				err := lifecycle.Restore(context.Background(), "test", fakeCache, fakePhaseFactory)
 				h.AssertNil(t, err)
@@ -1592,6 +1964,75 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 	})
 
 	when("#Build", func() {
+		it("configures the phase to write the build env", func() {
+			lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+				options.Env = map[string]string{"some-key": "some-value"}
+			})
+			fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+			err := lifecycle.Build(context.Background(), "test", []string{}, fakePhaseFactory)
+			h.AssertNil(t, err)
+
+			configProvider := fakePhaseFactory.NewCalledWithProvider[len(fakePhaseFactory.NewCalledWithProvider)-1]
+			h.AssertEq(t, len(configProvider.ContainerOps()), 1)
+			h.AssertFunctionName(t, configProvider.ContainerOps()[0], "WritePlatformEnv")
+		})
+
+		when("secrets are provided", func() {
+			var secretPath string
+
+			it.Before(func() {
+				tmpDir, err := ioutil.TempDir("", "lifecycle-secrets")
+				h.AssertNil(t, err)
+				secretPath = filepath.Join(tmpDir, "npmrc")
+				h.AssertNil(t, ioutil.WriteFile(secretPath, []byte("some-secret"), 0600))
+			})
+
+			it.After(func() {
+				h.AssertNil(t, os.RemoveAll(filepath.Dir(secretPath)))
+			})
+
+			it("mounts the secrets into the build phase", func() {
+				lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+					options.Secrets = []build.Secret{{ID: "npmrc", Source: secretPath}}
+				})
+				fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+				err := lifecycle.Build(context.Background(), "test", []string{}, fakePhaseFactory)
+				h.AssertNil(t, err)
+
+				configProvider := fakePhaseFactory.NewCalledWithProvider[len(fakePhaseFactory.NewCalledWithProvider)-1]
+				mounts := configProvider.HostConfig().Mounts
+				h.AssertEq(t, len(mounts), 2)
+				h.AssertEq(t, mounts[1].Source, secretPath)
+				h.AssertEq(t, mounts[1].Target, "/run/secrets/npmrc")
+				h.AssertEq(t, mounts[1].ReadOnly, true)
+			})
+
+			it("errors when a secret cannot be read", func() {
+				_, err := newTestLifecycleExecErr(t, false, func(options *build.LifecycleOptions) {
+					options.Secrets = []build.Secret{{ID: "missing", Source: filepath.Join(filepath.Dir(secretPath), "missing")}}
+				})
+				h.AssertError(t, err, "reading secret 'missing'")
+			})
+		})
+
+		it("forwards the SSH agent socket when provided", func() {
+			lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+				options.SSHAuthSock = "/tmp/some-agent.sock"
+			})
+			fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+			err := lifecycle.Build(context.Background(), "test", []string{}, fakePhaseFactory)
+			h.AssertNil(t, err)
+
+			configProvider := fakePhaseFactory.NewCalledWithProvider[len(fakePhaseFactory.NewCalledWithProvider)-1]
+			h.AssertEq(t, configProvider.HostConfig().Mounts, []mount.Mount{
+				{Type: mount.TypeBind, Source: "/tmp/some-agent.sock", Target: "/run/ssh/agent.sock"},
+			})
+			h.AssertSliceContains(t, configProvider.ContainerConfig().Env, "SSH_AUTH_SOCK=/run/ssh/agent.sock")
+		})
+
 		it("creates a phase and then runs it", func() {
 			lifecycle := newTestLifecycleExec(t, false)
 			fakePhase := &fakes.FakePhase{}
@@ -1706,6 +2147,41 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 			)
 		})
 
+		when("an event sink is provided", func() {
+			it("configures the phase to read the processes and the report", func() {
+				fakeBuilder, err := fakes.NewFakeBuilder(fakes.WithSupportedPlatformAPIs([]*api.Version{api.MustParse("0.5")}))
+				h.AssertNil(t, err)
+				lifecycle := newTestLifecycleExec(t, false, fakes.WithBuilder(fakeBuilder), func(options *build.LifecycleOptions) {
+					options.EventSink = events.SinkFunc(func(events.Event) {})
+				})
+				fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+				err = lifecycle.Export(context.Background(), "some-repo-name", "some-run-image", false, "", "test", fakeBuildCache, fakeLaunchCache, []string{}, fakePhaseFactory)
+				h.AssertNil(t, err)
+
+				configProvider := fakePhaseFactory.NewCalledWithProvider[len(fakePhaseFactory.NewCalledWithProvider)-1]
+				h.AssertEq(t, len(configProvider.PostContainerRunOps()), 3)
+				h.AssertFunctionName(t, configProvider.PostContainerRunOps()[0], "CopyOut")
+				h.AssertFunctionName(t, configProvider.PostContainerRunOps()[1], "CopyOut")
+			})
+
+			when("the report is not written to the layers directory", func() {
+				it("only reads the processes", func() {
+					lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+						options.EventSink = events.SinkFunc(func(events.Event) {})
+					})
+					fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+					err := lifecycle.Export(context.Background(), "some-repo-name", "some-run-image", false, "", "test", fakeBuildCache, fakeLaunchCache, []string{}, fakePhaseFactory)
+					h.AssertNil(t, err)
+
+					configProvider := fakePhaseFactory.NewCalledWithProvider[len(fakePhaseFactory.NewCalledWithProvider)-1]
+					h.AssertEq(t, len(configProvider.PostContainerRunOps()), 2)
+					h.AssertFunctionName(t, configProvider.PostContainerRunOps()[0], "CopyOut")
+				})
+			})
+		})
+
 		when("additional tags are specified", func() {
 			it("passes tag arguments to the exporter", func() {
 				verboseLifecycle := newTestLifecycleExec(t, true)
@@ -1758,6 +2234,27 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 					configProvider.ContainerConfig().Cmd,
 					[]string{"-cache-image", "some-cache-image"},
 				)
+				h.AssertSliceContains(t, configProvider.ContainerConfig().Env, "CNB_REGISTRY_AUTH={}")
+			})
+
+			when("the cache image is read-only", func() {
+				it("does not export the cache", func() {
+					lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+						options.CacheImageReadOnly = true
+					})
+					fakePhaseFactory := fakes.NewFakePhaseFactory()
+
+					err := lifecycle.Export(context.Background(), "some-repo-name", "some-run-image", false, "", "test", fakeBuildCache, fakeLaunchCache, []string{}, fakePhaseFactory)
+					h.AssertNil(t, err)
+
+					lastCallIndex := len(fakePhaseFactory.NewCalledWithProvider) - 1
+					h.AssertNotEq(t, lastCallIndex, -1)
+
+					configProvider := fakePhaseFactory.NewCalledWithProvider[lastCallIndex]
+					h.AssertSliceNotContains(t, configProvider.ContainerConfig().Cmd, "-cache-image")
+					h.AssertSliceNotContains(t, configProvider.ContainerConfig().Cmd, "-cache-dir")
+					h.AssertSliceNotContains(t, configProvider.ContainerConfig().Env, "CNB_REGISTRY_AUTH={}")
+				})
 			})
 		})
 
@@ -2228,15 +2725,27 @@ func testLifecycleExecution(t *testing.T, when spec.G, it spec.S) {
 }
 
 func newTestLifecycleExecErr(t *testing.T, logVerbose bool, ops ...func(*build.LifecycleOptions)) (*build.LifecycleExecution, error) {
-	docker, err := client.NewClientWithOpts(client.FromEnv, client.WithVersion("1.38"))
-	h.AssertNil(t, err)
-
 	var outBuf bytes.Buffer
 	logger := ilogging.NewLogWithWriters(&outBuf, &outBuf)
 	if logVerbose {
 		logger.Level = log.DebugLevel
 	}
 
+	return newTestLifecycleExecWithLoggerErr(t, logger, ops...)
+}
+
+func newTestLifecycleExecWithLogger(t *testing.T, logger logging.Logger, ops ...func(*build.LifecycleOptions)) *build.LifecycleExecution {
+	t.Helper()
+
+	lifecycleExec, err := newTestLifecycleExecWithLoggerErr(t, logger, ops...)
+	h.AssertNil(t, err)
+	return lifecycleExec
+}
+
+func newTestLifecycleExecWithLoggerErr(t *testing.T, logger logging.Logger, ops ...func(*build.LifecycleOptions)) (*build.LifecycleExecution, error) {
+	docker, err := client.NewClientWithOpts(client.FromEnv, client.WithVersion("1.38"))
+	h.AssertNil(t, err)
+
 	defaultBuilder, err := fakes.NewFakeBuilder()
 	h.AssertNil(t, err)
 
@@ -2,6 +2,7 @@ package build
 
 import (
 	"context"
+	"io"
 	"math/rand"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 
 	"github.com/buildpacks/pack/internal/builder"
 	"github.com/buildpacks/pack/logging"
+	"github.com/buildpacks/pack/pkg/events"
 )
 
 var (
@@ -47,6 +49,14 @@ type Cache interface {
 	Type() cache.Type
 }
 
+// CacheOptions selects the backend of a cache. Name is the image or volume to use and Source the host directory
+// of a bind cache. A volume named after the app image is used when Name is empty.
+type CacheOptions struct {
+	Type   cache.Type
+	Name   string
+	Source string
+}
+
 func init() {
 	rand.Seed(time.Now().UTC().UnixNano())
 }
@@ -65,6 +75,9 @@ type LifecycleOptions struct {
 	UseCreator         bool
 	DockerHost         string
 	CacheImage         string
+	CacheImageReadOnly bool
+	BuildCache         *CacheOptions
+	LaunchCache        *CacheOptions
 	HTTPProxy          string
 	HTTPSProxy         string
 	NoProxy            string
@@ -76,6 +89,19 @@ type LifecycleOptions struct {
 	Workspace          string
 	GID                int
 	PreviousImage      string
+	EventSink          events.Sink
+	DryRun             bool
+	DebugOnFailure     bool
+	DebugShellIn       io.Reader
+	DebugShellOut      io.Writer
+	Secrets            []Secret
+	SSHAuthSock        string
+	CPUs               float64
+	Memory             int64
+	PidsLimit          int64
+	PhaseTimeout       time.Duration
+	Env                map[string]string
+	CreationTime       *time.Time
 }
 
 func NewLifecycleExecutor(logger logging.Logger, docker client.CommonAPIClient) *LifecycleExecutor {
@@ -87,6 +113,23 @@ func (l *LifecycleExecutor) Execute(ctx context.Context, opts LifecycleOptions)
 	if err != nil {
 		return err
 	}
-	defer lifecycleExec.Cleanup()
-	return lifecycleExec.Run(ctx, NewDefaultPhaseFactory)
+
+	if err := lifecycleExec.createVolumes(ctx); err != nil {
+		lifecycleExec.Cleanup()
+		return err
+	}
+
+	err = lifecycleExec.Run(ctx, NewDefaultPhaseFactory)
+	if err != nil && opts.DebugOnFailure {
+		l.logger.Errorf("Build failed: %s", err)
+		debugErr := lifecycleExec.StartDebugShell(ctx, opts.DebugShellIn, opts.DebugShellOut)
+		if debugErr == nil {
+			// the volumes are kept so that the failed build can be inspected again
+			return err
+		}
+		l.logger.Warnf("Unable to start debug shell: %s", debugErr)
+	}
+
+	lifecycleExec.Cleanup()
+	return err
 }
@@ -0,0 +1,356 @@
+package build
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/auth"
+	"github.com/buildpacks/lifecycle/platform"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/builder"
+	"github.com/buildpacks/pack/internal/cache"
+	"github.com/buildpacks/pack/internal/style"
+	"github.com/buildpacks/pack/logging"
+	"github.com/buildpacks/pack/pkg/events"
+)
+
+// DefaultCNBDir is the directory in which a build image provides the lifecycle, buildpacks, order and stack.
+const DefaultCNBDir = "/cnb"
+
+// LocalLifecycleExecutor runs the creator of the lifecycle installed on the host as a local process, without a docker daemon.
+// It is meant to run within a build image, where cnbDir holds the lifecycle, buildpacks, order and stack of the builder.
+// The image is always published to a registry.
+type LocalLifecycleExecutor struct {
+	logger logging.Logger
+	cnbDir string
+}
+
+func NewLocalLifecycleExecutor(logger logging.Logger, cnbDir string) *LocalLifecycleExecutor {
+	return &LocalLifecycleExecutor{logger: logger, cnbDir: cnbDir}
+}
+
+func (l *LocalLifecycleExecutor) Execute(ctx context.Context, opts LifecycleOptions) error {
+	if runtime.GOOS == "windows" {
+		return errors.New("the local executor is not supported on Windows")
+	}
+	if !opts.Publish {
+		return errors.New("the local executor requires publishing the image")
+	}
+
+	platformAPI, err := l.platformAPI()
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := ioutil.TempDir("", "pack.local.build.")
+	if err != nil {
+		return errors.Wrap(err, "creating build directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dirs := map[string]string{}
+	for _, dir := range []string{"app", "layers", "platform"} {
+		dirs[dir] = filepath.Join(tmpDir, dir)
+		if err := os.MkdirAll(dirs[dir], 0755); err != nil {
+			return errors.Wrapf(err, "creating %s directory", dir)
+		}
+	}
+
+	if err := copyApp(opts.AppPath, dirs["app"], opts.FileFilter); err != nil {
+		return err
+	}
+	if err := writePlatformEnv(filepath.Join(dirs["platform"], "env"), opts.Env); err != nil {
+		return err
+	}
+	if err := writeProjectMetadataFile(filepath.Join(dirs["layers"], "project-metadata.toml"), opts.ProjectMetadata); err != nil {
+		return err
+	}
+
+	flags := []string{
+		"-app", dirs["app"],
+		"-layers", dirs["layers"],
+		"-platform", dirs["platform"],
+		"-buildpacks", filepath.Join(l.cnbDir, "buildpacks"),
+		"-order", filepath.Join(l.cnbDir, "order.toml"),
+		"-stack", filepath.Join(l.cnbDir, "stack.toml"),
+	}
+	flags, images, err := localCacheFlags(flags, opts.CacheImage, opts.BuildCache, filepath.Join(tmpDir, "cache"))
+	if err != nil {
+		return err
+	}
+	flags = addTags(flags, opts.AdditionalTags)
+
+	if opts.RunImage != "" {
+		flags = append(flags, "-run-image", opts.RunImage)
+		images = append(images, opts.RunImage)
+	}
+	if opts.ClearCache {
+		flags = append(flags, "-skip-restore")
+	}
+	if opts.GID >= overrideGID {
+		flags = append(flags, "-gid", strconv.Itoa(opts.GID))
+	}
+	if opts.PreviousImage != "" {
+		flags = append(flags, "-previous-image", opts.PreviousImage)
+		images = append(images, opts.PreviousImage)
+	}
+	if processType := determineDefaultProcessType(platformAPI, opts.DefaultProcessType); processType != "" {
+		flags = append(flags, "-process-type", processType)
+	}
+	if l.logger.IsVerbose() {
+		flags = append(flags, "-log-level", "debug")
+	}
+
+	authConfig, err := auth.BuildEnvVar(authn.DefaultKeychain, append(images, opts.Image.Name())...)
+	if err != nil {
+		return err
+	}
+
+	env := append(creatorEnv(os.Environ()),
+		"CNB_PLATFORM_API="+platformAPI.String(),
+		"CNB_REGISTRY_AUTH="+authConfig,
+	)
+	if opts.CreationTime != nil {
+		if supportsCreationTime(platformAPI) {
+			env = append(env, fmt.Sprintf("%s=%d", sourceDateEpochEnv, opts.CreationTime.Unix()))
+		} else {
+			l.logger.Warn(creationTimeUnsupportedWarning(platformAPI))
+		}
+	}
+	for k, v := range map[string]string{"HTTP_PROXY": opts.HTTPProxy, "HTTPS_PROXY": opts.HTTPSProxy, "NO_PROXY": opts.NoProxy} {
+		if v != "" {
+			env = append(env, k+"="+v, strings.ToLower(k)+"="+v)
+		}
+	}
+
+	runCtx := ctx
+	if opts.PhaseTimeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.PhaseTimeout)
+		defer cancel()
+	}
+
+	l.logger.Info(style.Step("ANALYZING, DETECTING, RESTORING, BUILDING AND EXPORTING"))
+	cmd := exec.CommandContext(runCtx, filepath.Join(l.cnbDir, "lifecycle", "creator"), append(flags, opts.Image.Name())...)
+	cmd.Env = env
+	cmd.Stdout = logging.GetWriterForLevel(l.logger, logging.InfoLevel)
+	cmd.Stderr = logging.GetWriterForLevel(l.logger, logging.ErrorLevel)
+
+	start := time.Now()
+	events.Emit(opts.EventSink, events.Event{Type: events.PhaseStarted, Time: start, Phase: "creator"})
+	err = cmd.Run()
+	if err != nil {
+		if ctx.Err() == nil && runCtx.Err() == context.DeadlineExceeded {
+			err = errors.Errorf("phase %s exceeded the timeout of %s", style.Symbol("creator"), opts.PhaseTimeout)
+		} else {
+			err = errors.Wrapf(err, "running %s", style.Symbol("creator"))
+		}
+	}
+	finished := events.Event{Type: events.PhaseFinished, Time: time.Now(), Phase: "creator", Duration: time.Since(start)}
+	if err != nil {
+		finished.Error = err.Error()
+	}
+	events.Emit(opts.EventSink, finished)
+	if err != nil {
+		return err
+	}
+
+	if opts.EventSink != nil {
+		report, err := exportReport(dirs["layers"], platformAPI)
+		if err != nil {
+			return err
+		}
+		opts.EventSink.Emit(report)
+	}
+	return nil
+}
+
+// inheritedEnv are the variables of the env of pack the creator receives, which let it find executables and certificates.
+// The CNB_ variables the build image sets, such as CNB_USER_ID and CNB_GROUP_ID, are received as well.
+var inheritedEnv = map[string]bool{
+	"PATH":          true,
+	"HOME":          true,
+	"USER":          true,
+	"TMPDIR":        true,
+	"LANG":          true,
+	"SSL_CERT_FILE": true,
+	"SSL_CERT_DIR":  true,
+}
+
+// creatorEnv returns the variables of environ the creator receives, so that the credentials and other secrets in the env
+// of pack are not exposed to buildpacks.
+func creatorEnv(environ []string) []string {
+	var env []string
+	for _, kv := range environ {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if inheritedEnv[key] || (strings.HasPrefix(key, "CNB_") && key != "CNB_REGISTRY_AUTH" && key != "CNB_PLATFORM_API") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// exportReport reads the processes and report.toml the creator wrote to layersDir into an ExportReported event.
+func exportReport(layersDir string, platformAPI *api.Version) (events.Event, error) {
+	report := events.Event{Type: events.ExportReported, Time: time.Now(), PlatformAPI: platformAPI.String()}
+
+	contents, err := ioutil.ReadFile(filepath.Join(layersDir, "config", "metadata.toml"))
+	if err != nil {
+		return report, errors.Wrap(err, "reading build metadata")
+	}
+	if report.Processes, err = parseProcesses(contents); err != nil {
+		return report, err
+	}
+
+	if platformAPI.Compare(minPlatformAPIWithReportInLayers) < 0 {
+		return report, nil
+	}
+	contents, err = ioutil.ReadFile(filepath.Join(layersDir, "report.toml"))
+	if err != nil {
+		return report, errors.Wrap(err, "reading export report")
+	}
+	report.Tags, err = parseReportTags(contents)
+	return report, err
+}
+
+// platformAPI returns the latest Platform API supported by both pack and the lifecycle in cnbDir.
+func (l *LocalLifecycleExecutor) platformAPI() (*api.Version, error) {
+	descriptorPath := filepath.Join(l.cnbDir, "lifecycle", "lifecycle.toml")
+	contents, err := ioutil.ReadFile(descriptorPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading lifecycle descriptor %s", style.Symbol(descriptorPath))
+	}
+
+	descriptor, err := builder.ParseDescriptor(string(contents))
+	if err != nil {
+		return nil, err
+	}
+	descriptor = builder.CompatDescriptor(descriptor)
+
+	return findLatestSupported(append(descriptor.APIs.Platform.Deprecated, descriptor.APIs.Platform.Supported...))
+}
+
+// localCacheFlags adds the flags selecting the build cache to flags, and returns the images the lifecycle needs access to.
+// Volume caches require a daemon, so without a cache image or bind cache the layers are cached in tmpCacheDir, which is
+// removed after the build.
+func localCacheFlags(flags []string, cacheImage string, buildCache *CacheOptions, tmpCacheDir string) ([]string, []string, error) {
+	if cacheImage != "" {
+		return append(flags, "-cache-image", cacheImage), []string{cacheImage}, nil
+	}
+	if buildCache == nil {
+		return append(flags, "-cache-dir", tmpCacheDir), nil, nil
+	}
+
+	switch buildCache.Type {
+	case cache.Image:
+		return append(flags, "-cache-image", buildCache.Name), []string{buildCache.Name}, nil
+	case cache.Bind:
+		return append(flags, "-cache-dir", buildCache.Source), nil, nil
+	default:
+		return nil, nil, errors.New("the local executor does not support volume caches")
+	}
+}
+
+func copyApp(src, dst string, fileFilter func(string) bool) error {
+	reader, err := createReader(src, ".", os.Getuid(), os.Getgid(), false, fileFilter)
+	if err != nil {
+		return errors.Wrapf(err, "create tar archive from '%s'", src)
+	}
+	defer reader.Close()
+
+	if err := extractTar(reader, dst); err != nil {
+		return errors.Wrapf(err, "copying app from '%s'", src)
+	}
+	return nil
+}
+
+func extractTar(r io.Reader, dst string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(header.Name))
+		if target != dst && !strings.HasPrefix(target, dst+string(filepath.Separator)) {
+			return errors.Errorf("invalid path %s", style.Symbol(header.Name))
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)|0700); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := writeFile(target, tr, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// writePlatformEnv writes each variable in env to a file in dir, which is how the lifecycle provides user env to buildpacks.
+func writePlatformEnv(dir string, env map[string]string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "creating platform env directory")
+	}
+
+	for k, v := range env {
+		if err := ioutil.WriteFile(filepath.Join(dir, k), []byte(v), 0644); err != nil {
+			return errors.Wrapf(err, "writing env %s", style.Symbol(k))
+		}
+	}
+	return nil
+}
+
+func writeProjectMetadataFile(path string, metadata platform.ProjectMetadata) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "writing project metadata")
+	}
+	defer f.Close()
+
+	if err := toml.NewEncoder(f).Encode(metadata); err != nil {
+		return errors.Wrap(err, "marshaling project metadata")
+	}
+	return nil
+}
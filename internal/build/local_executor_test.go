@@ -0,0 +1,315 @@
+package build_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/lifecycle/platform"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/build"
+	"github.com/buildpacks/pack/internal/cache"
+	ilogging "github.com/buildpacks/pack/internal/logging"
+	"github.com/buildpacks/pack/pkg/events"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestLocalLifecycleExecutor(t *testing.T) {
+	h.SkipIf(t, runtime.GOOS == "windows", "The local executor is not supported on Windows")
+
+	color.Disable(true)
+	defer color.Disable(false)
+
+	spec.Run(t, "LocalLifecycleExecutor", testLocalLifecycleExecutor, spec.Report(report.Terminal{}), spec.Sequential())
+}
+
+// fakeCreator records the args, env and directories it receives in the out dir next to the cnb dir, as the build directory
+// is removed afterwards. It is configured by the fake-* files next to it, as the creator does not receive the env of pack.
+const fakeCreator = `#!/bin/sh
+dir="$(dirname "$0")"
+out="$dir/../../out"
+echo "running creator"
+printf '%s\n' "$@" > "$out/args"
+env > "$out/env"
+while [ $# -gt 0 ]; do
+  case "$1" in
+    -app) cp -R "$2" "$out/app" ;;
+    -platform) cp -R "$2" "$out/platform" ;;
+    -layers) cp -R "$2" "$out/layers"; layers="$2" ;;
+  esac
+  shift
+done
+if [ -f "$dir/fake-metadata" ]; then
+  mkdir -p "$layers/config"
+  cp "$dir/fake-metadata" "$layers/config/metadata.toml"
+fi
+if [ -f "$dir/fake-report" ]; then cp "$dir/fake-report" "$layers/report.toml"; fi
+if [ -f "$dir/fake-sleep" ]; then exec sleep "$(cat "$dir/fake-sleep")"; fi
+if [ -f "$dir/fake-exit" ]; then exit "$(cat "$dir/fake-exit")"; fi
+`
+
+func testLocalLifecycleExecutor(t *testing.T, when spec.G, it spec.S) {
+	var (
+		subject        *build.LocalLifecycleExecutor
+		outBuf         bytes.Buffer
+		tmpDir         string
+		cnbDir         string
+		outDir         string
+		appDir         string
+		opts           build.LifecycleOptions
+		dockerConfig   string
+		restoreEnvVars []string
+	)
+
+	setEnv := func(key, value string) {
+		h.AssertNil(t, os.Setenv(key, value))
+		restoreEnvVars = append(restoreEnvVars, key)
+	}
+
+	configureCreator := func(setting, value string) {
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(cnbDir, "lifecycle", "fake-"+setting), []byte(value), 0644))
+	}
+
+	readOutput := func(file string) string {
+		t.Helper()
+		contents, err := ioutil.ReadFile(filepath.Join(outDir, file))
+		h.AssertNil(t, err)
+		return string(contents)
+	}
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "local-executor-test")
+		h.AssertNil(t, err)
+
+		cnbDir = filepath.Join(tmpDir, "cnb")
+		h.AssertNil(t, os.MkdirAll(filepath.Join(cnbDir, "lifecycle"), 0755))
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(cnbDir, "lifecycle", "creator"), []byte(fakeCreator), 0755))
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(cnbDir, "lifecycle", "lifecycle.toml"), []byte(`
+[apis.platform]
+deprecated = ["0.2"]
+supported = ["0.3", "0.4"]
+`), 0644))
+
+		outDir = filepath.Join(tmpDir, "out")
+		h.AssertNil(t, os.MkdirAll(outDir, 0755))
+
+		// avoid using the docker configuration of the host for registry auth
+		dockerConfig = filepath.Join(tmpDir, "docker-config")
+		h.AssertNil(t, os.MkdirAll(dockerConfig, 0755))
+		setEnv("DOCKER_CONFIG", dockerConfig)
+
+		appDir = filepath.Join(tmpDir, "app")
+		h.AssertNil(t, os.MkdirAll(filepath.Join(appDir, "src"), 0755))
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(appDir, "src", "main.go"), []byte("package main"), 0644))
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(appDir, "secret.txt"), []byte("secret"), 0644))
+
+		imageRef, err := name.ParseReference("registry.example.com/some/app:latest")
+		h.AssertNil(t, err)
+
+		opts = build.LifecycleOptions{
+			AppPath: appDir,
+			Image:   imageRef,
+			Publish: true,
+			GID:     -1,
+		}
+		subject = build.NewLocalLifecycleExecutor(ilogging.NewLogWithWriters(&outBuf, &outBuf), cnbDir)
+	})
+
+	it.After(func() {
+		for _, key := range restoreEnvVars {
+			h.AssertNil(t, os.Unsetenv(key))
+		}
+		restoreEnvVars = nil
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	when("#Execute", func() {
+		it("runs the creator of the lifecycle in the cnb dir", func() {
+			h.AssertNil(t, subject.Execute(context.TODO(), opts))
+
+			h.AssertContains(t, outBuf.String(), "running creator")
+			args := strings.Split(strings.TrimSpace(readOutput("args")), "\n")
+			h.AssertEq(t, args[len(args)-1], "registry.example.com/some/app:latest")
+			h.AssertSliceContainsInOrder(t, args, "-buildpacks", filepath.Join(cnbDir, "buildpacks"))
+			h.AssertSliceContainsInOrder(t, args, "-order", filepath.Join(cnbDir, "order.toml"))
+			h.AssertSliceContainsInOrder(t, args, "-stack", filepath.Join(cnbDir, "stack.toml"))
+			h.AssertSliceContains(t, args, "-cache-dir")
+			h.AssertSliceNotContains(t, args, "-run-image")
+			h.AssertSliceNotContains(t, args, "-gid")
+
+			env := readOutput("env")
+			h.AssertContains(t, env, "CNB_PLATFORM_API=0.4\n")
+			h.AssertContains(t, env, "CNB_REGISTRY_AUTH={}\n")
+		})
+
+		it("only passes the CNB and system variables of the env", func() {
+			setEnv("CNB_USER_ID", "1000")
+			setEnv("SOME_SECRET", "some-value")
+
+			h.AssertNil(t, subject.Execute(context.TODO(), opts))
+
+			env := readOutput("env")
+			h.AssertContains(t, env, "CNB_USER_ID=1000\n")
+			h.AssertContains(t, env, "PATH="+os.Getenv("PATH")+"\n")
+			h.AssertNotContains(t, env, "SOME_SECRET")
+			h.AssertNotContains(t, env, "DOCKER_CONFIG")
+		})
+
+		it("copies the app, excluding filtered files", func() {
+			opts.FileFilter = func(path string) bool { return path != "secret.txt" }
+			h.AssertNil(t, subject.Execute(context.TODO(), opts))
+
+			h.AssertEq(t, readOutput(filepath.Join("app", "src", "main.go")), "package main")
+			_, err := os.Stat(filepath.Join(outDir, "app", "secret.txt"))
+			h.AssertTrue(t, os.IsNotExist(err))
+		})
+
+		it("provides the env and project metadata", func() {
+			opts.Env = map[string]string{"SOME_KEY": "some-value"}
+			opts.ProjectMetadata = platform.ProjectMetadata{Source: &platform.ProjectSource{Type: "project"}}
+			h.AssertNil(t, subject.Execute(context.TODO(), opts))
+
+			h.AssertEq(t, readOutput(filepath.Join("platform", "env", "SOME_KEY")), "some-value")
+			h.AssertContains(t, readOutput(filepath.Join("layers", "project-metadata.toml")), `type = "project"`)
+		})
+
+		it("passes the build options as flags", func() {
+			opts.RunImage = "registry.example.com/some/run"
+			opts.AdditionalTags = []string{"registry.example.com/some/app:other"}
+			opts.PreviousImage = "registry.example.com/some/app:previous"
+			opts.ClearCache = true
+			opts.GID = 2
+			opts.DefaultProcessType = "worker"
+			h.AssertNil(t, subject.Execute(context.TODO(), opts))
+
+			args := strings.Split(strings.TrimSpace(readOutput("args")), "\n")
+			h.AssertSliceContainsInOrder(t, args, "-run-image", "registry.example.com/some/run")
+			h.AssertSliceContainsInOrder(t, args, "-tag", "registry.example.com/some/app:other")
+			h.AssertSliceContainsInOrder(t, args, "-previous-image", "registry.example.com/some/app:previous")
+			h.AssertSliceContains(t, args, "-skip-restore")
+			h.AssertSliceContainsInOrder(t, args, "-gid", "2")
+			h.AssertSliceContainsInOrder(t, args, "-process-type", "worker")
+		})
+
+		it("ignores the creation time when the Platform API does not support it", func() {
+			creationTime := time.Unix(1600000000, 0)
+			opts.CreationTime = &creationTime
+			h.AssertNil(t, subject.Execute(context.TODO(), opts))
+
+			h.AssertContains(t, outBuf.String(), "Ignoring the creation time, it requires Platform API 0.9 or later but the build uses Platform API 0.4")
+			h.AssertNotContains(t, readOutput("env"), "SOURCE_DATE_EPOCH=1600000000")
+		})
+
+		when("an event sink is provided", func() {
+			var received []events.Event
+
+			it.Before(func() {
+				received = nil
+				opts.EventSink = events.SinkFunc(func(e events.Event) { received = append(received, e) })
+				configureCreator("metadata", `[[processes]]
+type = "web"
+command = "some-command"
+args = ["some-arg"]
+direct = true
+`)
+			})
+
+			it("emits the phase and export report", func() {
+				h.AssertNil(t, subject.Execute(context.TODO(), opts))
+
+				h.AssertEq(t, len(received), 3)
+				h.AssertEq(t, received[0].Type, events.PhaseStarted)
+				h.AssertEq(t, received[1].Type, events.PhaseFinished)
+				h.AssertEq(t, received[1].Phase, "creator")
+				h.AssertEq(t, received[2].Type, events.ExportReported)
+				h.AssertEq(t, received[2].PlatformAPI, "0.4")
+				h.AssertEq(t, received[2].Processes, []events.Process{{Type: "web", Command: "some-command", Args: []string{"some-arg"}, Direct: true}})
+				h.AssertEq(t, len(received[2].Tags), 0)
+			})
+
+			it("reads the tags from report.toml on Platform API 0.5", func() {
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(cnbDir, "lifecycle", "lifecycle.toml"), []byte(`
+[apis.platform]
+supported = ["0.5"]
+`), 0644))
+				configureCreator("report", `[image]
+tags = ["registry.example.com/some/app:latest", "registry.example.com/some/app:other"]
+`)
+				h.AssertNil(t, subject.Execute(context.TODO(), opts))
+
+				report := received[len(received)-1]
+				h.AssertEq(t, report.PlatformAPI, "0.5")
+				h.AssertEq(t, report.Tags, []string{"registry.example.com/some/app:latest", "registry.example.com/some/app:other"})
+			})
+
+			it("reports a failed creator", func() {
+				configureCreator("exit", "3")
+				h.AssertNotNil(t, subject.Execute(context.TODO(), opts))
+
+				h.AssertEq(t, len(received), 2)
+				h.AssertEq(t, received[1].Error, "running 'creator': exit status 3")
+			})
+		})
+
+		when("a cache image is provided", func() {
+			it("uses the cache image", func() {
+				opts.CacheImage = "registry.example.com/some/cache"
+				h.AssertNil(t, subject.Execute(context.TODO(), opts))
+
+				args := strings.Split(strings.TrimSpace(readOutput("args")), "\n")
+				h.AssertSliceContainsInOrder(t, args, "-cache-image", "registry.example.com/some/cache")
+				h.AssertSliceNotContains(t, args, "-cache-dir")
+			})
+		})
+
+		when("a bind cache is provided", func() {
+			it("uses the directory as the cache", func() {
+				cacheDir := filepath.Join(tmpDir, "cache")
+				opts.BuildCache = &build.CacheOptions{Type: cache.Bind, Source: cacheDir}
+				h.AssertNil(t, subject.Execute(context.TODO(), opts))
+
+				args := strings.Split(strings.TrimSpace(readOutput("args")), "\n")
+				h.AssertSliceContainsInOrder(t, args, "-cache-dir", cacheDir)
+			})
+		})
+
+		when("a volume cache is provided", func() {
+			it("errors", func() {
+				opts.BuildCache = &build.CacheOptions{Type: cache.Volume, Name: "some-volume"}
+				h.AssertError(t, subject.Execute(context.TODO(), opts), "the local executor does not support volume caches")
+			})
+		})
+
+		it("errors when not publishing", func() {
+			opts.Publish = false
+			h.AssertError(t, subject.Execute(context.TODO(), opts), "the local executor requires publishing the image")
+		})
+
+		it("errors when the lifecycle has no descriptor", func() {
+			h.AssertNil(t, os.Remove(filepath.Join(cnbDir, "lifecycle", "lifecycle.toml")))
+			h.AssertError(t, subject.Execute(context.TODO(), opts), "reading lifecycle descriptor")
+		})
+
+		it("errors when the creator fails", func() {
+			configureCreator("exit", "3")
+			h.AssertError(t, subject.Execute(context.TODO(), opts), "running 'creator': exit status 3")
+		})
+
+		it("errors when the creator exceeds the phase timeout", func() {
+			configureCreator("sleep", "10")
+			opts.PhaseTimeout = 100 * time.Millisecond
+			h.AssertError(t, subject.Execute(context.TODO(), opts), "phase 'creator' exceeded the timeout of 100ms")
+		})
+	})
+}
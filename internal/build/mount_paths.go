@@ -38,6 +38,22 @@ func (m mountPaths) stackPath() string {
 	return m.join(m.layersDir(), "stack.toml")
 }
 
+func (m mountPaths) groupPath() string {
+	return m.join(m.layersDir(), "group.toml")
+}
+
+func (m mountPaths) planPath() string {
+	return m.join(m.layersDir(), "plan.toml")
+}
+
+func (m mountPaths) reportPath() string {
+	return m.join(m.layersDir(), "report.toml")
+}
+
+func (m mountPaths) metadataPath() string {
+	return m.join(m.layersDir(), "config", "metadata.toml")
+}
+
 func (m mountPaths) projectPath() string {
 	return m.join(m.layersDir(), "project-metadata.toml")
 }
@@ -57,3 +73,7 @@ func (m mountPaths) cacheDir() string {
 func (m mountPaths) launchCacheDir() string {
 	return m.join(m.volume, "launch-cache")
 }
+
+func (m mountPaths) platformEnvDir() string {
+	return m.join(m.volume, "platform", "env")
+}
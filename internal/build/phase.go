@@ -1,15 +1,20 @@
 package build
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"regexp"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	dcontainer "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/docker/go-units"
 	"github.com/pkg/errors"
 
 	"github.com/buildpacks/pack/internal/container"
+	"github.com/buildpacks/pack/internal/style"
 )
 
 type Phase struct {
@@ -23,7 +28,9 @@ type Phase struct {
 	uid, gid     int
 	appPath      string
 	containerOps []ContainerOperation
+	postRunOps   []ContainerOperation
 	fileFilter   func(string) bool
+	timeout      time.Duration
 }
 
 func (p *Phase) Run(ctx context.Context) error {
@@ -39,13 +46,99 @@ func (p *Phase) Run(ctx context.Context) error {
 		}
 	}
 
-	return container.Run(
-		ctx,
+	runCtx := ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	infoWriter, errorWriter := p.infoWriter, p.errorWriter
+	var pidsScanners []*matchWriter
+	if p.hostConf.PidsLimit != nil {
+		infoScanner, errorScanner := newMatchWriter(infoWriter, pidsExhaustedExp), newMatchWriter(errorWriter, pidsExhaustedExp)
+		infoWriter, errorWriter = infoScanner, errorScanner
+		pidsScanners = append(pidsScanners, infoScanner, errorScanner)
+	}
+
+	if err := container.Run(
+		runCtx,
 		p.docker,
 		p.ctr.ID,
-		p.infoWriter,
-		p.errorWriter,
-	)
+		infoWriter,
+		errorWriter,
+	); err != nil {
+		pidsExhausted := false
+		for _, scanner := range pidsScanners {
+			pidsExhausted = pidsExhausted || scanner.matched
+		}
+		return p.limitError(ctx, runCtx, err, pidsExhausted)
+	}
+
+	for _, postRunOp := range p.postRunOps {
+		if err := postRunOp(p.docker, ctx, p.ctr.ID, p.infoWriter, p.errorWriter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// limitError returns an error naming the limit exceeded by the phase when it failed due to its timeout, memory limit or
+// pids limit, or err otherwise. A container that is still running once the timeout expires is removed by Cleanup.
+// The pids limit is only named when the output of the phase shows a process failing to start.
+func (p *Phase) limitError(ctx, runCtx context.Context, err error, pidsExhausted bool) error {
+	if ctx.Err() == nil && runCtx.Err() == context.DeadlineExceeded {
+		return errors.Errorf("phase %s exceeded the timeout of %s", style.Symbol(p.name), p.timeout)
+	}
+
+	if p.hostConf.Memory > 0 {
+		inspect, inspectErr := p.docker.ContainerInspect(ctx, p.ctr.ID)
+		if inspectErr == nil && inspect.ContainerJSONBase != nil && inspect.State != nil && inspect.State.OOMKilled {
+			return errors.Errorf("phase %s exceeded the memory limit of %s", style.Symbol(p.name), units.BytesSize(float64(p.hostConf.Memory)))
+		}
+	}
+
+	if p.hostConf.PidsLimit != nil && pidsExhausted {
+		return errors.Errorf("phase %s reached the limit of %d processes", style.Symbol(p.name), *p.hostConf.PidsLimit)
+	}
+
+	return err
+}
+
+// pidsExhaustedExp matches the errors of processes and threads failing to start once the pids limit is reached.
+var pidsExhaustedExp = regexp.MustCompile(`(?i)resource temporarily unavailable|errno=11\b`)
+
+// matchWriter passes output through unchanged while recording whether any line of it matches exp.
+type matchWriter struct {
+	out     io.Writer
+	exp     *regexp.Regexp
+	partial []byte
+	matched bool
+}
+
+func newMatchWriter(out io.Writer, exp *regexp.Regexp) *matchWriter {
+	return &matchWriter{out: out, exp: exp}
+}
+
+func (w *matchWriter) Write(data []byte) (int, error) {
+	w.partial = append(w.partial, data...)
+	if i := bytes.LastIndexByte(w.partial, '\n'); i >= 0 {
+		w.matched = w.matched || w.exp.Match(w.partial[:i])
+		w.partial = append([]byte{}, w.partial[i+1:]...)
+	}
+	return w.out.Write(data)
+}
+
+// Close checks any remaining output and closes the underlying writer.
+func (w *matchWriter) Close() error {
+	w.matched = w.matched || w.exp.Match(w.partial)
+	w.partial = nil
+
+	if closer, ok := w.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
 }
 
 func (p *Phase) Cleanup() error {
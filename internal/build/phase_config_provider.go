@@ -7,7 +7,9 @@ import (
 	"strings"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 
+	"github.com/buildpacks/pack/internal/ephemeral"
 	"github.com/buildpacks/pack/internal/style"
 	"github.com/buildpacks/pack/logging"
 )
@@ -16,6 +18,9 @@ const (
 	linuxContainerAdmin   = "root"
 	windowsContainerAdmin = "ContainerAdministrator"
 	platformAPIEnvVar     = "CNB_PLATFORM_API"
+
+	// SSHAuthSock is the path in the detect and build containers at which the forwarded SSH agent socket is mounted.
+	SSHAuthSock = "/run/ssh/agent.sock"
 )
 
 type PhaseConfigProviderOperation func(*PhaseConfigProvider)
@@ -26,6 +31,7 @@ type PhaseConfigProvider struct {
 	name         string
 	os           string
 	containerOps []ContainerOperation
+	postRunOps   []ContainerOperation
 	infoWriter   io.Writer
 	errorWriter  io.Writer
 }
@@ -42,6 +48,9 @@ func NewPhaseConfigProvider(name string, lifecycleExec *LifecycleExecution, ops
 
 	provider.ctrConf.Image = lifecycleExec.opts.Builder.Name()
 	provider.ctrConf.Labels = map[string]string{"author": "pack"}
+	for k, v := range ephemeral.Labels() {
+		provider.ctrConf.Labels[k] = v
+	}
 
 	if lifecycleExec.os == "windows" {
 		provider.hostConf.Isolation = container.IsolationProcess
@@ -50,6 +59,7 @@ func NewPhaseConfigProvider(name string, lifecycleExec *LifecycleExecution, ops
 	ops = append(ops,
 		WithEnv(fmt.Sprintf("%s=%s", platformAPIEnvVar, lifecycleExec.platformAPI.String())),
 		WithLifecycleProxy(lifecycleExec),
+		WithResourceLimits(lifecycleExec.opts.CPUs, lifecycleExec.opts.Memory, lifecycleExec.opts.PidsLimit),
 		WithBinds([]string{
 			fmt.Sprintf("%s:%s", lifecycleExec.layersVolume, lifecycleExec.mountPaths.layersDir()),
 			fmt.Sprintf("%s:%s", lifecycleExec.appVolume, lifecycleExec.mountPaths.appDir()),
@@ -62,6 +72,19 @@ func NewPhaseConfigProvider(name string, lifecycleExec *LifecycleExecution, ops
 
 	provider.ctrConf.Cmd = append([]string{"/cnb/lifecycle/" + name}, provider.ctrConf.Cmd...)
 
+	if lifecycleExec.opts.EventSink != nil {
+		provider.infoWriter = newEventWriter(provider.infoWriter, lifecycleExec)
+	}
+
+	// redact before events are emitted so that secrets do not reach the event sink either
+	if len(lifecycleExec.redactions) > 0 {
+		provider.infoWriter = newRedactingWriter(provider.infoWriter, lifecycleExec.redactions)
+		provider.errorWriter = newRedactingWriter(provider.errorWriter, lifecycleExec.redactions)
+	}
+
+	// phases run one after another and stop at the first failure, so the last phase configured is the one to debug
+	lifecycleExec.lastPhase = provider
+
 	lifecycleExec.logger.Debugf("Running the %s on OS %s with:", style.Symbol(provider.Name()), style.Symbol(provider.os))
 	lifecycleExec.logger.Debug("Container Settings:")
 	lifecycleExec.logger.Debugf("  Args: %s", style.Symbol(strings.Join(provider.ctrConf.Cmd, " ")))
@@ -84,6 +107,10 @@ func (p *PhaseConfigProvider) ContainerOps() []ContainerOperation {
 	return p.containerOps
 }
 
+func (p *PhaseConfigProvider) PostContainerRunOps() []ContainerOperation {
+	return p.postRunOps
+}
+
 func (p *PhaseConfigProvider) HostConfig() *container.HostConfig {
 	return p.hostConf
 }
@@ -158,6 +185,23 @@ func WithEnv(envs ...string) PhaseConfigProviderOperation {
 	}
 }
 
+// WithSSHAgent mounts the SSH agent socket at hostSocket into the container and points SSH_AUTH_SOCK at it.
+// Only the socket is shared, keys held by the agent are not copied into the container.
+func WithSSHAgent(hostSocket string) PhaseConfigProviderOperation {
+	return func(provider *PhaseConfigProvider) {
+		if hostSocket == "" {
+			return
+		}
+
+		provider.hostConf.Mounts = append(provider.hostConf.Mounts, mount.Mount{
+			Type:   mount.TypeBind,
+			Source: hostSocket,
+			Target: SSHAuthSock,
+		})
+		provider.ctrConf.Env = append(provider.ctrConf.Env, "SSH_AUTH_SOCK="+SSHAuthSock)
+	}
+}
+
 func WithImage(image string) PhaseConfigProviderOperation {
 	return func(provider *PhaseConfigProvider) {
 		provider.ctrConf.Image = image
@@ -190,6 +234,22 @@ func WithLifecycleProxy(lifecycleExec *LifecycleExecution) PhaseConfigProviderOp
 	}
 }
 
+// WithResourceLimits limits the CPUs, memory in bytes and number of processes available to the phase.
+// A zero value leaves the corresponding resource unlimited.
+func WithResourceLimits(cpus float64, memory, pidsLimit int64) PhaseConfigProviderOperation {
+	return func(provider *PhaseConfigProvider) {
+		if cpus > 0 {
+			provider.hostConf.NanoCPUs = int64(cpus * 1e9)
+		}
+		if memory > 0 {
+			provider.hostConf.Memory = memory
+		}
+		if pidsLimit > 0 {
+			provider.hostConf.PidsLimit = &pidsLimit
+		}
+	}
+}
+
 func WithNetwork(networkMode string) PhaseConfigProviderOperation {
 	return func(provider *PhaseConfigProvider) {
 		provider.hostConf.NetworkMode = container.NetworkMode(networkMode)
@@ -217,3 +277,10 @@ func WithContainerOperations(operations ...ContainerOperation) PhaseConfigProvid
 		provider.containerOps = append(provider.containerOps, operations...)
 	}
 }
+
+// WithPostContainerRunOperations adds operations that run against the container once it has exited successfully
+func WithPostContainerRunOperations(operations ...ContainerOperation) PhaseConfigProviderOperation {
+	return func(provider *PhaseConfigProvider) {
+		provider.postRunOps = append(provider.postRunOps, operations...)
+	}
+}
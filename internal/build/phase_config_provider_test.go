@@ -2,12 +2,18 @@ package build_test
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	ifakes "github.com/buildpacks/imgutil/fakes"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/client"
 	"github.com/heroku/color"
@@ -16,8 +22,10 @@ import (
 
 	"github.com/buildpacks/pack/internal/build"
 	"github.com/buildpacks/pack/internal/build/fakes"
+	"github.com/buildpacks/pack/internal/ephemeral"
 	ilogging "github.com/buildpacks/pack/internal/logging"
 	"github.com/buildpacks/pack/logging"
+	"github.com/buildpacks/pack/pkg/events"
 	h "github.com/buildpacks/pack/testhelpers"
 )
 
@@ -45,7 +53,9 @@ func testPhaseConfigProvider(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, phaseConfigProvider.Name(), expectedPhaseName)
 			h.AssertEq(t, phaseConfigProvider.ContainerConfig().Cmd, expectedCmd)
 			h.AssertEq(t, phaseConfigProvider.ContainerConfig().Image, expectedBuilderImage.Name())
-			h.AssertEq(t, phaseConfigProvider.ContainerConfig().Labels, map[string]string{"author": "pack"})
+			h.AssertEq(t, phaseConfigProvider.ContainerConfig().Labels["author"], "pack")
+			h.AssertEq(t, phaseConfigProvider.ContainerConfig().Labels[ephemeral.Label], "true")
+			h.AssertEq(t, phaseConfigProvider.ContainerConfig().Labels[ephemeral.OwnerLabel], ephemeral.Labels()[ephemeral.OwnerLabel])
 
 			// NewFakeBuilder sets the Platform API
 			h.AssertSliceContains(t, phaseConfigProvider.ContainerConfig().Env, "CNB_PLATFORM_API=0.4")
@@ -208,6 +218,33 @@ func testPhaseConfigProvider(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("resource limits are provided", func() {
+			it("sets them on the host config", func() {
+				lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+					options.CPUs = 1.5
+					options.Memory = 512 * 1024 * 1024
+					options.PidsLimit = 100
+				})
+
+				phaseConfigProvider := build.NewPhaseConfigProvider("some-name", lifecycle)
+
+				h.AssertEq(t, phaseConfigProvider.HostConfig().NanoCPUs, int64(1500000000))
+				h.AssertEq(t, phaseConfigProvider.HostConfig().Memory, int64(512*1024*1024))
+				h.AssertNotNil(t, phaseConfigProvider.HostConfig().PidsLimit)
+				h.AssertEq(t, *phaseConfigProvider.HostConfig().PidsLimit, int64(100))
+			})
+
+			it("leaves resources unlimited by default", func() {
+				lifecycle := newTestLifecycleExec(t, false)
+
+				phaseConfigProvider := build.NewPhaseConfigProvider("some-name", lifecycle)
+
+				h.AssertEq(t, phaseConfigProvider.HostConfig().NanoCPUs, int64(0))
+				h.AssertEq(t, phaseConfigProvider.HostConfig().Memory, int64(0))
+				h.AssertNil(t, phaseConfigProvider.HostConfig().PidsLimit)
+			})
+		})
+
 		when("called with WithRegistryAccess", func() {
 			it("sets registry access on the config", func() {
 				lifecycle := newTestLifecycleExec(t, false)
@@ -279,6 +316,152 @@ func testPhaseConfigProvider(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("called with WithSecrets", func() {
+			it("mounts each secret read-only on a tmpfs", func() {
+				lifecycle := newTestLifecycleExec(t, false)
+
+				phaseConfigProvider := build.NewPhaseConfigProvider(
+					"some-name",
+					lifecycle,
+					build.WithSecrets(build.Secret{ID: "npmrc", Source: "/some/.npmrc"}),
+				)
+
+				h.AssertEq(t, phaseConfigProvider.HostConfig().Mounts, []mount.Mount{
+					{Type: mount.TypeTmpfs, Target: "/run/secrets"},
+					{Type: mount.TypeBind, Source: "/some/.npmrc", Target: "/run/secrets/npmrc", ReadOnly: true},
+				})
+			})
+
+			it("does nothing without secrets", func() {
+				lifecycle := newTestLifecycleExec(t, false)
+
+				phaseConfigProvider := build.NewPhaseConfigProvider("some-name", lifecycle, build.WithSecrets())
+
+				h.AssertEq(t, len(phaseConfigProvider.HostConfig().Mounts), 0)
+			})
+		})
+
+		when("called with WithSSHAgent", func() {
+			it("mounts the socket and sets SSH_AUTH_SOCK", func() {
+				lifecycle := newTestLifecycleExec(t, false)
+
+				phaseConfigProvider := build.NewPhaseConfigProvider(
+					"some-name",
+					lifecycle,
+					build.WithSSHAgent("/tmp/some-agent.sock"),
+				)
+
+				h.AssertEq(t, phaseConfigProvider.HostConfig().Mounts, []mount.Mount{
+					{Type: mount.TypeBind, Source: "/tmp/some-agent.sock", Target: "/run/ssh/agent.sock"},
+				})
+				h.AssertSliceContains(t, phaseConfigProvider.ContainerConfig().Env, "SSH_AUTH_SOCK=/run/ssh/agent.sock")
+			})
+
+			it("does nothing without a socket", func() {
+				lifecycle := newTestLifecycleExec(t, false)
+
+				phaseConfigProvider := build.NewPhaseConfigProvider("some-name", lifecycle, build.WithSSHAgent(""))
+
+				h.AssertEq(t, len(phaseConfigProvider.HostConfig().Mounts), 0)
+				h.AssertSliceNotContains(t, phaseConfigProvider.ContainerConfig().Env, "SSH_AUTH_SOCK=/run/ssh/agent.sock")
+			})
+		})
+
+		when("secrets are provided", func() {
+			it("redacts them from the phase output", func() {
+				tmpDir, err := ioutil.TempDir("", "phase-config-provider-secrets")
+				h.AssertNil(t, err)
+				defer os.RemoveAll(tmpDir)
+
+				tokenPath := filepath.Join(tmpDir, "token")
+				h.AssertNil(t, ioutil.WriteFile(tokenPath, []byte("some-token\n"), 0600))
+				npmrcPath := filepath.Join(tmpDir, "npmrc")
+				h.AssertNil(t, ioutil.WriteFile(npmrcPath, []byte("//registry.example.com/:_authToken=other-token\n"), 0600))
+
+				var outBuf bytes.Buffer
+				logger := ilogging.NewLogWithWriters(&outBuf, &outBuf)
+				lifecycle := newTestLifecycleExecWithLogger(t, logger, func(options *build.LifecycleOptions) {
+					options.Secrets = []build.Secret{{ID: "token", Source: tokenPath}, {ID: "npmrc", Source: npmrcPath}}
+				})
+
+				phaseConfigProvider := build.NewPhaseConfigProvider("some-name", lifecycle)
+
+				_, err = phaseConfigProvider.InfoWriter().Write([]byte("using token some-"))
+				h.AssertNil(t, err)
+				_, err = phaseConfigProvider.InfoWriter().Write([]byte("token\n"))
+				h.AssertNil(t, err)
+				_, err = phaseConfigProvider.ErrorWriter().Write([]byte("failed with //registry.example.com/:_authToken=other-token\n"))
+				h.AssertNil(t, err)
+
+				h.AssertContains(t, outBuf.String(), "using token [REDACTED]")
+				h.AssertContains(t, outBuf.String(), "failed with [REDACTED]")
+				h.AssertNotContains(t, outBuf.String(), "some-token")
+				h.AssertNotContains(t, outBuf.String(), "other-token")
+			})
+
+			it("does not redact the common values a secret contains", func() {
+				tmpDir, err := ioutil.TempDir("", "phase-config-provider-secrets")
+				h.AssertNil(t, err)
+				defer os.RemoveAll(tmpDir)
+
+				secretPath := filepath.Join(tmpDir, "config")
+				h.AssertNil(t, ioutil.WriteFile(secretPath, []byte("strict-ssl=true\nregistry=https://registry.example.com/\ntoken=some-token\n"), 0600))
+				shortPath := filepath.Join(tmpDir, "flag")
+				h.AssertNil(t, ioutil.WriteFile(shortPath, []byte("true\n"), 0600))
+
+				var outBuf bytes.Buffer
+				logger := ilogging.NewLogWithWriters(&outBuf, &outBuf)
+				lifecycle := newTestLifecycleExecWithLogger(t, logger, func(options *build.LifecycleOptions) {
+					options.Secrets = []build.Secret{{ID: "config", Source: secretPath}, {ID: "flag", Source: shortPath}}
+				})
+
+				phaseConfigProvider := build.NewPhaseConfigProvider("some-name", lifecycle)
+
+				_, err = phaseConfigProvider.InfoWriter().Write([]byte("fetching https://registry.example.com/some-package with cache=true\n"))
+				h.AssertNil(t, err)
+
+				h.AssertContains(t, outBuf.String(), "fetching https://registry.example.com/some-package with cache=true")
+				h.AssertNotContains(t, outBuf.String(), "[REDACTED]")
+			})
+		})
+
+		when("an event sink is provided", func() {
+			it("emits events for the lifecycle output it recognizes", func() {
+				var received []events.Event
+				lifecycle := newTestLifecycleExec(t, false, func(options *build.LifecycleOptions) {
+					options.EventSink = events.SinkFunc(func(e events.Event) { received = append(received, e) })
+				})
+
+				phaseConfigProvider := build.NewPhaseConfigProvider("creator", lifecycle)
+
+				writer := phaseConfigProvider.InfoWriter()
+				_, err := writer.Write([]byte("===> RESTORING\nRestoring data for \"some/bp:some-layer\" from cache\n===> EXP"))
+				h.AssertNil(t, err)
+				_, err = writer.Write([]byte("ORTING\nReusing layer 'some/bp:reused'\nAdding layer 'some/bp:added'\n"))
+				h.AssertNil(t, err)
+				_, err = writer.Write([]byte("Warning: some warning"))
+				h.AssertNil(t, err)
+				closer, ok := writer.(io.Closer)
+				h.AssertTrue(t, ok)
+				h.AssertNil(t, closer.Close())
+
+				var summary []string
+				for _, e := range received {
+					summary = append(summary, fmt.Sprintf("%s:%s%s%s", e.Type, e.Phase, e.Layer, e.Message))
+				}
+				h.AssertEq(t, summary, []string{
+					"phase_started:restorer",
+					"layer_restored:some/bp:some-layer",
+					"phase_finished:restorer",
+					"phase_started:exporter",
+					"layer_reused:some/bp:reused",
+					"layer_exported:some/bp:added",
+					"warning:some warning",
+					"phase_finished:exporter",
+				})
+			})
+		})
+
 		when("verbose", func() {
 			it("prints debug information about the phase", func() {
 				var outBuf bytes.Buffer
@@ -309,7 +492,7 @@ func testPhaseConfigProvider(t *testing.T, when spec.G, it spec.S) {
 				h.AssertContains(t, outBuf.String(), "System Envs: 'CNB_PLATFORM_API=0.4'")
 				h.AssertContains(t, outBuf.String(), "Image: 'some-builder-name'")
 				h.AssertContains(t, outBuf.String(), "User:")
-				h.AssertContains(t, outBuf.String(), "Labels: 'map[author:pack]'")
+				h.AssertContains(t, outBuf.String(), "Labels: 'map[author:pack io.buildpacks.pack.ephemeral:true")
 				h.AssertContainsMatch(t, outBuf.String(), `Binds: \'\S+:\S+layers \S+:\S+workspace'`)
 				h.AssertContains(t, outBuf.String(), "Network Mode: ''")
 			})
@@ -33,6 +33,8 @@ func (m *DefaultPhaseFactory) New(provider *PhaseConfigProvider) RunnerCleaner {
 		gid:          m.lifecycleExec.opts.Builder.GID(),
 		appPath:      m.lifecycleExec.opts.AppPath,
 		containerOps: provider.containerOps,
+		postRunOps:   provider.postRunOps,
 		fileFilter:   m.lifecycleExec.opts.FileFilter,
+		timeout:      m.lifecycleExec.opts.PhaseTimeout,
 	}
 }
@@ -397,6 +397,73 @@ func testPhase(t *testing.T, when spec.G, it spec.S) {
 				})
 			})
 		})
+
+		when("a phase exceeds its timeout", func() {
+			it("names the phase and the timeout", func() {
+				var err error
+				lifecycleExec, err = CreateFakeLifecycleExecution(logger, docker, filepath.Join("testdata", "fake-app"), repoName, func(opts *build.LifecycleOptions) {
+					opts.PhaseTimeout = time.Second
+				})
+				h.AssertNil(t, err)
+				phaseFactory = build.NewDefaultPhaseFactory(lifecycleExec)
+
+				configProvider := build.NewPhaseConfigProvider(phaseName, lifecycleExec, build.WithArgs("sleep", "1m"))
+				phase := phaseFactory.New(configProvider)
+				err = phase.Run(context.TODO())
+				h.AssertNil(t, phase.Cleanup())
+				h.AssertError(t, err, fmt.Sprintf("phase '%s' exceeded the timeout of 1s", phaseName))
+			})
+		})
+
+		when("a phase exceeds its memory limit", func() {
+			it("names the phase and the limit", func() {
+				var err error
+				lifecycleExec, err = CreateFakeLifecycleExecution(logger, docker, filepath.Join("testdata", "fake-app"), repoName, func(opts *build.LifecycleOptions) {
+					opts.Memory = 16 * 1024 * 1024
+				})
+				h.AssertNil(t, err)
+				phaseFactory = build.NewDefaultPhaseFactory(lifecycleExec)
+
+				configProvider := build.NewPhaseConfigProvider(phaseName, lifecycleExec, build.WithArgs("allocate", "256"))
+				phase := phaseFactory.New(configProvider)
+				err = phase.Run(context.TODO())
+				h.AssertNil(t, phase.Cleanup())
+				h.AssertError(t, err, fmt.Sprintf("phase '%s' exceeded the memory limit of 16MiB", phaseName))
+			})
+		})
+
+		when("a phase reaches its pids limit", func() {
+			it("names the phase and the limit", func() {
+				var err error
+				lifecycleExec, err = CreateFakeLifecycleExecution(logger, docker, filepath.Join("testdata", "fake-app"), repoName, func(opts *build.LifecycleOptions) {
+					opts.PidsLimit = 16
+				})
+				h.AssertNil(t, err)
+				phaseFactory = build.NewDefaultPhaseFactory(lifecycleExec)
+
+				configProvider := build.NewPhaseConfigProvider(phaseName, lifecycleExec, build.WithArgs("spawn", "64"))
+				phase := phaseFactory.New(configProvider)
+				err = phase.Run(context.TODO())
+				h.AssertNil(t, phase.Cleanup())
+				h.AssertError(t, err, fmt.Sprintf("phase '%s' reached the limit of 16 processes", phaseName))
+			})
+
+			it("returns the error of a phase failing for another reason", func() {
+				var err error
+				lifecycleExec, err = CreateFakeLifecycleExecution(logger, docker, filepath.Join("testdata", "fake-app"), repoName, func(opts *build.LifecycleOptions) {
+					opts.PidsLimit = 16
+				})
+				h.AssertNil(t, err)
+				phaseFactory = build.NewDefaultPhaseFactory(lifecycleExec)
+
+				configProvider := build.NewPhaseConfigProvider(phaseName, lifecycleExec, build.WithArgs("read", "/workspace/does-not-exist"))
+				phase := phaseFactory.New(configProvider)
+				err = phase.Run(context.TODO())
+				h.AssertNil(t, phase.Cleanup())
+				h.AssertError(t, err, "failed with status code: 1")
+				h.AssertNotContains(t, err.Error(), "processes")
+			})
+		})
 	})
 
 	when("#Cleanup", func() {
@@ -457,7 +524,7 @@ func assertRunSucceeds(t *testing.T, phase build.RunnerCleaner, outBuf *bytes.Bu
 	h.AssertNilE(t, phase.Cleanup())
 }
 
-func CreateFakeLifecycleExecution(logger logging.Logger, docker client.CommonAPIClient, appDir string, repoName string) (*build.LifecycleExecution, error) {
+func CreateFakeLifecycleExecution(logger logging.Logger, docker client.CommonAPIClient, appDir string, repoName string, ops ...func(*build.LifecycleOptions)) (*build.LifecycleExecution, error) {
 	builderImage, err := local.NewImage(repoName, docker, local.FromBaseImage(repoName))
 	if err != nil {
 		return nil, err
@@ -471,13 +538,19 @@ func CreateFakeLifecycleExecution(logger logging.Logger, docker client.CommonAPI
 		return nil, err
 	}
 
-	return build.NewLifecycleExecution(logger, docker, build.LifecycleOptions{
+	opts := build.LifecycleOptions{
 		AppPath:    appDir,
 		Builder:    fakeBuilder,
 		HTTPProxy:  "some-http-proxy",
 		HTTPSProxy: "some-https-proxy",
 		NoProxy:    "some-no-proxy",
-	})
+	}
+
+	for _, op := range ops {
+		op(&opts)
+	}
+
+	return build.NewLifecycleExecution(logger, docker, opts)
 }
 
 // helper function to expose standard UNIX socket `/var/run/docker.sock` via TCP localhost:PORT
@@ -0,0 +1,128 @@
+package build
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/style"
+)
+
+const (
+	// SecretsDir is the directory in the detect and build containers at which secrets are mounted.
+	SecretsDir = "/run/secrets"
+
+	redactedText = "[REDACTED]"
+
+	// minRedactionLength is the length below which a secret is not redacted, so that common words are left intact.
+	minRedactionLength = 8
+)
+
+// Secret is a file mounted read-only into the detect and build containers at SecretsDir/<ID>.
+type Secret struct {
+	ID     string
+	Source string
+}
+
+// WithSecrets mounts a tmpfs at SecretsDir and each secret, read-only, within it.
+// Nothing is written to the container filesystem, the app or layers volumes.
+func WithSecrets(secrets ...Secret) PhaseConfigProviderOperation {
+	return func(provider *PhaseConfigProvider) {
+		if len(secrets) == 0 {
+			return
+		}
+
+		provider.hostConf.Mounts = append(provider.hostConf.Mounts, mount.Mount{
+			Type:   mount.TypeTmpfs,
+			Target: SecretsDir,
+		})
+		for _, secret := range secrets {
+			provider.hostConf.Mounts = append(provider.hostConf.Mounts, mount.Mount{
+				Type:     mount.TypeBind,
+				Source:   secret.Source,
+				Target:   path.Join(SecretsDir, secret.ID),
+				ReadOnly: true,
+			})
+		}
+	}
+}
+
+// secretRedactions reads each secret and returns the strings to redact from phase output, longest first.
+// Only the whole value of a secret is redacted, so that the generic values a secret file may contain alongside its
+// credentials, such as URLs and booleans, are not redacted from unrelated output.
+func secretRedactions(secrets []Secret) ([]string, error) {
+	seen := map[string]bool{}
+	var redactions []string
+	for _, secret := range secrets {
+		contents, err := ioutil.ReadFile(secret.Source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading secret %s", style.Symbol(secret.ID))
+		}
+
+		value := strings.TrimSpace(string(contents))
+		if len(value) < minRedactionLength || seen[value] {
+			continue
+		}
+		seen[value] = true
+		redactions = append(redactions, value)
+	}
+
+	sort.SliceStable(redactions, func(i, j int) bool {
+		return len(redactions[i]) > len(redactions[j])
+	})
+	return redactions, nil
+}
+
+// redactingWriter replaces secrets in each line written to it before passing the line on.
+type redactingWriter struct {
+	out      io.Writer
+	buf      bytes.Buffer
+	replacer *strings.Replacer
+}
+
+func newRedactingWriter(out io.Writer, redactions []string) *redactingWriter {
+	var oldnew []string
+	for _, r := range redactions {
+		oldnew = append(oldnew, r, redactedText)
+	}
+	return &redactingWriter{out: out, replacer: strings.NewReplacer(oldnew...)}
+}
+
+func (w *redactingWriter) Write(data []byte) (int, error) {
+	w.buf.Write(data)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// incomplete line, keep it for the next write
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			break
+		}
+		if _, err := io.WriteString(w.out, w.replacer.Replace(line)); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(data), nil
+}
+
+// Close writes any remaining output and closes the underlying writer.
+func (w *redactingWriter) Close() error {
+	if w.buf.Len() > 0 {
+		if _, err := io.WriteString(w.out, w.replacer.Replace(w.buf.String())); err != nil {
+			return err
+		}
+		w.buf.Reset()
+	}
+
+	if closer, ok := w.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -7,9 +7,12 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"os/exec"
 	"os/user"
 	"path/filepath"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/buildpacks/lifecycle/auth"
 	"github.com/docker/docker/api/types"
@@ -53,6 +56,15 @@ func main() {
 	if len(os.Args) > 1 && os.Args[1] == "user" {
 		testUser()
 	}
+	if len(os.Args) > 2 && os.Args[1] == "sleep" {
+		testSleep(os.Args[2])
+	}
+	if len(os.Args) > 2 && os.Args[1] == "allocate" {
+		testAllocate(os.Args[2])
+	}
+	if len(os.Args) > 2 && os.Args[1] == "spawn" {
+		testSpawn(os.Args[2])
+	}
 }
 
 func testWrite(filename, contents string) {
@@ -201,6 +213,52 @@ func readDir(dir string) {
 	}
 }
 
+func testSleep(duration string) {
+	fmt.Println("sleep test")
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		fmt.Printf("failed to parse duration %s: %s\n", duration, err)
+		os.Exit(1)
+	}
+	time.Sleep(d)
+}
+
+func testAllocate(megabytes string) {
+	fmt.Println("allocate test")
+	n, err := strconv.Atoi(megabytes)
+	if err != nil {
+		fmt.Printf("failed to parse megabytes %s: %s\n", megabytes, err)
+		os.Exit(1)
+	}
+
+	var chunks [][]byte
+	for i := 0; i < n; i++ {
+		chunk := make([]byte, 1024*1024)
+		for j := range chunk {
+			chunk[j] = 1
+		}
+		chunks = append(chunks, chunk)
+	}
+	fmt.Printf("allocated %d chunks\n", len(chunks))
+}
+
+func testSpawn(processes string) {
+	fmt.Println("spawn test")
+	n, err := strconv.Atoi(processes)
+	if err != nil {
+		fmt.Printf("failed to parse processes %s: %s\n", processes, err)
+		os.Exit(1)
+	}
+
+	for i := 0; i < n; i++ {
+		if err := exec.Command("sleep", "60").Start(); err != nil {
+			fmt.Printf("failed to start process: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("started %d processes\n", n)
+}
+
 func testUser() {
 	fmt.Println("user test")
 	user, err := user.Current()
@@ -1,7 +1,6 @@
 package buildpackage
 
 import (
-	"archive/tar"
 	"compress/gzip"
 	"io/ioutil"
 	"os"
@@ -11,15 +10,14 @@ import (
 	"github.com/buildpacks/imgutil"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
-	"github.com/google/go-containerregistry/pkg/v1/layout"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/pkg/errors"
 
 	"github.com/buildpacks/pack/internal/dist"
+	"github.com/buildpacks/pack/internal/image"
 	"github.com/buildpacks/pack/internal/stack"
 	"github.com/buildpacks/pack/internal/style"
-	"github.com/buildpacks/pack/pkg/archive"
 )
 
 type ImageFactory interface {
@@ -169,30 +167,7 @@ func (b *PackageBuilder) SaveAsFile(path, imageOS string) error {
 		return err
 	}
 
-	layoutDir, err := ioutil.TempDir(tmpDir, "oci-layout")
-	if err != nil {
-		return errors.Wrap(err, "creating oci-layout temp dir")
-	}
-
-	p, err := layout.Write(layoutDir, empty.Index)
-	if err != nil {
-		return errors.Wrap(err, "writing index")
-	}
-
-	if err := p.AppendImage(layoutImage); err != nil {
-		return errors.Wrap(err, "writing layout")
-	}
-
-	outputFile, err := os.Create(path)
-	if err != nil {
-		return errors.Wrap(err, "creating output file")
-	}
-	defer outputFile.Close()
-
-	tw := tar.NewWriter(outputFile)
-	defer tw.Close()
-
-	return archive.WriteDirToTar(tw, layoutDir, "/", 0, 0, 0755, true, false, nil)
+	return image.WriteLayoutArchive(path, layoutImage, "")
 }
 
 func newLayoutImage(imageOS string) (*layoutImage, error) {
@@ -0,0 +1,112 @@
+// Package buildreport assembles the machine-readable report of a build written by `pack build --report-file`.
+package buildreport
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/pkg/events"
+)
+
+// Report describes the app image produced by a build and how it was built.
+type Report struct {
+	Image          string             `json:"image"`
+	Digest         string             `json:"digest"`
+	AdditionalTags []string           `json:"additional_tags,omitempty"`
+	Builder        Image              `json:"builder"`
+	RunImage       Image              `json:"run_image"`
+	Lifecycle      Lifecycle          `json:"lifecycle"`
+	Buildpacks     []events.Buildpack `json:"buildpacks,omitempty"`
+	Processes      []events.Process   `json:"processes,omitempty"`
+	Phases         []Phase            `json:"phases,omitempty"`
+	CreationTime   *time.Time         `json:"creation_time,omitempty"`
+}
+
+// Image is an image used by the build.
+type Image struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// Lifecycle describes the lifecycle that ran the build.
+type Lifecycle struct {
+	Version     string `json:"version,omitempty"`
+	PlatformAPI string `json:"platform_api,omitempty"`
+}
+
+// Phase is a lifecycle phase that ran during the build.
+type Phase struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration_seconds"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Collector is an events.Sink that assembles a Report from the events emitted during a build.
+type Collector struct {
+	mu     sync.Mutex
+	report Report
+	tags   []string
+}
+
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+func (c *Collector) Emit(e events.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Type {
+	case events.BuildResolved:
+		c.report.Builder = Image{Name: e.Builder, Digest: e.BuilderDigest}
+		c.report.RunImage = Image{Name: e.RunImage, Digest: e.RunImageDigest}
+		c.report.Lifecycle.Version = e.LifecycleVersion
+		c.report.CreationTime = e.CreationTime
+		c.tags = e.Tags
+	case events.GroupDetected:
+		c.report.Buildpacks = e.Buildpacks
+	case events.PhaseFinished:
+		c.report.Phases = append(c.report.Phases, Phase{Name: e.Phase, Duration: e.Duration.Seconds(), Error: e.Error})
+	case events.ExportReported:
+		c.report.Lifecycle.PlatformAPI = e.PlatformAPI
+		c.report.Processes = e.Processes
+		if len(e.Tags) > 0 {
+			c.tags = e.Tags
+		}
+	case events.ImageExported:
+		// the image index of a multi-platform build is exported last
+		c.report.Image = e.Image
+		c.report.Digest = e.Digest
+	}
+}
+
+// Report returns the report, with the tags exported other than the image name as additional tags.
+func (c *Collector) Report() Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := c.report
+	report.AdditionalTags = nil
+	for _, tag := range c.tags {
+		if tag != report.Image {
+			report.AdditionalTags = append(report.AdditionalTags, tag)
+		}
+	}
+	return report
+}
+
+// WriteFile writes report to path as indented JSON.
+func WriteFile(path string, report Report) error {
+	contents, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshalling build report")
+	}
+	if err := ioutil.WriteFile(path, append(contents, '\n'), 0644); err != nil {
+		return errors.Wrap(err, "writing build report")
+	}
+	return nil
+}
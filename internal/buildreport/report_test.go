@@ -0,0 +1,105 @@
+package buildreport_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/buildreport"
+	"github.com/buildpacks/pack/pkg/events"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestBuildReport(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "BuildReport", testBuildReport, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testBuildReport(t *testing.T, when spec.G, it spec.S) {
+	var collector *buildreport.Collector
+
+	it.Before(func() {
+		collector = buildreport.NewCollector()
+		collector.Emit(events.Event{
+			Type:             events.BuildResolved,
+			Builder:          "some/builder",
+			BuilderDigest:    "sha256:builder",
+			RunImage:         "some/run",
+			RunImageDigest:   "sha256:run",
+			LifecycleVersion: "0.11.4",
+			Tags:             []string{"some/app:other"},
+		})
+		collector.Emit(events.Event{Type: events.PhaseStarted, Phase: "detector"})
+		collector.Emit(events.Event{Type: events.GroupDetected, Buildpacks: []events.Buildpack{{ID: "some/bp", Version: "1.2.3"}}})
+		collector.Emit(events.Event{Type: events.PhaseFinished, Phase: "detector", Duration: 1500 * time.Millisecond})
+		collector.Emit(events.Event{Type: events.PhaseFinished, Phase: "exporter", Duration: 2 * time.Second})
+		collector.Emit(events.Event{
+			Type:        events.ExportReported,
+			PlatformAPI: "0.5",
+			Processes:   []events.Process{{Type: "web", Command: "some-command"}},
+		})
+		collector.Emit(events.Event{Type: events.ImageExported, Image: "index.docker.io/some/app:latest", Digest: "sha256:app"})
+	})
+
+	when("Collector", func() {
+		it("assembles the report from build events", func() {
+			subject := collector.Report()
+			h.AssertEq(t, subject.Image, "index.docker.io/some/app:latest")
+			h.AssertEq(t, subject.Digest, "sha256:app")
+			h.AssertEq(t, subject.AdditionalTags, []string{"some/app:other"})
+			h.AssertEq(t, subject.Builder, buildreport.Image{Name: "some/builder", Digest: "sha256:builder"})
+			h.AssertEq(t, subject.RunImage, buildreport.Image{Name: "some/run", Digest: "sha256:run"})
+			h.AssertEq(t, subject.Lifecycle, buildreport.Lifecycle{Version: "0.11.4", PlatformAPI: "0.5"})
+			h.AssertEq(t, subject.Buildpacks, []events.Buildpack{{ID: "some/bp", Version: "1.2.3"}})
+			h.AssertEq(t, subject.Processes, []events.Process{{Type: "web", Command: "some-command"}})
+			h.AssertEq(t, subject.Phases, []buildreport.Phase{{Name: "detector", Duration: 1.5}, {Name: "exporter", Duration: 2}})
+		})
+
+		it("uses the tags the exporter reported", func() {
+			collector.Emit(events.Event{
+				Type: events.ExportReported,
+				Tags: []string{"index.docker.io/some/app:latest", "index.docker.io/some/app:other"},
+			})
+
+			h.AssertEq(t, collector.Report().AdditionalTags, []string{"index.docker.io/some/app:other"})
+		})
+
+		it("uses the image exported last", func() {
+			collector.Emit(events.Event{Type: events.ImageExported, Image: "index.docker.io/some/app:latest", Digest: "sha256:index"})
+
+			h.AssertEq(t, collector.Report().Digest, "sha256:index")
+		})
+	})
+
+	when("#WriteFile", func() {
+		it("writes the report as JSON", func() {
+			tmpDir, err := ioutil.TempDir("", "build-report-test")
+			h.AssertNil(t, err)
+			defer os.RemoveAll(tmpDir)
+
+			path := filepath.Join(tmpDir, "build-report.json")
+			h.AssertNil(t, buildreport.WriteFile(path, collector.Report()))
+
+			contents, err := ioutil.ReadFile(path)
+			h.AssertNil(t, err)
+			h.AssertContains(t, string(contents), `"digest": "sha256:app"`)
+
+			var written buildreport.Report
+			h.AssertNil(t, json.Unmarshal(contents, &written))
+			h.AssertEq(t, written, collector.Report())
+		})
+
+		it("errors when the file cannot be written", func() {
+			err := buildreport.WriteFile(filepath.Join("does-not-exist", "build-report.json"), collector.Report())
+			h.AssertError(t, err, "writing build report")
+		})
+	})
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// BindCache stores layers in a directory on the host, for example a workspace directory persisted by a CI system.
+type BindCache struct {
+	source string
+}
+
+// NewBindCache returns a cache backed by the host directory at source, which should be an absolute path.
+func NewBindCache(source string) *BindCache {
+	return &BindCache{
+		source: source,
+	}
+}
+
+func (c *BindCache) Name() string {
+	return c.source
+}
+
+// Clear removes the contents of the directory, the directory itself is kept so that it can be mounted.
+func (c *BindCache) Clear(_ context.Context) error {
+	entries, err := ioutil.ReadDir(c.source)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(c.source, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *BindCache) Type() Type {
+	return Bind
+}
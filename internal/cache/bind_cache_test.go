@@ -0,0 +1,69 @@
+package cache_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/cache"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestBindCache(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "BindCache", testBindCache, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testBindCache(t *testing.T, when spec.G, it spec.S) {
+	var tmpDir string
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "bind-cache-test")
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	when("#Name", func() {
+		it("returns the source directory", func() {
+			subject := cache.NewBindCache(tmpDir)
+			h.AssertEq(t, subject.Name(), tmpDir)
+		})
+	})
+
+	when("#Type", func() {
+		it("returns the cache type", func() {
+			subject := cache.NewBindCache(tmpDir)
+			h.AssertEq(t, subject.Type(), cache.Bind)
+		})
+	})
+
+	when("#Clear", func() {
+		it("removes the contents of the directory", func() {
+			h.AssertNil(t, os.MkdirAll(filepath.Join(tmpDir, "committed", "layer"), 0755))
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(tmpDir, "some-file"), []byte("contents"), 0600))
+
+			subject := cache.NewBindCache(tmpDir)
+			h.AssertNil(t, subject.Clear(context.TODO()))
+
+			entries, err := ioutil.ReadDir(tmpDir)
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(entries), 0)
+		})
+
+		it("does not fail when the directory does not exist", func() {
+			subject := cache.NewBindCache(filepath.Join(tmpDir, "missing"))
+			h.AssertNil(t, subject.Clear(context.TODO()))
+		})
+	})
+}
@@ -3,6 +3,19 @@ package cache
 const (
 	Image Type = iota
 	Volume
+	Bind
 )
 
 type Type int
+
+func (t Type) String() string {
+	switch t {
+	case Image:
+		return "image"
+	case Volume:
+		return "volume"
+	case Bind:
+		return "bind"
+	}
+	return "unknown"
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/docker/docker/client"
@@ -12,6 +13,11 @@ import (
 	"github.com/buildpacks/pack/internal/paths"
 )
 
+// VolumePrefix is the prefix of the names of all volume caches.
+const VolumePrefix = "pack-cache-"
+
+var volumeNameRegexp = regexp.MustCompile(`^` + VolumePrefix + `(.+)-[0-9a-f]{12}\.([^.]+)$`)
+
 type VolumeCache struct {
 	docker client.CommonAPIClient
 	volume string
@@ -22,7 +28,15 @@ func NewVolumeCache(imageRef name.Reference, suffix string, dockerClient client.
 
 	vol := paths.FilterReservedNames(fmt.Sprintf("%s-%x", sanitizedRef(imageRef), sum[:6]))
 	return &VolumeCache{
-		volume: fmt.Sprintf("pack-cache-%s.%s", vol, suffix),
+		volume: fmt.Sprintf("%s%s.%s", VolumePrefix, vol, suffix),
+		docker: dockerClient,
+	}
+}
+
+// NewNamedVolumeCache returns a cache backed by the volume with the given name, which may be shared by several images.
+func NewNamedVolumeCache(volume string, dockerClient client.CommonAPIClient) *VolumeCache {
+	return &VolumeCache{
+		volume: volume,
 		docker: dockerClient,
 	}
 }
@@ -50,3 +64,13 @@ func sanitizedRef(ref name.Reference) string {
 	result = strings.ReplaceAll(result, "/", "_")
 	return fmt.Sprintf("%s_%s", result, ref.Identifier())
 }
+
+// ParseVolumeName returns the sanitized image reference and the suffix encoded in the name of a volume cache.
+// The image name itself cannot be recovered, registries are dropped and slashes replaced.
+func ParseVolumeName(volume string) (ref string, suffix string, ok bool) {
+	matches := volumeNameRegexp.FindStringSubmatch(volume)
+	if matches == nil {
+		return "", "", false
+	}
+	return matches[1], matches[2], true
+}
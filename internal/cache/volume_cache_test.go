@@ -179,4 +179,32 @@ func testCache(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, subject.Type(), expected)
 		})
 	})
+
+	when("#NewNamedVolumeCache", func() {
+		it("uses the given volume name", func() {
+			subject := cache.NewNamedVolumeCache("some-shared-cache", dockerClient)
+			h.AssertEq(t, subject.Name(), "some-shared-cache")
+			h.AssertEq(t, subject.Type(), cache.Volume)
+		})
+	})
+
+	when("#ParseVolumeName", func() {
+		it("returns the sanitized reference and suffix", func() {
+			ref, err := name.ParseReference("registry.com/my/repo:some-tag", name.WeakValidation)
+			h.AssertNil(t, err)
+			subject := cache.NewVolumeCache(ref, "build", dockerClient)
+
+			sanitized, suffix, ok := cache.ParseVolumeName(subject.Name())
+			h.AssertTrue(t, ok)
+			h.AssertEq(t, sanitized, "my_repo_some-tag")
+			h.AssertEq(t, suffix, "build")
+		})
+
+		it("does not match other volumes", func() {
+			for _, volumeName := range []string{"some-volume", "pack-layers-abcdefghij", "pack-cache-my_repo_latest.build"} {
+				_, _, ok := cache.ParseVolumeName(volumeName)
+				h.AssertFalse(t, ok)
+			}
+		})
+	})
 }
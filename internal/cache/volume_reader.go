@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/ephemeral"
+	"github.com/buildpacks/pack/internal/style"
+)
+
+const mountRoot = "/caches"
+
+// VolumeReader reads the contents of volume caches through a container that mounts them.
+// The container is created from an empty image and never started, so no image has to be pulled.
+type VolumeReader struct {
+	docker      client.CommonAPIClient
+	image       string
+	containerID string
+}
+
+// NewVolumeReader creates a container mounting each of the volumes read-only.
+// Close must be called to remove the container and its image.
+func NewVolumeReader(ctx context.Context, docker client.CommonAPIClient, volumes ...string) (*VolumeReader, error) {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, errors.Wrap(err, "generating image name")
+	}
+
+	r := &VolumeReader{
+		docker: docker,
+		image:  fmt.Sprintf("pack.local/cache-reader/%x:latest", suffix),
+	}
+	if err := r.importImage(ctx); err != nil {
+		return nil, err
+	}
+
+	var binds []string
+	for _, volume := range volumes {
+		binds = append(binds, fmt.Sprintf("%s:%s:ro", volume, volumePath(volume)))
+	}
+
+	ctr, err := docker.ContainerCreate(ctx,
+		&container.Config{Image: r.image, Cmd: []string{"/none"}, Labels: ephemeral.Labels()},
+		&container.HostConfig{Binds: binds},
+		nil, nil, "",
+	)
+	if err != nil {
+		r.removeImage()
+		return nil, errors.Wrap(err, "creating cache reader container")
+	}
+	r.containerID = ctr.ID
+
+	return r, nil
+}
+
+func (r *VolumeReader) importImage(ctx context.Context) error {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: mountRoot[1:] + "/", Mode: 0755}); err != nil {
+		return errors.Wrap(err, "writing cache reader image")
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "writing cache reader image")
+	}
+
+	var changes []string
+	for k, v := range ephemeral.Labels() {
+		changes = append(changes, fmt.Sprintf("LABEL %s=%s", k, v))
+	}
+
+	rc, err := r.docker.ImageImport(ctx, types.ImageImportSource{Source: buf, SourceName: "-"}, r.image, types.ImageImportOptions{Changes: changes})
+	if err != nil {
+		return errors.Wrap(err, "importing cache reader image")
+	}
+	defer rc.Close()
+
+	if err := jsonmessage.DisplayJSONMessagesStream(rc, ioutil.Discard, 0, false, nil); err != nil {
+		return errors.Wrap(err, "importing cache reader image")
+	}
+	return nil
+}
+
+// LastModified returns the time the contents of volume last changed.
+// The lifecycle recreates its staging directory at the root of a volume cache whenever a build uses it.
+func (r *VolumeReader) LastModified(ctx context.Context, volume string) (time.Time, error) {
+	stat, err := r.docker.ContainerStatPath(ctx, r.containerID, volumePath(volume))
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "reading volume %s", style.Symbol(volume))
+	}
+	return stat.Mtime, nil
+}
+
+// ReadFile returns the contents of the file at path within volume.
+// A missing file is reported with an error satisfying client.IsErrNotFound.
+func (r *VolumeReader) ReadFile(ctx context.Context, volume, filePath string) ([]byte, error) {
+	src := path.Join(volumePath(volume), filePath)
+	rc, _, err := r.docker.CopyFromContainer(ctx, r.containerID, src)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s from volume %s", style.Symbol(filePath), style.Symbol(volume))
+	}
+	defer rc.Close()
+
+	tr := tar.NewReader(rc)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.Errorf("%s in volume %s is not a file", style.Symbol(filePath), style.Symbol(volume))
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s from volume %s", style.Symbol(filePath), style.Symbol(volume))
+		}
+		if header.Typeflag == tar.TypeReg {
+			return ioutil.ReadAll(tr)
+		}
+	}
+}
+
+// Close removes the container and its image.
+func (r *VolumeReader) Close() error {
+	err := r.docker.ContainerRemove(context.Background(), r.containerID, types.ContainerRemoveOptions{Force: true})
+	if rmErr := r.removeImage(); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+func (r *VolumeReader) removeImage() error {
+	_, err := r.docker.ImageRemove(context.Background(), r.image, types.ImageRemoveOptions{Force: true, PruneChildren: true})
+	return err
+}
+
+func volumePath(volume string) string {
+	return path.Join(mountRoot, volume)
+}
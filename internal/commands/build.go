@@ -1,12 +1,16 @@
 package commands
 
 import (
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/docker/go-units"
 	"github.com/google/go-containerregistry/pkg/name"
 
 	pubcfg "github.com/buildpacks/pack/config"
@@ -15,9 +19,12 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/buildreport"
 	"github.com/buildpacks/pack/internal/config"
+	"github.com/buildpacks/pack/internal/dryrun"
 	"github.com/buildpacks/pack/internal/style"
 	"github.com/buildpacks/pack/logging"
+	"github.com/buildpacks/pack/pkg/events"
 	"github.com/buildpacks/pack/project"
 )
 
@@ -27,6 +34,7 @@ type BuildFlags struct {
 	TrustBuilder       bool
 	DockerHost         string
 	CacheImage         string
+	WriteCacheImage    bool
 	AppPath            string
 	Builder            string
 	Registry           string
@@ -44,6 +52,28 @@ type BuildFlags struct {
 	Workspace          string
 	GID                int
 	PreviousImage      string
+	OutputFormat       string
+	DryRun             bool
+	DebugOnFailure     bool
+	Secrets            []string
+	SSH                []string
+	Platforms          []string
+	Output             string
+	Caches             []string
+	CPUs               float64
+	Memory             string
+	PidsLimit          int64
+	PhaseTimeout       time.Duration
+	Executor           string
+	Labels             []string
+	SkipGitMetadata    bool
+	CreationTime       string
+	ReportFile         string
+	SignKey            string
+	ProvenanceFile     string
+	AttachProvenance   bool
+	Lock               bool
+	UpdateLock         bool
 }
 
 // Matches `KEY=VALUE` or `KEY` separated by a coma.
@@ -82,6 +112,8 @@ func Build(logger logging.Logger, cfg config.Config, packClient PackClient) *cob
 				logger.Debugf("Using project descriptor located at %s", style.Symbol(actualDescriptorPath))
 			}
 
+			lockOpts := projectLock(logger, flags, actualDescriptorPath)
+
 			builder := flags.Builder
 			// We only override the builder to the one in the project descriptor
 			// if it was not explicitly set by the user
@@ -89,7 +121,8 @@ func Build(logger logging.Logger, cfg config.Config, packClient PackClient) *cob
 				builder = descriptor.Build.Builder
 			}
 
-			if builder == "" {
+			// the local executor uses the build image pack runs in, so no builder is needed
+			if builder == "" && flags.Executor != string(pack.LocalExecutor) {
 				suggestSettingBuilder(logger, packClient)
 				return pack.NewSoftError()
 			}
@@ -101,8 +134,14 @@ func Build(logger logging.Logger, cfg config.Config, packClient PackClient) *cob
 				return err
 			}
 
-			trustBuilder := isTrustedBuilder(cfg, builder) || flags.TrustBuilder
-			if trustBuilder {
+			trustedBuilder, isTrusted := findTrustedBuilder(cfg, builder)
+			trustBuilder := isTrusted || flags.TrustBuilder
+			var builderTrust pack.BuilderTrust
+			// a pinned trust is kept with --trust-builder, so that a builder image which does not match it is not trusted
+			if isPinned(trustedBuilder) {
+				builderTrust = pack.BuilderTrust{Digests: trustedBuilder.Digests, SigningKey: trustedBuilder.SigningKey}
+				logger.Debugf("Builder %s is trusted by %s", style.Symbol(builder), trustMode(trustedBuilder))
+			} else if trustBuilder {
 				logger.Debugf("Builder %s is trusted", style.Symbol(builder))
 			} else {
 				logger.Debugf("Builder %s is untrusted", style.Symbol(builder))
@@ -130,10 +169,83 @@ func Build(logger logging.Logger, cfg config.Config, packClient PackClient) *cob
 				}
 				lifecycleImage = ref.Name()
 			}
+			creationTime, err := parseCreationTime(flags.CreationTime)
+			if err != nil {
+				return err
+			}
+			labels, err := parseLabels(flags.Labels)
+			if err != nil {
+				return err
+			}
+			secrets, err := parseSecrets(flags.Secrets)
+			if err != nil {
+				return err
+			}
+			sshConfig, err := parseSSH(flags.SSH)
+			if err != nil {
+				return err
+			}
+			output, err := parseOutput(flags.Output)
+			if err != nil {
+				return err
+			}
+			memory, err := parseMemory(flags.Memory)
+			if err != nil {
+				return err
+			}
+			buildCache, launchCache, err := parseCaches(flags.Caches)
+			if err != nil {
+				return err
+			}
+			if buildCache != nil && flags.CacheImage != "" {
+				return errors.New("cache-image flag cannot be combined with a build cache")
+			}
+
+			var signKeyPassword string
+			if flags.SignKey != "" {
+				signKeyPassword = os.Getenv(signKeyPasswordEnv)
+			}
+
 			var gid = -1
 			if cmd.Flags().Changed("gid") {
 				gid = flags.GID
 			}
+
+			if flags.OutputFormat != "human-readable" {
+				// Structured output is written to stdout, so human-readable output is reduced to warnings and errors.
+				if ql, ok := logger.(quietableLogger); ok {
+					ql.WantQuiet(true)
+				}
+			}
+
+			var eventSink events.Sink
+			var dryRunCollector *dryrun.Collector
+			switch {
+			case flags.DryRun:
+				dryRunCollector = dryrun.NewCollector()
+				eventSink = dryRunCollector
+			case flags.OutputFormat == "json":
+				eventSink = events.NewJSONSink(logger.Writer())
+			}
+			// the debug shell is connected to the terminal directly, as the logger discards its output when quiet,
+			// and to stderr when structured output is written to stdout
+			var debugShellIn io.Reader
+			var debugShellOut io.Writer
+			if flags.DebugOnFailure {
+				debugShellIn, debugShellOut = cmd.InOrStdin(), os.Stdout
+				if flags.OutputFormat != "human-readable" {
+					debugShellOut = os.Stderr
+				}
+			}
+			var reportCollector *buildreport.Collector
+			if flags.ReportFile != "" {
+				reportCollector = buildreport.NewCollector()
+				if eventSink == nil {
+					eventSink = reportCollector
+				} else {
+					eventSink = events.MultiSink{eventSink, reportCollector}
+				}
+			}
 			if err := packClient.Build(cmd.Context(), pack.BuildOptions{
 				AppPath:           flags.AppPath,
 				Builder:           builder,
@@ -148,22 +260,64 @@ func Build(logger logging.Logger, cfg config.Config, packClient PackClient) *cob
 				PullPolicy:        pullPolicy,
 				ClearCache:        flags.ClearCache,
 				TrustBuilder:      trustBuilder,
+				BuilderTrust:      builderTrust,
 				Buildpacks:        buildpacks,
 				ContainerConfig: pack.ContainerConfig{
-					Network: flags.Network,
-					Volumes: flags.Volumes,
+					Network:      flags.Network,
+					Volumes:      flags.Volumes,
+					Secrets:      secrets,
+					SSH:          sshConfig,
+					CPUs:         flags.CPUs,
+					Memory:       memory,
+					PidsLimit:    flags.PidsLimit,
+					PhaseTimeout: flags.PhaseTimeout,
 				},
 				DefaultProcessType:       flags.DefaultProcessType,
 				ProjectDescriptorBaseDir: filepath.Dir(actualDescriptorPath),
 				ProjectDescriptor:        descriptor,
 				CacheImage:               flags.CacheImage,
+				WriteCacheImage:          flags.WriteCacheImage,
+				BuildCache:               buildCache,
+				LaunchCache:              launchCache,
 				Workspace:                flags.Workspace,
 				LifecycleImage:           lifecycleImage,
 				GroupID:                  gid,
 				PreviousImage:            flags.PreviousImage,
+				EventSink:                eventSink,
+				DryRun:                   flags.DryRun,
+				DebugOnFailure:           flags.DebugOnFailure,
+				DebugShellIn:             debugShellIn,
+				DebugShellOut:            debugShellOut,
+				Platforms:                flags.Platforms,
+				Output:                   output,
+				Executor:                 pack.ExecutorType(flags.Executor),
+				Labels:                   labels,
+				SkipGitMetadata:          flags.SkipGitMetadata,
+				CreationTime:             creationTime,
+				SignKey:                  flags.SignKey,
+				SignKeyPassword:          signKeyPassword,
+				Provenance: pack.ProvenanceOptions{
+					File:   flags.ProvenanceFile,
+					Attach: flags.AttachProvenance,
+				},
+				Lock: lockOpts,
 			}); err != nil {
 				return errors.Wrap(err, "failed to build")
 			}
+
+			if flags.DryRun {
+				writer, err := dryrun.NewWriter(flags.OutputFormat)
+				if err != nil {
+					return err
+				}
+				return writer.Print(logger, dryRunCollector.Reports())
+			}
+			if reportCollector != nil {
+				buildReport := reportCollector.Report()
+				if err := buildreport.WriteFile(flags.ReportFile, buildReport); err != nil {
+					return err
+				}
+			}
 			logger.Infof("Successfully built image %s", style.Symbol(imageName))
 			return nil
 		}),
@@ -177,7 +331,9 @@ func buildCommandFlags(cmd *cobra.Command, buildFlags *BuildFlags, cfg config.Co
 	cmd.Flags().StringVarP(&buildFlags.AppPath, "path", "p", "", "Path to app dir or zip-formatted file (defaults to current working directory)")
 	cmd.Flags().StringSliceVarP(&buildFlags.Buildpacks, "buildpack", "b", nil, "Buildpack to use. One of:\n  a buildpack by id and version in the form of '<buildpack>@<version>',\n  path to a buildpack directory (not supported on Windows),\n  path/URL to a buildpack .tar or .tgz file, or\n  a packaged buildpack image name in the form of '<hostname>/<repo>[:<tag>]'"+multiValueHelp("buildpack"))
 	cmd.Flags().StringVarP(&buildFlags.Builder, "builder", "B", cfg.DefaultBuilder, "Builder image")
-	cmd.Flags().StringVar(&buildFlags.CacheImage, "cache-image", "", `Cache build layers in remote registry. Requires --publish`)
+	cmd.Flags().StringVar(&buildFlags.CacheImage, "cache-image", "", "Cache build layers in remote registry.\nWithout --publish, layers are only restored from the image unless --write-cache-image is used.")
+	cmd.Flags().BoolVar(&buildFlags.WriteCacheImage, "write-cache-image", false, "Push the build cache to the cache image when building without --publish")
+	cmd.Flags().StringArrayVar(&buildFlags.Caches, "cache", nil, "Cache storage, in the form 'type=<build|launch>;format=<volume|image|bind>[;name=<name>][;source=<path>]'.\n- 'volume' (default): a volume, named after the image unless 'name' is set, which may be shared by several apps.\n- 'image': an image in a registry given by 'name', build caches only. Read-only without --publish, unless --write-cache-image is used.\n- 'bind': a directory on the host given by 'source', created if it does not exist."+multiValueHelp("cache"))
 	cmd.Flags().BoolVar(&buildFlags.ClearCache, "clear-cache", false, "Clear image's associated cache before building")
 	cmd.Flags().StringVarP(&buildFlags.DescriptorPath, "descriptor", "d", "", "Path to the project descriptor file")
 	cmd.Flags().StringVarP(&buildFlags.DefaultProcessType, "default-process", "D", "", `Set the default process type. (default "web")`)
@@ -196,11 +352,32 @@ This option may set DOCKER_HOST environment variable for the build container if
 	cmd.Flags().StringVarP(&buildFlags.Registry, "buildpack-registry", "r", cfg.DefaultRegistryName, "Buildpack Registry by name")
 	cmd.Flags().StringVar(&buildFlags.RunImage, "run-image", "", "Run image (defaults to default stack's run image)")
 	cmd.Flags().StringSliceVarP(&buildFlags.AdditionalTags, "tag", "t", nil, "Additional tags to push the output image to."+multiValueHelp("tag"))
-	cmd.Flags().BoolVar(&buildFlags.TrustBuilder, "trust-builder", false, "Trust the provided builder\nAll lifecycle phases will be run in a single container (if supported by the lifecycle).")
+	cmd.Flags().BoolVar(&buildFlags.TrustBuilder, "trust-builder", false, "Trust the provided builder\nAll lifecycle phases will be run in a single container (if supported by the lifecycle).\nA builder trusted by digest or signing key is still only trusted when its image matches.")
+	cmd.Flags().StringArrayVar(&buildFlags.Labels, "label", nil, "Label added to the app image, in the form 'key=value'.\nOverrides the labels in the project descriptor and the org.opencontainers.image labels derived from it.\nThe image is saved again after it is exported to add the labels, and is re-pushed when publishing, so its digest changes."+multiValueHelp("label"))
+	cmd.Flags().StringVar(&buildFlags.CreationTime, "creation-time", "", "Creation time of the app image, either 'now' or a unix timestamp.\nDefaults to SOURCE_DATE_EPOCH when it is set, and to a fixed time for reproducible builds otherwise.\nRequires Platform API 0.9 or later.")
+	cmd.Flags().StringVar(&buildFlags.SignKey, "sign-key", "", "Path to a cosign private key to sign the published image with, decrypted with "+signKeyPasswordEnv+".\nThe signature is pushed to the repository of the image. Requires --publish.")
+	cmd.Flags().StringVar(&buildFlags.ProvenanceFile, "provenance-file", "", "Write an in-toto provenance statement of the build to the given file, in the SLSA provenance format")
+	cmd.Flags().BoolVar(&buildFlags.AttachProvenance, "attach-provenance", false, "Push an in-toto provenance statement of the build to the repository of the image, signed with --sign-key if given.\nRequires --publish.")
+	cmd.Flags().BoolVar(&buildFlags.Lock, "lock", false, "Write "+project.LockFileName+" next to project.toml, pinning the builder, run image, lifecycle image and buildpacks\nresolved from a registry or URI to their digests. Builds of a project with "+project.LockFileName+" use what it pins,\nunless they build for multiple platforms or with the local executor.")
+	cmd.Flags().BoolVar(&buildFlags.UpdateLock, "update-lock", false, "Resolve the builder, run image, lifecycle image and buildpacks again, ignoring "+project.LockFileName+", and rewrite it")
+	cmd.Flags().StringVar(&buildFlags.ReportFile, "report-file", "", "Write a JSON report of the build to the given file, with the digest and tags of the app image,\nthe builder, run image and lifecycle used, the buildpacks and process types, and the time each phase took")
+	cmd.Flags().BoolVar(&buildFlags.SkipGitMetadata, "skip-git-metadata", false, "Do not record the remote URL, commit, branch and uncommitted changes of the git work tree containing the app in the app image")
+	cmd.Flags().StringArrayVar(&buildFlags.Secrets, "secret", nil, "Secret file made available to the detect and build phases, in the form 'id=<id>,src=<path>'.\nThe secret is mounted read-only at /run/secrets/<id> and is not stored in the app image."+multiValueHelp("secret"))
+	cmd.Flags().StringArrayVar(&buildFlags.SSH, "ssh", nil, "SSH agent forwarded to the detect and build phases, either 'default' for the agent at SSH_AUTH_SOCK or 'id=<path>' for a private key.\nThe agent socket is available at SSH_AUTH_SOCK, keys are not copied into the build containers."+multiValueHelp("ssh"))
 	cmd.Flags().StringArrayVar(&buildFlags.Volumes, "volume", nil, "Mount host volume into the build container, in the form '<host path>:<target path>[:<options>]'.\n- 'host path': Name of the volume or absolute directory path to mount.\n- 'target path': The path where the file or directory is available in the container.\n- 'options' (default \"ro\"): An optional comma separated list of mount options.\n    - \"ro\", volume contents are read-only.\n    - \"rw\", volume contents are readable and writeable.\n    - \"volume-opt=<key>=<value>\", can be specified more than once, takes a key-value pair consisting of the option name and its value."+multiValueHelp("volume"))
 	cmd.Flags().StringVar(&buildFlags.Workspace, "workspace", "", "Location at which to mount the app dir in the build image")
 	cmd.Flags().IntVar(&buildFlags.GID, "gid", 0, `Override GID of user's group in the stack's build and run images. The provided value must be a positive number`)
+	cmd.Flags().StringVar(&buildFlags.Output, "output", "", "Write the app image to the file system instead of the daemon, as 'oci-layout:<dir>' or 'oci-archive:<file>'.\nThe build cache is kept across builds of the same image name.")
+	cmd.Flags().StringSliceVar(&buildFlags.Platforms, "platform", nil, "Platform to build for, in the form os/arch[/variant], e.g. linux/arm64.\nWith more than one platform the image of each is published by digest and an image index referencing them is published under the image name, requires --publish."+multiValueHelp("platform"))
 	cmd.Flags().StringVar(&buildFlags.PreviousImage, "previous-image", "", "Set previous image to a particular tag reference, digest reference, or (when performing a daemon build) image ID")
+	cmd.Flags().StringVar(&buildFlags.OutputFormat, "output-format", "human-readable", "Output format for build progress (human-readable, json).\nWith json, build events are written to stdout as newline-delimited JSON.\nWith --dry-run, the format of the report (human-readable, json, yaml).")
+	cmd.Flags().BoolVar(&buildFlags.DebugOnFailure, "debug-on-failure", false, "When a lifecycle phase fails, keep the build volumes and start an interactive shell\nwith the mounts, environment and user of the failed phase.")
+	cmd.Flags().Float64Var(&buildFlags.CPUs, "cpus", 0, "Number of CPUs available to each lifecycle phase, e.g. 1.5 (default unlimited)")
+	cmd.Flags().StringVar(&buildFlags.Memory, "memory", "", "Memory available to each lifecycle phase, e.g. 512m or 2g. A phase that exceeds it fails the build (default unlimited)")
+	cmd.Flags().Int64Var(&buildFlags.PidsLimit, "pids-limit", 0, "Maximum number of processes running at once in each lifecycle phase (default unlimited)")
+	cmd.Flags().DurationVar(&buildFlags.PhaseTimeout, "phase-timeout", 0, "Fail the build when a lifecycle phase runs for longer than this, e.g. 10m (default no timeout).\nWith a trusted builder all phases run in one creator container, and the timeout applies to the whole build.")
+	cmd.Flags().BoolVar(&buildFlags.DryRun, "dry-run", false, "Resolve the builder, run image and buildpacks and run detection only.\nReports the detected buildpack group and build plan without building an image, for each platform given with --platform.")
+	cmd.Flags().StringVar(&buildFlags.Executor, "executor", string(pack.DockerExecutor), "Where to run the lifecycle, one of 'docker' or 'local'.\n'local' runs the lifecycle of the build image pack is running in without a docker daemon, requires --publish.\nWithout --cache-image or a bind --cache, the 'local' executor caches layers in a temporary directory removed after each build.")
 }
 
 func validateBuildFlags(flags *BuildFlags, cfg config.Config, packClient PackClient, logger logging.Logger) error {
@@ -208,16 +385,239 @@ func validateBuildFlags(flags *BuildFlags, cfg config.Config, packClient PackCli
 		return pack.NewExperimentError("Support for buildpack registries is currently experimental.")
 	}
 
-	if flags.CacheImage != "" && !flags.Publish {
-		return errors.New("cache-image flag requires the publish flag")
-	}
-
 	if flags.GID < 0 {
 		return errors.New("gid flag must be in the range of 0-2147483647")
 	}
+
+	if flags.CPUs < 0 {
+		return errors.New("cpus flag must not be negative")
+	}
+
+	if flags.PidsLimit < 0 {
+		return errors.New("pids-limit flag must not be negative")
+	}
+
+	if flags.PhaseTimeout < 0 {
+		return errors.New("phase-timeout flag must not be negative")
+	}
+
+	switch pack.ExecutorType(flags.Executor) {
+	case pack.DockerExecutor:
+	case pack.LocalExecutor:
+		if !flags.Publish {
+			return errors.New("local executor requires the publish flag")
+		}
+	default:
+		return errors.Errorf("executor %s is not supported", style.Symbol(flags.Executor))
+	}
+
+	if flags.Output != "" && flags.Publish {
+		return errors.New("output flag cannot be combined with the publish flag")
+	}
+
+	if flags.SignKey != "" && !flags.Publish {
+		return errors.New("sign-key flag requires the publish flag")
+	}
+
+	if flags.AttachProvenance && !flags.Publish {
+		return errors.New("attach-provenance flag requires the publish flag")
+	}
+
+	if flags.ProvenanceFile != "" && flags.DryRun {
+		return errors.New("provenance-file flag cannot be combined with the dry-run flag")
+	}
+
+	if flags.ReportFile != "" && flags.DryRun {
+		return errors.New("report-file flag cannot be combined with the dry-run flag")
+	}
+
+	if (flags.Lock || flags.UpdateLock) && flags.DryRun {
+		return errors.New("lock flags cannot be combined with the dry-run flag")
+	}
+
+	if len(flags.Platforms) > 1 && !flags.Publish && !flags.DryRun {
+		return errors.New("building for multiple platforms requires the publish flag")
+	}
+
+	switch {
+	case flags.OutputFormat == "human-readable", flags.OutputFormat == "json":
+	case flags.OutputFormat == "yaml" && flags.DryRun:
+	default:
+		return errors.Errorf("output format %s is not supported", style.Symbol(flags.OutputFormat))
+	}
 	return nil
 }
 
+// parseCreationTime parses 'now' or a unix timestamp, falling back to SOURCE_DATE_EPOCH when value is empty.
+func parseCreationTime(value string) (*time.Time, error) {
+	if value == "" {
+		value = os.Getenv("SOURCE_DATE_EPOCH")
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	if value == "now" {
+		now := time.Now().UTC()
+		return &now, nil
+	}
+
+	epoch, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || epoch < 0 {
+		return nil, errors.Errorf("invalid creation time %s: expected 'now' or a unix timestamp", style.Symbol(value))
+	}
+	creationTime := time.Unix(epoch, 0).UTC()
+	return &creationTime, nil
+}
+
+func parseLabels(labelFlags []string) (map[string]string, error) {
+	if len(labelFlags) == 0 {
+		return nil, nil
+	}
+
+	labels := map[string]string{}
+	for _, labelFlag := range labelFlags {
+		kv := strings.SplitN(labelFlag, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf("invalid label %s: expected the form 'key=value'", style.Symbol(labelFlag))
+		}
+		labels[kv[0]] = kv[1]
+	}
+	return labels, nil
+}
+
+func parseSecrets(secretFlags []string) ([]pack.Secret, error) {
+	var secrets []pack.Secret
+	for _, secretFlag := range secretFlags {
+		var secret pack.Secret
+		for _, field := range strings.Split(secretFlag, ",") {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				return nil, errors.Errorf("invalid secret %s: expected the form 'id=<id>,src=<path>'", style.Symbol(secretFlag))
+			}
+
+			switch kv[0] {
+			case "id":
+				secret.ID = kv[1]
+			case "src", "source":
+				secret.Source = kv[1]
+			default:
+				return nil, errors.Errorf("invalid secret %s: unknown key %s", style.Symbol(secretFlag), style.Symbol(kv[0]))
+			}
+		}
+
+		if secret.ID == "" || secret.Source == "" {
+			return nil, errors.Errorf("invalid secret %s: id and src are required", style.Symbol(secretFlag))
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+func parseCaches(cacheFlags []string) (buildCache, launchCache *pack.CacheConfig, err error) {
+	for _, cacheFlag := range cacheFlags {
+		var (
+			cacheType string
+			cfg       pack.CacheConfig
+		)
+		for _, field := range strings.Split(cacheFlag, ";") {
+			parts := strings.SplitN(field, "=", 2)
+			if len(parts) != 2 || parts[1] == "" {
+				return nil, nil, errors.Errorf("invalid cache %s: expected the form 'type=<build|launch>;format=<volume|image|bind>[;name=<name>][;source=<path>]'", style.Symbol(cacheFlag))
+			}
+
+			switch parts[0] {
+			case "type":
+				cacheType = parts[1]
+			case "format":
+				cfg.Format = pack.CacheFormat(parts[1])
+			case "name":
+				cfg.Name = parts[1]
+			case "source":
+				cfg.Source = parts[1]
+			default:
+				return nil, nil, errors.Errorf("invalid cache %s: unknown key %s", style.Symbol(cacheFlag), style.Symbol(parts[0]))
+			}
+		}
+
+		var target **pack.CacheConfig
+		switch cacheType {
+		case "build":
+			target = &buildCache
+		case "launch":
+			target = &launchCache
+		case "":
+			return nil, nil, errors.Errorf("invalid cache %s: type must be specified", style.Symbol(cacheFlag))
+		default:
+			return nil, nil, errors.Errorf("invalid cache %s: type %s is not supported", style.Symbol(cacheFlag), style.Symbol(cacheType))
+		}
+		if *target != nil {
+			return nil, nil, errors.Errorf("%s cache is specified more than once", cacheType)
+		}
+		*target = &cfg
+	}
+	return buildCache, launchCache, nil
+}
+
+func parseMemory(memoryFlag string) (int64, error) {
+	if memoryFlag == "" {
+		return 0, nil
+	}
+
+	memory, err := units.RAMInBytes(memoryFlag)
+	if err != nil {
+		return 0, errors.Wrapf(err, "parsing memory %s", style.Symbol(memoryFlag))
+	}
+	return memory, nil
+}
+
+func parseOutput(outputFlag string) (*pack.ImageOutput, error) {
+	if outputFlag == "" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(outputFlag, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, errors.Errorf("invalid output %s: expected the form 'oci-layout:<dir>' or 'oci-archive:<file>'", style.Symbol(outputFlag))
+	}
+
+	format := pack.ImageOutputFormat(parts[0])
+	switch format {
+	case pack.OCILayoutOutput, pack.OCIArchiveOutput:
+	default:
+		return nil, errors.Errorf("invalid output %s: format %s is not supported", style.Symbol(outputFlag), style.Symbol(parts[0]))
+	}
+	return &pack.ImageOutput{Format: format, Path: parts[1]}, nil
+}
+
+func parseSSH(sshFlags []string) (*pack.SSHConfig, error) {
+	if len(sshFlags) == 0 {
+		return nil, nil
+	}
+
+	sshConfig := &pack.SSHConfig{}
+	useDefault := false
+	for _, sshFlag := range sshFlags {
+		switch {
+		case sshFlag == "default":
+			useDefault = true
+		case strings.HasPrefix(sshFlag, "id="):
+			key := strings.TrimPrefix(sshFlag, "id=")
+			if key == "" {
+				return nil, errors.Errorf("invalid ssh %s: a key path is required", style.Symbol(sshFlag))
+			}
+			sshConfig.Keys = append(sshConfig.Keys, key)
+		default:
+			return nil, errors.Errorf("invalid ssh %s: expected 'default' or the form 'id=<path>'", style.Symbol(sshFlag))
+		}
+	}
+
+	if useDefault && len(sshConfig.Keys) > 0 {
+		return nil, errors.New("--ssh default cannot be combined with keys")
+	}
+	return sshConfig, nil
+}
+
 func parseEnv(envFiles []string, envVars []string) (map[string]string, error) {
 	env := map[string]string{}
 
@@ -292,6 +692,38 @@ func addEnvVar(env map[string]string, item string) map[string]string {
 	return env
 }
 
+// projectLock returns the project lock of the build. The lock is kept next to the project descriptor, or in the app
+// dir when there is none, and is used whenever it exists. Without the lock flags, an existing lock is ignored by builds
+// for several platforms or with the local executor, which cannot honour it.
+func projectLock(logger logging.Logger, flags BuildFlags, descriptorPath string) pack.LockOptions {
+	dir := flags.AppPath
+	if descriptorPath != "" {
+		dir = filepath.Dir(descriptorPath)
+	} else if fi, err := os.Stat(dir); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	path := filepath.Join(dir, project.LockFileName)
+
+	if !flags.Lock && !flags.UpdateLock {
+		if _, err := os.Stat(path); err != nil {
+			return pack.LockOptions{}
+		}
+
+		var unsupported string
+		switch {
+		case len(flags.Platforms) > 1:
+			unsupported = "building for multiple platforms"
+		case flags.Executor == string(pack.LocalExecutor):
+			unsupported = "the local executor"
+		}
+		if unsupported != "" {
+			logger.Warnf("Ignoring project lock %s, it is not supported with %s", style.Symbol(path), unsupported)
+			return pack.LockOptions{}
+		}
+	}
+	return pack.LockOptions{File: path, Update: flags.UpdateLock}
+}
+
 func parseProjectToml(appPath, descriptorPath string) (project.Descriptor, string, error) {
 	actualPath := descriptorPath
 	computePath := descriptorPath == ""
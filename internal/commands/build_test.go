@@ -2,12 +2,16 @@ package commands_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	pubcfg "github.com/buildpacks/pack/config"
 	"github.com/buildpacks/pack/project"
@@ -24,6 +28,7 @@ import (
 	"github.com/buildpacks/pack/internal/commands/testmocks"
 	"github.com/buildpacks/pack/internal/config"
 	ilogging "github.com/buildpacks/pack/internal/logging"
+	"github.com/buildpacks/pack/pkg/events"
 	h "github.com/buildpacks/pack/testhelpers"
 )
 
@@ -102,6 +107,41 @@ func testBuildCommand(t *testing.T, when spec.G, it spec.S) {
 					h.AssertNil(t, command.Execute())
 					h.AssertContains(t, outBuf.String(), "Builder 'my-builder' is trusted")
 				})
+
+				when("the trust is pinned to digests", func() {
+					var cfg config.Config
+
+					it.Before(func() {
+						cfg = config.Config{TrustedBuilders: []config.TrustedBuilder{{
+							Name:    "my-builder",
+							Digests: []string{"sha256:some-digest"},
+						}}}
+					})
+
+					it("passes the digests for any tag of the builder", func() {
+						mockClient.EXPECT().
+							Build(gomock.Any(), EqBuildOptionsWithBuilderTrust(pack.BuilderTrust{Digests: []string{"sha256:some-digest"}})).
+							Return(nil)
+
+						command := commands.Build(logger, cfg, mockClient)
+
+						logger.WantVerbose(true)
+						command.SetArgs([]string{"image", "--builder", "index.docker.io/library/my-builder:latest"})
+						h.AssertNil(t, command.Execute())
+						h.AssertContains(t, outBuf.String(), "Builder 'index.docker.io/library/my-builder:latest' is trusted by digest sha256:some-digest")
+					})
+
+					it("keeps the trust pinned when the builder is trusted with --trust-builder", func() {
+						mockClient.EXPECT().
+							Build(gomock.Any(), EqBuildOptionsWithBuilderTrust(pack.BuilderTrust{Digests: []string{"sha256:some-digest"}})).
+							Return(nil)
+
+						command := commands.Build(logger, cfg, mockClient)
+
+						command.SetArgs([]string{"image", "--builder", "my-builder", "--trust-builder"})
+						h.AssertNil(t, command.Execute())
+					})
+				})
 			})
 
 			when("the builder is suggested", func() {
@@ -329,10 +369,24 @@ func testBuildCommand(t *testing.T, when spec.G, it spec.S) {
 
 		when("a cache-image passed", func() {
 			when("--publish is not used", func() {
-				it("errors", func() {
+				it("succeeds", func() {
+					mockClient.EXPECT().
+						Build(gomock.Any(), EqBuildOptionsWithCacheImage("some-cache-image")).
+						Return(nil)
+
 					command.SetArgs([]string{"--builder", "my-builder", "image", "--cache-image", "some-cache-image"})
-					err := command.Execute()
-					h.AssertError(t, err, "cache-image flag requires the publish flag")
+					h.AssertNil(t, command.Execute())
+				})
+
+				when("--write-cache-image is used", func() {
+					it("writes the cache image", func() {
+						mockClient.EXPECT().
+							Build(gomock.Any(), EqBuildOptionsWithWriteCacheImage(true)).
+							Return(nil)
+
+						command.SetArgs([]string{"--builder", "my-builder", "image", "--cache-image", "some-cache-image", "--write-cache-image"})
+						h.AssertNil(t, command.Execute())
+					})
 				})
 			})
 			when("--publish is used", func() {
@@ -777,141 +831,990 @@ builder = "my-builder"
 				})
 			})
 		})
-	})
-}
 
-func EqBuildOptionsWithImage(builder, image string) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("Builder=%s and Image=%s", builder, image),
-		equals: func(o pack.BuildOptions) bool {
-			return o.Builder == builder && o.Image == image
-		},
-	}
-}
+		when("--output-format flag is provided", func() {
+			when("json", func() {
+				it("streams build events to the client", func() {
+					mockClient.EXPECT().
+						Build(gomock.Any(), EqBuildOptionsWithEventSink()).
+						Return(nil)
 
-func EqBuildOptionsDefaultProcess(defaultProc string) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("Default Process Type=%s", defaultProc),
-		equals: func(o pack.BuildOptions) bool {
-			return o.DefaultProcessType == defaultProc
-		},
-	}
-}
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--output-format", "json"})
+					h.AssertNil(t, command.Execute())
+				})
+			})
 
-func EqBuildOptionsWithPullPolicy(policy pubcfg.PullPolicy) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("PullPolicy=%s", policy),
-		equals: func(o pack.BuildOptions) bool {
-			return o.PullPolicy == policy
-		},
-	}
-}
+			when("the format is not supported", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--output-format", "xml"})
+					h.AssertError(t, command.Execute(), "output format 'xml' is not supported")
+				})
+			})
+		})
 
-func EqBuildOptionsWithCacheImage(cacheImage string) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("CacheImage=%s", cacheImage),
-		equals: func(o pack.BuildOptions) bool {
-			return o.CacheImage == cacheImage
-		},
-	}
-}
+		when("--secret flag is provided", func() {
+			it("forwards the secrets onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithSecrets([]pack.Secret{
+						{ID: "npmrc", Source: "./.npmrc"},
+						{ID: "token", Source: "/some/token"},
+					})).
+					Return(nil)
 
-func EqBuildOptionsWithLifecycleImage(lifecycleImage string) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("LifecycleImage=%s", lifecycleImage),
-		equals: func(o pack.BuildOptions) bool {
-			return o.LifecycleImage == lifecycleImage
-		},
-	}
-}
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--secret", "id=npmrc,src=./.npmrc", "--secret", "id=token,source=/some/token"})
+				h.AssertNil(t, command.Execute())
+			})
 
-func EqBuildOptionsWithNetwork(network string) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("Network=%s", network),
-		equals: func(o pack.BuildOptions) bool {
-			return o.ContainerConfig.Network == network
-		},
-	}
-}
+			when("the secret is missing a src", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--secret", "id=npmrc"})
+					h.AssertError(t, command.Execute(), "invalid secret 'id=npmrc': id and src are required")
+				})
+			})
 
-func EqBuildOptionsWithBuilder(builder string) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("Builder=%s", builder),
-		equals: func(o pack.BuildOptions) bool {
-			return o.Builder == builder
-		},
-	}
-}
+			when("the secret has an unknown key", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--secret", "id=npmrc,src=.npmrc,mode=0600"})
+					h.AssertError(t, command.Execute(), "unknown key 'mode'")
+				})
+			})
+		})
 
-func EqBuildOptionsWithTrustedBuilder(trustBuilder bool) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("Trust Builder=%t", trustBuilder),
-		equals: func(o pack.BuildOptions) bool {
-			return o.TrustBuilder == trustBuilder
-		},
-	}
-}
+		when("--output flag is provided", func() {
+			it("forwards an oci-layout output onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithOutput(&pack.ImageOutput{Format: pack.OCILayoutOutput, Path: "./out"})).
+					Return(nil)
 
-func EqBuildOptionsWithVolumes(volumes []string) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("Volumes=%s", volumes),
-		equals: func(o pack.BuildOptions) bool {
-			return reflect.DeepEqual(o.ContainerConfig.Volumes, volumes)
-		},
-	}
-}
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--output", "oci-layout:./out"})
+				h.AssertNil(t, command.Execute())
+			})
 
-func EqBuildOptionsWithAdditionalTags(additionalTags []string) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("AdditionalTags=%s", additionalTags),
-		equals: func(o pack.BuildOptions) bool {
-			return reflect.DeepEqual(o.AdditionalTags, additionalTags)
-		},
-	}
-}
+			it("forwards an oci-archive output onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithOutput(&pack.ImageOutput{Format: pack.OCIArchiveOutput, Path: "app.tar"})).
+					Return(nil)
 
-func EqBuildOptionsWithProjectDescriptor(descriptor project.Descriptor) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("Descriptor=%s", descriptor),
-		equals: func(o pack.BuildOptions) bool {
-			return reflect.DeepEqual(o.ProjectDescriptor, descriptor)
-		},
-	}
-}
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--output", "oci-archive:app.tar"})
+				h.AssertNil(t, command.Execute())
+			})
 
-func EqBuildOptionsWithEnv(env map[string]string) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("Env=%+v", env),
-		equals: func(o pack.BuildOptions) bool {
-			for k, v := range o.Env {
-				if env[k] != v {
-					return false
-				}
-			}
-			for k, v := range env {
-				if o.Env[k] != v {
-					return false
-				}
-			}
-			return true
-		},
-	}
-}
+			when("the format is not supported", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--output", "docker-archive:app.tar"})
+					h.AssertError(t, command.Execute(), "format 'docker-archive' is not supported")
+				})
+			})
 
-func EqBuildOptionsWithOverrideGroupID(gid int) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("GID=%d", gid),
-		equals: func(o pack.BuildOptions) bool {
-			return o.GroupID == gid
-		},
-	}
-}
+			when("the path is missing", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--output", "oci-layout"})
+					h.AssertError(t, command.Execute(), "expected the form 'oci-layout:<dir>' or 'oci-archive:<file>'")
+				})
+			})
 
-func EqBuildOptionsWithPreviousImage(prevImage string) gomock.Matcher {
-	return buildOptionsMatcher{
-		description: fmt.Sprintf("Previous image=%s", prevImage),
-		equals: func(o pack.BuildOptions) bool {
-			return o.PreviousImage == prevImage
+			when("--publish is provided", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--output", "oci-layout:./out", "--publish"})
+					h.AssertError(t, command.Execute(), "output flag cannot be combined with the publish flag")
+				})
+			})
+		})
+
+		when("--cache flag is provided", func() {
+			it("forwards a bind build cache onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithCaches(
+						&pack.CacheConfig{Format: pack.BindCacheFormat, Source: "./.cache"},
+						nil,
+					)).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--cache", "type=build;format=bind;source=./.cache"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("forwards build and launch caches onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithCaches(
+						&pack.CacheConfig{Format: pack.VolumeCacheFormat, Name: "shared-cache"},
+						&pack.CacheConfig{Format: pack.BindCacheFormat, Source: "/tmp/launch"},
+					)).
+					Return(nil)
+
+				command.SetArgs([]string{
+					"--builder", "my-builder", "image",
+					"--cache", "type=build;format=volume;name=shared-cache",
+					"--cache", "type=launch;format=bind;source=/tmp/launch",
+				})
+				h.AssertNil(t, command.Execute())
+			})
+
+			when("the type is missing", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--cache", "format=bind;source=./.cache"})
+					h.AssertError(t, command.Execute(), "type must be specified")
+				})
+			})
+
+			when("the type is not supported", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--cache", "type=run;format=volume"})
+					h.AssertError(t, command.Execute(), "type 'run' is not supported")
+				})
+			})
+
+			when("a key is unknown", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--cache", "type=build;src=./.cache"})
+					h.AssertError(t, command.Execute(), "unknown key 'src'")
+				})
+			})
+
+			when("a field is malformed", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--cache", "type=build;bind"})
+					h.AssertError(t, command.Execute(), "expected the form 'type=<build|launch>;format=<volume|image|bind>[;name=<name>][;source=<path>]'")
+				})
+			})
+
+			when("a type is specified more than once", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--cache", "type=build;format=volume", "--cache", "type=build;format=bind;source=./.cache"})
+					h.AssertError(t, command.Execute(), "build cache is specified more than once")
+				})
+			})
+
+			when("--cache-image is provided with a build cache", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--publish", "--cache-image", "some-cache-image", "--cache", "type=build;format=volume"})
+					h.AssertError(t, command.Execute(), "cache-image flag cannot be combined with a build cache")
+				})
+			})
+		})
+
+		when("--platform flag is provided", func() {
+			it("forwards the platforms onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithPlatforms([]string{"linux/amd64", "linux/arm64"})).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--publish", "--platform", "linux/amd64,linux/arm64"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			when("multiple platforms are given without --publish", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--platform", "linux/amd64", "--platform", "linux/arm64"})
+					h.AssertError(t, command.Execute(), "building for multiple platforms requires the publish flag")
+				})
+			})
+		})
+
+		when("resource limit flags are provided", func() {
+			it("passes the limits onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithResourceLimits(1.5, 512*1024*1024, 100, 10*time.Minute)).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--cpus", "1.5", "--memory", "512m", "--pids-limit", "100", "--phase-timeout", "10m"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			when("the memory is invalid", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--memory", "lots"})
+					h.AssertError(t, command.Execute(), "parsing memory 'lots'")
+				})
+			})
+
+			when("a limit is negative", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--cpus", "-1"})
+					h.AssertError(t, command.Execute(), "cpus flag must not be negative")
+
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--cpus", "0", "--pids-limit", "-1"})
+					h.AssertError(t, command.Execute(), "pids-limit flag must not be negative")
+
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--pids-limit", "0", "--phase-timeout", "-1s"})
+					h.AssertError(t, command.Execute(), "phase-timeout flag must not be negative")
+				})
+			})
+		})
+
+		when("--executor flag is provided", func() {
+			when("local", func() {
+				it("passes the executor onto the client without requiring a builder", func() {
+					mockClient.EXPECT().
+						Build(gomock.Any(), EqBuildOptionsWithExecutor(pack.LocalExecutor)).
+						Return(nil)
+
+					command.SetArgs([]string{"image", "--executor", "local", "--publish"})
+					h.AssertNil(t, command.Execute())
+				})
+
+				it("errors without --publish", func() {
+					command.SetArgs([]string{"image", "--executor", "local"})
+					h.AssertError(t, command.Execute(), "local executor requires the publish flag")
+				})
+			})
+
+			it("errors for unknown executors", func() {
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--executor", "kubernetes"})
+				h.AssertError(t, command.Execute(), "executor 'kubernetes' is not supported")
+			})
+		})
+
+		when("--label flag is provided", func() {
+			it("forwards the labels onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithLabels(map[string]string{
+						"org.opencontainers.image.revision": "abc123",
+						"com.example.cost-center":           "a=b",
+					})).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--label", "org.opencontainers.image.revision=abc123", "--label", "com.example.cost-center=a=b"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("errors when the label has no value", func() {
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--label", "com.example.team"})
+				h.AssertError(t, command.Execute(), "invalid label 'com.example.team': expected the form 'key=value'")
+			})
+		})
+
+		when("--creation-time flag is provided", func() {
+			it("forwards the unix timestamp onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithCreationTime(time.Unix(1600000000, 0))).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--creation-time", "1600000000"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("forwards the current time onto the client for 'now'", func() {
+				before := time.Now()
+				mockClient.EXPECT().
+					Build(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, opts pack.BuildOptions) {
+						h.AssertNotNil(t, opts.CreationTime)
+						h.AssertTrue(t, !opts.CreationTime.Before(before.Truncate(time.Second)))
+					}).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--creation-time", "now"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("errors for other values", func() {
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--creation-time", "yesterday"})
+				h.AssertError(t, command.Execute(), "invalid creation time 'yesterday': expected 'now' or a unix timestamp")
+			})
+
+			when("SOURCE_DATE_EPOCH is set", func() {
+				it.Before(func() {
+					h.AssertNil(t, os.Setenv("SOURCE_DATE_EPOCH", "1500000000"))
+				})
+
+				it.After(func() {
+					h.AssertNil(t, os.Unsetenv("SOURCE_DATE_EPOCH"))
+				})
+
+				it("prefers the flag", func() {
+					mockClient.EXPECT().
+						Build(gomock.Any(), EqBuildOptionsWithCreationTime(time.Unix(1600000000, 0))).
+						Return(nil)
+
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--creation-time", "1600000000"})
+					h.AssertNil(t, command.Execute())
+				})
+			})
+		})
+
+		when("SOURCE_DATE_EPOCH is set", func() {
+			it.Before(func() {
+				h.AssertNil(t, os.Setenv("SOURCE_DATE_EPOCH", "1500000000"))
+			})
+
+			it.After(func() {
+				h.AssertNil(t, os.Unsetenv("SOURCE_DATE_EPOCH"))
+			})
+
+			it("forwards it onto the client as the creation time", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithCreationTime(time.Unix(1500000000, 0))).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image"})
+				h.AssertNil(t, command.Execute())
+			})
+		})
+
+		when("--skip-git-metadata flag is provided", func() {
+			it("forwards the option onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithSkipGitMetadata(true)).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--skip-git-metadata"})
+				h.AssertNil(t, command.Execute())
+			})
+		})
+
+		when("--ssh flag is provided", func() {
+			it("forwards the default agent onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithSSH(&pack.SSHConfig{})).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--ssh", "default"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("forwards the keys onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithSSH(&pack.SSHConfig{Keys: []string{"/some/id_rsa", "./id_ed25519"}})).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--ssh", "id=/some/id_rsa", "--ssh", "id=./id_ed25519"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			when("default is combined with keys", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--ssh", "default", "--ssh", "id=/some/id_rsa"})
+					h.AssertError(t, command.Execute(), "--ssh default cannot be combined with keys")
+				})
+			})
+
+			when("the value is invalid", func() {
+				it("errors", func() {
+					command.SetArgs([]string{"--builder", "my-builder", "image", "--ssh", "/some/id_rsa"})
+					h.AssertError(t, command.Execute(), "invalid ssh '/some/id_rsa': expected 'default' or the form 'id=<path>'")
+				})
+			})
+		})
+
+		when("--ssh flag is not provided", func() {
+			it("does not forward an agent", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithSSH(nil)).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image"})
+				h.AssertNil(t, command.Execute())
+			})
+		})
+
+		when("--debug-on-failure flag is provided", func() {
+			it("forwards the option onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithDebugOnFailure()).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--debug-on-failure"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("connects the debug shell to stderr with json output", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithDebugShellOut(os.Stderr)).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--debug-on-failure", "--output-format", "json"})
+				h.AssertNil(t, command.Execute())
+			})
+		})
+
+		when("--dry-run flag is provided", func() {
+			it("runs a dry run build", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithDryRun()).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--dry-run"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), "Detected group:")
+				h.AssertNotContains(t, outBuf.String(), "Successfully built image")
+			})
+
+			it("supports yaml output", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithDryRun()).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--dry-run", "--output-format", "yaml"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), "run_image:")
+			})
+		})
+
+		when("--report-file flag is provided", func() {
+			var reportFile string
+
+			it.Before(func() {
+				tmpDir, err := ioutil.TempDir("", "build-report-file-test")
+				h.AssertNil(t, err)
+				reportFile = filepath.Join(tmpDir, "build-report.json")
+			})
+
+			it.After(func() {
+				h.AssertNil(t, os.RemoveAll(filepath.Dir(reportFile)))
+			})
+
+			emitBuild := func(_ context.Context, opts pack.BuildOptions) {
+				h.AssertNotNil(t, opts.EventSink)
+				opts.EventSink.Emit(events.Event{Type: events.BuildResolved, Builder: "my-builder", LifecycleVersion: "0.11.4"})
+				opts.EventSink.Emit(events.Event{Type: events.ImageExported, Image: "index.docker.io/library/image:latest", Digest: "sha256:some-digest"})
+			}
+
+			it("writes the build report to the file", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), gomock.Any()).
+					Do(emitBuild).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--report-file", reportFile})
+				h.AssertNil(t, command.Execute())
+
+				contents, err := ioutil.ReadFile(reportFile)
+				h.AssertNil(t, err)
+				h.AssertContains(t, string(contents), `"digest": "sha256:some-digest"`)
+				h.AssertContains(t, string(contents), `"version": "0.11.4"`)
+			})
+
+			it("still streams build events with json output", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), gomock.Any()).
+					Do(emitBuild).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--report-file", reportFile, "--output-format", "json"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), `"type":"image_exported"`)
+
+				_, err := os.Stat(reportFile)
+				h.AssertNil(t, err)
+			})
+
+			it("writes the file in quiet mode and leaves printing the image digest to the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), gomock.Any()).
+					Do(func(ctx context.Context, opts pack.BuildOptions) {
+						emitBuild(ctx, opts)
+						// the client prints the image and digest when output is suppressed
+						_, err := fmt.Fprintln(logger.Writer(), "index.docker.io/library/image@sha256:some-digest")
+						h.AssertNil(t, err)
+					}).
+					Return(nil)
+				logger.WantQuiet(true)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--report-file", reportFile})
+				h.AssertNil(t, command.Execute())
+				h.AssertEq(t, strings.Count(outBuf.String(), "index.docker.io/library/image@sha256:some-digest"), 1)
+
+				contents, err := ioutil.ReadFile(reportFile)
+				h.AssertNil(t, err)
+				h.AssertContains(t, string(contents), `"digest": "sha256:some-digest"`)
+			})
+
+			it("does not write the file when the build fails", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), gomock.Any()).
+					Return(errors.New("some-error"))
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--report-file", reportFile})
+				h.AssertError(t, command.Execute(), "some-error")
+
+				_, err := os.Stat(reportFile)
+				h.AssertTrue(t, os.IsNotExist(err))
+			})
+
+			it("errors with --dry-run", func() {
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--report-file", reportFile, "--dry-run"})
+				h.AssertError(t, command.Execute(), "report-file flag cannot be combined with the dry-run flag")
+			})
+		})
+
+		when("--sign-key flag is provided", func() {
+			it.Before(func() {
+				h.AssertNil(t, os.Setenv("COSIGN_PASSWORD", "some-password"))
+			})
+
+			it.After(func() {
+				h.AssertNil(t, os.Unsetenv("COSIGN_PASSWORD"))
+			})
+
+			it("forwards the key and the password from COSIGN_PASSWORD onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithSignKey("cosign.key", "some-password")).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--sign-key", "cosign.key", "--publish"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("errors without --publish", func() {
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--sign-key", "cosign.key"})
+				h.AssertError(t, command.Execute(), "sign-key flag requires the publish flag")
+			})
+		})
+
+		when("provenance flags are provided", func() {
+			it("forwards them onto the client", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithProvenance(pack.ProvenanceOptions{File: "provenance.json", Attach: true})).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--publish", "--provenance-file", "provenance.json", "--attach-provenance"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("errors when attaching without --publish", func() {
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--attach-provenance"})
+				h.AssertError(t, command.Execute(), "attach-provenance flag requires the publish flag")
+			})
+
+			it("errors when writing a file with --dry-run", func() {
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--provenance-file", "provenance.json", "--dry-run"})
+				h.AssertError(t, command.Execute(), "provenance-file flag cannot be combined with the dry-run flag")
+			})
+		})
+
+		when("lock flags are provided", func() {
+			var tmpDir string
+
+			it.Before(func() {
+				var err error
+				tmpDir, err = ioutil.TempDir("", "build-lock-test")
+				h.AssertNil(t, err)
+			})
+
+			it.After(func() {
+				h.AssertNil(t, os.RemoveAll(tmpDir))
+			})
+
+			it("writes the lock to the app dir", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithLock(pack.LockOptions{File: filepath.Join(tmpDir, "project.lock")})).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--path", tmpDir, "--lock"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("writes the lock next to the project descriptor", func() {
+				descriptorDir := filepath.Join(tmpDir, "descriptor")
+				h.AssertNil(t, os.MkdirAll(descriptorDir, 0755))
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(descriptorDir, "project.toml"), []byte("[project]\nname = \"some-app\"\n"), 0644))
+
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithLock(pack.LockOptions{File: filepath.Join(descriptorDir, "project.lock"), Update: true})).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--path", tmpDir, "--descriptor", filepath.Join(descriptorDir, "project.toml"), "--update-lock"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("uses an existing lock without the flags", func() {
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(tmpDir, "project.lock"), nil, 0644))
+
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithLock(pack.LockOptions{File: filepath.Join(tmpDir, "project.lock")})).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--path", tmpDir})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("ignores an existing lock when building for multiple platforms without the flags", func() {
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(tmpDir, "project.lock"), nil, 0644))
+
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithLock(pack.LockOptions{})).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--path", tmpDir, "--publish", "--platform", "linux/amd64,linux/arm64"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), "Warning: Ignoring project lock")
+				h.AssertContains(t, outBuf.String(), "it is not supported with building for multiple platforms")
+			})
+
+			it("ignores an existing lock with the local executor without the flags", func() {
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(tmpDir, "project.lock"), nil, 0644))
+
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithLock(pack.LockOptions{})).
+					Return(nil)
+
+				command.SetArgs([]string{"image", "--path", tmpDir, "--publish", "--executor", "local"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), "it is not supported with the local executor")
+			})
+
+			it("uses an existing lock when building for a single platform", func() {
+				h.AssertNil(t, ioutil.WriteFile(filepath.Join(tmpDir, "project.lock"), nil, 0644))
+
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithLock(pack.LockOptions{File: filepath.Join(tmpDir, "project.lock")})).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--path", tmpDir, "--platform", "linux/arm64"})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("does not use a lock that does not exist without the flags", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithLock(pack.LockOptions{})).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--path", tmpDir})
+				h.AssertNil(t, command.Execute())
+			})
+
+			it("errors with --dry-run", func() {
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--lock", "--dry-run"})
+				h.AssertError(t, command.Execute(), "lock flags cannot be combined with the dry-run flag")
+			})
+		})
+
+		when("--output-format is yaml without --dry-run", func() {
+			it("errors", func() {
+				command.SetArgs([]string{"--builder", "my-builder", "image", "--output-format", "yaml"})
+				h.AssertError(t, command.Execute(), "output format 'yaml' is not supported")
+			})
+		})
+
+		when("--output-format flag is not provided", func() {
+			it("does not stream build events", func() {
+				mockClient.EXPECT().
+					Build(gomock.Any(), EqBuildOptionsWithoutEventSink()).
+					Return(nil)
+
+				command.SetArgs([]string{"--builder", "my-builder", "image"})
+				h.AssertNil(t, command.Execute())
+			})
+		})
+	})
+}
+
+func EqBuildOptionsWithImage(builder, image string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Builder=%s and Image=%s", builder, image),
+		equals: func(o pack.BuildOptions) bool {
+			return o.Builder == builder && o.Image == image
+		},
+	}
+}
+
+func EqBuildOptionsDefaultProcess(defaultProc string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Default Process Type=%s", defaultProc),
+		equals: func(o pack.BuildOptions) bool {
+			return o.DefaultProcessType == defaultProc
+		},
+	}
+}
+
+func EqBuildOptionsWithPullPolicy(policy pubcfg.PullPolicy) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("PullPolicy=%s", policy),
+		equals: func(o pack.BuildOptions) bool {
+			return o.PullPolicy == policy
+		},
+	}
+}
+
+func EqBuildOptionsWithCacheImage(cacheImage string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("CacheImage=%s", cacheImage),
+		equals: func(o pack.BuildOptions) bool {
+			return o.CacheImage == cacheImage
+		},
+	}
+}
+
+func EqBuildOptionsWithWriteCacheImage(writeCacheImage bool) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("WriteCacheImage=%t", writeCacheImage),
+		equals: func(o pack.BuildOptions) bool {
+			return o.WriteCacheImage == writeCacheImage
+		},
+	}
+}
+
+func EqBuildOptionsWithLifecycleImage(lifecycleImage string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("LifecycleImage=%s", lifecycleImage),
+		equals: func(o pack.BuildOptions) bool {
+			return o.LifecycleImage == lifecycleImage
+		},
+	}
+}
+
+func EqBuildOptionsWithNetwork(network string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Network=%s", network),
+		equals: func(o pack.BuildOptions) bool {
+			return o.ContainerConfig.Network == network
+		},
+	}
+}
+
+func EqBuildOptionsWithBuilder(builder string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Builder=%s", builder),
+		equals: func(o pack.BuildOptions) bool {
+			return o.Builder == builder
+		},
+	}
+}
+
+func EqBuildOptionsWithTrustedBuilder(trustBuilder bool) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Trust Builder=%t", trustBuilder),
+		equals: func(o pack.BuildOptions) bool {
+			return o.TrustBuilder == trustBuilder
+		},
+	}
+}
+
+func EqBuildOptionsWithBuilderTrust(builderTrust pack.BuilderTrust) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("BuilderTrust=%+v", builderTrust),
+		equals: func(o pack.BuildOptions) bool {
+			return o.TrustBuilder && reflect.DeepEqual(o.BuilderTrust, builderTrust)
+		},
+	}
+}
+
+func EqBuildOptionsWithVolumes(volumes []string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Volumes=%s", volumes),
+		equals: func(o pack.BuildOptions) bool {
+			return reflect.DeepEqual(o.ContainerConfig.Volumes, volumes)
+		},
+	}
+}
+
+func EqBuildOptionsWithAdditionalTags(additionalTags []string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("AdditionalTags=%s", additionalTags),
+		equals: func(o pack.BuildOptions) bool {
+			return reflect.DeepEqual(o.AdditionalTags, additionalTags)
+		},
+	}
+}
+
+func EqBuildOptionsWithProjectDescriptor(descriptor project.Descriptor) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Descriptor=%+v", descriptor),
+		equals: func(o pack.BuildOptions) bool {
+			return reflect.DeepEqual(o.ProjectDescriptor, descriptor)
+		},
+	}
+}
+
+func EqBuildOptionsWithEnv(env map[string]string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Env=%+v", env),
+		equals: func(o pack.BuildOptions) bool {
+			for k, v := range o.Env {
+				if env[k] != v {
+					return false
+				}
+			}
+			for k, v := range env {
+				if o.Env[k] != v {
+					return false
+				}
+			}
+			return true
+		},
+	}
+}
+
+func EqBuildOptionsWithOverrideGroupID(gid int) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("GID=%d", gid),
+		equals: func(o pack.BuildOptions) bool {
+			return o.GroupID == gid
+		},
+	}
+}
+
+func EqBuildOptionsWithPreviousImage(prevImage string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Previous image=%s", prevImage),
+		equals: func(o pack.BuildOptions) bool {
+			return o.PreviousImage == prevImage
+		},
+	}
+}
+
+func EqBuildOptionsWithEventSink() gomock.Matcher {
+	return buildOptionsMatcher{
+		description: "EventSink set",
+		equals: func(o pack.BuildOptions) bool {
+			return o.EventSink != nil
+		},
+	}
+}
+
+func EqBuildOptionsWithSecrets(secrets []pack.Secret) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Secrets=%+v", secrets),
+		equals: func(o pack.BuildOptions) bool {
+			return reflect.DeepEqual(o.ContainerConfig.Secrets, secrets)
+		},
+	}
+}
+
+func EqBuildOptionsWithOutput(output *pack.ImageOutput) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Output=%+v", output),
+		equals: func(o pack.BuildOptions) bool {
+			return reflect.DeepEqual(o.Output, output)
+		},
+	}
+}
+
+func EqBuildOptionsWithCaches(buildCache, launchCache *pack.CacheConfig) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("BuildCache=%+v LaunchCache=%+v", buildCache, launchCache),
+		equals: func(o pack.BuildOptions) bool {
+			return reflect.DeepEqual(o.BuildCache, buildCache) && reflect.DeepEqual(o.LaunchCache, launchCache)
+		},
+	}
+}
+
+func EqBuildOptionsWithResourceLimits(cpus float64, memory, pidsLimit int64, phaseTimeout time.Duration) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("CPUs=%v Memory=%d PidsLimit=%d PhaseTimeout=%s", cpus, memory, pidsLimit, phaseTimeout),
+		equals: func(o pack.BuildOptions) bool {
+			return o.ContainerConfig.CPUs == cpus &&
+				o.ContainerConfig.Memory == memory &&
+				o.ContainerConfig.PidsLimit == pidsLimit &&
+				o.ContainerConfig.PhaseTimeout == phaseTimeout
+		},
+	}
+}
+
+func EqBuildOptionsWithExecutor(executor pack.ExecutorType) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Executor=%s", executor),
+		equals: func(o pack.BuildOptions) bool {
+			return o.Executor == executor
+		},
+	}
+}
+
+func EqBuildOptionsWithLabels(labels map[string]string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Labels=%s", labels),
+		equals: func(o pack.BuildOptions) bool {
+			return reflect.DeepEqual(o.Labels, labels)
+		},
+	}
+}
+
+func EqBuildOptionsWithCreationTime(creationTime time.Time) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("CreationTime=%s", creationTime),
+		equals: func(o pack.BuildOptions) bool {
+			return o.CreationTime != nil && o.CreationTime.Equal(creationTime)
+		},
+	}
+}
+
+func EqBuildOptionsWithSkipGitMetadata(skip bool) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("SkipGitMetadata=%t", skip),
+		equals: func(o pack.BuildOptions) bool {
+			return o.SkipGitMetadata == skip
+		},
+	}
+}
+
+func EqBuildOptionsWithPlatforms(platforms []string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Platforms=%s", platforms),
+		equals: func(o pack.BuildOptions) bool {
+			return reflect.DeepEqual(o.Platforms, platforms)
+		},
+	}
+}
+
+func EqBuildOptionsWithSSH(sshConfig *pack.SSHConfig) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("SSH=%+v", sshConfig),
+		equals: func(o pack.BuildOptions) bool {
+			return reflect.DeepEqual(o.ContainerConfig.SSH, sshConfig)
+		},
+	}
+}
+
+func EqBuildOptionsWithDebugOnFailure() gomock.Matcher {
+	return buildOptionsMatcher{
+		description: "DebugOnFailure=true",
+		equals: func(o pack.BuildOptions) bool {
+			return o.DebugOnFailure && o.DebugShellIn != nil && o.DebugShellOut == os.Stdout
+		},
+	}
+}
+
+func EqBuildOptionsWithDebugShellOut(out io.Writer) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("DebugShellOut=%v", out),
+		equals: func(o pack.BuildOptions) bool {
+			return o.DebugShellOut == out
+		},
+	}
+}
+
+func EqBuildOptionsWithDryRun() gomock.Matcher {
+	return buildOptionsMatcher{
+		description: "DryRun=true",
+		equals: func(o pack.BuildOptions) bool {
+			return o.DryRun && o.EventSink != nil
+		},
+	}
+}
+
+func EqBuildOptionsWithSignKey(key, password string) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("SignKey=%s", key),
+		equals: func(o pack.BuildOptions) bool {
+			return o.SignKey == key && o.SignKeyPassword == password
+		},
+	}
+}
+
+func EqBuildOptionsWithProvenance(provenance pack.ProvenanceOptions) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Provenance=%+v", provenance),
+		equals: func(o pack.BuildOptions) bool {
+			return o.Provenance == provenance
+		},
+	}
+}
+
+func EqBuildOptionsWithLock(lock pack.LockOptions) gomock.Matcher {
+	return buildOptionsMatcher{
+		description: fmt.Sprintf("Lock=%+v", lock),
+		equals: func(o pack.BuildOptions) bool {
+			return o.Lock == lock
+		},
+	}
+}
+
+func EqBuildOptionsWithoutEventSink() gomock.Matcher {
+	return buildOptionsMatcher{
+		description: "EventSink not set",
+		equals: func(o pack.BuildOptions) bool {
+			return o.EventSink == nil
 		},
 	}
 }
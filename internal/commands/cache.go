@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack/logging"
+)
+
+func NewCacheCommand(logger logging.Logger, client PackClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "cache",
+		Aliases: []string{"caches"},
+		Short:   "Interact with build and launch caches",
+		RunE:    nil,
+	}
+
+	cmd.AddCommand(CacheList(logger, client))
+	cmd.AddCommand(CacheInspect(logger, client))
+	cmd.AddCommand(CachePrune(logger, client))
+
+	AddHelpFlag(cmd, "cache")
+	return cmd
+}
+
+func humanSize(size int64) string {
+	if size < 0 {
+		return "N/A"
+	}
+	return units.HumanSize(float64(size))
+}
+
+func humanSince(t time.Time) string {
+	if t.IsZero() {
+		return "N/A"
+	}
+	return units.HumanDuration(time.Since(t)) + " ago"
+}
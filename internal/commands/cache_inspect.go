@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/style"
+	"github.com/buildpacks/pack/logging"
+)
+
+func CacheInspect(logger logging.Logger, client PackClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "inspect <image-name>",
+		Args:    cobra.ExactArgs(1),
+		Short:   "Show the caches of an image and the layers cached by each buildpack",
+		Example: "pack cache inspect my-app",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			details, err := client.InspectCache(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			writeCacheDetails(logger, details)
+			return nil
+		}),
+	}
+
+	AddHelpFlag(cmd, "inspect")
+	return cmd
+}
+
+func writeCacheDetails(logger logging.Logger, details *pack.CacheDetails) {
+	logger.Infof("Image: %s", style.Symbol(details.Image))
+	logger.Info("")
+	logger.Info("Caches:")
+	tw := tabwriter.NewWriter(logger.Writer(), 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "  NAME\tTYPE\tSIZE\tLAST USED")
+	for _, info := range details.Caches {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", info.Name, info.Type, humanSize(info.Size), humanSince(info.LastUsed))
+	}
+	tw.Flush()
+
+	logger.Info("")
+	if len(details.Buildpacks) == 0 {
+		logger.Info("Buildpacks: (none)")
+		return
+	}
+
+	logger.Info("Buildpacks:")
+	for _, bp := range details.Buildpacks {
+		logger.Infof("  %s@%s", bp.ID, bp.Version)
+		tw := tabwriter.NewWriter(logger.Writer(), 0, 0, 3, ' ', 0)
+		for _, layer := range bp.Layers {
+			fmt.Fprintf(tw, "    %s\t%s\t%s\n", layer.Name, layerFlags(layer), layer.SHA)
+		}
+		tw.Flush()
+	}
+}
+
+func layerFlags(layer pack.CachedLayer) string {
+	var flags []string
+	if layer.Build {
+		flags = append(flags, "build")
+	}
+	if layer.Launch {
+		flags = append(flags, "launch")
+	}
+	if layer.Cache {
+		flags = append(flags, "cache")
+	}
+	return strings.Join(flags, ",")
+}
@@ -0,0 +1,95 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/commands"
+	"github.com/buildpacks/pack/internal/commands/testmocks"
+	ilogging "github.com/buildpacks/pack/internal/logging"
+	"github.com/buildpacks/pack/logging"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestCacheInspectCommand(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "CacheInspectCommand", testCacheInspectCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testCacheInspectCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		cmd        *cobra.Command
+		logger     logging.Logger
+		outBuf     bytes.Buffer
+		mockClient *testmocks.MockPackClient
+	)
+
+	it.Before(func() {
+		logger = ilogging.NewLogWithWriters(&outBuf, &outBuf)
+		mockClient = testmocks.NewMockPackClient(gomock.NewController(t))
+		cmd = commands.CacheInspect(logger, mockClient)
+	})
+
+	when("#CacheInspect", func() {
+		it("shows the caches and the layers of each buildpack", func() {
+			mockClient.EXPECT().InspectCache(gomock.Any(), "my/app").Return(&pack.CacheDetails{
+				Image: "my/app",
+				Caches: []pack.CacheInfo{
+					{Name: "pack-cache-my_app_latest-0123456789ab.build", Type: "build", Size: 2000000, LastUsed: time.Now().Add(-49 * time.Hour)},
+				},
+				Buildpacks: []pack.CachedBuildpack{
+					{
+						ID:      "some/buildpack",
+						Version: "1.2.3",
+						Layers: []pack.CachedLayer{
+							{Name: "deps", SHA: "sha256:abc", Build: true, Cache: true},
+							{Name: "runtime", SHA: "sha256:def", Launch: true, Cache: true},
+						},
+					},
+				},
+			}, nil)
+
+			cmd.SetArgs([]string{"my/app"})
+			h.AssertNil(t, cmd.Execute())
+			h.AssertContains(t, outBuf.String(), "Image: 'my/app'")
+			h.AssertContains(t, outBuf.String(), "pack-cache-my_app_latest-0123456789ab.build   build   2MB    2 days ago")
+			h.AssertContains(t, outBuf.String(), `Buildpacks:
+  some/buildpack@1.2.3
+    deps      build,cache    sha256:abc
+    runtime   launch,cache   sha256:def`)
+		})
+
+		it("reports buildpacks without cached layers", func() {
+			mockClient.EXPECT().InspectCache(gomock.Any(), "my/app").Return(&pack.CacheDetails{
+				Image:  "my/app",
+				Caches: []pack.CacheInfo{{Name: "pack-cache-my_app_latest-0123456789ab.launch", Type: "launch"}},
+			}, nil)
+
+			cmd.SetArgs([]string{"my/app"})
+			h.AssertNil(t, cmd.Execute())
+			h.AssertContains(t, outBuf.String(), "Buildpacks: (none)")
+		})
+
+		it("returns errors from the client", func() {
+			mockClient.EXPECT().InspectCache(gomock.Any(), "my/app").Return(nil, errors.New("no caches found"))
+
+			cmd.SetArgs([]string{"my/app"})
+			h.AssertError(t, cmd.Execute(), "no caches found")
+		})
+
+		it("requires an image name", func() {
+			cmd.SetArgs([]string{})
+			h.AssertError(t, cmd.Execute(), "accepts 1 arg(s)")
+		})
+	})
+}
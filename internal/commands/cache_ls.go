@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack/logging"
+)
+
+func CacheList(logger logging.Logger, client PackClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Args:    cobra.NoArgs,
+		Short:   "List build and launch caches",
+		Example: "pack cache ls",
+		Long: "List the volumes in which build and launch layers are cached between builds.\n\n" +
+			"Caches are named after the image they are used for. When the image is not available locally, " +
+			"the image is shown as it appears in the volume name.\n\n" +
+			"Only the caches pack names after an image are listed. Caches given a name with " +
+			"--cache 'type=build;format=volume;name=<name>' and bind caches given with " +
+			"--cache 'type=build;format=bind;source=<path>' are not listed.",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			caches, err := client.ListCaches(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			if len(caches) == 0 {
+				logger.Info("No caches found")
+				return nil
+			}
+
+			tw := tabwriter.NewWriter(logger.Writer(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(tw, "IMAGE\tTYPE\tSIZE\tLAST USED")
+			for _, info := range caches {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", info.Image, info.Type, humanSize(info.Size), humanSince(info.LastUsed))
+			}
+			return tw.Flush()
+		}),
+	}
+
+	AddHelpFlag(cmd, "ls")
+	return cmd
+}
@@ -0,0 +1,74 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/commands"
+	"github.com/buildpacks/pack/internal/commands/testmocks"
+	ilogging "github.com/buildpacks/pack/internal/logging"
+	"github.com/buildpacks/pack/logging"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestCacheListCommand(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "CacheListCommand", testCacheListCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testCacheListCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		cmd        *cobra.Command
+		logger     logging.Logger
+		outBuf     bytes.Buffer
+		mockClient *testmocks.MockPackClient
+	)
+
+	it.Before(func() {
+		logger = ilogging.NewLogWithWriters(&outBuf, &outBuf)
+		mockClient = testmocks.NewMockPackClient(gomock.NewController(t))
+		cmd = commands.CacheList(logger, mockClient)
+	})
+
+	when("#CacheList", func() {
+		it("lists the caches", func() {
+			mockClient.EXPECT().ListCaches(gomock.Any()).Return([]pack.CacheInfo{
+				{Name: "pack-cache-my_app_latest-0123456789ab.build", Image: "my/app:latest", Type: "build", Size: 2000000, LastUsed: time.Now().Add(-49 * time.Hour)},
+				{Name: "pack-cache-other_latest-0123456789ab.launch", Image: "other_latest", Type: "launch", Size: -1, LastUsed: time.Now().Add(-time.Hour)},
+			}, nil)
+
+			h.AssertNil(t, cmd.Execute())
+			h.AssertContains(t, outBuf.String(), "IMAGE           TYPE     SIZE   LAST USED")
+			h.AssertContains(t, outBuf.String(), "my/app:latest   build    2MB    2 days ago")
+			h.AssertContains(t, outBuf.String(), "other_latest    launch   N/A    About an hour ago")
+		})
+
+		it("reports when there are no caches", func() {
+			mockClient.EXPECT().ListCaches(gomock.Any()).Return(nil, nil)
+
+			h.AssertNil(t, cmd.Execute())
+			h.AssertContains(t, outBuf.String(), "No caches found")
+		})
+
+		it("returns errors from the client", func() {
+			mockClient.EXPECT().ListCaches(gomock.Any()).Return(nil, errors.New("some error"))
+
+			h.AssertError(t, cmd.Execute(), "some error")
+		})
+
+		it("does not accept arguments", func() {
+			cmd.SetArgs([]string{"my/app"})
+			h.AssertError(t, cmd.Execute(), "unknown command")
+		})
+	})
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/style"
+	"github.com/buildpacks/pack/logging"
+)
+
+type CachePruneFlags struct {
+	OlderThan time.Duration
+}
+
+func CachePrune(logger logging.Logger, client PackClient) *cobra.Command {
+	var flags CachePruneFlags
+
+	cmd := &cobra.Command{
+		Use:     "prune",
+		Args:    cobra.NoArgs,
+		Short:   "Remove build and launch caches",
+		Example: "pack cache prune --older-than 168h",
+		Long: "Remove the build and launch caches which are not in use. Caches used within the duration given by --older-than are kept.\n\n" +
+			"Only the caches listed by `pack cache ls` are removed. Named volume caches and bind caches given with --cache " +
+			"are left in place; remove them with `docker volume rm` or by deleting their source directory.",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			if flags.OlderThan < 0 {
+				return errors.New("older-than must not be negative")
+			}
+
+			pruned, err := client.PruneCaches(cmd.Context(), pack.PruneCachesOptions{OlderThan: flags.OlderThan})
+			for _, info := range pruned {
+				logger.Infof("Removed %s cache %s for %s", info.Type, style.Symbol(info.Name), style.Symbol(info.Image))
+			}
+			if err != nil {
+				return err
+			}
+
+			var reclaimed int64
+			for _, info := range pruned {
+				if info.Size > 0 {
+					reclaimed += info.Size
+				}
+			}
+			logger.Infof("Removed %d caches, reclaimed %s", len(pruned), humanSize(reclaimed))
+			return nil
+		}),
+	}
+
+	cmd.Flags().DurationVar(&flags.OlderThan, "older-than", 0, "Only remove caches which have not been used for this long, for example 168h")
+	AddHelpFlag(cmd, "prune")
+	return cmd
+}
@@ -0,0 +1,80 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/commands"
+	"github.com/buildpacks/pack/internal/commands/testmocks"
+	ilogging "github.com/buildpacks/pack/internal/logging"
+	"github.com/buildpacks/pack/logging"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestCachePruneCommand(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "CachePruneCommand", testCachePruneCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testCachePruneCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		cmd        *cobra.Command
+		logger     logging.Logger
+		outBuf     bytes.Buffer
+		mockClient *testmocks.MockPackClient
+	)
+
+	it.Before(func() {
+		logger = ilogging.NewLogWithWriters(&outBuf, &outBuf)
+		mockClient = testmocks.NewMockPackClient(gomock.NewController(t))
+		cmd = commands.CachePrune(logger, mockClient)
+	})
+
+	when("#CachePrune", func() {
+		it("removes caches older than the given duration", func() {
+			mockClient.EXPECT().PruneCaches(gomock.Any(), pack.PruneCachesOptions{OlderThan: 168 * time.Hour}).Return([]pack.CacheInfo{
+				{Name: "pack-cache-my_app_latest-0123456789ab.build", Image: "my/app:latest", Type: "build", Size: 1500000},
+				{Name: "pack-cache-my_app_latest-0123456789ab.launch", Image: "my/app:latest", Type: "launch", Size: 500000},
+			}, nil)
+
+			cmd.SetArgs([]string{"--older-than", "168h"})
+			h.AssertNil(t, cmd.Execute())
+			h.AssertContains(t, outBuf.String(), "Removed build cache 'pack-cache-my_app_latest-0123456789ab.build' for 'my/app:latest'")
+			h.AssertContains(t, outBuf.String(), "Removed launch cache 'pack-cache-my_app_latest-0123456789ab.launch' for 'my/app:latest'")
+			h.AssertContains(t, outBuf.String(), "Removed 2 caches, reclaimed 2MB")
+		})
+
+		it("removes all unused caches by default", func() {
+			mockClient.EXPECT().PruneCaches(gomock.Any(), pack.PruneCachesOptions{}).Return(nil, nil)
+
+			cmd.SetArgs([]string{})
+			h.AssertNil(t, cmd.Execute())
+			h.AssertContains(t, outBuf.String(), "Removed 0 caches, reclaimed 0B")
+		})
+
+		it("reports the caches removed before an error", func() {
+			mockClient.EXPECT().PruneCaches(gomock.Any(), gomock.Any()).Return([]pack.CacheInfo{
+				{Name: "pack-cache-my_app_latest-0123456789ab.build", Image: "my/app:latest", Type: "build"},
+			}, errors.New("removing cache"))
+
+			cmd.SetArgs([]string{})
+			h.AssertError(t, cmd.Execute(), "removing cache")
+			h.AssertContains(t, outBuf.String(), "Removed build cache 'pack-cache-my_app_latest-0123456789ab.build'")
+		})
+
+		it("errors for negative durations", func() {
+			cmd.SetArgs([]string{"--older-than", "-1h"})
+			h.AssertError(t, cmd.Execute(), "older-than must not be negative")
+		})
+	})
+}
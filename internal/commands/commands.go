@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
@@ -16,10 +17,15 @@ import (
 	"github.com/buildpacks/pack/logging"
 )
 
+// signKeyPasswordEnv is the environment variable holding the password of an encrypted signing key, as read by cosign.
+const signKeyPasswordEnv = "COSIGN_PASSWORD"
+
 //go:generate mockgen -package testmocks -destination testmocks/mock_pack_client.go github.com/buildpacks/pack/internal/commands PackClient
 type PackClient interface {
 	InspectBuilder(string, bool, ...pack.BuilderInspectionModifier) (*pack.BuilderInfo, error)
 	InspectImage(string, bool) (*pack.ImageInfo, error)
+	InspectLayoutImage(string, string) (*pack.ImageInfo, error)
+	VerifyImage(context.Context, pack.VerifyImageOptions) (name.Digest, error)
 	Rebase(context.Context, pack.RebaseOptions) error
 	CreateBuilder(context.Context, pack.CreateBuilderOptions) error
 	NewBuildpack(context.Context, pack.NewBuildpackOptions) error
@@ -29,19 +35,31 @@ type PackClient interface {
 	YankBuildpack(pack.YankBuildpackOptions) error
 	InspectBuildpack(pack.InspectBuildpackOptions) (*pack.BuildpackInfo, error)
 	PullBuildpack(context.Context, pack.PullBuildpackOptions) error
+	ListCaches(context.Context) ([]pack.CacheInfo, error)
+	InspectCache(context.Context, string) (*pack.CacheDetails, error)
+	PruneCaches(context.Context, pack.PruneCachesOptions) ([]pack.CacheInfo, error)
+	PruneSystem(context.Context) (pack.PrunedResources, error)
+}
+
+// quietableLogger is implemented by loggers whose output can be reduced to warnings and errors.
+type quietableLogger interface {
+	WantQuiet(f bool)
 }
 
 func AddHelpFlag(cmd *cobra.Command, commandName string) {
 	cmd.Flags().BoolP("help", "h", false, fmt.Sprintf("Help for '%s'", commandName))
 }
 
+// CreateCancellableContext returns a context that is cancelled on the first interrupt or termination signal,
+// so that a command can clean up the resources it created. A second signal terminates pack immediately.
 func CreateCancellableContext() context.Context {
-	signals := make(chan os.Signal)
+	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 	ctx, cancel := context.WithCancel(context.Background())
 
 	go func() {
 		<-signals
+		signal.Stop(signals)
 		cancel()
 	}()
 
@@ -88,13 +106,42 @@ func getMirrors(config config.Config) map[string][]string {
 }
 
 func isTrustedBuilder(cfg config.Config, builder string) bool {
+	_, ok := findTrustedBuilder(cfg, builder)
+	return ok
+}
+
+// findTrustedBuilder returns the record trusting builder. A record pinned to digests or a signing key matches
+// any image of its repository, as whether the image is trusted is decided by the digest it resolves to.
+func findTrustedBuilder(cfg config.Config, builder string) (config.TrustedBuilder, bool) {
 	for _, trustedBuilder := range cfg.TrustedBuilders {
 		if builder == trustedBuilder.Name {
-			return true
+			return trustedBuilder, true
+		}
+		if isPinned(trustedBuilder) && sameRepository(builder, trustedBuilder.Name) {
+			return trustedBuilder, true
 		}
 	}
 
-	return isSuggestedBuilder(builder)
+	if isSuggestedBuilder(builder) {
+		return config.TrustedBuilder{Name: builder}, true
+	}
+	return config.TrustedBuilder{}, false
+}
+
+func isPinned(trustedBuilder config.TrustedBuilder) bool {
+	return len(trustedBuilder.Digests) > 0 || trustedBuilder.SigningKey != ""
+}
+
+func sameRepository(image, otherImage string) bool {
+	ref, err := name.ParseReference(image, name.WeakValidation)
+	if err != nil {
+		return false
+	}
+	otherRef, err := name.ParseReference(otherImage, name.WeakValidation)
+	if err != nil {
+		return false
+	}
+	return ref.Context().Name() == otherRef.Context().Name()
 }
 
 func deprecationWarning(logger logging.Logger, oldCmd, replacementCmd string) {
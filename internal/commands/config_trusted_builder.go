@@ -1,16 +1,26 @@
 package commands
 
 import (
+	"path/filepath"
+	"reflect"
 	"sort"
+	"strings"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/buildpacks/pack/internal/config"
+	"github.com/buildpacks/pack/internal/sign"
 	"github.com/buildpacks/pack/internal/style"
 	"github.com/buildpacks/pack/logging"
 )
 
+type TrustedBuilderFlags struct {
+	Digest     string
+	SigningKey string
+}
+
 func ConfigTrustedBuilder(logger logging.Logger, cfg config.Config, cfgPath string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "trusted-builders",
@@ -32,9 +42,17 @@ func ConfigTrustedBuilder(logger logging.Logger, cfg config.Config, cfgPath stri
 	listCmd.Example = "pack config trusted-builders list"
 	cmd.AddCommand(listCmd)
 
-	addCmd := generateAdd("trusted-builders", logger, cfg, cfgPath, addTrustedBuilder)
-	addCmd.Long = "Trust builder.\n\nWhen building with this builder, all lifecycle phases will be run in a single container using the builder image."
-	addCmd.Example = "pack config trusted-builders add cnbs/sample-stack-run:bionic"
+	var addFlags TrustedBuilderFlags
+	addCmd := generateAdd("trusted-builders", logger, cfg, cfgPath, func(args []string, logger logging.Logger, cfg config.Config, cfgPath string) error {
+		return addTrustedBuilder(args, addFlags, logger, cfg, cfgPath)
+	})
+	addCmd.Use = "add <builder> [--digest <digest> | --signing-key <key>]"
+	addCmd.Long = "Trust builder.\n\nWhen building with this builder, all lifecycle phases will be run in a single container using the builder image.\n\n" +
+		"By default, whichever image the builder name resolves to is trusted. Provide a digest, either with --digest or as " +
+		"<builder>@<digest>, to trust only that image, or a cosign public key with --signing-key to trust only images signed with it."
+	addCmd.Example = "pack config trusted-builders add cnbs/sample-stack-run:bionic --digest sha256:..."
+	addCmd.Flags().StringVar(&addFlags.Digest, "digest", "", "Trust only the builder image with this digest\nRepeat the command to trust several digests")
+	addCmd.Flags().StringVar(&addFlags.SigningKey, "signing-key", "", "Trust only builder images signed with this cosign public key")
 	cmd.AddCommand(addCmd)
 
 	rmCmd := generateRemove("trusted-builders", logger, cfg, cfgPath, removeTrustedBuilder)
@@ -46,24 +64,101 @@ func ConfigTrustedBuilder(logger logging.Logger, cfg config.Config, cfgPath stri
 	return cmd
 }
 
-func addTrustedBuilder(args []string, logger logging.Logger, cfg config.Config, cfgPath string) error {
-	imageName := args[0]
-	builderToTrust := config.TrustedBuilder{Name: imageName}
+func addTrustedBuilder(args []string, flags TrustedBuilderFlags, logger logging.Logger, cfg config.Config, cfgPath string) error {
+	builderToTrust, err := parseTrustedBuilder(args[0], flags.Digest, flags.SigningKey)
+	if err != nil {
+		return err
+	}
+	imageName := builderToTrust.Name
 
-	if isTrustedBuilder(cfg, imageName) {
-		logger.Infof("Builder %s is already trusted", style.Symbol(imageName))
-		return nil
+	idx := -1
+	for i, trustedBuilder := range cfg.TrustedBuilders {
+		if trustedBuilder.Name == imageName {
+			idx = i
+			break
+		}
+	}
+
+	if idx == -1 {
+		if !isPinned(builderToTrust) && isSuggestedBuilder(imageName) {
+			logger.Infof("Builder %s is already trusted", style.Symbol(imageName))
+			return nil
+		}
+		cfg.TrustedBuilders = append(cfg.TrustedBuilders, builderToTrust)
+	} else {
+		existing := cfg.TrustedBuilders[idx]
+		if len(existing.Digests) > 0 && len(builderToTrust.Digests) > 0 {
+			builderToTrust.Digests = dedupAndSortSlice(append(existing.Digests, builderToTrust.Digests...))
+		}
+		if reflect.DeepEqual(existing, builderToTrust) {
+			logger.Infof("Builder %s is already trusted", style.Symbol(imageName))
+			return nil
+		}
+		cfg.TrustedBuilders[idx] = builderToTrust
 	}
 
-	cfg.TrustedBuilders = append(cfg.TrustedBuilders, builderToTrust)
 	if err := config.Write(cfg, cfgPath); err != nil {
 		return errors.Wrap(err, "writing config")
 	}
-	logger.Infof("Builder %s is now trusted", style.Symbol(imageName))
+	if isPinned(builderToTrust) {
+		logger.Infof("Builder %s is now trusted by %s", style.Symbol(imageName), trustMode(builderToTrust))
+	} else {
+		logger.Infof("Builder %s is now trusted", style.Symbol(imageName))
+	}
 
 	return nil
 }
 
+// parseTrustedBuilder returns the record trusting imageName, which may include a digest, by the given digest or signing key.
+func parseTrustedBuilder(imageName, digest, signingKey string) (config.TrustedBuilder, error) {
+	if parts := strings.SplitN(imageName, "@", 2); len(parts) == 2 {
+		if digest != "" && digest != parts[1] {
+			return config.TrustedBuilder{}, errors.Errorf("builder %s already includes a digest, which differs from %s", style.Symbol(imageName), style.Symbol(digest))
+		}
+		imageName, digest = parts[0], parts[1]
+	}
+
+	ref, err := name.ParseReference(imageName, name.WeakValidation)
+	if err != nil {
+		return config.TrustedBuilder{}, errors.Wrapf(err, "invalid builder %s", style.Symbol(imageName))
+	}
+	trustedBuilder := config.TrustedBuilder{Name: imageName}
+
+	if digest != "" && signingKey != "" {
+		return config.TrustedBuilder{}, errors.New("a builder can be trusted by digest or by signing key, not both")
+	}
+
+	if digest != "" {
+		if _, err := name.NewDigest(ref.Context().Name()+"@"+digest, name.WeakValidation); err != nil {
+			return config.TrustedBuilder{}, errors.Wrapf(err, "invalid digest %s", style.Symbol(digest))
+		}
+		trustedBuilder.Digests = []string{digest}
+	}
+
+	if signingKey != "" {
+		if _, err := sign.LoadPublicKey(signingKey); err != nil {
+			return config.TrustedBuilder{}, err
+		}
+		if trustedBuilder.SigningKey, err = filepath.Abs(signingKey); err != nil {
+			return config.TrustedBuilder{}, err
+		}
+	}
+
+	return trustedBuilder, nil
+}
+
+// trustMode describes which images of the trusted builder are trusted.
+func trustMode(trustedBuilder config.TrustedBuilder) string {
+	switch {
+	case len(trustedBuilder.Digests) > 0:
+		return "digest " + strings.Join(trustedBuilder.Digests, ", ")
+	case trustedBuilder.SigningKey != "":
+		return "signing key " + trustedBuilder.SigningKey
+	default:
+		return "name"
+	}
+}
+
 func removeTrustedBuilder(args []string, logger logging.Logger, cfg config.Config, cfgPath string) error {
 	builder := args[0]
 
@@ -100,18 +195,25 @@ func removeTrustedBuilder(args []string, logger logging.Logger, cfg config.Confi
 func listTrustedBuilders(args []string, logger logging.Logger, cfg config.Config) {
 	logger.Info("Trusted Builders:")
 
-	var trustedBuilders []string
+	var trustedBuilders []config.TrustedBuilder
 	for _, builder := range suggestedBuilders {
-		trustedBuilders = append(trustedBuilders, builder.Image)
+		trustedBuilders = append(trustedBuilders, config.TrustedBuilder{Name: builder.Image})
 	}
 
-	for _, builder := range cfg.TrustedBuilders {
-		trustedBuilders = append(trustedBuilders, builder.Name)
-	}
+	trustedBuilders = append(trustedBuilders, cfg.TrustedBuilders...)
 
-	sort.Strings(trustedBuilders)
+	sort.SliceStable(trustedBuilders, func(i, j int) bool {
+		return trustedBuilders[i].Name < trustedBuilders[j].Name
+	})
+
+	width := 0
+	for _, builder := range trustedBuilders {
+		if len(builder.Name) > width {
+			width = len(builder.Name)
+		}
+	}
 
 	for _, builder := range trustedBuilders {
-		logger.Infof("  %s", builder)
+		logger.Infof("  %-*s    trusted by %s", width, builder.Name, trustMode(builder))
 	}
 }
@@ -2,6 +2,11 @@ package commands_test
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -118,6 +123,22 @@ func testTrustedBuilderCommand(t *testing.T, when spec.G, it spec.S) {
 				"paketobuildpacks/builder:tiny",
 			)
 		})
+
+		it("shows how each builder is trusted", func() {
+			cfg := config.Config{TrustedBuilders: []config.TrustedBuilder{
+				{Name: "by-name/builder"},
+				{Name: "by-digest/builder", Digests: []string{"sha256:some-digest"}},
+				{Name: "by-key/builder:tag", SigningKey: "/keys/cosign.pub"},
+			}}
+			command = commands.ConfigTrustedBuilder(logger, cfg, configPath)
+			command.SetArgs([]string{"list"})
+			h.AssertNil(t, command.Execute())
+
+			h.AssertContainsMatch(t, outBuf.String(), `by-digest/builder\s+trusted by digest sha256:some-digest`)
+			h.AssertContainsMatch(t, outBuf.String(), `by-key/builder:tag\s+trusted by signing key /keys/cosign.pub`)
+			h.AssertContainsMatch(t, outBuf.String(), `by-name/builder\s+trusted by name`)
+			h.AssertContainsMatch(t, outBuf.String(), `paketobuildpacks/builder:base\s+trusted by name`)
+		})
 	})
 
 	when("add", func() {
@@ -169,6 +190,97 @@ func testTrustedBuilderCommand(t *testing.T, when spec.G, it spec.S) {
 				})
 			})
 
+			when("a digest is provided", func() {
+				const (
+					digest      = "sha256:363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4"
+					otherDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+				)
+
+				it("records the digest", func() {
+					command.SetArgs(append(args, "some-builder", "--digest", digest))
+					h.AssertNil(t, command.Execute())
+
+					b, err := ioutil.ReadFile(configPath)
+					h.AssertNil(t, err)
+					h.AssertContains(t, string(b), `[[trusted-builders]]
+  name = "some-builder"
+  digests = ["`+digest+`"]`)
+					h.AssertContains(t, outBuf.String(), "Builder 'some-builder' is now trusted by digest "+digest)
+				})
+
+				it("records the digest of builders given by digest", func() {
+					command.SetArgs(append(args, "some-builder@"+digest))
+					h.AssertNil(t, command.Execute())
+
+					b, err := ioutil.ReadFile(configPath)
+					h.AssertNil(t, err)
+					h.AssertContains(t, string(b), `name = "some-builder"
+  digests = ["`+digest+`"]`)
+				})
+
+				it("adds digests to the digests already trusted", func() {
+					command.SetArgs(append(args, "some-builder", "--digest", digest))
+					h.AssertNil(t, command.Execute())
+
+					cfg, err := config.Read(configPath)
+					h.AssertNil(t, err)
+					command = commands.ConfigTrustedBuilder(logger, cfg, configPath)
+					command.SetArgs(append(args, "some-builder", "--digest", otherDigest))
+					h.AssertNil(t, command.Execute())
+
+					cfg, err = config.Read(configPath)
+					h.AssertNil(t, err)
+					h.AssertEq(t, cfg.TrustedBuilders, []config.TrustedBuilder{{Name: "some-builder", Digests: []string{otherDigest, digest}}})
+				})
+
+				it("pins builders trusted by name", func() {
+					cfg := newConfigManager(t, configPath).configWithTrustedBuilders("some-builder")
+					command = commands.ConfigTrustedBuilder(logger, cfg, configPath)
+					command.SetArgs(append(args, "some-builder", "--digest", digest))
+					h.AssertNil(t, command.Execute())
+
+					cfg, err := config.Read(configPath)
+					h.AssertNil(t, err)
+					h.AssertEq(t, cfg.TrustedBuilders, []config.TrustedBuilder{{Name: "some-builder", Digests: []string{digest}}})
+				})
+
+				it("errors for invalid digests", func() {
+					command.SetArgs(append(args, "some-builder", "--digest", "not-a-digest"))
+					h.AssertError(t, command.Execute(), "invalid digest 'not-a-digest'")
+				})
+
+				it("errors when combined with a signing key", func() {
+					command.SetArgs(append(args, "some-builder", "--digest", digest, "--signing-key", "cosign.pub"))
+					h.AssertError(t, command.Execute(), "a builder can be trusted by digest or by signing key, not both")
+				})
+			})
+
+			when("a signing key is provided", func() {
+				it("records the path of the key", func() {
+					key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+					h.AssertNil(t, err)
+					der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+					h.AssertNil(t, err)
+					keyPath := filepath.Join(tempPackHome, "cosign.pub")
+					h.AssertNil(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+
+					command.SetArgs(append(args, "some-builder:tag", "--signing-key", keyPath))
+					h.AssertNil(t, command.Execute())
+
+					cfg, err := config.Read(configPath)
+					h.AssertNil(t, err)
+					h.AssertEq(t, cfg.TrustedBuilders, []config.TrustedBuilder{{Name: "some-builder:tag", SigningKey: keyPath}})
+				})
+
+				it("errors when the key cannot be read", func() {
+					command.SetArgs(append(args, "some-builder", "--signing-key", filepath.Join(tempPackHome, "missing.pub")))
+					h.AssertNotNil(t, command.Execute())
+
+					_, err := os.Stat(configPath)
+					h.AssertTrue(t, os.IsNotExist(err))
+				})
+			})
+
 			when("builder is a suggested builder", func() {
 				it("does nothing", func() {
 					h.AssertNil(t, ioutil.WriteFile(configPath, []byte(""), os.ModePerm))
@@ -0,0 +1,20 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack/logging"
+)
+
+func NewImageCommand(logger logging.Logger, client PackClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "image",
+		Short: "Interact with app images",
+		RunE:  nil,
+	}
+
+	cmd.AddCommand(ImageVerify(logger, client))
+
+	AddHelpFlag(cmd, "image")
+	return cmd
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/style"
+	"github.com/buildpacks/pack/logging"
+)
+
+type ImageVerifyFlags struct {
+	Key string
+}
+
+func ImageVerify(logger logging.Logger, client PackClient) *cobra.Command {
+	var flags ImageVerifyFlags
+	cmd := &cobra.Command{
+		Use:     "verify <image-name> --key <public-key-path>",
+		Args:    cobra.ExactArgs(1),
+		Short:   "Verify the signature of an image in a registry",
+		Long:    "Verify that an image in a registry was signed with the private key of the given cosign public key, such as by `pack build --sign-key`.",
+		Example: "pack image verify registry.example.com/my-app --key cosign.pub",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			if flags.Key == "" {
+				return errors.New("Please provide a public key path, using --key.")
+			}
+
+			digest, err := client.VerifyImage(cmd.Context(), pack.VerifyImageOptions{
+				Image: args[0],
+				Key:   flags.Key,
+			})
+			if err != nil {
+				return err
+			}
+			logger.Infof("Verified signature of %s", style.Symbol(digest.Name()))
+			return nil
+		}),
+	}
+
+	cmd.Flags().StringVar(&flags.Key, "key", "", "Path to the cosign public key the image must be signed with (required)")
+
+	AddHelpFlag(cmd, "verify")
+	return cmd
+}
@@ -0,0 +1,75 @@
+package commands_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/heroku/color"
+	"github.com/pkg/errors"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/commands"
+	"github.com/buildpacks/pack/internal/commands/testmocks"
+	ilogging "github.com/buildpacks/pack/internal/logging"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestImageVerifyCommand(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "ImageVerifyCommand", testImageVerifyCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testImageVerifyCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		command        *cobra.Command
+		outBuf         bytes.Buffer
+		mockController *gomock.Controller
+		mockClient     *testmocks.MockPackClient
+	)
+
+	it.Before(func() {
+		logger := ilogging.NewLogWithWriters(&outBuf, &outBuf)
+		mockController = gomock.NewController(t)
+		mockClient = testmocks.NewMockPackClient(mockController)
+		command = commands.ImageVerify(logger, mockClient)
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("#ImageVerify", func() {
+		it("verifies the image with the key", func() {
+			digest, err := name.NewDigest("registry.example.com/some/app@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+			h.AssertNil(t, err)
+			mockClient.EXPECT().
+				VerifyImage(gomock.Any(), pack.VerifyImageOptions{Image: "registry.example.com/some/app", Key: "cosign.pub"}).
+				Return(digest, nil)
+
+			command.SetArgs([]string{"registry.example.com/some/app", "--key", "cosign.pub"})
+			h.AssertNil(t, command.Execute())
+			h.AssertContains(t, outBuf.String(), "Verified signature of")
+			h.AssertContains(t, outBuf.String(), "registry.example.com/some/app@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+		})
+
+		it("errors when the signature does not verify", func() {
+			mockClient.EXPECT().
+				VerifyImage(gomock.Any(), gomock.Any()).
+				Return(name.Digest{}, errors.New("no signature of 'some/app' matches the key"))
+
+			command.SetArgs([]string{"some/app", "--key", "cosign.pub"})
+			h.AssertError(t, command.Execute(), "no signature of 'some/app' matches the key")
+		})
+
+		it("errors without --key", func() {
+			command.SetArgs([]string{"some/app"})
+			h.AssertError(t, command.Execute(), "Please provide a public key path, using --key.")
+		})
+	})
+}
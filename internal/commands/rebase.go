@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"os"
+
 	"github.com/pkg/errors"
 
 	"github.com/spf13/cobra"
@@ -27,6 +29,9 @@ func Rebase(logger logging.Logger, cfg config.Config, client PackClient) *cobra.
 		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
 			opts.RepoName = args[0]
 			opts.AdditionalMirrors = getMirrors(cfg)
+			if opts.SignKey != "" {
+				opts.SignKeyPassword = os.Getenv(signKeyPasswordEnv)
+			}
 
 			var err error
 			stringPolicy := policy
@@ -48,6 +53,7 @@ func Rebase(logger logging.Logger, cfg config.Config, client PackClient) *cobra.
 
 	cmd.Flags().BoolVar(&opts.Publish, "publish", false, "Publish to registry")
 	cmd.Flags().StringVar(&opts.RunImage, "run-image", "", "Run image to use for rebasing")
+	cmd.Flags().StringVar(&opts.SignKey, "sign-key", "", "Path to a cosign private key to sign the rebased image with, decrypted with "+signKeyPasswordEnv+".\nRequires --publish.")
 	cmd.Flags().StringVar(&policy, "pull-policy", "", "Pull policy to use. Accepted values are always, never, and if-not-present. The default is always")
 
 	AddHelpFlag(cmd, "rebase")
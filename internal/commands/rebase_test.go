@@ -2,6 +2,7 @@ package commands_test
 
 import (
 	"bytes"
+	"os"
 	"testing"
 
 	"github.com/heroku/color"
@@ -117,6 +118,28 @@ func testRebaseCommand(t *testing.T, when spec.G, it spec.S) {
 				})
 			})
 
+			when("--sign-key", func() {
+				it.Before(func() {
+					h.AssertNil(t, os.Setenv("COSIGN_PASSWORD", "some-password"))
+				})
+
+				it.After(func() {
+					h.AssertNil(t, os.Unsetenv("COSIGN_PASSWORD"))
+				})
+
+				it("forwards the key and the password from COSIGN_PASSWORD", func() {
+					opts.Publish = true
+					opts.SignKey = "cosign.key"
+					opts.SignKeyPassword = "some-password"
+					mockClient.EXPECT().
+						Rebase(gomock.Any(), opts).
+						Return(nil)
+
+					command.SetArgs([]string{repoName, "--publish", "--sign-key", "cosign.key"})
+					h.AssertNil(t, command.Execute())
+				})
+			})
+
 			when("--pull-policy unknown-policy", func() {
 				it("fails to run", func() {
 					command.SetArgs([]string{repoName, "--pull-policy", "unknown-policy"})
@@ -15,6 +15,7 @@ import (
 
 	"github.com/buildpacks/pack/internal/build"
 	"github.com/buildpacks/pack/internal/builder"
+	"github.com/buildpacks/pack/internal/dockerhost"
 	"github.com/buildpacks/pack/logging"
 )
 
@@ -53,6 +54,9 @@ Default Lifecycle Version:  {{ .DefaultLifecycleVersion }}
 
 Supported Platform APIs:  {{ .SupportedPlatformAPIs }}
 
+Docker:
+  Endpoint:  {{ .DockerEndpoint }}
+
 Config:
 {{ .Config -}}`))
 
@@ -73,16 +77,38 @@ Config:
 
 	platformAPIs := strings.Join(build.SupportedPlatformAPIVersions.AsStrings(), ", ")
 
+	var dockerEndpoint string
+	if endpoint, err := dockerhost.Resolve(); err != nil {
+		dockerEndpoint = fmt.Sprintf("(unable to resolve: %s)", err)
+	} else {
+		if !explicit {
+			endpoint.Host = sanitizeHost(endpoint.Host)
+		}
+		dockerEndpoint = endpoint.String()
+	}
+
 	return tpl.Execute(writer, map[string]string{
 		"Version":                 version,
 		"OS":                      runtime.GOOS,
 		"Arch":                    runtime.GOARCH,
 		"DefaultLifecycleVersion": builder.DefaultLifecycleVersion,
 		"SupportedPlatformAPIs":   platformAPIs,
+		"DockerEndpoint":          dockerEndpoint,
 		"Config":                  configData,
 	})
 }
 
+// sanitizeHost redacts the address of a remote daemon, local sockets are kept as they help diagnose connection issues.
+func sanitizeHost(host string) string {
+	if strings.HasPrefix(host, "unix://") || strings.HasPrefix(host, "npipe://") {
+		return host
+	}
+	if i := strings.Index(host, "://"); i >= 0 {
+		return host[:i+3] + "[REDACTED]"
+	}
+	return "[REDACTED]"
+}
+
 func sanitize(line string) string {
 	re := regexp.MustCompile(`"(.*?)"`)
 	redactedString := `"[REDACTED]"`
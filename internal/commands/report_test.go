@@ -70,6 +70,28 @@ experimental = true
 	})
 
 	when("#ReportCommand", func() {
+		when("DOCKER_HOST is set", func() {
+			it.Before(func() {
+				h.AssertNil(t, os.Setenv("DOCKER_HOST", "tcp://secret-host:2376"))
+			})
+
+			it.After(func() {
+				h.AssertNil(t, os.Unsetenv("DOCKER_HOST"))
+			})
+
+			it("presents the redacted docker endpoint", func() {
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), "Endpoint:  tcp://[REDACTED] (DOCKER_HOST)")
+				h.AssertNotContains(t, outBuf.String(), "secret-host")
+			})
+
+			it("presents the docker endpoint if explicit", func() {
+				command.SetArgs([]string{"--explicit"})
+				h.AssertNil(t, command.Execute())
+				h.AssertContains(t, outBuf.String(), "Endpoint:  tcp://secret-host:2376 (DOCKER_HOST)")
+			})
+		})
+
 		when("config.toml is present", func() {
 			it("presents output", func() {
 				h.AssertNil(t, command.Execute())
@@ -0,0 +1,20 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack/logging"
+)
+
+func NewSBOMCommand(logger logging.Logger, packVersion string, client PackClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sbom",
+		Short: "Interact with the software bill of materials of app images",
+		RunE:  nil,
+	}
+
+	cmd.AddCommand(SBOMExport(logger, packVersion, client))
+
+	AddHelpFlag(cmd, "sbom")
+	return cmd
+}
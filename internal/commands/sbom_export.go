@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/sbom"
+	"github.com/buildpacks/pack/internal/style"
+	"github.com/buildpacks/pack/logging"
+)
+
+// ociLayoutPrefix selects an image in an OCI image layout directory, as written by `pack build --output`.
+const ociLayoutPrefix = "oci-layout:"
+
+type SBOMExportFlags struct {
+	Format      string
+	OutputFile  string
+	Remote      bool
+	LayoutImage string
+}
+
+func SBOMExport(logger logging.Logger, packVersion string, client PackClient) *cobra.Command {
+	var flags SBOMExportFlags
+	cmd := &cobra.Command{
+		Use:   "export <image-name>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Export the bill of materials of an app image as a CycloneDX or SPDX document",
+		Long: "Export the bill of materials recorded by buildpacks on an app image, along with its buildpacks and run image, as a CycloneDX or SPDX document.\n" +
+			"The image is read from the daemon unless --remote is set. Images in an OCI image layout are given as 'oci-layout:<dir>'.",
+		Example: "pack sbom export my-app --format spdx-json --output-file my-app.spdx.json",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			imageName := args[0]
+
+			var (
+				info *pack.ImageInfo
+				err  error
+			)
+			if dir := strings.TrimPrefix(imageName, ociLayoutPrefix); dir != imageName {
+				info, err = client.InspectLayoutImage(dir, flags.LayoutImage)
+				if flags.LayoutImage != "" {
+					imageName = flags.LayoutImage
+				}
+			} else {
+				info, err = client.InspectImage(imageName, !flags.Remote)
+			}
+			if err != nil {
+				return errors.Wrapf(err, "inspecting image %s", style.Symbol(args[0]))
+			}
+			if info == nil {
+				return errors.Errorf("image %s not found", style.Symbol(args[0]))
+			}
+
+			contents, err := sbom.Encode(flags.Format, info, sbom.Options{
+				ImageName:   imageName,
+				PackVersion: packVersion,
+				Timestamp:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+
+			if flags.OutputFile == "" {
+				_, err = logger.Writer().Write(contents)
+				return err
+			}
+			if err := ioutil.WriteFile(flags.OutputFile, contents, 0644); err != nil {
+				return errors.Wrap(err, "writing SBOM")
+			}
+			logger.Infof("Wrote SBOM of %s to %s", style.Symbol(args[0]), style.Symbol(flags.OutputFile))
+			return nil
+		}),
+	}
+
+	cmd.Flags().StringVar(&flags.Format, "format", sbom.CycloneDXJSON, "SBOM format, one of "+strings.Join(sbom.Formats, ", "))
+	cmd.Flags().StringVar(&flags.OutputFile, "output-file", "", "File to write the SBOM to, instead of stdout")
+	cmd.Flags().BoolVar(&flags.Remote, "remote", false, "Read the image from the registry instead of the daemon")
+	cmd.Flags().StringVar(&flags.LayoutImage, "layout-image", "", "Name of the image to export from an OCI image layout holding several images")
+	AddHelpFlag(cmd, "export")
+	return cmd
+}
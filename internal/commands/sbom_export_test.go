@@ -0,0 +1,115 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/golang/mock/gomock"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/commands"
+	"github.com/buildpacks/pack/internal/commands/testmocks"
+	ilogging "github.com/buildpacks/pack/internal/logging"
+	"github.com/buildpacks/pack/logging"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestSBOMExportCommand(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "SBOMExportCommand", testSBOMExportCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testSBOMExportCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		cmd        *cobra.Command
+		logger     logging.Logger
+		outBuf     bytes.Buffer
+		mockClient *testmocks.MockPackClient
+		info       *pack.ImageInfo
+	)
+
+	it.Before(func() {
+		logger = ilogging.NewLogWithWriters(&outBuf, &outBuf)
+		mockClient = testmocks.NewMockPackClient(gomock.NewController(t))
+		cmd = commands.SBOMExport(logger, "0.0.0", mockClient)
+
+		info = &pack.ImageInfo{
+			BOM:    []buildpack.BOMEntry{{Require: buildpack.Require{Name: "node", Version: "14.17.0"}}},
+			Digest: "sha256:app-digest",
+		}
+	})
+
+	when("#SBOMExport", func() {
+		it("prints a CycloneDX document for the image in the daemon", func() {
+			mockClient.EXPECT().InspectImage("my/app", true).Return(info, nil)
+
+			cmd.SetArgs([]string{"my/app"})
+			h.AssertNil(t, cmd.Execute())
+			h.AssertContains(t, outBuf.String(), `"bomFormat": "CycloneDX"`)
+			h.AssertContains(t, outBuf.String(), `"name": "node"`)
+		})
+
+		it("reads the image from the registry with --remote", func() {
+			mockClient.EXPECT().InspectImage("my/app", false).Return(info, nil)
+
+			cmd.SetArgs([]string{"my/app", "--remote", "--format", "spdx-json"})
+			h.AssertNil(t, cmd.Execute())
+			h.AssertContains(t, outBuf.String(), `"spdxVersion": "SPDX-2.2"`)
+		})
+
+		it("reads images from an OCI image layout", func() {
+			mockClient.EXPECT().InspectLayoutImage("./out", "my/app").Return(info, nil)
+
+			cmd.SetArgs([]string{"oci-layout:./out", "--layout-image", "my/app"})
+			h.AssertNil(t, cmd.Execute())
+			h.AssertContains(t, outBuf.String(), `"name": "my/app"`)
+		})
+
+		it("writes the document to the output file", func() {
+			tmpDir, err := ioutil.TempDir("", "sbom-export-test")
+			h.AssertNil(t, err)
+			defer os.RemoveAll(tmpDir)
+			outputFile := filepath.Join(tmpDir, "sbom.json")
+			mockClient.EXPECT().InspectImage("my/app", true).Return(info, nil)
+
+			cmd.SetArgs([]string{"my/app", "--output-file", outputFile})
+			h.AssertNil(t, cmd.Execute())
+
+			contents, err := ioutil.ReadFile(outputFile)
+			h.AssertNil(t, err)
+			h.AssertContains(t, string(contents), `"bomFormat": "CycloneDX"`)
+			h.AssertContains(t, outBuf.String(), "Wrote SBOM of 'my/app'")
+		})
+
+		it("errors when the image is not found", func() {
+			mockClient.EXPECT().InspectImage("my/app", true).Return(nil, nil)
+
+			cmd.SetArgs([]string{"my/app"})
+			h.AssertError(t, cmd.Execute(), "image 'my/app' not found")
+		})
+
+		it("errors when the image cannot be inspected", func() {
+			mockClient.EXPECT().InspectImage("my/app", true).Return(nil, errors.New("some-error"))
+
+			cmd.SetArgs([]string{"my/app"})
+			h.AssertError(t, cmd.Execute(), "inspecting image 'my/app': some-error")
+		})
+
+		it("errors for unsupported formats", func() {
+			mockClient.EXPECT().InspectImage("my/app", true).Return(info, nil)
+
+			cmd.SetArgs([]string{"my/app", "--format", "xml"})
+			h.AssertError(t, cmd.Execute(), "SBOM format 'xml' is not supported")
+		})
+	})
+}
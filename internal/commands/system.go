@@ -0,0 +1,20 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack/logging"
+)
+
+func NewSystemCommand(logger logging.Logger, client PackClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "system",
+		Short: "Manage the resources pack creates for builds",
+		RunE:  nil,
+	}
+
+	cmd.AddCommand(SystemPrune(logger, client))
+
+	AddHelpFlag(cmd, "system")
+	return cmd
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack/internal/style"
+	"github.com/buildpacks/pack/logging"
+)
+
+func SystemPrune(logger logging.Logger, client PackClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "prune",
+		Args:    cobra.NoArgs,
+		Short:   "Remove containers, volumes and builders left behind by interrupted builds",
+		Example: "pack system prune",
+		Long: "Remove the containers, volumes and images that pack created for builds " +
+			"and could not remove because it was killed.\n\n" +
+			"Resources of builds that are still running are kept, as are resources created by pack on another host. " +
+			"Debug shell containers started by --debug-on-failure are kept along with the volumes they mount. " +
+			"Builders with added buildpacks or a custom order are kept for later builds until the builder they were created from " +
+			"is removed or replaced by another image.\n\n" +
+			"Build and launch caches are not removed, see 'pack cache prune'.",
+		RunE: logError(logger, func(cmd *cobra.Command, args []string) error {
+			pruned, err := client.PruneSystem(cmd.Context())
+			for _, id := range pruned.Containers {
+				logger.Infof("Removed container %s", style.Symbol(shortID(id)))
+			}
+			for _, name := range pruned.Volumes {
+				logger.Infof("Removed volume %s", style.Symbol(name))
+			}
+			for _, name := range pruned.Images {
+				logger.Infof("Removed image %s", style.Symbol(name))
+			}
+			if err != nil {
+				return err
+			}
+
+			logger.Infof("Removed %d containers, %d volumes and %d images", len(pruned.Containers), len(pruned.Volumes), len(pruned.Images))
+			return nil
+		}),
+	}
+
+	AddHelpFlag(cmd, "prune")
+	return cmd
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
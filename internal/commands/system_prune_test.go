@@ -0,0 +1,69 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/spf13/cobra"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/commands"
+	"github.com/buildpacks/pack/internal/commands/testmocks"
+	ilogging "github.com/buildpacks/pack/internal/logging"
+	"github.com/buildpacks/pack/logging"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestSystemPruneCommand(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "SystemPruneCommand", testSystemPruneCommand, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testSystemPruneCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		cmd        *cobra.Command
+		logger     logging.Logger
+		outBuf     bytes.Buffer
+		mockClient *testmocks.MockPackClient
+	)
+
+	it.Before(func() {
+		logger = ilogging.NewLogWithWriters(&outBuf, &outBuf)
+		mockClient = testmocks.NewMockPackClient(gomock.NewController(t))
+		cmd = commands.SystemPrune(logger, mockClient)
+	})
+
+	when("#SystemPrune", func() {
+		it("reports the resources removed", func() {
+			mockClient.EXPECT().PruneSystem(gomock.Any()).Return(pack.PrunedResources{
+				Containers: []string{"0123456789abcdef0123456789abcdef"},
+				Volumes:    []string{"pack-layers-abcdefghij", "pack-app-abcdefghij"},
+				Images:     []string{"pack.local/builder/6162636465:latest"},
+			}, nil)
+
+			cmd.SetArgs([]string{})
+			h.AssertNil(t, cmd.Execute())
+			h.AssertContains(t, outBuf.String(), "Removed container '0123456789ab'")
+			h.AssertContains(t, outBuf.String(), "Removed volume 'pack-layers-abcdefghij'")
+			h.AssertContains(t, outBuf.String(), "Removed volume 'pack-app-abcdefghij'")
+			h.AssertContains(t, outBuf.String(), "Removed image 'pack.local/builder/6162636465:latest'")
+			h.AssertContains(t, outBuf.String(), "Removed 1 containers, 2 volumes and 1 images")
+		})
+
+		it("reports the resources removed before an error", func() {
+			mockClient.EXPECT().PruneSystem(gomock.Any()).Return(pack.PrunedResources{
+				Volumes: []string{"pack-layers-abcdefghij"},
+			}, errors.New("removing volume"))
+
+			cmd.SetArgs([]string{})
+			h.AssertError(t, cmd.Execute(), "removing volume")
+			h.AssertContains(t, outBuf.String(), "Removed volume 'pack-layers-abcdefghij'")
+		})
+	})
+}
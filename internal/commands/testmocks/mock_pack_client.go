@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	name "github.com/google/go-containerregistry/pkg/name"
 
 	pack "github.com/buildpacks/pack"
 )
@@ -99,6 +100,21 @@ func (mr *MockPackClientMockRecorder) InspectBuildpack(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectBuildpack", reflect.TypeOf((*MockPackClient)(nil).InspectBuildpack), arg0)
 }
 
+// InspectCache mocks base method.
+func (m *MockPackClient) InspectCache(arg0 context.Context, arg1 string) (*pack.CacheDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InspectCache", arg0, arg1)
+	ret0, _ := ret[0].(*pack.CacheDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InspectCache indicates an expected call of InspectCache.
+func (mr *MockPackClientMockRecorder) InspectCache(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectCache", reflect.TypeOf((*MockPackClient)(nil).InspectCache), arg0, arg1)
+}
+
 // InspectImage mocks base method.
 func (m *MockPackClient) InspectImage(arg0 string, arg1 bool) (*pack.ImageInfo, error) {
 	m.ctrl.T.Helper()
@@ -114,6 +130,36 @@ func (mr *MockPackClientMockRecorder) InspectImage(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectImage", reflect.TypeOf((*MockPackClient)(nil).InspectImage), arg0, arg1)
 }
 
+// InspectLayoutImage mocks base method.
+func (m *MockPackClient) InspectLayoutImage(arg0, arg1 string) (*pack.ImageInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InspectLayoutImage", arg0, arg1)
+	ret0, _ := ret[0].(*pack.ImageInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InspectLayoutImage indicates an expected call of InspectLayoutImage.
+func (mr *MockPackClientMockRecorder) InspectLayoutImage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectLayoutImage", reflect.TypeOf((*MockPackClient)(nil).InspectLayoutImage), arg0, arg1)
+}
+
+// ListCaches mocks base method.
+func (m *MockPackClient) ListCaches(arg0 context.Context) ([]pack.CacheInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCaches", arg0)
+	ret0, _ := ret[0].([]pack.CacheInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCaches indicates an expected call of ListCaches.
+func (mr *MockPackClientMockRecorder) ListCaches(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCaches", reflect.TypeOf((*MockPackClient)(nil).ListCaches), arg0)
+}
+
 // NewBuildpack mocks base method.
 func (m *MockPackClient) NewBuildpack(arg0 context.Context, arg1 pack.NewBuildpackOptions) error {
 	m.ctrl.T.Helper()
@@ -142,6 +188,36 @@ func (mr *MockPackClientMockRecorder) PackageBuildpack(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PackageBuildpack", reflect.TypeOf((*MockPackClient)(nil).PackageBuildpack), arg0, arg1)
 }
 
+// PruneCaches mocks base method.
+func (m *MockPackClient) PruneCaches(arg0 context.Context, arg1 pack.PruneCachesOptions) ([]pack.CacheInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneCaches", arg0, arg1)
+	ret0, _ := ret[0].([]pack.CacheInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneCaches indicates an expected call of PruneCaches.
+func (mr *MockPackClientMockRecorder) PruneCaches(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneCaches", reflect.TypeOf((*MockPackClient)(nil).PruneCaches), arg0, arg1)
+}
+
+// PruneSystem mocks base method.
+func (m *MockPackClient) PruneSystem(arg0 context.Context) (pack.PrunedResources, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneSystem", arg0)
+	ret0, _ := ret[0].(pack.PrunedResources)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneSystem indicates an expected call of PruneSystem.
+func (mr *MockPackClientMockRecorder) PruneSystem(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneSystem", reflect.TypeOf((*MockPackClient)(nil).PruneSystem), arg0)
+}
+
 // PullBuildpack mocks base method.
 func (m *MockPackClient) PullBuildpack(arg0 context.Context, arg1 pack.PullBuildpackOptions) error {
 	m.ctrl.T.Helper()
@@ -184,6 +260,21 @@ func (mr *MockPackClientMockRecorder) RegisterBuildpack(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterBuildpack", reflect.TypeOf((*MockPackClient)(nil).RegisterBuildpack), arg0, arg1)
 }
 
+// VerifyImage mocks base method.
+func (m *MockPackClient) VerifyImage(arg0 context.Context, arg1 pack.VerifyImageOptions) (name.Digest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyImage", arg0, arg1)
+	ret0, _ := ret[0].(name.Digest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyImage indicates an expected call of VerifyImage.
+func (mr *MockPackClientMockRecorder) VerifyImage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyImage", reflect.TypeOf((*MockPackClient)(nil).VerifyImage), arg0, arg1)
+}
+
 // YankBuildpack mocks base method.
 func (m *MockPackClient) YankBuildpack(arg0 pack.YankBuildpackOptions) error {
 	m.ctrl.T.Helper()
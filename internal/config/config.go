@@ -22,6 +22,7 @@ type Config struct {
 	Registries          []Registry        `toml:"registries,omitempty"`
 	LifecycleImage      string            `toml:"lifecycle-image,omitempty"`
 	RegistryMirrors     map[string]string `toml:"registry-mirrors,omitempty"`
+	Policy              string            `toml:"policy,omitempty"`
 }
 
 type Registry struct {
@@ -35,8 +36,12 @@ type RunImage struct {
 	Mirrors []string `toml:"mirrors"`
 }
 
+// TrustedBuilder is a builder trusted by name or, when Digests or SigningKey is set,
+// only for images with one of the digests or signed with the key.
 type TrustedBuilder struct {
-	Name string `toml:"name"`
+	Name       string   `toml:"name"`
+	Digests    []string `toml:"digests,omitempty"`
+	SigningKey string   `toml:"signing-key,omitempty"`
 }
 
 const OfficialRegistryName = "official"
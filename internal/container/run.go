@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 
 	"github.com/docker/docker/api/types"
 	dcontainer "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 func Run(ctx context.Context, docker client.CommonAPIClient, ctrID string, out, errOut io.Writer) error {
@@ -29,25 +31,30 @@ func Run(ctx context.Context, docker client.CommonAPIClient, ctrID string, out,
 		return errors.Wrap(err, "container start")
 	}
 
-	copyErr := make(chan error)
+	// the writers are closed before Run returns, so that anything they flush on close precedes what the caller writes next
+	copyErr := make(chan error, 1)
 	go func() {
 		_, err := stdcopy.StdCopy(out, errOut, resp.Reader)
-		defer optionallyCloseWriter(out)
-		defer optionallyCloseWriter(errOut)
+		optionallyCloseWriter(out)
+		optionallyCloseWriter(errOut)
 
 		copyErr <- err
 	}()
 
 	select {
 	case body := <-bodyChan:
+		// the output of the container ends once it has exited
+		err := <-copyErr
 		if body.StatusCode != 0 {
 			return fmt.Errorf("failed with status code: %d", body.StatusCode)
 		}
+		return err
 	case err := <-errChan:
+		// stop reading the output, which may not end while the container is still running
+		resp.Close()
+		<-copyErr
 		return err
 	}
-
-	return <-copyErr
 }
 
 func optionallyCloseWriter(writer io.Writer) error {
@@ -57,3 +64,53 @@ func optionallyCloseWriter(writer io.Writer) error {
 
 	return nil
 }
+
+// RunInteractive starts the container and connects in and out to its TTY until the container exits.
+// If in is a terminal, it is put into raw mode for the duration of the session.
+func RunInteractive(ctx context.Context, docker client.CommonAPIClient, ctrID string, in io.Reader, out io.Writer) error {
+	bodyChan, errChan := docker.ContainerWait(ctx, ctrID, dcontainer.WaitConditionNextExit)
+
+	resp, err := docker.ContainerAttach(ctx, ctrID, types.ContainerAttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	if f, ok := in.(*os.File); ok && terminal.IsTerminal(int(f.Fd())) {
+		state, err := terminal.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return errors.Wrap(err, "setting terminal to raw mode")
+		}
+		defer terminal.Restore(int(f.Fd()), state)
+	}
+
+	if err := docker.ContainerStart(ctx, ctrID, types.ContainerStartOptions{}); err != nil {
+		return errors.Wrap(err, "container start")
+	}
+
+	go func() {
+		_, _ = io.Copy(resp.Conn, in)
+		_ = resp.CloseWrite()
+	}()
+
+	outputDone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(out, resp.Reader)
+		close(outputDone)
+	}()
+
+	// the exit code of an interactive session is that of the last command run by the user, so it is not treated as an error
+	select {
+	case <-bodyChan:
+	case err := <-errChan:
+		return err
+	}
+
+	<-outputDone
+	return nil
+}
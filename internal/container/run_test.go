@@ -0,0 +1,95 @@
+package container_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/golang/mock/gomock"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/container"
+	h "github.com/buildpacks/pack/testhelpers"
+	"github.com/buildpacks/pack/testmocks"
+)
+
+func TestRun(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "Run", testRun, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+// closeRecorder records its output and whether it was closed.
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (w *closeRecorder) Close() error {
+	w.closed = true
+	return nil
+}
+
+func testRun(t *testing.T, when spec.G, it spec.S) {
+	var (
+		mockController *gomock.Controller
+		mockDocker     *testmocks.MockCommonAPIClient
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockDocker = testmocks.NewMockCommonAPIClient(mockController)
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	expectContainerExit := func(statusCode int64) {
+		var output bytes.Buffer
+		_, err := stdcopy.NewStdWriter(&output, stdcopy.Stdout).Write([]byte("some output\n"))
+		h.AssertNil(t, err)
+
+		conn, _ := net.Pipe()
+		bodyChan := make(chan dcontainer.ContainerWaitOKBody, 1)
+		bodyChan <- dcontainer.ContainerWaitOKBody{StatusCode: statusCode}
+
+		mockDocker.EXPECT().
+			ContainerWait(gomock.Any(), "some-container", dcontainer.WaitConditionNextExit).
+			Return(bodyChan, make(chan error))
+		mockDocker.EXPECT().
+			ContainerAttach(gomock.Any(), "some-container", gomock.Any()).
+			Return(types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(&output)}, nil)
+		mockDocker.EXPECT().
+			ContainerStart(gomock.Any(), "some-container", gomock.Any()).
+			Return(nil)
+	}
+
+	when("#Run", func() {
+		it("writes all of the output and closes the writers before returning", func() {
+			expectContainerExit(0)
+			out := &closeRecorder{}
+
+			h.AssertNil(t, container.Run(context.TODO(), mockDocker, "some-container", out, out))
+			h.AssertEq(t, out.String(), "some output\n")
+			h.AssertTrue(t, out.closed)
+		})
+
+		it("writes all of the output of a container which failed", func() {
+			expectContainerExit(1)
+			out := &closeRecorder{}
+
+			err := container.Run(context.TODO(), mockDocker, "some-container", out, out)
+			h.AssertError(t, err, "failed with status code: 1")
+			h.AssertEq(t, out.String(), "some output\n")
+			h.AssertTrue(t, out.closed)
+		})
+	})
+}
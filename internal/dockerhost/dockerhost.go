@@ -0,0 +1,251 @@
+// Package dockerhost resolves the endpoint of the docker daemon the way the docker CLI does,
+// falling back to the socket of rootless Podman when there is no docker daemon socket.
+package dockerhost
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/docker/cli/cli/connhelper"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/style"
+)
+
+const (
+	// SourceEnv is the source of an endpoint given by the DOCKER_HOST environment variable.
+	SourceEnv = "DOCKER_HOST"
+
+	// SourcePodman is the source of an endpoint found at the socket of rootless Podman.
+	SourcePodman = "rootless Podman"
+
+	// SourceDefault is the source of the default endpoint of the docker client.
+	SourceDefault = "default"
+)
+
+// defaultSocket is the socket of a docker daemon running as root, overridden in tests.
+var defaultSocket = "/var/run/docker.sock"
+
+// Endpoint is the address of a docker daemon along with the settings needed to connect to it.
+type Endpoint struct {
+	// Host is the address of the daemon, for example unix:///var/run/docker.sock.
+	Host string
+
+	// Source describes where Host was found, for example "docker context 'colima'".
+	Source string
+
+	// TLSDir holds the ca.pem, cert.pem and key.pem of a docker context using TLS, and is empty otherwise.
+	TLSDir string
+
+	// SkipTLSVerify is set when a docker context does not verify the certificate of the daemon.
+	SkipTLSVerify bool
+}
+
+// Resolve returns the endpoint of the docker daemon. DOCKER_HOST takes precedence over the docker context
+// selected by DOCKER_CONTEXT or the docker config file. Without either, the default docker socket is used,
+// unless it does not exist and rootless Podman is listening under XDG_RUNTIME_DIR.
+func Resolve() (Endpoint, error) {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return Endpoint{Host: host, Source: SourceEnv}, nil
+	}
+
+	configDir, err := configDir()
+	if err != nil {
+		return Endpoint{}, err
+	}
+
+	contextName, err := currentContext(configDir)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	if contextName != "" && contextName != "default" {
+		return contextEndpoint(configDir, contextName)
+	}
+
+	if runtime.GOOS != "windows" {
+		if _, err := os.Stat(defaultSocket); os.IsNotExist(err) {
+			if socket := podmanSocket(); socket != "" {
+				return Endpoint{Host: "unix://" + socket, Source: SourcePodman}, nil
+			}
+		}
+	}
+
+	return Endpoint{Host: client.DefaultDockerHost, Source: SourceDefault}, nil
+}
+
+// ClientOpts returns the options connecting a docker client to the endpoint.
+// They are meant to be applied after client.FromEnv, which already handles DOCKER_HOST unless it is an ssh:// host.
+func (e Endpoint) ClientOpts() []client.Opt {
+	if strings.HasPrefix(e.Host, "ssh://") {
+		return []client.Opt{withSSH(e.Host)}
+	}
+	if e.Source == SourceEnv || e.Source == SourceDefault {
+		return nil
+	}
+
+	var opts []client.Opt
+	if e.TLSDir != "" {
+		opts = append(opts, withTLS(e.TLSDir, e.SkipTLSVerify))
+	}
+	return append(opts, client.WithHost(e.Host))
+}
+
+// DaemonAccessHost returns the host to give lifecycle containers access to the daemon through,
+// or an empty string when the default socket is to be mounted.
+// Only sockets on Linux are returned: on macOS and Windows the daemon runs in a virtual machine,
+// where the socket on the host cannot be mounted and the default socket is the right one.
+func (e Endpoint) DaemonAccessHost() string {
+	if runtime.GOOS != "linux" || !strings.HasPrefix(e.Host, "unix://") || e.Host == client.DefaultDockerHost {
+		return ""
+	}
+	return e.Host
+}
+
+func (e Endpoint) String() string {
+	return fmt.Sprintf("%s (%s)", e.Host, e.Source)
+}
+
+func configDir() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "getting home directory")
+	}
+	return filepath.Join(home, ".docker"), nil
+}
+
+func currentContext(configDir string) (string, error) {
+	if name := os.Getenv("DOCKER_CONTEXT"); name != "" {
+		return name, nil
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(configDir, "config.json"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "reading docker config")
+	}
+
+	var config struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if err := json.Unmarshal(contents, &config); err != nil {
+		return "", errors.Wrap(err, "parsing docker config")
+	}
+	return config.CurrentContext, nil
+}
+
+// contextEndpoint reads the docker endpoint of the context from its metadata,
+// which the docker CLI stores in a directory named after the digest of the context name.
+func contextEndpoint(configDir, name string) (Endpoint, error) {
+	digest := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))
+	contents, err := ioutil.ReadFile(filepath.Join(configDir, "contexts", "meta", digest, "meta.json"))
+	if os.IsNotExist(err) {
+		return Endpoint{}, errors.Errorf("docker context %s does not exist", style.Symbol(name))
+	}
+	if err != nil {
+		return Endpoint{}, errors.Wrapf(err, "reading docker context %s", style.Symbol(name))
+	}
+
+	var meta struct {
+		Endpoints map[string]struct {
+			Host          string
+			SkipTLSVerify bool
+		}
+	}
+	if err := json.Unmarshal(contents, &meta); err != nil {
+		return Endpoint{}, errors.Wrapf(err, "parsing docker context %s", style.Symbol(name))
+	}
+
+	docker, ok := meta.Endpoints["docker"]
+	if !ok || docker.Host == "" {
+		return Endpoint{}, errors.Errorf("docker context %s has no docker endpoint", style.Symbol(name))
+	}
+
+	endpoint := Endpoint{
+		Host:          docker.Host,
+		Source:        fmt.Sprintf("docker context %s", style.Symbol(name)),
+		SkipTLSVerify: docker.SkipTLSVerify,
+	}
+	tlsDir := filepath.Join(configDir, "contexts", "tls", digest, "docker")
+	if _, err := os.Stat(tlsDir); err == nil {
+		endpoint.TLSDir = tlsDir
+	}
+	return endpoint, nil
+}
+
+func podmanSocket() string {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		return ""
+	}
+
+	socket := filepath.Join(runtimeDir, "podman", "podman.sock")
+	if fi, err := os.Stat(socket); err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return ""
+	}
+	return socket
+}
+
+func withTLS(dir string, skipVerify bool) client.Opt {
+	return func(c *client.Client) error {
+		options := tlsconfig.Options{
+			CAFile:             filepath.Join(dir, "ca.pem"),
+			CertFile:           filepath.Join(dir, "cert.pem"),
+			KeyFile:            filepath.Join(dir, "key.pem"),
+			InsecureSkipVerify: skipVerify,
+		}
+		for _, file := range []*string{&options.CAFile, &options.CertFile, &options.KeyFile} {
+			if _, err := os.Stat(*file); err != nil {
+				*file = ""
+			}
+		}
+
+		tlsc, err := tlsconfig.Client(options)
+		if err != nil {
+			return errors.Wrap(err, "reading docker context certificates")
+		}
+		return client.WithHTTPClient(&http.Client{
+			Transport:     &http.Transport{TLSClientConfig: tlsc},
+			CheckRedirect: client.CheckRedirect,
+		})(c)
+	}
+}
+
+// withSSH connects to the daemon of an ssh:// host the way the docker CLI does, by running `docker system dial-stdio` on
+// the remote host over ssh, as the docker client only dials sockets and TCP itself.
+func withSSH(host string) client.Opt {
+	return func(c *client.Client) error {
+		helper, err := connhelper.GetConnectionHelper(host)
+		if err != nil {
+			return errors.Wrapf(err, "connecting to docker host %s", style.Symbol(host))
+		}
+
+		for _, opt := range []client.Opt{
+			client.WithHTTPClient(&http.Client{
+				Transport:     &http.Transport{DialContext: helper.Dialer},
+				CheckRedirect: client.CheckRedirect,
+			}),
+			client.WithHost(helper.Host),
+			client.WithDialContext(helper.Dialer),
+		} {
+			if err := opt(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
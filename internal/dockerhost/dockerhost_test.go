@@ -0,0 +1,235 @@
+package dockerhost
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/docker/docker/client"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestDockerHost(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "DockerHost", testDockerHost, spec.Sequential(), spec.Report(report.Terminal{}))
+}
+
+func testDockerHost(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir            string
+		configDir         string
+		origSocket        string
+		origEnv           = map[string]string{}
+		envVars           = []string{"DOCKER_HOST", "DOCKER_CONTEXT", "DOCKER_CONFIG", "XDG_RUNTIME_DIR"}
+		writeContext      func(name, meta string) string
+		writeDockerConfig func(contents string)
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "dockerhost-test")
+		h.AssertNil(t, err)
+
+		for _, key := range envVars {
+			origEnv[key] = os.Getenv(key)
+			h.AssertNil(t, os.Unsetenv(key))
+		}
+
+		configDir = filepath.Join(tmpDir, "docker-config")
+		h.AssertNil(t, os.MkdirAll(configDir, 0755))
+		h.AssertNil(t, os.Setenv("DOCKER_CONFIG", configDir))
+
+		origSocket = defaultSocket
+		defaultSocket = filepath.Join(tmpDir, "docker.sock")
+
+		writeContext = func(name, meta string) string {
+			digest := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))
+			dir := filepath.Join(configDir, "contexts", "meta", digest)
+			h.AssertNil(t, os.MkdirAll(dir, 0755))
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(dir, "meta.json"), []byte(meta), 0644))
+			return digest
+		}
+		writeDockerConfig = func(contents string) {
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(configDir, "config.json"), []byte(contents), 0644))
+		}
+	})
+
+	it.After(func() {
+		defaultSocket = origSocket
+		for key, value := range origEnv {
+			if value == "" {
+				h.AssertNil(t, os.Unsetenv(key))
+			} else {
+				h.AssertNil(t, os.Setenv(key, value))
+			}
+		}
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	when("#Resolve", func() {
+		it("uses DOCKER_HOST", func() {
+			h.AssertNil(t, os.Setenv("DOCKER_HOST", "tcp://some-host:2376"))
+			writeDockerConfig(`{"currentContext": "some-context"}`)
+
+			endpoint, err := Resolve()
+			h.AssertNil(t, err)
+			h.AssertEq(t, endpoint, Endpoint{Host: "tcp://some-host:2376", Source: SourceEnv})
+			h.AssertEq(t, len(endpoint.ClientOpts()), 0)
+		})
+
+		when("a docker context is selected", func() {
+			it.Before(func() {
+				writeContext("some-context", `{"Name":"some-context","Endpoints":{"docker":{"Host":"unix:///some/docker.sock","SkipTLSVerify":false}}}`)
+			})
+
+			it("uses the current context of the docker config", func() {
+				writeDockerConfig(`{"auths": {}, "currentContext": "some-context"}`)
+
+				endpoint, err := Resolve()
+				h.AssertNil(t, err)
+				h.AssertEq(t, endpoint.Host, "unix:///some/docker.sock")
+				h.AssertEq(t, endpoint.Source, "docker context 'some-context'")
+				h.AssertEq(t, endpoint.TLSDir, "")
+				h.AssertEq(t, len(endpoint.ClientOpts()), 1)
+			})
+
+			it("prefers DOCKER_CONTEXT over the docker config", func() {
+				writeDockerConfig(`{"currentContext": "default"}`)
+				h.AssertNil(t, os.Setenv("DOCKER_CONTEXT", "some-context"))
+
+				endpoint, err := Resolve()
+				h.AssertNil(t, err)
+				h.AssertEq(t, endpoint.Host, "unix:///some/docker.sock")
+			})
+
+			it("finds the certificates of the context", func() {
+				digest := writeContext("tls-context", `{"Name":"tls-context","Endpoints":{"docker":{"Host":"tcp://some-host:2376","SkipTLSVerify":true}}}`)
+				tlsDir := filepath.Join(configDir, "contexts", "tls", digest, "docker")
+				h.AssertNil(t, os.MkdirAll(tlsDir, 0755))
+				h.AssertNil(t, os.Setenv("DOCKER_CONTEXT", "tls-context"))
+
+				endpoint, err := Resolve()
+				h.AssertNil(t, err)
+				h.AssertEq(t, endpoint, Endpoint{
+					Host:          "tcp://some-host:2376",
+					Source:        "docker context 'tls-context'",
+					TLSDir:        tlsDir,
+					SkipTLSVerify: true,
+				})
+
+				_, err = client.NewClientWithOpts(endpoint.ClientOpts()...)
+				h.AssertNil(t, err)
+			})
+
+			it("errors when the context does not exist", func() {
+				h.AssertNil(t, os.Setenv("DOCKER_CONTEXT", "missing-context"))
+
+				_, err := Resolve()
+				h.AssertError(t, err, "docker context 'missing-context' does not exist")
+			})
+
+			it("errors when the context has no docker endpoint", func() {
+				writeContext("k8s-context", `{"Name":"k8s-context","Endpoints":{"kubernetes":{"Host":"https://some-cluster"}}}`)
+				h.AssertNil(t, os.Setenv("DOCKER_CONTEXT", "k8s-context"))
+
+				_, err := Resolve()
+				h.AssertError(t, err, "docker context 'k8s-context' has no docker endpoint")
+			})
+		})
+
+		it("uses the default host for the default context", func() {
+			writeDockerConfig(`{"currentContext": "default"}`)
+
+			endpoint, err := Resolve()
+			h.AssertNil(t, err)
+			h.AssertEq(t, endpoint, Endpoint{Host: client.DefaultDockerHost, Source: SourceDefault})
+		})
+
+		when("rootless Podman is running", func() {
+			var (
+				podmanSocket string
+				listener     net.Listener
+			)
+
+			it.Before(func() {
+				h.SkipIf(t, runtime.GOOS == "windows", "Podman sockets are not detected on Windows")
+
+				runtimeDir := filepath.Join(tmpDir, "run")
+				h.AssertNil(t, os.MkdirAll(filepath.Join(runtimeDir, "podman"), 0755))
+				h.AssertNil(t, os.Setenv("XDG_RUNTIME_DIR", runtimeDir))
+
+				podmanSocket = filepath.Join(runtimeDir, "podman", "podman.sock")
+				var err error
+				listener, err = net.Listen("unix", podmanSocket)
+				h.AssertNil(t, err)
+			})
+
+			it.After(func() {
+				if listener != nil {
+					h.AssertNil(t, listener.Close())
+					listener = nil
+				}
+			})
+
+			it("uses the Podman socket when there is no docker socket", func() {
+				endpoint, err := Resolve()
+				h.AssertNil(t, err)
+				h.AssertEq(t, endpoint, Endpoint{Host: "unix://" + podmanSocket, Source: SourcePodman})
+			})
+
+			it("prefers the docker socket", func() {
+				h.AssertNil(t, ioutil.WriteFile(defaultSocket, nil, 0644))
+
+				endpoint, err := Resolve()
+				h.AssertNil(t, err)
+				h.AssertEq(t, endpoint.Source, SourceDefault)
+			})
+		})
+	})
+
+	when("#ClientOpts", func() {
+		it("connects to ssh hosts through ssh", func() {
+			for _, source := range []string{SourceEnv, "docker context 'remote'"} {
+				c, err := client.NewClientWithOpts(Endpoint{Host: "ssh://user@some-host", Source: source}.ClientOpts()...)
+				h.AssertNil(t, err)
+				h.AssertEq(t, c.DaemonHost(), "http://docker")
+			}
+		})
+
+		it("errors for invalid ssh hosts", func() {
+			_, err := client.NewClientWithOpts(Endpoint{Host: "ssh://user@some-host/some-path", Source: SourceEnv}.ClientOpts()...)
+			h.AssertError(t, err, "connecting to docker host 'ssh://user@some-host/some-path'")
+		})
+
+		it("uses the host of a docker context", func() {
+			c, err := client.NewClientWithOpts(Endpoint{Host: "tcp://some-host:2376", Source: "docker context 'remote'"}.ClientOpts()...)
+			h.AssertNil(t, err)
+			h.AssertEq(t, c.DaemonHost(), "tcp://some-host:2376")
+		})
+	})
+
+	when("#DaemonAccessHost", func() {
+		it("is empty for the default host", func() {
+			h.AssertEq(t, Endpoint{Host: client.DefaultDockerHost}.DaemonAccessHost(), "")
+		})
+
+		it("is empty for remote hosts", func() {
+			h.AssertEq(t, Endpoint{Host: "tcp://some-host:2376"}.DaemonAccessHost(), "")
+		})
+
+		it("is the socket on Linux", func() {
+			h.SkipIf(t, runtime.GOOS != "linux", "Sockets are only mounted on Linux")
+			h.AssertEq(t, Endpoint{Host: "unix:///run/user/1000/podman/podman.sock"}.DaemonAccessHost(), "unix:///run/user/1000/podman/podman.sock")
+		})
+	})
+}
@@ -0,0 +1,161 @@
+package dryrun_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/dryrun"
+	ilogging "github.com/buildpacks/pack/internal/logging"
+	"github.com/buildpacks/pack/pkg/events"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestDryRun(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "DryRun", testDryRun, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testDryRun(t *testing.T, when spec.G, it spec.S) {
+	var (
+		outBuf  bytes.Buffer
+		logger  *ilogging.LogWithWriters
+		subject dryrun.Report
+	)
+
+	it.Before(func() {
+		outBuf.Reset()
+		logger = ilogging.NewLogWithWriters(&outBuf, &outBuf)
+
+		collector := dryrun.NewCollector()
+		collector.Emit(events.Event{
+			Type:            events.BuildResolved,
+			Builder:         "some/builder",
+			RunImage:        "some/run",
+			RunImageMirrors: []string{"mirror.example.com/some/run"},
+			Buildpacks:      []events.Buildpack{{ID: "added/bp", Version: "0.0.1"}},
+		})
+		collector.Emit(events.Event{Type: events.PhaseStarted, Phase: "detector"})
+		collector.Emit(events.Event{
+			Type:       events.GroupDetected,
+			Buildpacks: []events.Buildpack{{ID: "some/bp", Version: "1.2.3"}, {ID: "added/bp", Version: "0.0.1"}},
+		})
+		collector.Emit(events.Event{
+			Type: events.PlanDetected,
+			Plan: []events.PlanEntry{{
+				Providers: []events.Buildpack{{ID: "some/bp", Version: "1.2.3"}},
+				Requires:  []events.Require{{Name: "node", Metadata: map[string]interface{}{"version": "14"}}},
+			}},
+		})
+		reports := collector.Reports()
+		h.AssertEq(t, len(reports), 1)
+		subject = reports[0]
+	})
+
+	when("Collector", func() {
+		it("assembles the report from build events", func() {
+			h.AssertEq(t, subject.Builder, "some/builder")
+			h.AssertEq(t, subject.RunImage, "some/run")
+			h.AssertEq(t, subject.RunImageMirrors, []string{"mirror.example.com/some/run"})
+			h.AssertEq(t, len(subject.Group), 2)
+			h.AssertEq(t, subject.Plan[0].Requires[0].Name, "node")
+		})
+
+		it("assembles a report for each platform", func() {
+			collector := dryrun.NewCollector()
+			for _, platform := range []string{"linux/amd64", "linux/arm64"} {
+				collector.Emit(events.Event{Type: events.BuildResolved, Platform: platform, Builder: "some/builder-" + platform})
+			}
+			collector.Emit(events.Event{Type: events.GroupDetected, Platform: "linux/arm64", Buildpacks: []events.Buildpack{{ID: "arm/bp"}}})
+			collector.Emit(events.Event{Type: events.GroupDetected, Platform: "linux/amd64", Buildpacks: []events.Buildpack{{ID: "amd/bp"}}})
+
+			reports := collector.Reports()
+			h.AssertEq(t, len(reports), 2)
+			h.AssertEq(t, reports[0].Platform, "linux/amd64")
+			h.AssertEq(t, reports[0].Builder, "some/builder-linux/amd64")
+			h.AssertEq(t, reports[0].Group, []events.Buildpack{{ID: "amd/bp"}})
+			h.AssertEq(t, reports[1].Platform, "linux/arm64")
+			h.AssertEq(t, reports[1].Group, []events.Buildpack{{ID: "arm/bp"}})
+		})
+	})
+
+	when("#NewWriter", func() {
+		it("errors for unsupported formats", func() {
+			_, err := dryrun.NewWriter("toml")
+			h.AssertError(t, err, "output format 'toml' is not supported")
+		})
+
+		when("human-readable", func() {
+			it("prints the report", func() {
+				writer, err := dryrun.NewWriter("human-readable")
+				h.AssertNil(t, err)
+
+				h.AssertNil(t, writer.Print(logger, []dryrun.Report{subject}))
+
+				h.AssertContainsMatch(t, outBuf.String(), `Builder: +some/builder`)
+				h.AssertContainsMatch(t, outBuf.String(), `Run Image Mirrors: +mirror.example.com/some/run`)
+				h.AssertContains(t, outBuf.String(), "Buildpacks added to builder:\n  added/bp@0.0.1\n")
+				h.AssertContains(t, outBuf.String(), "Detected group:\n  some/bp@1.2.3\n  added/bp@0.0.1\n")
+				h.AssertContains(t, outBuf.String(), "Build plan:\n  node (14)\n    provided by: some/bp@1.2.3\n")
+				h.AssertNotContains(t, outBuf.String(), "Platform:")
+			})
+
+			it("prints the report of each platform", func() {
+				writer, err := dryrun.NewWriter("human-readable")
+				h.AssertNil(t, err)
+
+				amd64, arm64 := subject, subject
+				amd64.Platform, arm64.Platform = "linux/amd64", "linux/arm64"
+				h.AssertNil(t, writer.Print(logger, []dryrun.Report{amd64, arm64}))
+
+				h.AssertContainsMatch(t, outBuf.String(), `(?s)Platform: +linux/amd64\nBuilder: +some/builder.*Platform: +linux/arm64\nBuilder: +some/builder`)
+			})
+		})
+
+		when("json", func() {
+			it("prints the report as JSON", func() {
+				writer, err := dryrun.NewWriter("json")
+				h.AssertNil(t, err)
+
+				h.AssertNil(t, writer.Print(logger, []dryrun.Report{subject}))
+
+				h.AssertContains(t, outBuf.String(), `"run_image": "some/run"`)
+				h.AssertContains(t, outBuf.String(), `"group": [`)
+				h.AssertContains(t, outBuf.String(), `"name": "node"`)
+				h.AssertTrue(t, strings.HasPrefix(outBuf.String(), "{"))
+			})
+
+			it("prints the reports of several platforms as a list", func() {
+				writer, err := dryrun.NewWriter("json")
+				h.AssertNil(t, err)
+
+				amd64, arm64 := subject, subject
+				amd64.Platform, arm64.Platform = "linux/amd64", "linux/arm64"
+				h.AssertNil(t, writer.Print(logger, []dryrun.Report{amd64, arm64}))
+
+				var reports []dryrun.Report
+				h.AssertNil(t, json.Unmarshal(outBuf.Bytes(), &reports))
+				h.AssertEq(t, len(reports), 2)
+				h.AssertEq(t, reports[1].Platform, "linux/arm64")
+			})
+		})
+
+		when("yaml", func() {
+			it("prints the report as YAML", func() {
+				writer, err := dryrun.NewWriter("yaml")
+				h.AssertNil(t, err)
+
+				h.AssertNil(t, writer.Print(logger, []dryrun.Report{subject}))
+
+				h.AssertContains(t, outBuf.String(), "run_image: some/run\n")
+				h.AssertContains(t, outBuf.String(), "group:\n    - id: some/bp\n      version: 1.2.3\n")
+			})
+		})
+	})
+}
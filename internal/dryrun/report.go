@@ -0,0 +1,76 @@
+package dryrun
+
+import (
+	"sync"
+
+	"github.com/buildpacks/pack/pkg/events"
+)
+
+// Report is the outcome of a dry run build.
+type Report struct {
+	Platform        string               `json:"platform,omitempty" yaml:"platform,omitempty"`
+	Builder         string               `json:"builder" yaml:"builder"`
+	RunImage        string               `json:"run_image" yaml:"run_image"`
+	RunImageMirrors []string             `json:"run_image_mirrors,omitempty" yaml:"run_image_mirrors,omitempty"`
+	Buildpacks      []events.Buildpack   `json:"buildpacks,omitempty" yaml:"buildpacks,omitempty"`
+	Order           [][]events.Buildpack `json:"order,omitempty" yaml:"order,omitempty"`
+	Group           []events.Buildpack   `json:"group" yaml:"group"`
+	Plan            []events.PlanEntry   `json:"plan" yaml:"plan"`
+}
+
+// Collector is an events.Sink that assembles a Report for each platform from the events emitted during a dry run build.
+type Collector struct {
+	mu      sync.Mutex
+	reports []*Report
+}
+
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+func (c *Collector) Emit(e events.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Type {
+	case events.BuildResolved:
+		report := c.platformReport(e.Platform)
+		report.Builder = e.Builder
+		report.RunImage = e.RunImage
+		report.RunImageMirrors = e.RunImageMirrors
+		report.Buildpacks = e.Buildpacks
+		report.Order = e.Order
+	case events.GroupDetected:
+		c.platformReport(e.Platform).Group = e.Buildpacks
+	case events.PlanDetected:
+		c.platformReport(e.Platform).Plan = e.Plan
+	}
+}
+
+func (c *Collector) platformReport(platform string) *Report {
+	for _, report := range c.reports {
+		if report.Platform == platform {
+			return report
+		}
+	}
+
+	report := &Report{Platform: platform}
+	c.reports = append(c.reports, report)
+	return report
+}
+
+// Reports returns the report of each platform, in the order the platforms were built.
+func (c *Collector) Reports() []Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.reports) == 0 {
+		return []Report{{}}
+	}
+
+	var reports []Report
+	for _, report := range c.reports {
+		reports = append(reports, *report)
+	}
+	return reports
+}
@@ -0,0 +1,149 @@
+package dryrun
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/buildpacks/pack/internal/style"
+	"github.com/buildpacks/pack/logging"
+	"github.com/buildpacks/pack/pkg/events"
+)
+
+// Writer prints the reports of a dry run, one for each platform built for.
+type Writer interface {
+	Print(logger logging.Logger, reports []Report) error
+}
+
+// NewWriter returns a Writer for the given output format.
+func NewWriter(kind string) (Writer, error) {
+	switch kind {
+	case "human-readable":
+		return &HumanReadable{}, nil
+	case "json":
+		return &StructuredFormat{MarshalFunc: func(v interface{}) ([]byte, error) {
+			return json.MarshalIndent(v, "", "  ")
+		}}, nil
+	case "yaml":
+		return &StructuredFormat{MarshalFunc: func(v interface{}) ([]byte, error) {
+			buf := bytes.NewBuffer(nil)
+			if err := yaml.NewEncoder(buf).Encode(v); err != nil {
+				return []byte{}, err
+			}
+			return buf.Bytes(), nil
+		}}, nil
+	}
+
+	return nil, fmt.Errorf("output format %s is not supported", style.Symbol(kind))
+}
+
+type StructuredFormat struct {
+	MarshalFunc func(interface{}) ([]byte, error)
+}
+
+// Print writes a single report as is, and the reports of several platforms as a list.
+func (w *StructuredFormat) Print(logger logging.Logger, reports []Report) error {
+	var v interface{} = reports
+	if len(reports) == 1 {
+		v = reports[0]
+	}
+
+	out, err := w.MarshalFunc(v)
+	if err != nil {
+		return fmt.Errorf("marshaling dry run report: %w", err)
+	}
+
+	// Access the logger's Writer directly so the report is written even when the logger is quiet
+	_, err = logger.Writer().Write(append(bytes.TrimRight(out, "\n"), '\n'))
+	return err
+}
+
+type HumanReadable struct{}
+
+func (h *HumanReadable) Print(logger logging.Logger, reports []Report) error {
+	for _, report := range reports {
+		if err := h.print(logger, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *HumanReadable) print(logger logging.Logger, report Report) error {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+
+	if report.Platform != "" {
+		fmt.Fprintf(tw, "Platform:\t%s\n", report.Platform)
+	}
+	fmt.Fprintf(tw, "Builder:\t%s\n", report.Builder)
+	fmt.Fprintf(tw, "Run Image:\t%s\n", report.RunImage)
+	if len(report.RunImageMirrors) > 0 {
+		fmt.Fprintf(tw, "Run Image Mirrors:\t%s\n", strings.Join(report.RunImageMirrors, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(report.Buildpacks) > 0 {
+		buf.WriteString("\nBuildpacks added to builder:\n")
+		for _, bp := range report.Buildpacks {
+			fmt.Fprintf(buf, "  %s\n", buildpackName(bp))
+		}
+	}
+
+	buf.WriteString("\nDetected group:\n")
+	if len(report.Group) == 0 {
+		buf.WriteString("  (none)\n")
+	}
+	for _, bp := range report.Group {
+		fmt.Fprintf(buf, "  %s\n", buildpackName(bp))
+	}
+
+	buf.WriteString("\nBuild plan:\n")
+	if len(report.Plan) == 0 {
+		buf.WriteString("  (empty)\n")
+	}
+	for _, entry := range report.Plan {
+		var requires, providers []string
+		for _, req := range entry.Requires {
+			requires = append(requires, requireName(req))
+		}
+		for _, bp := range entry.Providers {
+			providers = append(providers, buildpackName(bp))
+		}
+		fmt.Fprintf(buf, "  %s\n", strings.Join(requires, ", "))
+		fmt.Fprintf(buf, "    provided by: %s\n", strings.Join(providers, ", "))
+	}
+
+	logger.Info(buf.String())
+	return nil
+}
+
+func buildpackName(bp events.Buildpack) string {
+	name := bp.ID
+	if bp.Version != "" {
+		name += "@" + bp.Version
+	}
+	if bp.Optional {
+		name += " (optional)"
+	}
+	return name
+}
+
+func requireName(req events.Require) string {
+	version := req.Version
+	if version == "" {
+		if v, ok := req.Metadata["version"]; ok {
+			version = fmt.Sprintf("%v", v)
+		}
+	}
+	if version == "" {
+		return req.Name
+	}
+	return fmt.Sprintf("%s (%s)", req.Name, version)
+}
@@ -0,0 +1,100 @@
+// Package ephemeral labels the containers, volumes and images that pack creates for the duration of a build,
+// so that those left behind when pack is killed can be found and removed later.
+package ephemeral
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Label marks a resource that pack removes once the build that created it has finished.
+	Label = "io.buildpacks.pack.ephemeral"
+
+	// OwnerLabel identifies the pack process that created a resource, in the form <pid>@<hostname>.
+	OwnerLabel = "io.buildpacks.pack.owner"
+
+	// KeepLabel marks a resource that pack created for a build and kept once it finished, such as a debug shell container.
+	// The volumes mounted by a kept container are kept along with it.
+	KeepLabel = "io.buildpacks.pack.keep"
+)
+
+// Labels returns the labels to set on a resource created by this process.
+func Labels() map[string]string {
+	return map[string]string{
+		Label:      "true",
+		OwnerLabel: owner(os.Getpid()),
+	}
+}
+
+// Keep returns a copy of labels in which the labels marking the resource as ephemeral are replaced by KeepLabel.
+func Keep(labels map[string]string) map[string]string {
+	kept := map[string]string{KeepLabel: "true"}
+	for k, v := range labels {
+		if k == Label || k == OwnerLabel {
+			continue
+		}
+		kept[k] = v
+	}
+	return kept
+}
+
+// KeepFilter returns the label filter matching kept resources, in the form accepted by the docker API.
+func KeepFilter() string {
+	return KeepLabel + "=true"
+}
+
+// Filter returns the label filter matching ephemeral resources, in the form accepted by the docker API.
+func Filter() string {
+	return Label + "=true"
+}
+
+// Orphaned reports whether the resource with the given labels was created by a pack process that is no longer running.
+// Resources created on another host are never reported as orphaned, as there is no way to tell whether their owner is running.
+func Orphaned(labels map[string]string) bool {
+	parts := strings.SplitN(labels[OwnerLabel], "@", 2)
+	if len(parts) != 2 {
+		return true
+	}
+
+	pid, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return true
+	}
+
+	if hostname, err := os.Hostname(); err != nil || parts[1] != hostname {
+		return false
+	}
+
+	return !processRunning(pid)
+}
+
+func owner(pid int) string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%d@%s", pid, hostname)
+}
+
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// on Windows finding the process opens it, which fails if it is not running
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	// signal 0 checks that the process exists without signalling it, a process of another user cannot be signalled
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,81 @@
+package ephemeral_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/ephemeral"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestEphemeral(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "Ephemeral", testEphemeral, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testEphemeral(t *testing.T, when spec.G, it spec.S) {
+	var hostname string
+
+	it.Before(func() {
+		var err error
+		hostname, err = os.Hostname()
+		h.AssertNil(t, err)
+	})
+
+	when("#Labels", func() {
+		it("marks the resource as ephemeral and owned by this process", func() {
+			h.AssertEq(t, ephemeral.Labels(), map[string]string{
+				ephemeral.Label:      "true",
+				ephemeral.OwnerLabel: fmt.Sprintf("%d@%s", os.Getpid(), hostname),
+			})
+		})
+	})
+
+	when("#Keep", func() {
+		it("replaces the ephemeral labels and keeps the others", func() {
+			labels := ephemeral.Labels()
+			labels["author"] = "pack"
+
+			h.AssertEq(t, ephemeral.Keep(labels), map[string]string{
+				ephemeral.KeepLabel: "true",
+				"author":            "pack",
+			})
+			h.AssertEq(t, labels[ephemeral.Label], "true")
+		})
+	})
+
+	when("#Orphaned", func() {
+		it("is false for resources of this process", func() {
+			h.AssertFalse(t, ephemeral.Orphaned(ephemeral.Labels()))
+		})
+
+		it("is true for resources of a process that has exited", func() {
+			cmd := exec.Command(os.Args[0], "-test.run=^$")
+			h.AssertNil(t, cmd.Run())
+
+			h.AssertTrue(t, ephemeral.Orphaned(map[string]string{
+				ephemeral.Label:      "true",
+				ephemeral.OwnerLabel: fmt.Sprintf("%d@%s", cmd.Process.Pid, hostname),
+			}))
+		})
+
+		it("is false for resources created on another host", func() {
+			h.AssertFalse(t, ephemeral.Orphaned(map[string]string{
+				ephemeral.Label:      "true",
+				ephemeral.OwnerLabel: "1@some-other-host",
+			}))
+		})
+
+		it("is true for resources without a valid owner", func() {
+			h.AssertTrue(t, ephemeral.Orphaned(map[string]string{ephemeral.Label: "true"}))
+			h.AssertTrue(t, ephemeral.Orphaned(map[string]string{ephemeral.Label: "true", ephemeral.OwnerLabel: "pid@" + hostname}))
+		})
+	})
+}
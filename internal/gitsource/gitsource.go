@@ -0,0 +1,320 @@
+// Package gitsource describes the git commit an app is built from.
+package gitsource
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/utils/merkletrie"
+	"gopkg.in/src-d/go-git.v4/utils/merkletrie/filesystem"
+	mindex "gopkg.in/src-d/go-git.v4/utils/merkletrie/index"
+	"gopkg.in/src-d/go-git.v4/utils/merkletrie/noder"
+)
+
+// Source is the commit checked out in a git work tree.
+type Source struct {
+	// URL of the origin remote, or of the first remote when there is no origin. Credentials are removed.
+	URL string
+
+	// Commit is the SHA of the commit checked out.
+	Commit string
+
+	// Branch is the name of the branch checked out, and is empty when HEAD is detached.
+	Branch string
+
+	// Dirty is set when the files under the directory detected have changes or untracked files which are not committed.
+	Dirty bool
+}
+
+// Detect returns the source of the git work tree containing dir, or nil when dir is not within a git work tree.
+// When checkDirty is set, the files under dir are compared with the commit checked out. Only those files are read, so
+// that the rest of a large repository is not hashed.
+func Detect(dir string, checkDirty bool) (*Source, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err == git.ErrRepositoryNotExists {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "opening git repository")
+	}
+
+	head, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		// nothing has been committed yet
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading git HEAD")
+	}
+
+	source := &Source{Commit: head.Hash().String()}
+	if head.Name().IsBranch() {
+		source.Branch = head.Name().Short()
+	}
+
+	if source.URL, err = remoteURL(repo); err != nil {
+		return nil, err
+	}
+
+	if !checkDirty {
+		return source, nil
+	}
+	worktree, err := repo.Worktree()
+	if err == git.ErrIsBareRepository {
+		return source, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "opening git work tree")
+	}
+	if source.Dirty, err = dirty(repo, worktree, head.Hash(), dir); err != nil {
+		return nil, errors.Wrap(err, "reading git status")
+	}
+
+	return source, nil
+}
+
+// dirty reports whether the files under dir differ from the commit checked out, either in the index or in the work tree.
+// It compares the same trees as git.Worktree.Status, which reads every file of the work tree, narrowed to dir.
+func dirty(repo *git.Repository, worktree *git.Worktree, commit plumbing.Hash, dir string) (bool, error) {
+	path, err := relativePath(worktree.Filesystem.Root(), dir)
+	if err != nil {
+		return false, err
+	}
+
+	var committed noder.Noder
+	c, err := repo.CommitObject(commit)
+	if err != nil {
+		return false, err
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return false, err
+	}
+	if len(path) > 0 {
+		tree, err = tree.Tree(strings.Join(path, "/"))
+	}
+	switch {
+	case err == object.ErrDirectoryNotFound:
+	case err != nil:
+		return false, err
+	default:
+		committed = object.NewTreeRootNode(tree)
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return false, err
+	}
+	// a noder is walked by a single diff, so one is created for each of them
+	staged := subIndex(idx, path)
+
+	changes, err := merkletrie.DiffTree(committed, mindex.NewRootNode(staged), isEqual)
+	if err != nil || len(changes) > 0 {
+		return len(changes) > 0, err
+	}
+
+	fs, err := worktree.Filesystem.Chroot(filepath.Join(path...))
+	if err != nil {
+		return false, err
+	}
+	submodules, err := submodules(worktree, path)
+	if err != nil {
+		return false, err
+	}
+	changes, err = merkletrie.DiffTree(mindex.NewRootNode(staged), filesystem.NewRootNode(fs, submodules), isEqual)
+	if err != nil {
+		return false, err
+	}
+
+	patterns, err := ignorePatterns(worktree, path)
+	if err != nil {
+		return false, err
+	}
+	matcher := gitignore.NewMatcher(patterns)
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return false, err
+		}
+		if action == merkletrie.Insert && matcher.Match(append(path, changedPath(change.To)...), change.To.IsDir()) {
+			continue
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// relativePath returns the components of the path of dir within the work tree at root.
+func relativePath(root, dir string) ([]string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return nil, err
+	}
+	if rel == "." {
+		return nil, nil
+	}
+	if strings.HasPrefix(rel, "..") {
+		return nil, errors.Errorf("%s is not within the work tree %s", dir, root)
+	}
+	return strings.Split(filepath.ToSlash(rel), "/"), nil
+}
+
+// subIndex returns the entries of idx under path, named relative to path.
+func subIndex(idx *index.Index, path []string) *index.Index {
+	if len(path) == 0 {
+		return idx
+	}
+	prefix := strings.Join(path, "/") + "/"
+	sub := &index.Index{Version: idx.Version}
+	for _, e := range idx.Entries {
+		if strings.HasPrefix(e.Name, prefix) {
+			entry := *e
+			entry.Name = strings.TrimPrefix(e.Name, prefix)
+			sub.Entries = append(sub.Entries, &entry)
+		}
+	}
+	return sub
+}
+
+// submodules returns the commits checked out by the submodules under path, keyed by their path relative to it.
+func submodules(worktree *git.Worktree, path []string) (map[string]plumbing.Hash, error) {
+	all, err := worktree.Submodules()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.Join(path, "/")
+	commits := map[string]plumbing.Hash{}
+	for _, s := range all {
+		name := s.Config().Path
+		if prefix != "" {
+			if !strings.HasPrefix(name, prefix+"/") {
+				continue
+			}
+			name = strings.TrimPrefix(name, prefix+"/")
+		}
+
+		status, err := s.Status()
+		if err != nil {
+			return nil, err
+		}
+		commits[name] = status.Current
+		if status.Current.IsZero() {
+			commits[name] = status.Expected
+		}
+	}
+	return commits, nil
+}
+
+// ignorePatterns returns the patterns of the .gitignore files applying to the files under path: the ones of its parent
+// directories and the ones under it, followed by the excludes of the work tree.
+func ignorePatterns(worktree *git.Worktree, path []string) ([]gitignore.Pattern, error) {
+	var patterns []gitignore.Pattern
+	for i := range path {
+		ps, err := readIgnoreFile(worktree, path[:i])
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, ps...)
+	}
+
+	ps, err := gitignore.ReadPatterns(worktree.Filesystem, path)
+	if err != nil {
+		return nil, err
+	}
+	patterns = append(patterns, ps...)
+	return append(patterns, worktree.Excludes...), nil
+}
+
+// readIgnoreFile reads the patterns of the .gitignore file of the directory at path, which gitignore.ReadPatterns reads
+// along with the ones of every directory under it.
+func readIgnoreFile(worktree *git.Worktree, path []string) ([]gitignore.Pattern, error) {
+	fs := worktree.Filesystem
+	f, err := fs.Open(fs.Join(append(append([]string{}, path...), ".gitignore")...))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	var patterns []gitignore.Pattern
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "#") && strings.TrimSpace(line) != "" {
+			patterns = append(patterns, gitignore.ParsePattern(line, path))
+		}
+	}
+	return patterns, nil
+}
+
+func changedPath(path noder.Path) []string {
+	var names []string
+	for _, n := range path {
+		names = append(names, n.Name())
+	}
+	return names
+}
+
+var emptyHash = make([]byte, 24)
+
+// isEqual compares noders by their hash, as git.Worktree.Status does. Directories of the work tree have no hash, so are
+// never equal and are compared by their files.
+func isEqual(a, b noder.Hasher) bool {
+	if bytes.Equal(a.Hash(), emptyHash) || bytes.Equal(b.Hash(), emptyHash) {
+		return false
+	}
+	return bytes.Equal(a.Hash(), b.Hash())
+}
+
+func remoteURL(repo *git.Repository) (string, error) {
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return "", errors.Wrap(err, "reading git remotes")
+	}
+	if len(remotes) == 0 {
+		return "", nil
+	}
+
+	remote := remotes[0]
+	for _, r := range remotes {
+		if r.Config().Name == git.DefaultRemoteName {
+			remote = r
+		}
+	}
+	if len(remote.Config().URLs) == 0 {
+		return "", nil
+	}
+	return redact(remote.Config().URLs[0]), nil
+}
+
+// redact removes the credentials from the user info of a URL, such as a token used by a CI system to clone.
+// scp-like addresses such as git@github.com:org/repo.git are not URLs and are returned as is.
+func redact(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	if _, hasPassword := u.User.Password(); hasPassword || u.Scheme == "https" || u.Scheme == "http" {
+		u.User = nil
+	}
+	return u.String()
+}
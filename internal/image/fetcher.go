@@ -15,6 +15,7 @@ import (
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/google/go-containerregistry/pkg/authn"
+	gname "github.com/google/go-containerregistry/pkg/name"
 	"github.com/pkg/errors"
 
 	"github.com/buildpacks/pack/config"
@@ -69,17 +70,24 @@ func (f *Fetcher) Fetch(ctx context.Context, name string, options FetchOptions)
 	}
 
 	if !options.Daemon {
-		return f.fetchRemoteImage(name)
+		return f.fetchRemoteImage(name, options.Platform)
 	}
 
-	switch options.PullPolicy {
-	case config.PullNever:
-		img, err := f.fetchDaemonImage(name)
-		return img, err
-	case config.PullIfNotPresent:
-		img, err := f.fetchDaemonImage(name)
-		if err == nil || !errors.Is(err, ErrNotFound) {
-			return img, err
+	if options.PullPolicy == config.PullNever {
+		return f.fetchDaemonImage(name)
+	}
+
+	img, err := f.fetchDaemonImage(name)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		// the daemon holds a single platform per name, so an image for another platform has to be pulled
+		if !matchesPlatform(img, options.Platform) {
+			return f.fetchPlatformImage(ctx, name, options.Platform, options.PullPolicy, img)
+		}
+		if options.PullPolicy == config.PullIfNotPresent {
+			return img, nil
 		}
 	}
 
@@ -92,6 +100,54 @@ func (f *Fetcher) Fetch(ctx context.Context, name string, options FetchOptions)
 	return f.fetchDaemonImage(name)
 }
 
+// fetchPlatformImage fetches the daemon image name for platform when the daemon image under that name, existing, is for another platform.
+// The image pulled for the platform is kept under a name of its own and name is given back to existing,
+// so that building for another platform never replaces an image of the daemon.
+func (f *Fetcher) fetchPlatformImage(ctx context.Context, name, platform string, pullPolicy config.PullPolicy, existing imgutil.Image) (imgutil.Image, error) {
+	p, err := ParsePlatform(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := gname.ParseReference(name, gname.WeakValidation)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid image name %s", style.Symbol(name))
+	}
+	// pulling by digest does not tag the image, so no image is replaced
+	tag, ok := ref.(gname.Tag)
+	if !ok {
+		if err := f.pullImage(ctx, name, platform); err != nil {
+			return nil, err
+		}
+		return f.fetchDaemonImage(name)
+	}
+	platformName := PlatformTag(tag, p)
+
+	if pullPolicy == config.PullIfNotPresent {
+		if img, err := f.fetchDaemonImage(platformName); err == nil && matchesPlatform(img, platform) {
+			return img, nil
+		}
+	}
+
+	id, err := existing.Identifier()
+	if err != nil {
+		return nil, err
+	}
+
+	f.logger.Debugf("Pulling image %s for platform %s", style.Symbol(name), style.Symbol(platform))
+	if err := f.pullImage(ctx, name, platform); err != nil {
+		return nil, err
+	}
+	if err := f.docker.ImageTag(ctx, name, platformName); err != nil {
+		return nil, errors.Wrapf(err, "tagging image %s", style.Symbol(platformName))
+	}
+	if err := f.docker.ImageTag(ctx, id.String(), name); err != nil {
+		return nil, errors.Wrapf(err, "restoring image %s", style.Symbol(name))
+	}
+
+	return f.fetchDaemonImage(platformName)
+}
+
 func (f *Fetcher) fetchDaemonImage(name string) (imgutil.Image, error) {
 	image, err := local.NewImage(name, f.docker, local.FromBaseImage(name))
 	if err != nil {
@@ -105,8 +161,17 @@ func (f *Fetcher) fetchDaemonImage(name string) (imgutil.Image, error) {
 	return image, nil
 }
 
-func (f *Fetcher) fetchRemoteImage(name string) (imgutil.Image, error) {
-	image, err := remote.NewImage(name, authn.DefaultKeychain, remote.FromBaseImage(name))
+func (f *Fetcher) fetchRemoteImage(name string, platform string) (imgutil.Image, error) {
+	imageOpts := []remote.ImageOption{remote.FromBaseImage(name)}
+	if platform != "" {
+		p, err := ParsePlatform(platform)
+		if err != nil {
+			return nil, err
+		}
+		imageOpts = append(imageOpts, remote.WithDefaultPlatform(imgutil.Platform{OS: p.OS, Architecture: p.Architecture}))
+	}
+
+	image, err := remote.NewImage(name, authn.DefaultKeychain, imageOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +183,27 @@ func (f *Fetcher) fetchRemoteImage(name string) (imgutil.Image, error) {
 	return image, nil
 }
 
+func matchesPlatform(img imgutil.Image, platform string) bool {
+	if platform == "" {
+		return true
+	}
+
+	p, err := ParsePlatform(platform)
+	if err != nil {
+		return false
+	}
+
+	imgOS, err := img.OS()
+	if err != nil {
+		return false
+	}
+	imgArch, err := img.Architecture()
+	if err != nil {
+		return false
+	}
+	return imgOS == p.OS && imgArch == p.Architecture
+}
+
 func (f *Fetcher) pullImage(ctx context.Context, imageID string, platform string) error {
 	regAuth, err := registryAuth(imageID)
 	if err != nil {
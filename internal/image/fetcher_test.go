@@ -324,6 +324,53 @@ func testFetcher(t *testing.T, when spec.G, it spec.S) {
 						h.AssertError(t, err, "unknown operating system or architecture")
 					})
 				})
+
+				when("there is a local image for another platform", func() {
+					var (
+						platform     string
+						platformName string
+					)
+
+					it.Before(func() {
+						remoteImg, err := local.NewImage(repoName, docker)
+						h.AssertNil(t, err)
+						h.AssertNil(t, remoteImg.SetLabel("label", "remote"))
+						h.AssertNil(t, remoteImg.Save())
+						h.AssertNil(t, h.PushImage(docker, remoteImg.Name(), registryConfig))
+
+						imgOS, err := remoteImg.OS()
+						h.AssertNil(t, err)
+						imgArch, err := remoteImg.Architecture()
+						h.AssertNil(t, err)
+						platform = imgOS + "/" + imgArch
+						platformName = repoName + ":latest-" + imgOS + "-" + imgArch
+
+						localImg, err := local.NewImage(repoName, docker)
+						h.AssertNil(t, err)
+						h.AssertNil(t, localImg.SetLabel("label", "local"))
+						h.AssertNil(t, localImg.SetArchitecture("some-other-arch"))
+						h.AssertNil(t, localImg.Save())
+					})
+
+					it.After(func() {
+						h.DockerRmi(docker, repoName, platformName)
+					})
+
+					it("keeps the image pulled for the platform under a name of its own", func() {
+						fetchedImg, err := fetcher.Fetch(context.TODO(), repoName, image.FetchOptions{Daemon: true, PullPolicy: pubcfg.PullIfNotPresent, Platform: platform})
+						h.AssertNil(t, err)
+						h.AssertEq(t, fetchedImg.Name(), platformName)
+						fetchedLabel, err := fetchedImg.Label("label")
+						h.AssertNil(t, err)
+						h.AssertEq(t, fetchedLabel, "remote")
+
+						localImg, err := local.NewImage(repoName, docker, local.FromBaseImage(repoName))
+						h.AssertNil(t, err)
+						localLabel, err := localImg.Label("label")
+						h.AssertNil(t, err)
+						h.AssertEq(t, localLabel, "local")
+					})
+				})
 			})
 		})
 	})
@@ -0,0 +1,130 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/style"
+)
+
+// ParsePlatform parses a platform in the form os/arch[/variant], for example linux/arm64/v8.
+func ParsePlatform(platform string) (v1.Platform, error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v1.Platform{}, errors.Errorf("invalid platform %s: expected the form os/arch[/variant]", style.Symbol(platform))
+	}
+	for _, part := range parts {
+		if part == "" {
+			return v1.Platform{}, errors.Errorf("invalid platform %s: expected the form os/arch[/variant]", style.Symbol(platform))
+		}
+	}
+
+	p := v1.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// PlatformTag returns the name of tag with the platform appended to it, e.g. my/app:latest-linux-arm64.
+func PlatformTag(ref name.Reference, platform v1.Platform) string {
+	return fmt.Sprintf("%s:%s-%s", ref.Context().Name(), ref.Identifier(), PlatformSuffix(platform))
+}
+
+// PlatformSuffix returns the platform in a form that can be part of a tag or a path, e.g. linux-arm64-v8.
+func PlatformSuffix(platform v1.Platform) string {
+	suffix := platform.OS + "-" + platform.Architecture
+	if platform.Variant != "" {
+		suffix += "-" + platform.Variant
+	}
+	return suffix
+}
+
+// IndexManifest is an image to reference from an image index along with the platform it was built for.
+type IndexManifest struct {
+	Ref      name.Digest
+	Platform v1.Platform
+}
+
+// ResolveDigest returns a reference to the manifest that ref currently points to in the registry.
+func ResolveDigest(ctx context.Context, keychain authn.Keychain, ref name.Reference) (name.Digest, error) {
+	desc, err := remote.Head(ref, remote.WithAuthFromKeychain(keychain), remote.WithContext(ctx))
+	if err != nil {
+		return name.Digest{}, errors.Wrapf(err, "resolving digest of %s", style.Symbol(ref.Name()))
+	}
+
+	return ref.Context().Digest(desc.Digest.String()), nil
+}
+
+// WriteManifest publishes img to repo by its digest only, without tagging it, and returns a reference to it.
+func WriteManifest(ctx context.Context, keychain authn.Keychain, repo name.Repository, img v1.Image) (name.Digest, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return name.Digest{}, errors.Wrap(err, "computing image digest")
+	}
+
+	ref := repo.Digest(digest.String())
+	if err := remote.Write(ref, img, remote.WithAuthFromKeychain(keychain), remote.WithContext(ctx)); err != nil {
+		return name.Digest{}, errors.Wrapf(err, "writing image %s", style.Symbol(ref.Name()))
+	}
+	return ref, nil
+}
+
+// WriteIndex publishes an image index referencing each of manifests under ref and any additional tags, and returns its digest.
+// The manifests must already exist in the repository of ref.
+func WriteIndex(ctx context.Context, keychain authn.Keychain, ref name.Reference, manifests []IndexManifest, additionalTags ...name.Tag) (v1.Hash, error) {
+	opts := []remote.Option{remote.WithAuthFromKeychain(keychain), remote.WithContext(ctx)}
+
+	var (
+		adds      []mutate.IndexAddendum
+		mediaType = types.DockerManifestList
+	)
+	for _, manifest := range manifests {
+		desc, err := remote.Get(manifest.Ref, opts...)
+		if err != nil {
+			return v1.Hash{}, errors.Wrapf(err, "fetching manifest %s", style.Symbol(manifest.Ref.Name()))
+		}
+
+		img, err := desc.Image()
+		if err != nil {
+			return v1.Hash{}, errors.Wrapf(err, "reading image %s", style.Symbol(manifest.Ref.Name()))
+		}
+
+		// docker manifest lists may only reference docker manifests
+		if desc.MediaType != types.DockerManifestSchema2 {
+			mediaType = types.OCIImageIndex
+		}
+
+		platform := manifest.Platform
+		adds = append(adds, mutate.IndexAddendum{
+			Add: img,
+			Descriptor: v1.Descriptor{
+				MediaType: desc.MediaType,
+				Platform:  &platform,
+			},
+		})
+	}
+
+	index := mutate.IndexMediaType(mutate.AppendManifests(empty.Index, adds...), mediaType)
+	if err := remote.WriteIndex(ref, index, opts...); err != nil {
+		return v1.Hash{}, errors.Wrapf(err, "writing image index %s", style.Symbol(ref.Name()))
+	}
+
+	for _, tag := range additionalTags {
+		if err := remote.Tag(tag, index, opts...); err != nil {
+			return v1.Hash{}, errors.Wrapf(err, "tagging image index %s", style.Symbol(tag.Name()))
+		}
+	}
+
+	return index.Digest()
+}
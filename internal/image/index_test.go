@@ -0,0 +1,128 @@
+package image_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/image"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestIndex(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "Index", testIndex, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testIndex(t *testing.T, when spec.G, it spec.S) {
+	when("#ParsePlatform", func() {
+		it("parses os and architecture", func() {
+			platform, err := image.ParsePlatform("linux/amd64")
+			h.AssertNil(t, err)
+			h.AssertEq(t, platform, v1.Platform{OS: "linux", Architecture: "amd64"})
+		})
+
+		it("parses the variant", func() {
+			platform, err := image.ParsePlatform("linux/arm64/v8")
+			h.AssertNil(t, err)
+			h.AssertEq(t, platform, v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"})
+		})
+
+		it("errors for invalid platforms", func() {
+			for _, platform := range []string{"linux", "linux/", "linux/arm64/v8/extra"} {
+				_, err := image.ParsePlatform(platform)
+				h.AssertError(t, err, "expected the form os/arch[/variant]")
+			}
+		})
+	})
+
+	when("#WriteIndex", func() {
+		var (
+			server   *httptest.Server
+			repoName string
+		)
+
+		it.Before(func() {
+			server = httptest.NewServer(registry.New())
+			repoName = strings.TrimPrefix(server.URL, "http://") + "/some/app"
+		})
+
+		it.After(func() {
+			server.Close()
+		})
+
+		pushImage := func(tag string) name.Digest {
+			t.Helper()
+			img, err := random.Image(10, 1)
+			h.AssertNil(t, err)
+			ref, err := name.ParseReference(tag)
+			h.AssertNil(t, err)
+			h.AssertNil(t, remote.Write(ref, img))
+
+			digest, err := image.ResolveDigest(context.TODO(), authn.DefaultKeychain, ref)
+			h.AssertNil(t, err)
+			return digest
+		}
+
+		it("publishes an index referencing each manifest under each tag", func() {
+			amd64 := pushImage(repoName + ":latest")
+			arm64 := pushImage(repoName + ":latest")
+
+			ref, err := name.ParseReference(repoName + ":latest")
+			h.AssertNil(t, err)
+			additionalTag, err := name.NewTag(repoName + ":other")
+			h.AssertNil(t, err)
+
+			digest, err := image.WriteIndex(context.TODO(), authn.DefaultKeychain, ref, []image.IndexManifest{
+				{Ref: amd64, Platform: v1.Platform{OS: "linux", Architecture: "amd64"}},
+				{Ref: arm64, Platform: v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+			}, additionalTag)
+			h.AssertNil(t, err)
+
+			for _, tag := range []string{repoName + ":latest", repoName + ":other"} {
+				tagRef, err := name.ParseReference(tag)
+				h.AssertNil(t, err)
+				index, err := remote.Index(tagRef)
+				h.AssertNil(t, err)
+
+				indexDigest, err := index.Digest()
+				h.AssertNil(t, err)
+				h.AssertEq(t, indexDigest, digest)
+
+				manifest, err := index.IndexManifest()
+				h.AssertNil(t, err)
+				h.AssertEq(t, manifest.MediaType, types.DockerManifestList)
+				h.AssertEq(t, len(manifest.Manifests), 2)
+				h.AssertEq(t, manifest.Manifests[0].Digest.String(), amd64.DigestStr())
+				h.AssertEq(t, manifest.Manifests[0].Platform.Architecture, "amd64")
+				h.AssertEq(t, manifest.Manifests[1].Digest.String(), arm64.DigestStr())
+				h.AssertEq(t, manifest.Manifests[1].Platform.Variant, "v8")
+			}
+		})
+
+		it("errors when a manifest does not exist", func() {
+			ref, err := name.ParseReference(repoName + ":latest")
+			h.AssertNil(t, err)
+			missing, err := name.NewDigest(repoName + "@sha256:0000000000000000000000000000000000000000000000000000000000000000")
+			h.AssertNil(t, err)
+
+			_, err = image.WriteIndex(context.TODO(), authn.DefaultKeychain, ref, []image.IndexManifest{
+				{Ref: missing, Platform: v1.Platform{OS: "linux", Architecture: "amd64"}},
+			})
+			h.AssertError(t, err, "fetching manifest")
+		})
+	})
+}
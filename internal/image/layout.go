@@ -0,0 +1,117 @@
+package image
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/style"
+	"github.com/buildpacks/pack/pkg/archive"
+)
+
+// RefNameAnnotation is the annotation naming an image within an OCI image layout.
+const RefNameAnnotation = "org.opencontainers.image.ref.name"
+
+// WriteLayout adds img to the OCI image layout at dir, creating the layout if dir does not exist or is empty.
+// When refName is set the image is annotated with it, replacing any image in the layout with the same name.
+func WriteLayout(dir string, img v1.Image, refName string) error {
+	p, err := layout.FromPath(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return errors.Wrapf(err, "reading layout %s", style.Symbol(dir))
+		}
+		// files which are not a layout are never overwritten
+		files, err := ioutil.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "reading %s", style.Symbol(dir))
+		}
+		if len(files) > 0 {
+			return errors.Errorf("%s is not empty and is not an OCI image layout", style.Symbol(dir))
+		}
+		if p, err = layout.Write(dir, empty.Index); err != nil {
+			return errors.Wrap(err, "writing index")
+		}
+	}
+
+	if refName == "" {
+		if err := p.AppendImage(img); err != nil {
+			return errors.Wrap(err, "writing layout")
+		}
+		return nil
+	}
+
+	annotations := map[string]string{RefNameAnnotation: refName}
+	if err := p.ReplaceImage(img, match.Annotation(RefNameAnnotation, refName), layout.WithAnnotations(annotations)); err != nil {
+		return errors.Wrap(err, "writing layout")
+	}
+	return nil
+}
+
+// ReadLayout returns the image of the OCI image layout at dir annotated with refName or,
+// when refName is empty, the only image of the layout.
+func ReadLayout(dir, refName string) (v1.Image, error) {
+	p, err := layout.FromPath(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading layout %s", style.Symbol(dir))
+	}
+	index, err := p.ImageIndex()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading layout index")
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading layout index")
+	}
+
+	var images []v1.Descriptor
+	for _, desc := range manifest.Manifests {
+		if !desc.MediaType.IsImage() {
+			continue
+		}
+		if refName == "" || desc.Annotations[RefNameAnnotation] == refName {
+			images = append(images, desc)
+		}
+	}
+
+	switch {
+	case len(images) == 0 && refName != "":
+		return nil, errors.Errorf("layout %s has no image named %s", style.Symbol(dir), style.Symbol(refName))
+	case len(images) == 0:
+		return nil, errors.Errorf("layout %s has no images", style.Symbol(dir))
+	case len(images) > 1:
+		return nil, errors.Errorf("layout %s has %d images, one must be selected by name", style.Symbol(dir), len(images))
+	}
+	return index.Image(images[0].Digest)
+}
+
+// WriteLayoutArchive writes img as an OCI image layout to a tar archive at path.
+func WriteLayoutArchive(path string, img v1.Image, refName string) error {
+	tmpDir, err := ioutil.TempDir("", "oci-layout")
+	if err != nil {
+		return errors.Wrap(err, "creating oci-layout temp dir")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	layoutDir := filepath.Join(tmpDir, "layout")
+	if err := WriteLayout(layoutDir, img, refName); err != nil {
+		return err
+	}
+
+	outputFile, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "creating output file")
+	}
+	defer outputFile.Close()
+
+	tw := tar.NewWriter(outputFile)
+	defer tw.Close()
+
+	return archive.WriteDirToTar(tw, layoutDir, "/", 0, 0, 0755, true, false, nil)
+}
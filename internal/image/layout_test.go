@@ -0,0 +1,108 @@
+package image_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/image"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestLayout(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "Layout", testLayout, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testLayout(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir string
+		img    v1.Image
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "layout-test")
+		h.AssertNil(t, err)
+
+		img, err = random.Image(10, 1)
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	assertSameImage := func(actual v1.Image) {
+		t.Helper()
+		expected, err := img.Digest()
+		h.AssertNil(t, err)
+		digest, err := actual.Digest()
+		h.AssertNil(t, err)
+		h.AssertEq(t, digest, expected)
+	}
+
+	when("#WriteLayout", func() {
+		it("creates the layout in a directory which does not exist", func() {
+			dir := filepath.Join(tmpDir, "missing")
+			h.AssertNil(t, image.WriteLayout(dir, img, "some/app"))
+
+			read, err := image.ReadLayout(dir, "some/app")
+			h.AssertNil(t, err)
+			assertSameImage(read)
+		})
+
+		it("errors for a directory which is not a layout", func() {
+			h.AssertNil(t, ioutil.WriteFile(filepath.Join(tmpDir, "some-file"), []byte("some-content"), 0600))
+
+			err := image.WriteLayout(tmpDir, img, "some/app")
+			h.AssertError(t, err, "is not empty and is not an OCI image layout")
+
+			contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "some-file"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, string(contents), "some-content")
+		})
+	})
+
+	when("#ReadLayout", func() {
+		it("reads the image with the name", func() {
+			other, err := random.Image(10, 1)
+			h.AssertNil(t, err)
+			h.AssertNil(t, image.WriteLayout(tmpDir, other, "some/other-app"))
+			h.AssertNil(t, image.WriteLayout(tmpDir, img, "some/app"))
+
+			read, err := image.ReadLayout(tmpDir, "some/app")
+			h.AssertNil(t, err)
+			assertSameImage(read)
+		})
+
+		it("reads the only image without a name", func() {
+			h.AssertNil(t, image.WriteLayout(tmpDir, img, "some/app"))
+
+			read, err := image.ReadLayout(tmpDir, "")
+			h.AssertNil(t, err)
+			assertSameImage(read)
+		})
+
+		it("errors without a name when there are several images", func() {
+			h.AssertNil(t, image.WriteLayout(tmpDir, img, "some/app"))
+			h.AssertNil(t, image.WriteLayout(tmpDir, img, "some/other-app"))
+
+			_, err := image.ReadLayout(tmpDir, "")
+			h.AssertError(t, err, "has 2 images, one must be selected by name")
+		})
+
+		it("errors when there is no layout", func() {
+			_, err := image.ReadLayout(filepath.Join(tmpDir, "missing"), "")
+			h.AssertError(t, err, "reading layout")
+		})
+	})
+}
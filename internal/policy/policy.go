@@ -0,0 +1,231 @@
+// Package policy reads and enforces the organisation policy restricting the builders, run images,
+// buildpacks and lifecycles pack uses, and the registry it publishes images to.
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Masterminds/semver"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/style"
+)
+
+// Policy is an organisation policy, read from a TOML file such as:
+//
+//	[builders]
+//	allowed = ["registry.example.com/builders/base", "paketobuildpacks/builder@sha256:..."]
+//
+//	[run-images]
+//	allowed = ["registry.example.com/run/base"]
+//
+//	[buildpacks]
+//	forbidden = ["example/unvetted"]
+//
+//	[lifecycle]
+//	minimum-version = "0.11.0"
+//
+//	[publish]
+//	registry = "registry.example.com"
+//
+// A nil Policy allows everything.
+type Policy struct {
+	Builders   AllowList  `toml:"builders"`
+	RunImages  AllowList  `toml:"run-images"`
+	Buildpacks Buildpacks `toml:"buildpacks"`
+	Lifecycle  Lifecycle  `toml:"lifecycle"`
+	Publish    Publish    `toml:"publish"`
+
+	path             string
+	minimumLifecycle *semver.Version
+	publishRegistry  string
+}
+
+// AllowList is a list of image repositories. A repository given with a digest allows only the image with that digest.
+// An empty list allows any image.
+type AllowList struct {
+	Allowed []string `toml:"allowed"`
+}
+
+type Buildpacks struct {
+	Forbidden []string `toml:"forbidden"`
+}
+
+type Lifecycle struct {
+	MinimumVersion string `toml:"minimum-version"`
+}
+
+// Publish requires images to be published to Registry, when it is set.
+type Publish struct {
+	Registry string `toml:"registry"`
+}
+
+// Read reads the policy at path.
+func Read(path string) (*Policy, error) {
+	p := &Policy{path: path}
+	if _, err := toml.DecodeFile(path, p); err != nil {
+		return nil, errors.Wrapf(err, "reading policy %s", style.Symbol(path))
+	}
+
+	for _, list := range []AllowList{p.Builders, p.RunImages} {
+		for _, entry := range list.Allowed {
+			if _, err := name.ParseReference(entry, name.WeakValidation); err != nil {
+				return nil, errors.Wrapf(err, "invalid image %s in policy %s", style.Symbol(entry), style.Symbol(path))
+			}
+		}
+	}
+
+	if p.Lifecycle.MinimumVersion != "" {
+		v, err := semver.NewVersion(p.Lifecycle.MinimumVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid lifecycle minimum-version %s in policy %s", style.Symbol(p.Lifecycle.MinimumVersion), style.Symbol(path))
+		}
+		p.minimumLifecycle = v
+	}
+
+	if p.Publish.Registry != "" {
+		registry, err := name.NewRegistry(p.Publish.Registry, name.WeakValidation)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid publish registry %s in policy %s", style.Symbol(p.Publish.Registry), style.Symbol(path))
+		}
+		p.publishRegistry = registry.RegistryStr()
+	}
+	return p, nil
+}
+
+// DigestsFunc returns the digests an image is known by. It is only called when the policy pins the image by digest.
+type DigestsFunc func() ([]string, error)
+
+// CheckBuilder checks that the builder ref is allowed.
+func (p *Policy) CheckBuilder(ref name.Reference, digests DigestsFunc) error {
+	if p == nil {
+		return nil
+	}
+	return p.checkImage("builder", p.Builders, ref, digests)
+}
+
+// CheckUnidentifiedBuilder checks that a builder which cannot be identified, such as the build image the local executor
+// runs in, is allowed. That is only the case when the policy allows any builder.
+func (p *Policy) CheckUnidentifiedBuilder(description string) error {
+	if p == nil || len(p.Builders.Allowed) == 0 {
+		return nil
+	}
+	return p.violation("%s cannot be checked against the allowed builders", description)
+}
+
+// CheckRunImage checks that the run image ref is allowed.
+func (p *Policy) CheckRunImage(ref name.Reference, digests DigestsFunc) error {
+	if p == nil {
+		return nil
+	}
+	return p.checkImage("run image", p.RunImages, ref, digests)
+}
+
+func (p *Policy) checkImage(kind string, list AllowList, ref name.Reference, digests DigestsFunc) error {
+	if len(list.Allowed) == 0 {
+		return nil
+	}
+
+	var pinned []string
+	for _, entry := range list.Allowed {
+		allowed, err := name.ParseReference(entry, name.WeakValidation)
+		if err != nil {
+			return err
+		}
+		if allowed.Context().Name() != ref.Context().Name() {
+			continue
+		}
+		digest, ok := allowed.(name.Digest)
+		if !ok {
+			return nil
+		}
+		pinned = append(pinned, digest.DigestStr())
+	}
+	if len(pinned) == 0 {
+		return p.violation("%s %s is not in the allowed %ss", kind, style.Symbol(ref.String()), kind)
+	}
+
+	if digest, ok := ref.(name.Digest); ok && contains(pinned, digest.DigestStr()) {
+		return nil
+	}
+	if digests != nil {
+		resolved, err := digests()
+		if err != nil {
+			return errors.Wrapf(err, "resolving digest of %s %s", kind, style.Symbol(ref.String()))
+		}
+		for _, digest := range resolved {
+			if contains(pinned, digest) {
+				return nil
+			}
+		}
+	}
+	return p.violation("%s %s must have digest %s", kind, style.Symbol(ref.String()), strings.Join(pinned, " or "))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckBuildpacks checks that none of the buildpacks with the given IDs are forbidden.
+func (p *Policy) CheckBuildpacks(ids ...string) error {
+	if p == nil {
+		return nil
+	}
+	for _, id := range ids {
+		if contains(p.Buildpacks.Forbidden, id) {
+			return p.violation("buildpack %s is forbidden", style.Symbol(id))
+		}
+	}
+	return nil
+}
+
+// CheckLifecycle checks that the lifecycle version is at least the minimum version.
+func (p *Policy) CheckLifecycle(version *semver.Version) error {
+	if p == nil || p.minimumLifecycle == nil {
+		return nil
+	}
+	if version == nil || version.LessThan(p.minimumLifecycle) {
+		return p.violation("lifecycle %s is older than the minimum version %s", style.Symbol(versionString(version)), style.Symbol(p.minimumLifecycle.String()))
+	}
+	return nil
+}
+
+// CheckPublish checks that images with the given names, which are published when publish is true,
+// are published to the required registry.
+func (p *Policy) CheckPublish(publish bool, imageNames ...string) error {
+	if p == nil || p.Publish.Registry == "" {
+		return nil
+	}
+	if !publish {
+		return p.violation("images must be published to %s", style.Symbol(p.Publish.Registry))
+	}
+	for _, imageName := range imageNames {
+		ref, err := name.ParseReference(imageName, name.WeakValidation)
+		if err != nil {
+			return errors.Wrapf(err, "invalid image name %s", style.Symbol(imageName))
+		}
+		if ref.Context().RegistryStr() != p.publishRegistry {
+			return p.violation("image %s must be published to %s", style.Symbol(imageName), style.Symbol(p.Publish.Registry))
+		}
+	}
+	return nil
+}
+
+func (p *Policy) violation(format string, args ...interface{}) error {
+	return errors.Errorf("%s, as required by policy %s", fmt.Sprintf(format, args...), style.Symbol(p.path))
+}
+
+func versionString(v *semver.Version) string {
+	if v == nil {
+		return "unknown"
+	}
+	return v.String()
+}
@@ -0,0 +1,240 @@
+package policy_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/policy"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestPolicy(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "Policy", testPolicy, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testPolicy(t *testing.T, when spec.G, it spec.S) {
+	const digest = "sha256:363c754893f0efe22480b4359a5956cf3bd3ce22742fc576973c61348308c2e4"
+
+	var (
+		tmpDir string
+		path   string
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "policy-test")
+		h.AssertNil(t, err)
+		path = filepath.Join(tmpDir, "policy.toml")
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	read := func(contents string) *policy.Policy {
+		t.Helper()
+		h.AssertNil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+		p, err := policy.Read(path)
+		h.AssertNil(t, err)
+		return p
+	}
+
+	parse := func(ref string) name.Reference {
+		t.Helper()
+		r, err := name.ParseReference(ref, name.WeakValidation)
+		h.AssertNil(t, err)
+		return r
+	}
+
+	when("#Read", func() {
+		it("errors for invalid images", func() {
+			h.AssertNil(t, ioutil.WriteFile(path, []byte(`[builders]
+allowed = ["Some/Builder"]`), 0644))
+
+			_, err := policy.Read(path)
+			h.AssertError(t, err, "invalid image 'Some/Builder' in policy")
+		})
+
+		it("errors for invalid lifecycle versions", func() {
+			h.AssertNil(t, ioutil.WriteFile(path, []byte(`[lifecycle]
+minimum-version = "not-a-version"`), 0644))
+
+			_, err := policy.Read(path)
+			h.AssertError(t, err, "invalid lifecycle minimum-version 'not-a-version' in policy")
+		})
+
+		it("errors for missing files", func() {
+			_, err := policy.Read(filepath.Join(tmpDir, "missing.toml"))
+			h.AssertError(t, err, "reading policy")
+		})
+	})
+
+	when("a nil policy", func() {
+		it("allows everything", func() {
+			var p *policy.Policy
+			h.AssertNil(t, p.CheckBuilder(parse("some/builder"), nil))
+			h.AssertNil(t, p.CheckRunImage(parse("some/run"), nil))
+			h.AssertNil(t, p.CheckBuildpacks("some/bp"))
+			h.AssertNil(t, p.CheckLifecycle(nil))
+			h.AssertNil(t, p.CheckPublish(false, "some/app"))
+		})
+	})
+
+	when("#CheckBuilder", func() {
+		it("allows any image of an allowed repository", func() {
+			p := read(`[builders]
+allowed = ["some/builder"]`)
+
+			h.AssertNil(t, p.CheckBuilder(parse("some/builder:tag"), nil))
+			h.AssertNil(t, p.CheckBuilder(parse("index.docker.io/some/builder"), nil))
+		})
+
+		it("errors for other repositories", func() {
+			p := read(`[builders]
+allowed = ["some/builder"]`)
+
+			err := p.CheckBuilder(parse("other/builder"), nil)
+			h.AssertError(t, err, "builder 'other/builder' is not in the allowed builders, as required by policy '"+path+"'")
+		})
+
+		it("allows everything when no builders are listed", func() {
+			p := read(`[buildpacks]
+forbidden = ["some/bp"]`)
+
+			h.AssertNil(t, p.CheckBuilder(parse("other/builder"), nil))
+		})
+
+		when("the repository is pinned by digest", func() {
+			var p *policy.Policy
+
+			it.Before(func() {
+				p = read(`[builders]
+allowed = ["some/builder@` + digest + `"]`)
+			})
+
+			it("allows references by that digest", func() {
+				h.AssertNil(t, p.CheckBuilder(parse("some/builder@"+digest), nil))
+			})
+
+			it("allows images resolved to that digest", func() {
+				h.AssertNil(t, p.CheckBuilder(parse("some/builder:tag"), func() ([]string, error) {
+					return []string{"sha256:other", digest}, nil
+				}))
+			})
+
+			it("errors for images resolved to other digests", func() {
+				err := p.CheckBuilder(parse("some/builder:tag"), func() ([]string, error) {
+					return []string{"sha256:other"}, nil
+				})
+				h.AssertError(t, err, "builder 'some/builder:tag' must have digest "+digest)
+			})
+
+			it("errors when the digests cannot be resolved", func() {
+				err := p.CheckBuilder(parse("some/builder:tag"), func() ([]string, error) {
+					return nil, errors.New("some-error")
+				})
+				h.AssertError(t, err, "resolving digest of builder 'some/builder:tag': some-error")
+			})
+		})
+	})
+
+	when("#CheckUnidentifiedBuilder", func() {
+		it("errors when builders are restricted", func() {
+			p := read(`[builders]
+allowed = ["some/builder"]`)
+
+			h.AssertError(t, p.CheckUnidentifiedBuilder("some build image"), "some build image cannot be checked against the allowed builders")
+		})
+
+		it("allows them when no builders are listed", func() {
+			p := read(`[buildpacks]
+forbidden = ["some/bp"]`)
+
+			h.AssertNil(t, p.CheckUnidentifiedBuilder("some build image"))
+		})
+	})
+
+	when("#CheckRunImage", func() {
+		it("errors for run images not allowed", func() {
+			p := read(`[run-images]
+allowed = ["registry.example.com/run/base"]`)
+
+			h.AssertNil(t, p.CheckRunImage(parse("registry.example.com/run/base:latest"), nil))
+			h.AssertError(t, p.CheckRunImage(parse("some/run"), nil), "run image 'some/run' is not in the allowed run images")
+		})
+	})
+
+	when("#CheckBuildpacks", func() {
+		it("errors for forbidden buildpacks", func() {
+			p := read(`[buildpacks]
+forbidden = ["example/unvetted"]`)
+
+			h.AssertNil(t, p.CheckBuildpacks("some/bp", "other/bp"))
+			h.AssertError(t, p.CheckBuildpacks("some/bp", "example/unvetted"), "buildpack 'example/unvetted' is forbidden")
+		})
+	})
+
+	when("#CheckLifecycle", func() {
+		var p *policy.Policy
+
+		it.Before(func() {
+			p = read(`[lifecycle]
+minimum-version = "0.11.0"`)
+		})
+
+		it("allows the minimum version and newer", func() {
+			h.AssertNil(t, p.CheckLifecycle(semver.MustParse("0.11.0")))
+			h.AssertNil(t, p.CheckLifecycle(semver.MustParse("0.12.1")))
+		})
+
+		it("errors for older versions", func() {
+			err := p.CheckLifecycle(semver.MustParse("0.10.2"))
+			h.AssertError(t, err, "lifecycle '0.10.2' is older than the minimum version '0.11.0'")
+		})
+
+		it("errors for unknown versions", func() {
+			h.AssertError(t, p.CheckLifecycle(nil), "lifecycle 'unknown' is older than the minimum version '0.11.0'")
+		})
+	})
+
+	when("#CheckPublish", func() {
+		var p *policy.Policy
+
+		it.Before(func() {
+			p = read(`[publish]
+registry = "registry.example.com"`)
+		})
+
+		it("allows images published to the registry", func() {
+			h.AssertNil(t, p.CheckPublish(true, "registry.example.com/some/app", "registry.example.com/some/app:v1"))
+		})
+
+		it("errors when images are not published", func() {
+			err := p.CheckPublish(false, "registry.example.com/some/app")
+			h.AssertError(t, err, "images must be published to 'registry.example.com'")
+		})
+
+		it("errors for images in other registries", func() {
+			err := p.CheckPublish(true, "registry.example.com/some/app", "some/app")
+			h.AssertError(t, err, "image 'some/app' must be published to 'registry.example.com'")
+		})
+
+		it("allows everything when no registry is required", func() {
+			p = read(`[buildpacks]
+forbidden = ["some/bp"]`)
+
+			h.AssertNil(t, p.CheckPublish(false, "some/app"))
+		})
+	})
+}
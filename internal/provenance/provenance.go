@@ -0,0 +1,212 @@
+// Package provenance assembles the in-toto provenance statement of a build, in the SLSA provenance format.
+package provenance
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// PayloadType is the DSSE payload type of an in-toto statement.
+	PayloadType = "application/vnd.in-toto+json"
+
+	StatementType = "https://in-toto.io/Statement/v0.1"
+	PredicateType = "https://slsa.dev/provenance/v0.2"
+	BuildType     = "https://buildpacks.io/pack/build@v1"
+
+	builderID = "https://buildpacks.io/pack"
+)
+
+// Statement is an in-toto statement whose predicate is the provenance of its subject.
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Subject is an artifact the statement is about.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Predicate is a SLSA v0.2 provenance predicate.
+type Predicate struct {
+	Builder     Builder     `json:"builder"`
+	BuildType   string      `json:"buildType"`
+	Invocation  Invocation  `json:"invocation"`
+	BuildConfig BuildConfig `json:"buildConfig"`
+	Metadata    Metadata    `json:"metadata"`
+	Materials   []Material  `json:"materials,omitempty"`
+}
+
+// Builder identifies what ran the build, which is pack.
+type Builder struct {
+	ID string `json:"id"`
+}
+
+// Invocation describes how the build was invoked.
+type Invocation struct {
+	ConfigSource *Material              `json:"configSource,omitempty"`
+	Parameters   Parameters             `json:"parameters"`
+	Environment  map[string]interface{} `json:"environment"`
+}
+
+// Parameters are the options the build was invoked with. Only the names of environment variables are recorded.
+type Parameters struct {
+	Image          string   `json:"image"`
+	AdditionalTags []string `json:"additionalTags,omitempty"`
+	Builder        string   `json:"builder"`
+	RunImage       string   `json:"runImage"`
+	Publish        bool     `json:"publish"`
+	Platform       string   `json:"platform,omitempty"`
+	EnvKeys        []string `json:"envKeys,omitempty"`
+}
+
+// BuildConfig is what pack resolved for the build: the builder image, its lifecycle and the buildpack order.
+type BuildConfig struct {
+	Builder          Image         `json:"builder"`
+	RunImage         Image         `json:"runImage"`
+	LifecycleVersion string        `json:"lifecycleVersion"`
+	Order            [][]Buildpack `json:"order"`
+}
+
+// Image is an image used by the build.
+type Image struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// Buildpack is a buildpack of the buildpack order.
+type Buildpack struct {
+	ID       string `json:"id"`
+	Version  string `json:"version,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// Metadata records when the build ran and how complete the provenance is.
+type Metadata struct {
+	BuildStartedOn  time.Time    `json:"buildStartedOn"`
+	BuildFinishedOn time.Time    `json:"buildFinishedOn"`
+	Completeness    Completeness `json:"completeness"`
+	Reproducible    bool         `json:"reproducible"`
+}
+
+type Completeness struct {
+	Parameters  bool `json:"parameters"`
+	Environment bool `json:"environment"`
+	Materials   bool `json:"materials"`
+}
+
+// Material is an input of the build.
+type Material struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// Source is the source code of the app, when it is in a git repository.
+type Source struct {
+	URL    string
+	Commit string
+}
+
+// Inputs is everything resolved for a build that is recorded in its provenance.
+type Inputs struct {
+	Image          string
+	Digest         string
+	AdditionalTags []string
+	Publish        bool
+	Platform       string
+
+	Builder          Image
+	RunImage         Image
+	LifecycleVersion string
+	Order            [][]Buildpack
+
+	Source  *Source
+	EnvKeys []string
+	Invoker string
+
+	PackVersion string
+	StartedOn   time.Time
+	FinishedOn  time.Time
+}
+
+// New returns the provenance statement for the image built from inputs.
+func New(inputs Inputs) Statement {
+	envKeys := append([]string{}, inputs.EnvKeys...)
+	sort.Strings(envKeys)
+
+	predicate := Predicate{
+		Builder:   Builder{ID: builderID + "@v" + inputs.PackVersion},
+		BuildType: BuildType,
+		Invocation: Invocation{
+			Parameters: Parameters{
+				Image:          inputs.Image,
+				AdditionalTags: inputs.AdditionalTags,
+				Builder:        inputs.Builder.Name,
+				RunImage:       inputs.RunImage.Name,
+				Publish:        inputs.Publish,
+				Platform:       inputs.Platform,
+				EnvKeys:        envKeys,
+			},
+			Environment: map[string]interface{}{"invoker": inputs.Invoker},
+		},
+		BuildConfig: BuildConfig{
+			Builder:          inputs.Builder,
+			RunImage:         inputs.RunImage,
+			LifecycleVersion: inputs.LifecycleVersion,
+			Order:            inputs.Order,
+		},
+		Metadata: Metadata{
+			BuildStartedOn:  inputs.StartedOn.UTC(),
+			BuildFinishedOn: inputs.FinishedOn.UTC(),
+			Completeness:    Completeness{Parameters: true},
+		},
+	}
+
+	for _, img := range []Image{inputs.Builder, inputs.RunImage} {
+		if img.Digest != "" {
+			predicate.Materials = append(predicate.Materials, Material{URI: img.Name, Digest: digestSet(img.Digest)})
+		}
+	}
+	if inputs.Source != nil {
+		source := Material{URI: "git+" + inputs.Source.URL, Digest: map[string]string{"sha1": inputs.Source.Commit}}
+		predicate.Invocation.ConfigSource = &source
+		predicate.Materials = append(predicate.Materials, source)
+	}
+
+	return Statement{
+		Type:          StatementType,
+		Subject:       []Subject{{Name: inputs.Image, Digest: digestSet(inputs.Digest)}},
+		PredicateType: PredicateType,
+		Predicate:     predicate,
+	}
+}
+
+// digestSet returns the in-toto digest set of a digest such as sha256:<hex>.
+func digestSet(digest string) map[string]string {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		return map[string]string{"sha256": digest}
+	}
+	return map[string]string{parts[0]: parts[1]}
+}
+
+// WriteFile writes statement to path as indented JSON.
+func WriteFile(path string, statement Statement) error {
+	contents, err := json.MarshalIndent(statement, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshalling provenance")
+	}
+	if err := ioutil.WriteFile(path, append(contents, '\n'), 0644); err != nil {
+		return errors.Wrap(err, "writing provenance")
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package provenance_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/provenance"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestProvenance(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "Provenance", testProvenance, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testProvenance(t *testing.T, when spec.G, it spec.S) {
+	var inputs provenance.Inputs
+
+	it.Before(func() {
+		inputs = provenance.Inputs{
+			Image:            "registry.example.com/some/app:latest",
+			Digest:           "sha256:app-digest",
+			Publish:          true,
+			Builder:          provenance.Image{Name: "some/builder", Digest: "sha256:builder-digest"},
+			RunImage:         provenance.Image{Name: "some/run", Digest: "sha256:run-digest"},
+			LifecycleVersion: "0.11.3",
+			Order:            [][]provenance.Buildpack{{{ID: "some/bp", Version: "1.2.3"}, {ID: "other/bp", Version: "0.0.1", Optional: true}}},
+			Source:           &provenance.Source{URL: "https://github.com/some/app", Commit: "abc123"},
+			EnvKeys:          []string{"SECOND", "FIRST"},
+			Invoker:          "some-user",
+			PackVersion:      "0.20.0",
+			StartedOn:        time.Unix(1600000000, 0),
+			FinishedOn:       time.Unix(1600000060, 0),
+		}
+	})
+
+	when("#New", func() {
+		it("describes the build of the image", func() {
+			statement := provenance.New(inputs)
+
+			h.AssertEq(t, statement.Type, provenance.StatementType)
+			h.AssertEq(t, statement.PredicateType, provenance.PredicateType)
+			h.AssertEq(t, statement.Subject, []provenance.Subject{{
+				Name:   "registry.example.com/some/app:latest",
+				Digest: map[string]string{"sha256": "app-digest"},
+			}})
+
+			predicate := statement.Predicate
+			h.AssertEq(t, predicate.Builder.ID, "https://buildpacks.io/pack@v0.20.0")
+			h.AssertEq(t, predicate.BuildConfig.Builder, provenance.Image{Name: "some/builder", Digest: "sha256:builder-digest"})
+			h.AssertEq(t, predicate.BuildConfig.LifecycleVersion, "0.11.3")
+			h.AssertEq(t, predicate.BuildConfig.Order[0][1], provenance.Buildpack{ID: "other/bp", Version: "0.0.1", Optional: true})
+			h.AssertEq(t, predicate.Invocation.Parameters.EnvKeys, []string{"FIRST", "SECOND"})
+			h.AssertEq(t, predicate.Invocation.Environment["invoker"], "some-user")
+			h.AssertEq(t, predicate.Metadata.BuildFinishedOn.Sub(predicate.Metadata.BuildStartedOn), time.Minute)
+
+			h.AssertEq(t, predicate.Invocation.ConfigSource, &provenance.Material{
+				URI:    "git+https://github.com/some/app",
+				Digest: map[string]string{"sha1": "abc123"},
+			})
+			h.AssertEq(t, len(predicate.Materials), 3)
+			h.AssertEq(t, predicate.Materials[0], provenance.Material{URI: "some/builder", Digest: map[string]string{"sha256": "builder-digest"}})
+		})
+
+		it("omits the source and images without digests from the materials", func() {
+			inputs.Source = nil
+			inputs.Builder.Digest = ""
+
+			statement := provenance.New(inputs)
+			h.AssertNil(t, statement.Predicate.Invocation.ConfigSource)
+			h.AssertEq(t, statement.Predicate.Materials, []provenance.Material{{URI: "some/run", Digest: map[string]string{"sha256": "run-digest"}}})
+		})
+	})
+
+	when("#WriteFile", func() {
+		var tmpDir string
+
+		it.Before(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "provenance-test")
+			h.AssertNil(t, err)
+		})
+
+		it.After(func() {
+			h.AssertNil(t, os.RemoveAll(tmpDir))
+		})
+
+		it("writes the statement as JSON", func() {
+			path := filepath.Join(tmpDir, "provenance.json")
+			h.AssertNil(t, provenance.WriteFile(path, provenance.New(inputs)))
+
+			contents, err := ioutil.ReadFile(path)
+			h.AssertNil(t, err)
+			var doc map[string]interface{}
+			h.AssertNil(t, json.Unmarshal(contents, &doc))
+			h.AssertEq(t, doc["_type"], "https://in-toto.io/Statement/v0.1")
+			h.AssertEq(t, doc["predicate"].(map[string]interface{})["buildType"], provenance.BuildType)
+		})
+	})
+}
@@ -0,0 +1,127 @@
+package sbom
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/buildpacks/pack"
+)
+
+type cycloneDX struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	Version      int                   `json:"version"`
+	Metadata     cycloneDXMetadata     `json:"metadata"`
+	Components   []cycloneDXComponent  `json:"components"`
+	Dependencies []cycloneDXDependency `json:"dependencies,omitempty"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     []cycloneDXTool    `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTool struct {
+	Vendor  string `json:"vendor,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type cycloneDXComponent struct {
+	BOMRef     string              `json:"bom-ref"`
+	Type       string              `json:"type"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	Hashes     []cycloneDXHash     `json:"hashes,omitempty"`
+	Licenses   []cycloneDXLicense  `json:"licenses,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	CPE        string              `json:"cpe,omitempty"`
+	Properties []cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXLicense struct {
+	Expression string `json:"expression"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// newCycloneDX describes the app image as a container component depending on its run image
+// and on the components contributed by buildpacks. The buildpacks are listed as tools.
+func newCycloneDX(info *pack.ImageInfo, opts Options) cycloneDX {
+	app := cycloneDXComponent{BOMRef: "app-image", Type: "container", Name: opts.ImageName, Version: info.Digest}
+
+	doc := cycloneDX{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.4",
+		Version:     1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: opts.Timestamp.UTC().Format(time.RFC3339),
+			Tools:     []cycloneDXTool{{Vendor: "Cloud Native Buildpacks", Name: "pack", Version: opts.PackVersion}},
+			Component: app,
+		},
+		Components: []cycloneDXComponent{},
+	}
+	for _, bp := range info.Buildpacks {
+		doc.Metadata.Tools = append(doc.Metadata.Tools, cycloneDXTool{Name: bp.ID, Version: bp.Version})
+	}
+
+	dependency := cycloneDXDependency{Ref: app.BOMRef}
+	if info.Stack.RunImage.Image != "" {
+		runImage := cycloneDXComponent{
+			BOMRef:  "run-image",
+			Type:    "container",
+			Name:    info.Stack.RunImage.Image,
+			Version: info.Base.Reference,
+			Properties: []cycloneDXProperty{
+				{Name: "buildpacks:stack-id", Value: info.StackID},
+				{Name: "buildpacks:top-layer", Value: info.Base.TopLayer},
+			},
+		}
+		doc.Components = append(doc.Components, runImage)
+		dependency.DependsOn = append(dependency.DependsOn, runImage.BOMRef)
+	}
+
+	for i, e := range entries(info) {
+		component := cycloneDXComponent{
+			BOMRef:  fmt.Sprintf("component-%d", i),
+			Type:    "library",
+			Name:    e.Name,
+			Version: e.Version,
+			PURL:    e.purl,
+		}
+		if len(e.cpes) > 0 {
+			component.CPE = e.cpes[0]
+		}
+		if e.sha256 != "" {
+			component.Hashes = []cycloneDXHash{{Alg: "SHA-256", Content: e.sha256}}
+		}
+		for _, license := range e.licenses {
+			component.Licenses = append(component.Licenses, cycloneDXLicense{Expression: license})
+		}
+		if bp := e.buildpack(); bp != "" {
+			component.Properties = append(component.Properties, cycloneDXProperty{Name: "buildpacks:buildpack", Value: bp})
+		}
+		if e.uri != "" {
+			component.Properties = append(component.Properties, cycloneDXProperty{Name: "buildpacks:uri", Value: e.uri})
+		}
+		doc.Components = append(doc.Components, component)
+		dependency.DependsOn = append(dependency.DependsOn, component.BOMRef)
+	}
+
+	doc.Dependencies = []cycloneDXDependency{dependency}
+	return doc
+}
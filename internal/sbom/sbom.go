@@ -0,0 +1,135 @@
+// Package sbom converts the bill of materials recorded on an app image into standard SBOM documents.
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/inspectimage"
+	"github.com/buildpacks/pack/internal/style"
+)
+
+const (
+	// CycloneDXJSON is the JSON encoding of a CycloneDX 1.4 document.
+	CycloneDXJSON = "cyclonedx-json"
+
+	// SPDXJSON is the JSON encoding of an SPDX 2.2 document.
+	SPDXJSON = "spdx-json"
+)
+
+// Formats are the supported SBOM formats.
+var Formats = []string{CycloneDXJSON, SPDXJSON}
+
+// Options describe the image and the tool an SBOM document is written for.
+type Options struct {
+	// ImageName is the name of the app image.
+	ImageName string
+
+	// PackVersion is the version of pack recorded as the tool creating the document.
+	PackVersion string
+
+	// Timestamp is the creation time of the document.
+	Timestamp time.Time
+}
+
+// Encode returns the SBOM document in format for the app image described by info.
+func Encode(format string, info *pack.ImageInfo, opts Options) ([]byte, error) {
+	var doc interface{}
+	switch format {
+	case CycloneDXJSON:
+		doc = newCycloneDX(info, opts)
+	case SPDXJSON:
+		doc = newSPDX(info, opts)
+	default:
+		return nil, errors.Errorf("SBOM format %s is not supported, use one of %s", style.Symbol(format), strings.Join(Formats, ", "))
+	}
+
+	contents, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling SBOM")
+	}
+	return append(contents, '\n'), nil
+}
+
+// entry is a BOM entry along with the well-known keys buildpacks use in its metadata.
+type entry struct {
+	inspectimage.BOMEntryDisplay
+	purl     string
+	cpes     []string
+	licenses []string
+	sha256   string
+	uri      string
+}
+
+func entries(info *pack.ImageInfo) []entry {
+	var result []entry
+	for _, bom := range inspectimage.NewBOMDisplay(info) {
+		e := entry{BOMEntryDisplay: bom}
+		e.purl = stringValue(bom.Metadata["purl"])
+		e.sha256 = stringValue(bom.Metadata["sha256"])
+		e.uri = stringValue(bom.Metadata["uri"])
+		if e.Version == "" {
+			e.Version = stringValue(bom.Metadata["version"])
+		}
+		if cpe := stringValue(bom.Metadata["cpe"]); cpe != "" {
+			e.cpes = append(e.cpes, cpe)
+		}
+		e.cpes = append(e.cpes, stringValues(bom.Metadata["cpes"], "")...)
+		e.licenses = stringValues(bom.Metadata["licenses"], "type")
+		result = append(result, e)
+	}
+	return result
+}
+
+func (e entry) buildpack() string {
+	if e.Buildpack.ID == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s@%s", e.Buildpack.ID, e.Buildpack.Version)
+}
+
+func stringValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// stringValues reads a list of strings, or of tables holding the string at key.
+func stringValues(value interface{}, key string) []string {
+	var items []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case []map[string]interface{}:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	}
+
+	var result []string
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			result = append(result, v)
+		case map[string]interface{}:
+			if s := stringValue(v[key]); key != "" && s != "" {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
+
+func buildpacks(info *pack.ImageInfo) []string {
+	var result []string
+	for _, bp := range info.Buildpacks {
+		result = append(result, fmt.Sprintf("%s@%s", bp.ID, bp.Version))
+	}
+	return result
+}
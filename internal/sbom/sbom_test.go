@@ -0,0 +1,146 @@
+package sbom_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/platform"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/internal/sbom"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestSBOM(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "SBOM", testSBOM, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testSBOM(t *testing.T, when spec.G, it spec.S) {
+	var (
+		info *pack.ImageInfo
+		opts sbom.Options
+	)
+
+	it.Before(func() {
+		info = &pack.ImageInfo{
+			StackID:    "some.stack.id",
+			Buildpacks: []buildpack.GroupBuildpack{{ID: "some/bp", Version: "1.2.3"}},
+			Base:       platform.RunImageMetadata{TopLayer: "sha256:top-layer", Reference: "sha256:run-image"},
+			Stack:      platform.StackMetadata{RunImage: platform.StackRunImageMetadata{Image: "some/run"}},
+			BOM: []buildpack.BOMEntry{{
+				Require: buildpack.Require{
+					Name:    "node",
+					Version: "14.17.0",
+					Metadata: map[string]interface{}{
+						"purl":     "pkg:generic/node@14.17.0",
+						"cpes":     []interface{}{"cpe:2.3:a:nodejs:node.js:14.17.0:*:*:*:*:*:*:*"},
+						"licenses": []interface{}{map[string]interface{}{"type": "MIT", "uri": "https://example.com/license"}},
+						"sha256":   "some-sha",
+						"uri":      "https://example.com/node.tar.gz",
+					},
+				},
+				Buildpack: buildpack.GroupBuildpack{ID: "some/bp", Version: "1.2.3"},
+			}},
+			Digest: "sha256:app-digest",
+		}
+		opts = sbom.Options{ImageName: "some/app", PackVersion: "0.20.0", Timestamp: time.Unix(1600000000, 0)}
+	})
+
+	decode := func(contents []byte) map[string]interface{} {
+		t.Helper()
+		var doc map[string]interface{}
+		h.AssertNil(t, json.Unmarshal(contents, &doc))
+		return doc
+	}
+
+	when("#Encode", func() {
+		when("cyclonedx-json", func() {
+			it("describes the app image, run image and BOM entries", func() {
+				contents, err := sbom.Encode(sbom.CycloneDXJSON, info, opts)
+				h.AssertNil(t, err)
+
+				doc := decode(contents)
+				h.AssertEq(t, doc["bomFormat"], "CycloneDX")
+				h.AssertEq(t, doc["specVersion"], "1.4")
+
+				metadata := doc["metadata"].(map[string]interface{})
+				h.AssertEq(t, metadata["timestamp"], "2020-09-13T12:26:40Z")
+				h.AssertEq(t, metadata["component"], map[string]interface{}{
+					"bom-ref": "app-image",
+					"type":    "container",
+					"name":    "some/app",
+					"version": "sha256:app-digest",
+				})
+				h.AssertEq(t, len(metadata["tools"].([]interface{})), 2)
+
+				components := doc["components"].([]interface{})
+				h.AssertEq(t, len(components), 2)
+				h.AssertEq(t, components[0].(map[string]interface{})["name"], "some/run")
+				h.AssertEq(t, components[0].(map[string]interface{})["version"], "sha256:run-image")
+
+				node := components[1].(map[string]interface{})
+				h.AssertEq(t, node["name"], "node")
+				h.AssertEq(t, node["version"], "14.17.0")
+				h.AssertEq(t, node["purl"], "pkg:generic/node@14.17.0")
+				h.AssertEq(t, node["cpe"], "cpe:2.3:a:nodejs:node.js:14.17.0:*:*:*:*:*:*:*")
+				h.AssertEq(t, node["licenses"], []interface{}{map[string]interface{}{"expression": "MIT"}})
+				h.AssertEq(t, node["hashes"], []interface{}{map[string]interface{}{"alg": "SHA-256", "content": "some-sha"}})
+				h.AssertContains(t, string(contents), `"value": "some/bp@1.2.3"`)
+
+				dependencies := doc["dependencies"].([]interface{})
+				h.AssertEq(t, dependencies[0].(map[string]interface{})["dependsOn"], []interface{}{"run-image", "component-0"})
+			})
+		})
+
+		when("spdx-json", func() {
+			it("describes the app image, run image and BOM entries", func() {
+				contents, err := sbom.Encode(sbom.SPDXJSON, info, opts)
+				h.AssertNil(t, err)
+
+				doc := decode(contents)
+				h.AssertEq(t, doc["spdxVersion"], "SPDX-2.2")
+				h.AssertEq(t, doc["name"], "some/app")
+				h.AssertContains(t, doc["documentNamespace"].(string), "https://buildpacks.io/spdx/some-app-")
+				h.AssertEq(t, doc["creationInfo"].(map[string]interface{})["creators"], []interface{}{
+					"Organization: Cloud Native Buildpacks", "Tool: pack-0.20.0", "Tool: some/bp@1.2.3",
+				})
+
+				packages := doc["packages"].([]interface{})
+				h.AssertEq(t, len(packages), 3)
+				h.AssertEq(t, packages[0].(map[string]interface{})["versionInfo"], "sha256:app-digest")
+				h.AssertEq(t, packages[1].(map[string]interface{})["name"], "some/run")
+
+				node := packages[2].(map[string]interface{})
+				h.AssertEq(t, node["SPDXID"], "SPDXRef-Package-0")
+				h.AssertEq(t, node["licenseDeclared"], "MIT")
+				h.AssertEq(t, node["downloadLocation"], "https://example.com/node.tar.gz")
+				h.AssertEq(t, len(node["externalRefs"].([]interface{})), 2)
+
+				h.AssertContains(t, string(contents), `"relationshipType": "DESCENDANT_OF"`)
+				h.AssertContains(t, string(contents), `"relatedSpdxElement": "SPDXRef-Package-0"`)
+			})
+
+			it("uses a different namespace for another digest", func() {
+				first, err := sbom.Encode(sbom.SPDXJSON, info, opts)
+				h.AssertNil(t, err)
+				info.Digest = "sha256:other-digest"
+				second, err := sbom.Encode(sbom.SPDXJSON, info, opts)
+				h.AssertNil(t, err)
+
+				h.AssertNotEq(t, decode(first)["documentNamespace"], decode(second)["documentNamespace"])
+			})
+		})
+
+		it("errors for unsupported formats", func() {
+			_, err := sbom.Encode("syft-json", info, opts)
+			h.AssertError(t, err, "SBOM format 'syft-json' is not supported, use one of cyclonedx-json, spdx-json")
+		})
+	})
+}
@@ -0,0 +1,135 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/buildpacks/pack"
+)
+
+// spdxNoAssertion is the SPDX value for information which is not known.
+const spdxNoAssertion = "NOASSERTION"
+
+type spdx struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	Comment          string            `json:"comment,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// newSPDX describes the app image as a package containing the packages contributed by buildpacks
+// and descending from its run image. The buildpacks are listed as creators.
+func newSPDX(info *pack.ImageInfo, opts Options) spdx {
+	app := newSPDXPackage(opts.ImageName, "SPDXRef-AppImage", info.Digest)
+
+	doc := spdx{
+		SPDXVersion:       "SPDX-2.2",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              opts.ImageName,
+		DocumentNamespace: spdxNamespace(info, opts),
+		CreationInfo: spdxCreationInfo{
+			Created:  opts.Timestamp.UTC().Format(time.RFC3339),
+			Creators: []string{"Organization: Cloud Native Buildpacks", "Tool: pack-" + opts.PackVersion},
+		},
+		Packages:      []spdxPackage{app},
+		Relationships: []spdxRelationship{{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: app.SPDXID}},
+	}
+	for _, bp := range buildpacks(info) {
+		doc.CreationInfo.Creators = append(doc.CreationInfo.Creators, "Tool: "+bp)
+	}
+
+	if info.Stack.RunImage.Image != "" {
+		runImage := newSPDXPackage(info.Stack.RunImage.Image, "SPDXRef-RunImage", info.Base.Reference)
+		runImage.Comment = fmt.Sprintf("stack %s, top layer %s", info.StackID, info.Base.TopLayer)
+		doc.Packages = append(doc.Packages, runImage)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: app.SPDXID, RelationshipType: "DESCENDANT_OF", RelatedSPDXElement: runImage.SPDXID})
+	}
+
+	for i, e := range entries(info) {
+		pkg := newSPDXPackage(e.Name, fmt.Sprintf("SPDXRef-Package-%d", i), e.Version)
+		if e.uri != "" {
+			pkg.DownloadLocation = e.uri
+		}
+		if e.sha256 != "" {
+			pkg.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: e.sha256}}
+		}
+		if len(e.licenses) > 0 {
+			pkg.LicenseDeclared = strings.Join(e.licenses, " AND ")
+		}
+		if bp := e.buildpack(); bp != "" {
+			pkg.Comment = "contributed by buildpack " + bp
+		}
+		if e.purl != "" {
+			pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: e.purl})
+		}
+		for _, cpe := range e.cpes {
+			pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{ReferenceCategory: "SECURITY", ReferenceType: "cpe23Type", ReferenceLocator: cpe})
+		}
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: app.SPDXID, RelationshipType: "CONTAINS", RelatedSPDXElement: pkg.SPDXID})
+	}
+
+	return doc
+}
+
+func newSPDXPackage(name, id, version string) spdxPackage {
+	return spdxPackage{
+		Name:             name,
+		SPDXID:           id,
+		VersionInfo:      version,
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+	}
+}
+
+// spdxNamespace returns a URI unique to the document, derived from the image and the creation time.
+func spdxNamespace(info *pack.ImageInfo, opts Options) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s@%s %s", opts.ImageName, info.Digest, opts.Timestamp.UTC().Format(time.RFC3339Nano))))
+	name := strings.NewReplacer("/", "-", ":", "-", "@", "-").Replace(opts.ImageName)
+	return fmt.Sprintf("https://buildpacks.io/spdx/%s-%x", name, sum[:8])
+}
@@ -0,0 +1,141 @@
+package sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/buildpacks/pack/internal/style"
+)
+
+// PEM block types of the private keys written by `cosign generate-key-pair`.
+const (
+	encryptedCosignKeyType   = "ENCRYPTED COSIGN PRIVATE KEY"
+	encryptedSigstoreKeyType = "ENCRYPTED SIGSTORE PRIVATE KEY"
+)
+
+// encryptedKey is the contents of an encrypted cosign private key: a PKCS #8 key sealed
+// with NaCl secretbox under a key derived from the password with scrypt.
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// LoadPrivateKey reads an ECDSA private key from the PEM file at path. The key is either
+// an encrypted cosign key, decrypted with password, or an unencrypted PKCS #8 or EC key.
+func LoadPrivateKey(path string, password []byte) (*ecdsa.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+	switch block.Type {
+	case encryptedCosignKeyType, encryptedSigstoreKeyType:
+		der, err := decrypt(block.Bytes, password)
+		if err != nil {
+			return nil, err
+		}
+		key, err = x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing signing key")
+		}
+	case "PRIVATE KEY":
+		if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			return nil, errors.Wrap(err, "parsing signing key")
+		}
+	case "EC PRIVATE KEY":
+		if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+			return nil, errors.Wrap(err, "parsing signing key")
+		}
+	default:
+		return nil, errors.Errorf("signing key %s has unsupported PEM type %s", style.Symbol(path), style.Symbol(block.Type))
+	}
+
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("signing key %s must be an ECDSA key", style.Symbol(path))
+	}
+	return ecKey, nil
+}
+
+// LoadPublicKey reads an ECDSA public key from the PEM file at path, such as the cosign.pub written by cosign.
+func LoadPublicKey(path string) (*ecdsa.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, errors.Errorf("public key %s has unsupported PEM type %s", style.Symbol(path), style.Symbol(block.Type))
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing public key")
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("public key %s must be an ECDSA key", style.Symbol(path))
+	}
+	return ecKey, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading key")
+	}
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, errors.Errorf("key %s is not PEM encoded", style.Symbol(path))
+	}
+	return block, nil
+}
+
+func decrypt(contents, password []byte) ([]byte, error) {
+	var encrypted encryptedKey
+	if err := json.Unmarshal(contents, &encrypted); err != nil {
+		return nil, errors.Wrap(err, "parsing encrypted signing key")
+	}
+	if encrypted.KDF.Name != "scrypt" || encrypted.Cipher.Name != "nacl/secretbox" {
+		return nil, errors.Errorf("signing key is encrypted with unsupported algorithms %s and %s",
+			style.Symbol(encrypted.KDF.Name), style.Symbol(encrypted.Cipher.Name))
+	}
+	if len(encrypted.Cipher.Nonce) != 24 {
+		return nil, errors.New("signing key has an invalid nonce")
+	}
+
+	params := encrypted.KDF.Params
+	derived, err := scrypt.Key(password, encrypted.KDF.Salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "deriving key from password")
+	}
+
+	var secretKey [32]byte
+	var nonce [24]byte
+	copy(secretKey[:], derived)
+	copy(nonce[:], encrypted.Cipher.Nonce)
+	der, ok := secretbox.Open(nil, encrypted.Ciphertext, &nonce, &secretKey)
+	if !ok {
+		return nil, errors.New("decrypting signing key: incorrect password")
+	}
+	return der, nil
+}
@@ -0,0 +1,268 @@
+// Package sign signs and verifies images in a registry using cosign keys, storing signatures
+// and attestations in the repository of the image under the tags cosign uses.
+package sign
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/style"
+)
+
+const (
+	// SignatureAnnotation is the annotation of a signature layer holding the base64 encoded signature of its payload.
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	// PayloadMediaType is the media type of a signature layer, whose contents are the signed payload.
+	PayloadMediaType types.MediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+	// AttestationMediaType is the media type of an attestation layer, whose contents are a DSSE envelope.
+	AttestationMediaType types.MediaType = "application/vnd.dsse.envelope.v1+json"
+
+	signatureType = "cosign container image signature"
+)
+
+// Payload is the simple signing payload identifying the signed image.
+type Payload struct {
+	Critical Critical               `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+type Critical struct {
+	Identity Identity `json:"identity"`
+	Image    Image    `json:"image"`
+	Type     string   `json:"type"`
+}
+
+type Identity struct {
+	DockerReference string `json:"docker-reference"`
+}
+
+type Image struct {
+	DockerManifestDigest string `json:"docker-manifest-digest"`
+}
+
+// SignatureTag returns the tag the signatures of digest are stored under, e.g. my/app:sha256-<hex>.sig.
+func SignatureTag(digest name.Digest) name.Tag {
+	return digest.Context().Tag(strings.Replace(digest.DigestStr(), ":", "-", 1) + ".sig")
+}
+
+// AttestationTag returns the tag the attestations of digest are stored under, e.g. my/app:sha256-<hex>.att.
+func AttestationTag(digest name.Digest) name.Tag {
+	return digest.Context().Tag(strings.Replace(digest.DigestStr(), ":", "-", 1) + ".att")
+}
+
+// Sign signs the image at digest with key and pushes the signature to the repository of the image,
+// keeping the signatures already pushed. It returns the tag of the signatures.
+func Sign(ctx context.Context, keychain authn.Keychain, digest name.Digest, key *ecdsa.PrivateKey) (name.Tag, error) {
+	payload, err := json.Marshal(Payload{Critical: Critical{
+		Identity: Identity{DockerReference: digest.Context().Name()},
+		Image:    Image{DockerManifestDigest: digest.DigestStr()},
+		Type:     signatureType,
+	}})
+	if err != nil {
+		return name.Tag{}, errors.Wrap(err, "marshalling signature payload")
+	}
+
+	hash := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		return name.Tag{}, errors.Wrap(err, "signing payload")
+	}
+	encoded := base64.StdEncoding.EncodeToString(signature)
+
+	tag := SignatureTag(digest)
+	err = appendLayer(ctx, keychain, tag, mutate.Addendum{
+		Layer:       &payloadLayer{contents: payload, mediaType: PayloadMediaType},
+		Annotations: map[string]string{SignatureAnnotation: encoded},
+		MediaType:   PayloadMediaType,
+	})
+	if err != nil {
+		return name.Tag{}, errors.Wrap(err, "pushing signature")
+	}
+	return tag, nil
+}
+
+// envelope is a DSSE envelope, as used by in-toto attestations.
+type envelope struct {
+	PayloadType string              `json:"payloadType"`
+	Payload     []byte              `json:"payload"`
+	Signatures  []envelopeSignature `json:"signatures"`
+}
+
+type envelopeSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   []byte `json:"sig"`
+}
+
+// Attest pushes payload, of payloadType, to the repository of the image at digest as a DSSE envelope,
+// keeping the attestations already pushed. The envelope is signed with key, unless it is nil.
+// It returns the tag of the attestations.
+func Attest(ctx context.Context, keychain authn.Keychain, digest name.Digest, payloadType string, payload []byte, key *ecdsa.PrivateKey) (name.Tag, error) {
+	env := envelope{PayloadType: payloadType, Payload: payload, Signatures: []envelopeSignature{}}
+	if key != nil {
+		hash := sha256.Sum256(preAuthEncoding(payloadType, payload))
+		signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+		if err != nil {
+			return name.Tag{}, errors.Wrap(err, "signing attestation")
+		}
+		env.Signatures = append(env.Signatures, envelopeSignature{Sig: signature})
+	}
+
+	contents, err := json.Marshal(env)
+	if err != nil {
+		return name.Tag{}, errors.Wrap(err, "marshalling attestation")
+	}
+
+	tag := AttestationTag(digest)
+	err = appendLayer(ctx, keychain, tag, mutate.Addendum{
+		Layer:     &payloadLayer{contents: contents, mediaType: AttestationMediaType},
+		MediaType: AttestationMediaType,
+	})
+	if err != nil {
+		return name.Tag{}, errors.Wrap(err, "pushing attestation")
+	}
+	return tag, nil
+}
+
+// preAuthEncoding returns the DSSE pre-authentication encoding of payload, which is what an envelope signs.
+func preAuthEncoding(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// appendLayer adds the layer to the image at tag, which is created if it does not exist yet.
+func appendLayer(ctx context.Context, keychain authn.Keychain, tag name.Tag, addendum mutate.Addendum) error {
+	opts := []remote.Option{remote.WithAuthFromKeychain(keychain), remote.WithContext(ctx)}
+	img, err := remote.Image(tag, opts...)
+	if isNotFound(err) {
+		img, err = empty.Image, nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", style.Symbol(tag.Name()))
+	}
+
+	img, err = mutate.Append(img, addendum)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(remote.Write(tag, img, opts...), "writing %s", style.Symbol(tag.Name()))
+}
+
+// Verify checks that one of the signatures pushed for the image at digest was made by key for that image.
+func Verify(ctx context.Context, keychain authn.Keychain, digest name.Digest, key *ecdsa.PublicKey) error {
+	tag := SignatureTag(digest)
+	signatures, err := remote.Image(tag, remote.WithAuthFromKeychain(keychain), remote.WithContext(ctx))
+	if isNotFound(err) {
+		return errors.Errorf("no signatures found for %s", style.Symbol(digest.Name()))
+	}
+	if err != nil {
+		return errors.Wrapf(err, "reading signatures %s", style.Symbol(tag.Name()))
+	}
+
+	manifest, err := signatures.Manifest()
+	if err != nil {
+		return errors.Wrapf(err, "reading signatures %s", style.Symbol(tag.Name()))
+	}
+
+	for _, desc := range manifest.Layers {
+		if desc.MediaType != PayloadMediaType {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(desc.Annotations[SignatureAnnotation])
+		if err != nil {
+			continue
+		}
+
+		payload, err := readPayload(signatures, desc.Digest)
+		if err != nil {
+			return err
+		}
+		hash := sha256.Sum256(payload)
+		if !ecdsa.VerifyASN1(key, hash[:], signature) {
+			continue
+		}
+
+		var p Payload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			continue
+		}
+		if p.Critical.Image.DockerManifestDigest == digest.DigestStr() {
+			return nil
+		}
+	}
+	return errors.Errorf("no signature of %s matches the key", style.Symbol(digest.Name()))
+}
+
+func readPayload(img v1.Image, digest v1.Hash) ([]byte, error) {
+	layer, err := img.LayerByDigest(digest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading signature %s", digest)
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading signature %s", digest)
+	}
+	defer rc.Close()
+
+	payload, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading signature %s", digest)
+	}
+	return payload, nil
+}
+
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	return errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound
+}
+
+// payloadLayer is a layer holding a signature payload or attestation as is, without compression.
+type payloadLayer struct {
+	contents  []byte
+	mediaType types.MediaType
+}
+
+func (l *payloadLayer) Digest() (v1.Hash, error) {
+	h, _, err := v1.SHA256(bytes.NewReader(l.contents))
+	return h, err
+}
+
+func (l *payloadLayer) DiffID() (v1.Hash, error) {
+	return l.Digest()
+}
+
+func (l *payloadLayer) Compressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.contents)), nil
+}
+
+func (l *payloadLayer) Uncompressed() (io.ReadCloser, error) {
+	return l.Compressed()
+}
+
+func (l *payloadLayer) Size() (int64, error) {
+	return int64(len(l.contents)), nil
+}
+
+func (l *payloadLayer) MediaType() (types.MediaType, error) {
+	return l.mediaType, nil
+}
@@ -0,0 +1,247 @@
+package sign_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/buildpacks/pack/internal/image"
+	"github.com/buildpacks/pack/internal/sign"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestSign(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "Sign", testSign, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+// writeEncryptedKey writes key in the format of `cosign generate-key-pair`, with cheap scrypt parameters.
+func writeEncryptedKey(t *testing.T, path string, key *ecdsa.PrivateKey, password string) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	h.AssertNil(t, err)
+
+	salt := make([]byte, 32)
+	_, err = rand.Read(salt)
+	h.AssertNil(t, err)
+	derived, err := scrypt.Key([]byte(password), salt, 1024, 8, 1, 32)
+	h.AssertNil(t, err)
+
+	var secretKey [32]byte
+	var nonce [24]byte
+	copy(secretKey[:], derived)
+	_, err = rand.Read(nonce[:])
+	h.AssertNil(t, err)
+
+	contents, err := json.Marshal(map[string]interface{}{
+		"kdf":        map[string]interface{}{"name": "scrypt", "params": map[string]int{"N": 1024, "r": 8, "p": 1}, "salt": salt},
+		"cipher":     map[string]interface{}{"name": "nacl/secretbox", "nonce": nonce[:]},
+		"ciphertext": secretbox.Seal(nil, der, &nonce, &secretKey),
+	})
+	h.AssertNil(t, err)
+	h.AssertNil(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED COSIGN PRIVATE KEY", Bytes: contents}), 0600))
+}
+
+func writePublicKey(t *testing.T, path string, key *ecdsa.PrivateKey) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	h.AssertNil(t, err)
+	h.AssertNil(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+}
+
+func testSign(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir string
+		key    *ecdsa.PrivateKey
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "sign-test")
+		h.AssertNil(t, err)
+
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	when("#LoadPrivateKey", func() {
+		it("decrypts cosign keys with the password", func() {
+			path := filepath.Join(tmpDir, "cosign.key")
+			writeEncryptedKey(t, path, key, "some-password")
+
+			loaded, err := sign.LoadPrivateKey(path, []byte("some-password"))
+			h.AssertNil(t, err)
+			h.AssertTrue(t, loaded.Equal(key))
+		})
+
+		it("errors for an incorrect password", func() {
+			path := filepath.Join(tmpDir, "cosign.key")
+			writeEncryptedKey(t, path, key, "some-password")
+
+			_, err := sign.LoadPrivateKey(path, []byte("other-password"))
+			h.AssertError(t, err, "decrypting signing key: incorrect password")
+		})
+
+		it("reads unencrypted keys", func() {
+			der, err := x509.MarshalECPrivateKey(key)
+			h.AssertNil(t, err)
+			path := filepath.Join(tmpDir, "ec.key")
+			h.AssertNil(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+			loaded, err := sign.LoadPrivateKey(path, nil)
+			h.AssertNil(t, err)
+			h.AssertTrue(t, loaded.Equal(key))
+		})
+
+		it("errors for files which are not PEM encoded", func() {
+			path := filepath.Join(tmpDir, "cosign.key")
+			h.AssertNil(t, ioutil.WriteFile(path, []byte("not a key"), 0600))
+
+			_, err := sign.LoadPrivateKey(path, nil)
+			h.AssertError(t, err, "is not PEM encoded")
+		})
+	})
+
+	when("#LoadPublicKey", func() {
+		it("reads PKIX public keys", func() {
+			path := filepath.Join(tmpDir, "cosign.pub")
+			writePublicKey(t, path, key)
+
+			loaded, err := sign.LoadPublicKey(path)
+			h.AssertNil(t, err)
+			h.AssertTrue(t, loaded.Equal(&key.PublicKey))
+		})
+	})
+
+	when("signing images in a registry", func() {
+		var (
+			server *httptest.Server
+			digest name.Digest
+		)
+
+		it.Before(func() {
+			server = httptest.NewServer(registry.New())
+
+			ref, err := name.ParseReference(strings.TrimPrefix(server.URL, "http://") + "/some/app:latest")
+			h.AssertNil(t, err)
+			img, err := random.Image(10, 1)
+			h.AssertNil(t, err)
+			h.AssertNil(t, remote.Write(ref, img))
+
+			digest, err = image.ResolveDigest(context.TODO(), authn.DefaultKeychain, ref)
+			h.AssertNil(t, err)
+		})
+
+		it.After(func() {
+			server.Close()
+		})
+
+		it("pushes a signature which verifies with the public key", func() {
+			tag, err := sign.Sign(context.TODO(), authn.DefaultKeychain, digest, key)
+			h.AssertNil(t, err)
+			h.AssertEq(t, tag.TagStr(), strings.Replace(digest.DigestStr(), ":", "-", 1)+".sig")
+
+			h.AssertNil(t, sign.Verify(context.TODO(), authn.DefaultKeychain, digest, &key.PublicKey))
+		})
+
+		it("keeps the signatures already pushed", func() {
+			otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			h.AssertNil(t, err)
+
+			_, err = sign.Sign(context.TODO(), authn.DefaultKeychain, digest, key)
+			h.AssertNil(t, err)
+			tag, err := sign.Sign(context.TODO(), authn.DefaultKeychain, digest, otherKey)
+			h.AssertNil(t, err)
+
+			signatures, err := remote.Image(tag)
+			h.AssertNil(t, err)
+			manifest, err := signatures.Manifest()
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(manifest.Layers), 2)
+			h.AssertEq(t, manifest.Layers[0].MediaType, sign.PayloadMediaType)
+			h.AssertNotEq(t, manifest.Layers[0].Annotations[sign.SignatureAnnotation], "")
+
+			h.AssertNil(t, sign.Verify(context.TODO(), authn.DefaultKeychain, digest, &key.PublicKey))
+			h.AssertNil(t, sign.Verify(context.TODO(), authn.DefaultKeychain, digest, &otherKey.PublicKey))
+		})
+
+		it("does not verify with another key", func() {
+			otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			h.AssertNil(t, err)
+			_, err = sign.Sign(context.TODO(), authn.DefaultKeychain, digest, key)
+			h.AssertNil(t, err)
+
+			err = sign.Verify(context.TODO(), authn.DefaultKeychain, digest, &otherKey.PublicKey)
+			h.AssertError(t, err, "matches the key")
+		})
+
+		it("pushes attestations as DSSE envelopes signed with the key", func() {
+			tag, err := sign.Attest(context.TODO(), authn.DefaultKeychain, digest, "application/vnd.in-toto+json", []byte(`{"_type":"some-statement"}`), key)
+			h.AssertNil(t, err)
+			h.AssertEq(t, tag.TagStr(), strings.Replace(digest.DigestStr(), ":", "-", 1)+".att")
+
+			_, err = sign.Attest(context.TODO(), authn.DefaultKeychain, digest, "application/vnd.in-toto+json", []byte(`{"_type":"other-statement"}`), nil)
+			h.AssertNil(t, err)
+
+			attestations, err := remote.Image(tag)
+			h.AssertNil(t, err)
+			layers, err := attestations.Layers()
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(layers), 2)
+			mediaType, err := layers[0].MediaType()
+			h.AssertNil(t, err)
+			h.AssertEq(t, mediaType, sign.AttestationMediaType)
+
+			rc, err := layers[0].Compressed()
+			h.AssertNil(t, err)
+			defer rc.Close()
+			var envelope struct {
+				PayloadType string `json:"payloadType"`
+				Payload     []byte `json:"payload"`
+				Signatures  []struct {
+					Sig []byte `json:"sig"`
+				} `json:"signatures"`
+			}
+			h.AssertNil(t, json.NewDecoder(rc).Decode(&envelope))
+			h.AssertEq(t, string(envelope.Payload), `{"_type":"some-statement"}`)
+			h.AssertEq(t, len(envelope.Signatures), 1)
+
+			pae := fmt.Sprintf("DSSEv1 %d %s %d %s", len(envelope.PayloadType), envelope.PayloadType, len(envelope.Payload), envelope.Payload)
+			hash := sha256.Sum256([]byte(pae))
+			h.AssertTrue(t, ecdsa.VerifyASN1(&key.PublicKey, hash[:], envelope.Signatures[0].Sig))
+		})
+
+		it("errors when the image has no signatures", func() {
+			err := sign.Verify(context.TODO(), authn.DefaultKeychain, digest, &key.PublicKey)
+			h.AssertError(t, err, "no signatures found for")
+		})
+	})
+}
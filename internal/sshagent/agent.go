@@ -0,0 +1,172 @@
+// Package sshagent serves SSH agent requests on a temporary unix socket that can be mounted into build containers.
+package sshagent
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/buildpacks/pack/internal/style"
+)
+
+const socketName = "agent.sock"
+
+// Agent listens on a unix socket and hands each connection to a handler, either serving keys or forwarding
+// to another agent. Private keys never leave the host, only agent requests and responses pass through the socket.
+type Agent struct {
+	dir      string
+	listener net.Listener
+	handle   func(net.Conn)
+
+	mu     sync.Mutex
+	conns  map[net.Conn]bool
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Forward starts an agent that forwards each request to the agent listening at socket, usually SSH_AUTH_SOCK.
+func Forward(socket string) (*Agent, error) {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connecting to SSH agent at %s", style.Symbol(socket))
+	}
+	conn.Close()
+
+	return serve(func(conn net.Conn) {
+		upstream, err := net.Dial("unix", socket)
+		if err != nil {
+			return
+		}
+		defer upstream.Close()
+
+		done := make(chan struct{}, 2)
+		go func() {
+			_, _ = io.Copy(upstream, conn)
+			done <- struct{}{}
+		}()
+		go func() {
+			_, _ = io.Copy(conn, upstream)
+			done <- struct{}{}
+		}()
+		<-done
+	})
+}
+
+// WithKeys starts an agent holding the private keys at the given paths.
+func WithKeys(paths ...string) (*Agent, error) {
+	keyring := agent.NewKeyring()
+	for _, path := range paths {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading SSH key %s", style.Symbol(path))
+		}
+
+		key, err := ssh.ParseRawPrivateKey(contents)
+		if err != nil {
+			if _, ok := err.(*ssh.PassphraseMissingError); ok {
+				return nil, errors.Errorf("SSH key %s is protected by a passphrase, add it to your SSH agent and forward the agent instead", style.Symbol(path))
+			}
+			return nil, errors.Wrapf(err, "parsing SSH key %s", style.Symbol(path))
+		}
+
+		if err := keyring.Add(agent.AddedKey{PrivateKey: key, Comment: path}); err != nil {
+			return nil, errors.Wrapf(err, "adding SSH key %s", style.Symbol(path))
+		}
+	}
+
+	return serve(func(conn net.Conn) {
+		_ = agent.ServeAgent(keyring, conn)
+	})
+}
+
+func serve(handle func(net.Conn)) (*Agent, error) {
+	dir, err := ioutil.TempDir("", "pack-ssh-agent")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating SSH agent directory")
+	}
+
+	socket := filepath.Join(dir, socketName)
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, errors.Wrap(err, "listening on SSH agent socket")
+	}
+
+	// the build user in the container does not match the host user, the directory keeps other host users out
+	if err := os.Chmod(socket, 0666); err != nil {
+		listener.Close()
+		os.RemoveAll(dir)
+		return nil, errors.Wrap(err, "setting SSH agent socket permissions")
+	}
+
+	a := &Agent{
+		dir:      dir,
+		listener: listener,
+		handle:   handle,
+		conns:    map[net.Conn]bool{},
+	}
+
+	a.wg.Add(1)
+	go a.accept()
+	return a, nil
+}
+
+func (a *Agent) accept() {
+	defer a.wg.Done()
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		a.mu.Lock()
+		if a.closed {
+			a.mu.Unlock()
+			conn.Close()
+			return
+		}
+		a.conns[conn] = true
+		a.mu.Unlock()
+
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.handle(conn)
+
+			a.mu.Lock()
+			delete(a.conns, conn)
+			a.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// Socket returns the path of the socket on which the agent listens.
+func (a *Agent) Socket() string {
+	return filepath.Join(a.dir, socketName)
+}
+
+// Close stops the agent, closing open connections, and removes its socket.
+func (a *Agent) Close() error {
+	err := a.listener.Close()
+
+	a.mu.Lock()
+	a.closed = true
+	for conn := range a.conns {
+		conn.Close()
+	}
+	a.mu.Unlock()
+
+	a.wg.Wait()
+	if rmErr := os.RemoveAll(a.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+package sshagent_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/buildpacks/pack/internal/sshagent"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestSSHAgent(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "SSHAgent", testSSHAgent, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testSSHAgent(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir  string
+		keyPath string
+	)
+
+	it.Before(func() {
+		if runtime.GOOS == "windows" {
+			t.Skip("unix sockets are not used on Windows")
+		}
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "ssh-agent-test")
+		h.AssertNil(t, err)
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		h.AssertNil(t, err)
+		keyPath = filepath.Join(tmpDir, "id_rsa")
+		h.AssertNil(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		}), 0600))
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	listKeys := func(socket string) []*agent.Key {
+		t.Helper()
+		conn, err := net.Dial("unix", socket)
+		h.AssertNil(t, err)
+		defer conn.Close()
+
+		keys, err := agent.NewClient(conn).List()
+		h.AssertNil(t, err)
+		return keys
+	}
+
+	when("#WithKeys", func() {
+		it("serves the keys on the socket", func() {
+			subject, err := sshagent.WithKeys(keyPath)
+			h.AssertNil(t, err)
+			defer subject.Close()
+
+			keys := listKeys(subject.Socket())
+			h.AssertEq(t, len(keys), 1)
+			h.AssertEq(t, keys[0].Comment, keyPath)
+		})
+
+		it("makes the socket accessible to other users", func() {
+			subject, err := sshagent.WithKeys(keyPath)
+			h.AssertNil(t, err)
+			defer subject.Close()
+
+			fi, err := os.Stat(subject.Socket())
+			h.AssertNil(t, err)
+			h.AssertEq(t, fi.Mode().Perm(), os.FileMode(0666))
+
+			fi, err = os.Stat(filepath.Dir(subject.Socket()))
+			h.AssertNil(t, err)
+			h.AssertEq(t, fi.Mode().Perm(), os.FileMode(0700))
+		})
+
+		it("removes the socket on close", func() {
+			subject, err := sshagent.WithKeys(keyPath)
+			h.AssertNil(t, err)
+			h.AssertNil(t, subject.Close())
+
+			_, err = os.Stat(filepath.Dir(subject.Socket()))
+			h.AssertTrue(t, os.IsNotExist(err))
+		})
+
+		it("errors for passphrase protected keys", func() {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			h.AssertNil(t, err)
+			//nolint:staticcheck
+			block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), []byte("passphrase"), x509.PEMCipherAES256)
+			h.AssertNil(t, err)
+			encryptedPath := filepath.Join(tmpDir, "id_encrypted")
+			h.AssertNil(t, ioutil.WriteFile(encryptedPath, pem.EncodeToMemory(block), 0600))
+
+			_, err = sshagent.WithKeys(encryptedPath)
+			h.AssertError(t, err, "is protected by a passphrase")
+		})
+
+		it("errors for invalid keys", func() {
+			invalidPath := filepath.Join(tmpDir, "invalid")
+			h.AssertNil(t, ioutil.WriteFile(invalidPath, []byte("not a key"), 0600))
+
+			_, err := sshagent.WithKeys(invalidPath)
+			h.AssertError(t, err, "parsing SSH key")
+		})
+	})
+
+	when("#Forward", func() {
+		it("forwards requests to the agent at the socket", func() {
+			upstream, err := sshagent.WithKeys(keyPath)
+			h.AssertNil(t, err)
+			defer upstream.Close()
+
+			subject, err := sshagent.Forward(upstream.Socket())
+			h.AssertNil(t, err)
+			defer subject.Close()
+
+			h.AssertNotEq(t, subject.Socket(), upstream.Socket())
+			keys := listKeys(subject.Socket())
+			h.AssertEq(t, len(keys), 1)
+			h.AssertEq(t, keys[0].Comment, keyPath)
+		})
+
+		it("errors when the agent cannot be reached", func() {
+			_, err := sshagent.Forward(filepath.Join(tmpDir, "missing.sock"))
+			h.AssertError(t, err, "connecting to SSH agent at")
+		})
+	})
+}
@@ -0,0 +1,284 @@
+package pack
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpacks/imgutil"
+	"github.com/buildpacks/imgutil/local"
+	"github.com/buildpacks/imgutil/remote"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/config"
+	"github.com/buildpacks/pack/internal/blob"
+	"github.com/buildpacks/pack/internal/buildpack"
+	"github.com/buildpacks/pack/internal/dist"
+	"github.com/buildpacks/pack/internal/image"
+	"github.com/buildpacks/pack/internal/style"
+	"github.com/buildpacks/pack/project"
+)
+
+// LockOptions configures the project lock, which pins the builder, run image, lifecycle image and buildpacks
+// of a build to the digests they resolved to.
+type LockOptions struct {
+	// File is the path of the project lock. It is relative to RelativeBaseDir.
+	// When it exists, the build uses the images and buildpacks recorded in it. Otherwise, it is written
+	// with what the build resolved.
+	File string
+
+	// Update resolves the images and buildpacks again, ignoring those recorded in File, and writes them to File.
+	Update bool
+}
+
+func validateLock(opts BuildOptions) error {
+	if opts.Lock.Update && opts.Lock.File == "" {
+		return errors.New("updating the project lock requires a lock file")
+	}
+	if opts.Lock.File != "" && len(opts.Platforms) > 1 {
+		return errors.New("project lock does not support building for multiple platforms")
+	}
+	return nil
+}
+
+// buildLock replays the project lock of a build or, when there is none to replay, records what the build resolved.
+// A nil buildLock does neither.
+type buildLock struct {
+	path     string
+	locked   *project.Lock
+	resolved project.Lock
+}
+
+func (c *Client) readLock(opts BuildOptions) (*buildLock, error) {
+	if opts.Lock.File == "" {
+		return nil, nil
+	}
+
+	path := opts.Lock.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(opts.RelativeBaseDir, path)
+	}
+	lock := &buildLock{path: path}
+	if opts.Lock.Update {
+		return lock, nil
+	}
+
+	locked, err := project.ReadLock(path)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading project lock %s", style.Symbol(path))
+	}
+	c.logger.Debugf("Using images and buildpacks pinned by project lock %s", style.Symbol(path))
+	lock.locked = &locked
+	return lock, nil
+}
+
+func (l *buildLock) replaying() bool {
+	return l != nil && l.locked != nil
+}
+
+func (l *buildLock) recording() bool {
+	return l != nil && l.locked == nil
+}
+
+func (l *buildLock) stale(format string, args ...interface{}) error {
+	return errors.Errorf("%s, update the project lock %s to use it", fmt.Sprintf(format, args...), style.Symbol(l.path))
+}
+
+// pinImage returns the image pinned by locked, the lock entry for the image ref of the given kind.
+// The lock must pin an image from the same repository as ref.
+func (l *buildLock) pinImage(kind string, locked *project.LockedImage, ref name.Reference) (name.Reference, error) {
+	if locked == nil {
+		return nil, l.stale("%s %s is not in the project lock", kind, style.Symbol(ref.String()))
+	}
+
+	lockedRef, err := name.ParseReference(locked.Image, name.WeakValidation)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s %s in project lock", kind, style.Symbol(locked.Image))
+	}
+	if lockedRef.Context().Name() != ref.Context().Name() {
+		return nil, l.stale("%s %s differs from the %s %s in the project lock", kind, style.Symbol(ref.String()), kind, style.Symbol(locked.Image))
+	}
+	return lockedRef.Context().Digest(locked.Digest), nil
+}
+
+// pinImageName is pinImage for an image given by name.
+func (l *buildLock) pinImageName(kind string, locked *project.LockedImage, imageName string) (string, error) {
+	ref, err := name.ParseReference(imageName, name.WeakValidation)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid %s %s", kind, style.Symbol(imageName))
+	}
+	pinned, err := l.pinImage(kind, locked, ref)
+	if err != nil {
+		return "", err
+	}
+	return pinned.Name(), nil
+}
+
+// lockedImage returns the lock entry of the image img, fetched as ref.
+func (c *Client) lockedImage(ctx context.Context, ref name.Reference, img imgutil.Image) (*project.LockedImage, error) {
+	digest, err := c.registryDigest(ctx, ref, img)
+	if err != nil {
+		return nil, err
+	}
+	return &project.LockedImage{Image: ref.Context().Name(), Digest: digest}, nil
+}
+
+// registryDigest returns the digest of img, fetched as ref, in the repository of ref.
+func (c *Client) registryDigest(ctx context.Context, ref name.Reference, img imgutil.Image) (string, error) {
+	if digest, ok := ref.(name.Digest); ok {
+		return digest.DigestStr(), nil
+	}
+
+	id, err := img.Identifier()
+	if err != nil {
+		return "", err
+	}
+	switch v := id.(type) {
+	case remote.DigestIdentifier:
+		return v.Digest.DigestStr(), nil
+	case local.IDIdentifier:
+		inspect, _, err := c.docker.ImageInspectWithRaw(ctx, v.ImageID)
+		if err != nil {
+			return "", errors.Wrapf(err, "inspecting %s", style.Symbol(ref.String()))
+		}
+		for _, repoDigest := range inspect.RepoDigests {
+			digest, err := name.NewDigest(repoDigest, name.WeakValidation)
+			if err == nil && digest.Context().Name() == ref.Context().Name() {
+				return digest.DigestStr(), nil
+			}
+		}
+	}
+	return "", errors.Errorf("cannot lock %s, as it has no digest in a registry", style.Symbol(ref.String()))
+}
+
+// pinBuildpack returns the locator the buildpack declared as locator is downloaded from. When replaying, buildpacks
+// from images are pinned to the image digests in the lock, and archives must have the digest in the lock.
+// When recording, buildpacks from a buildpack registry are pinned to the image the registry resolves them to.
+func (c *Client) pinBuildpack(ctx context.Context, l *buildLock, locator string, locatorType buildpack.LocatorType, registryName string) (string, error) {
+	if l == nil || !isLockable(locator, locatorType) {
+		return locator, nil
+	}
+
+	if l.recording() {
+		if locatorType != buildpack.RegistryLocator {
+			return locator, nil
+		}
+		registryCache, err := getRegistry(c.logger, registryName)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid registry '%s'", registryName)
+		}
+		registryBp, err := registryCache.LocateBuildpack(locator)
+		if err != nil {
+			return "", errors.Wrapf(err, "locating in registry %s", style.Symbol(locator))
+		}
+		return "docker://" + registryBp.Address, nil
+	}
+
+	locked, ok := l.locked.FindBuildpack(locator)
+	if !ok {
+		return "", l.stale("buildpack %s is not in the project lock", style.Symbol(locator))
+	}
+	if locked.Image != "" {
+		return "docker://" + locked.Image + "@" + locked.Digest, nil
+	}
+
+	digest, err := c.archiveDigest(ctx, locator)
+	if err != nil {
+		return "", err
+	}
+	if digest != locked.Digest {
+		return "", l.stale("buildpack %s has digest %s, not the digest %s in the project lock", style.Symbol(locator), style.Symbol(digest), style.Symbol(locked.Digest))
+	}
+	return locator, nil
+}
+
+// recordBuildpack records the buildpack declared as locator, downloaded from pinnedLocator, when recording.
+func (c *Client) recordBuildpack(ctx context.Context, l *buildLock, locator, pinnedLocator string, info dist.BuildpackInfo, relativeBaseDir string, publish bool) error {
+	if !l.recording() {
+		return nil
+	}
+	locatorType, err := buildpack.GetLocatorType(pinnedLocator, relativeBaseDir, nil)
+	if err != nil {
+		return err
+	}
+	if !isLockable(pinnedLocator, locatorType) {
+		return nil
+	}
+
+	locked := project.LockedBuildpack{URI: locator, ID: info.ID, Version: info.Version}
+	if locatorType == buildpack.PackageLocator {
+		imageName := buildpack.ParsePackageLocator(pinnedLocator)
+		ref, err := name.ParseReference(imageName, name.WeakValidation)
+		if err != nil {
+			return errors.Wrapf(err, "invalid buildpack image %s", style.Symbol(imageName))
+		}
+		img, err := c.imageFetcher.Fetch(ctx, imageName, image.FetchOptions{Daemon: !publish, PullPolicy: config.PullNever})
+		if err != nil {
+			return errors.Wrapf(err, "fetching buildpack image %s", style.Symbol(imageName))
+		}
+		if locked.Digest, err = c.registryDigest(ctx, ref, img); err != nil {
+			return err
+		}
+		locked.Image = ref.Context().Name()
+	} else if locked.Digest, err = c.archiveDigest(ctx, pinnedLocator); err != nil {
+		return err
+	}
+
+	l.resolved.Buildpacks = append(l.resolved.Buildpacks, locked)
+	return nil
+}
+
+// isLockable returns whether the buildpack at locator comes from a registry or URI, rather than from the builder
+// or the local file system.
+func isLockable(locator string, locatorType buildpack.LocatorType) bool {
+	switch locatorType {
+	case buildpack.PackageLocator, buildpack.RegistryLocator:
+		return true
+	case buildpack.URILocator:
+		return strings.HasPrefix(locator, "http://") || strings.HasPrefix(locator, "https://")
+	}
+	return false
+}
+
+func (c *Client) archiveDigest(ctx context.Context, uri string) (string, error) {
+	b, err := c.downloader.Download(ctx, uri)
+	if err != nil {
+		return "", errors.Wrapf(err, "downloading buildpack from %s", style.Symbol(uri))
+	}
+	return blobDigest(b)
+}
+
+func blobDigest(b blob.Blob) (string, error) {
+	rc, err := b.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, rc); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// writeLock writes the lock resolved for the build, when recording.
+func (c *Client) writeLock(l *buildLock) error {
+	if !l.recording() {
+		return nil
+	}
+	if err := project.WriteLock(l.path, l.resolved); err != nil {
+		return errors.Wrapf(err, "writing project lock %s", style.Symbol(l.path))
+	}
+	c.logger.Infof("Wrote project lock to %s", style.Symbol(l.path))
+	return nil
+}
@@ -57,10 +57,20 @@ type PackageBuildpackOptions struct {
 
 // PackageBuildpack packages buildpack(s) into either an image or file.
 func (c *Client) PackageBuildpack(ctx context.Context, opts PackageBuildpackOptions) error {
+	if err := c.loadPolicy(); err != nil {
+		return err
+	}
+
 	if opts.Format == "" {
 		opts.Format = FormatImage
 	}
 
+	if opts.Format == FormatImage {
+		if err := c.policy.CheckPublish(opts.Publish, opts.Name); err != nil {
+			return err
+		}
+	}
+
 	if opts.Config.Platform.OS == "windows" && !c.experimental {
 		return NewExperimentError("Windows buildpackage support is currently experimental.")
 	}
@@ -92,6 +102,10 @@ func (c *Client) PackageBuildpack(ctx context.Context, opts PackageBuildpackOpti
 		return errors.Wrapf(err, "creating buildpack from %s", style.Symbol(bpURI))
 	}
 
+	if err := c.policy.CheckBuildpacks(bp.Descriptor().Info.ID); err != nil {
+		return err
+	}
+
 	packageBuilder.SetBuildpack(bp)
 
 	for _, dep := range opts.Config.Dependencies {
@@ -170,6 +184,10 @@ func (c *Client) PackageBuildpack(ctx context.Context, opts PackageBuildpackOpti
 			}
 		}
 
+		if err := c.policy.CheckBuildpacks(buildpackIDs(nil, depBPs...)...); err != nil {
+			return err
+		}
+
 		for _, depBP := range depBPs {
 			packageBuilder.AddDependency(depBP)
 		}
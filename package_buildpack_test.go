@@ -417,6 +417,65 @@ func testPackageBuildpack(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("a policy is set", func() {
+		var tmpDir string
+
+		it.Before(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "package-buildpack")
+			h.AssertNil(t, err)
+
+			policyPath := filepath.Join(tmpDir, "policy.toml")
+			h.AssertNil(t, ioutil.WriteFile(policyPath, []byte(`[buildpacks]
+forbidden = ["bp.forbidden"]
+
+[publish]
+registry = "registry.example.com"`), 0644))
+
+			subject, err = pack.NewClient(
+				pack.WithLogger(logging.NewLogWithWriters(&out, &out)),
+				pack.WithDownloader(mockDownloader),
+				pack.WithImageFactory(mockImageFactory),
+				pack.WithFetcher(mockImageFetcher),
+				pack.WithDockerClient(mockDockerClient),
+				pack.WithPolicyFile(policyPath),
+			)
+			h.AssertNil(t, err)
+		})
+
+		it.After(func() {
+			h.AssertNil(t, os.RemoveAll(tmpDir))
+		})
+
+		it("should fail when the package image is not published to the required registry", func() {
+			err := subject.PackageBuildpack(context.TODO(), pack.PackageBuildpackOptions{
+				Name: "some/package",
+				Config: pubbldpkg.Config{
+					Platform:  dist.Platform{OS: "linux"},
+					Buildpack: dist.BuildpackURI{URI: "https://example.com/bp.tgz"},
+				},
+				Publish: true,
+			})
+			h.AssertError(t, err, "image 'some/package' must be published to 'registry.example.com'")
+		})
+
+		it("should fail when the buildpack is forbidden", func() {
+			err := subject.PackageBuildpack(context.TODO(), pack.PackageBuildpackOptions{
+				Format: pack.FormatFile,
+				Name:   filepath.Join(tmpDir, "package.cnb"),
+				Config: pubbldpkg.Config{
+					Platform: dist.Platform{OS: "linux"},
+					Buildpack: dist.BuildpackURI{URI: createBuildpack(dist.BuildpackDescriptor{
+						API:    api.MustParse("0.2"),
+						Info:   dist.BuildpackInfo{ID: "bp.forbidden", Version: "1.2.3"},
+						Stacks: []dist.Stack{{ID: "some.stack.id"}},
+					})},
+				},
+			})
+			h.AssertError(t, err, "buildpack 'bp.forbidden' is forbidden")
+		})
+	})
+
 	when("FormatFile", func() {
 		when("simple package for both OS formats (experimental only)", func() {
 			it("creates package image in either OS format", func() {
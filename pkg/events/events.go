@@ -0,0 +1,196 @@
+// Package events defines the structured events emitted while an app image is being built.
+package events // import "github.com/buildpacks/pack/pkg/events"
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of an Event.
+type Type string
+
+const (
+	// BuildResolved is emitted once the builder, run image and buildpacks for the build have been resolved.
+	BuildResolved Type = "build_resolved"
+	// PhaseStarted is emitted when a lifecycle phase begins.
+	PhaseStarted Type = "phase_started"
+	// PhaseFinished is emitted when a lifecycle phase ends, whether or not it succeeded.
+	PhaseFinished Type = "phase_finished"
+	// GroupDetected is emitted once the detector has selected a buildpack group.
+	GroupDetected Type = "group_detected"
+	// PlanDetected is emitted once the detector has written the build plan.
+	PlanDetected Type = "plan_detected"
+	// LayerRestored is emitted when a layer is restored from the build cache.
+	LayerRestored Type = "layer_restored"
+	// LayerReused is emitted when a layer from the previous image is reused by the exporter.
+	LayerReused Type = "layer_reused"
+	// LayerExported is emitted when a new layer is added to the app image by the exporter.
+	LayerExported Type = "layer_exported"
+	// ExportReported is emitted once the exporter has reported the tags it exported the app image to,
+	// along with the processes of the app image and the Platform API of the build.
+	ExportReported Type = "export_reported"
+	// ImageExported is emitted once the app image has been written.
+	ImageExported Type = "image_exported"
+	// Warning is emitted for each warning reported by pack or the lifecycle.
+	Warning Type = "warning"
+)
+
+// Buildpack identifies a buildpack taking part in a build.
+type Buildpack struct {
+	ID       string `json:"id" yaml:"id"`
+	Version  string `json:"version,omitempty" yaml:"version,omitempty"`
+	Optional bool   `json:"optional,omitempty" yaml:"optional,omitempty"`
+}
+
+// PlanEntry is an entry of the build plan: a set of requirements and the buildpacks providing them.
+type PlanEntry struct {
+	Providers []Buildpack `json:"providers" yaml:"providers"`
+	Requires  []Require   `json:"requires" yaml:"requires"`
+}
+
+// Require is a dependency requested by a buildpack in the build plan.
+type Require struct {
+	Name     string                 `json:"name" yaml:"name"`
+	Version  string                 `json:"version,omitempty" yaml:"version,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// Process is a process type defined by the buildpacks for the app image.
+type Process struct {
+	Type    string   `json:"type" yaml:"type"`
+	Command string   `json:"command" yaml:"command"`
+	Args    []string `json:"args,omitempty" yaml:"args,omitempty"`
+	Direct  bool     `json:"direct" yaml:"direct"`
+}
+
+// Event describes something that happened during a build.
+// Only the fields relevant to the Type are populated.
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	// Platform is the platform of the build the event belongs to, in the form os/arch[/variant],
+	// when building for several platforms.
+	Platform string `json:"platform,omitempty"`
+
+	// Builder is the name of the builder image.
+	Builder string `json:"builder,omitempty"`
+	// BuilderDigest is the digest (or, for daemon images, the ID) of the builder image.
+	BuilderDigest string `json:"builder_digest,omitempty"`
+	// RunImage is the run image the app image will be based on.
+	RunImage string `json:"run_image,omitempty"`
+	// RunImageDigest is the digest (or, for daemon images, the ID) of the run image.
+	RunImageDigest string `json:"run_image_digest,omitempty"`
+	// LifecycleVersion is the version of the lifecycle of the builder.
+	LifecycleVersion string `json:"lifecycle_version,omitempty"`
+	// RunImageMirrors are the mirrors that were considered when selecting the run image.
+	RunImageMirrors []string `json:"run_image_mirrors,omitempty"`
+	// Order is the detection order of the builder used for the build.
+	Order [][]Buildpack `json:"order,omitempty"`
+	// CreationTime is the creation time requested for the app image.
+	CreationTime *time.Time `json:"creation_time,omitempty"`
+
+	// Phase is the name of the lifecycle phase, e.g. "detector" or "exporter".
+	Phase string `json:"phase,omitempty"`
+	// Duration is the time a finished phase took to run. It is encoded in nanoseconds.
+	Duration time.Duration `json:"duration,omitempty"`
+	// Error is set on PhaseFinished events for phases that failed.
+	Error string `json:"error,omitempty"`
+
+	// Buildpacks is the group selected by detection or, for BuildResolved events,
+	// the buildpacks added to the builder for this build.
+	Buildpacks []Buildpack `json:"buildpacks,omitempty"`
+	// Plan is the build plan written by the detector.
+	Plan []PlanEntry `json:"plan,omitempty"`
+	// Layer is the identifier of a layer, in the form '<buildpack-id>:<layer-name>'.
+	Layer string `json:"layer,omitempty"`
+
+	// Tags are the names the exporter wrote the app image to or, for BuildResolved events,
+	// the additional tags requested for the build.
+	Tags []string `json:"tags,omitempty"`
+	// Processes are the process types of the app image.
+	Processes []Process `json:"processes,omitempty"`
+	// PlatformAPI is the Platform API version the lifecycle was run with.
+	PlatformAPI string `json:"platform_api,omitempty"`
+
+	// Image is the name of the exported image.
+	Image string `json:"image,omitempty"`
+	// Digest is the digest (or, for daemon images, the ID) of the exported image.
+	Digest string `json:"digest,omitempty"`
+
+	// Message holds the text of a Warning.
+	Message string `json:"message,omitempty"`
+}
+
+// Sink receives events as they occur during a build.
+// Implementations must be safe for use by multiple goroutines.
+type Sink interface {
+	Emit(e Event)
+}
+
+// Emit sends e to sink, setting the time of e to now unless it is already set. It does nothing when sink is nil.
+func Emit(sink Sink, e Event) {
+	if sink == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	sink.Emit(e)
+}
+
+// SinkFunc adapts an ordinary function to a Sink.
+type SinkFunc func(e Event)
+
+// Emit calls f(e).
+func (f SinkFunc) Emit(e Event) {
+	f(e)
+}
+
+// MultiSink emits each event to all of its sinks.
+type MultiSink []Sink
+
+// Emit calls Emit on each sink in turn.
+func (m MultiSink) Emit(e Event) {
+	for _, sink := range m {
+		sink.Emit(e)
+	}
+}
+
+// WritesOutput reports whether sink writes the events it receives to the output of a command, as a JSONSink does.
+// Output meant for people is then left out, as it would be mixed in with the events.
+func WritesOutput(sink Sink) bool {
+	switch s := sink.(type) {
+	case *JSONSink:
+		return true
+	case MultiSink:
+		for _, inner := range s {
+			if WritesOutput(inner) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// JSONSink writes each event as a single line of JSON.
+type JSONSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONSink returns a Sink that writes newline-delimited JSON to w.
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{enc: json.NewEncoder(w)}
+}
+
+// Emit encodes e followed by a newline. Encoding errors are ignored so that a broken
+// output stream does not interrupt the build.
+func (s *JSONSink) Emit(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_ = s.enc.Encode(e)
+}
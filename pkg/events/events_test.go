@@ -0,0 +1,104 @@
+package events_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/pkg/events"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestEvents(t *testing.T) {
+	spec.Run(t, "Events", testEvents, spec.Sequential(), spec.Report(report.Terminal{}))
+}
+
+func testEvents(t *testing.T, when spec.G, it spec.S) {
+	when("JSONSink", func() {
+		it("writes one JSON object per line", func() {
+			var buf bytes.Buffer
+			sink := events.NewJSONSink(&buf)
+			eventTime := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+			sink.Emit(events.Event{Type: events.PhaseStarted, Time: eventTime, Phase: "detector"})
+			sink.Emit(events.Event{
+				Type:       events.GroupDetected,
+				Time:       eventTime,
+				Buildpacks: []events.Buildpack{{ID: "some/bp", Version: "1.2.3"}},
+			})
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			h.AssertEq(t, len(lines), 2)
+			h.AssertEq(t, lines[0], `{"type":"phase_started","time":"2021-03-01T12:00:00Z","phase":"detector"}`)
+
+			var decoded events.Event
+			h.AssertNil(t, json.Unmarshal([]byte(lines[1]), &decoded))
+			h.AssertEq(t, decoded.Type, events.GroupDetected)
+			h.AssertEq(t, decoded.Buildpacks, []events.Buildpack{{ID: "some/bp", Version: "1.2.3"}})
+		})
+	})
+
+	when("MultiSink", func() {
+		it("emits each event to all of the sinks", func() {
+			var first, second []events.Type
+			sink := events.MultiSink{
+				events.SinkFunc(func(e events.Event) { first = append(first, e.Type) }),
+				events.SinkFunc(func(e events.Event) { second = append(second, e.Type) }),
+			}
+
+			sink.Emit(events.Event{Type: events.PhaseStarted})
+			sink.Emit(events.Event{Type: events.ImageExported})
+
+			h.AssertEq(t, first, []events.Type{events.PhaseStarted, events.ImageExported})
+			h.AssertEq(t, second, []events.Type{events.PhaseStarted, events.ImageExported})
+		})
+	})
+
+	when("SinkFunc", func() {
+		it("calls the function for each event", func() {
+			var received []events.Type
+			sink := events.SinkFunc(func(e events.Event) { received = append(received, e.Type) })
+
+			sink.Emit(events.Event{Type: events.Warning})
+			sink.Emit(events.Event{Type: events.ImageExported})
+
+			h.AssertEq(t, received, []events.Type{events.Warning, events.ImageExported})
+		})
+	})
+	when("#Emit", func() {
+		it("sets the time of events without one", func() {
+			var received []events.Event
+			sink := events.SinkFunc(func(e events.Event) { received = append(received, e) })
+			someTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			events.Emit(sink, events.Event{Type: events.PhaseStarted})
+			events.Emit(sink, events.Event{Type: events.PhaseFinished, Time: someTime})
+
+			h.AssertEq(t, len(received), 2)
+			h.AssertFalse(t, received[0].Time.IsZero())
+			h.AssertEq(t, received[1].Time, someTime)
+		})
+
+		it("does nothing without a sink", func() {
+			events.Emit(nil, events.Event{Type: events.PhaseStarted})
+		})
+	})
+
+	when("#WritesOutput", func() {
+		it("is true for JSON sinks, including within a MultiSink", func() {
+			jsonSink := events.NewJSONSink(&bytes.Buffer{})
+			funcSink := events.SinkFunc(func(events.Event) {})
+
+			h.AssertTrue(t, events.WritesOutput(jsonSink))
+			h.AssertTrue(t, events.WritesOutput(events.MultiSink{funcSink, jsonSink}))
+			h.AssertFalse(t, events.WritesOutput(funcSink))
+			h.AssertFalse(t, events.WritesOutput(events.MultiSink{funcSink}))
+			h.AssertFalse(t, events.WritesOutput(nil))
+		})
+	})
+}
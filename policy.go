@@ -0,0 +1,158 @@
+package pack
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Masterminds/semver"
+	"github.com/buildpacks/imgutil"
+	"github.com/buildpacks/imgutil/local"
+	"github.com/buildpacks/imgutil/remote"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/builder"
+	"github.com/buildpacks/pack/internal/dist"
+	"github.com/buildpacks/pack/internal/image"
+	"github.com/buildpacks/pack/internal/policy"
+	"github.com/buildpacks/pack/internal/style"
+)
+
+// loadPolicy reads the policy file the client was configured with, the first time a command it governs needs it.
+func (c *Client) loadPolicy() error {
+	if !c.policyLoaded && c.policyPath != "" {
+		c.policy, c.policyErr = policy.Read(c.policyPath)
+	}
+	c.policyLoaded = true
+	return c.policyErr
+}
+
+// imageDigests returns the digests img is known by: the digest it was fetched from a registry by or,
+// for images in the daemon, the digests of the repositories it was pulled from.
+func (c *Client) imageDigests(ctx context.Context, img imgutil.Image) policy.DigestsFunc {
+	return func() ([]string, error) {
+		id, err := img.Identifier()
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := id.(type) {
+		case remote.DigestIdentifier:
+			return []string{v.Digest.DigestStr()}, nil
+		case local.IDIdentifier:
+			inspect, _, err := c.docker.ImageInspectWithRaw(ctx, v.ImageID)
+			if err != nil {
+				return nil, err
+			}
+			var digests []string
+			for _, repoDigest := range inspect.RepoDigests {
+				if i := strings.Index(repoDigest, "@"); i >= 0 {
+					digests = append(digests, repoDigest[i+1:])
+				}
+			}
+			return digests, nil
+		}
+		return nil, nil
+	}
+}
+
+func buildpackIDs(infos []dist.BuildpackInfo, bps ...dist.Buildpack) []string {
+	var ids []string
+	for _, info := range infos {
+		ids = append(ids, info.ID)
+	}
+	for _, bp := range bps {
+		ids = append(ids, bp.Descriptor().Info.ID)
+	}
+	return ids
+}
+
+// checkRunImagePolicy checks that the run image fetched as runImageName is allowed by the policy.
+// When the run image has not been fetched, only a digest in runImageName is checked against the policy.
+func (c *Client) checkRunImagePolicy(ctx context.Context, runImageName string, runImage imgutil.Image) error {
+	if c.policy == nil {
+		return nil
+	}
+	ref, err := name.ParseReference(runImageName, name.WeakValidation)
+	if err != nil {
+		return errors.Wrapf(err, "invalid run image %s", style.Symbol(runImageName))
+	}
+	var digests policy.DigestsFunc
+	if runImage != nil {
+		digests = c.imageDigests(ctx, runImage)
+	}
+	return c.policy.CheckRunImage(ref, digests)
+}
+
+// checkLocalBuildPolicy checks the lifecycle, buildpacks and stack the local executor builds with, which the build image
+// provides in cnbDir, against the policy. It returns the run image to build imageRef with: runImageName when given, or
+// the run image of the stack otherwise, so that the image checked is the image used.
+func (c *Client) checkLocalBuildPolicy(ctx context.Context, imageRef name.Reference, runImageName string, additionalMirrors map[string][]string) (string, error) {
+	if c.policy == nil {
+		return runImageName, nil
+	}
+
+	if err := c.policy.CheckUnidentifiedBuilder("the build image of the local executor"); err != nil {
+		return "", err
+	}
+
+	lifecyclePath := filepath.Join(c.cnbDir, "lifecycle", "lifecycle.toml")
+	contents, err := ioutil.ReadFile(lifecyclePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading lifecycle descriptor %s", style.Symbol(lifecyclePath))
+	}
+	lifecycle, err := builder.ParseDescriptor(string(contents))
+	if err != nil {
+		return "", err
+	}
+	if err := c.policy.CheckLifecycle(semverOf(lifecycle.Info.Version)); err != nil {
+		return "", err
+	}
+
+	descriptorPaths, err := filepath.Glob(filepath.Join(c.cnbDir, "buildpacks", "*", "*", "buildpack.toml"))
+	if err != nil {
+		return "", err
+	}
+	var ids []string
+	for _, path := range descriptorPaths {
+		var descriptor dist.BuildpackDescriptor
+		if _, err := toml.DecodeFile(path, &descriptor); err != nil {
+			return "", errors.Wrapf(err, "reading buildpack descriptor %s", style.Symbol(path))
+		}
+		ids = append(ids, descriptor.Info.ID)
+	}
+	if err := c.policy.CheckBuildpacks(ids...); err != nil {
+		return "", err
+	}
+
+	if runImageName == "" {
+		stackPath := filepath.Join(c.cnbDir, "stack.toml")
+		var stack builder.StackMetadata
+		if _, err := toml.DecodeFile(stackPath, &stack); err != nil {
+			return "", errors.Wrapf(err, "reading stack %s", style.Symbol(stackPath))
+		}
+		runImageName = c.resolveRunImage("", imageRef.Context().RegistryStr(), "", stack, additionalMirrors, true)
+	}
+	ref, err := name.ParseReference(runImageName, name.WeakValidation)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid run image %s", style.Symbol(runImageName))
+	}
+	// the run image is only fetched, from the registry, when the policy pins it by digest
+	return runImageName, c.policy.CheckRunImage(ref, func() ([]string, error) {
+		runImage, err := c.imageFetcher.Fetch(ctx, runImageName, image.FetchOptions{Daemon: false})
+		if err != nil {
+			return nil, err
+		}
+		return c.imageDigests(ctx, runImage)()
+	})
+}
+
+func semverOf(v *builder.Version) *semver.Version {
+	if v == nil {
+		return nil
+	}
+	return &v.Version
+}
@@ -0,0 +1,83 @@
+package project
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+)
+
+// LockFileName is the name of the project lock, which is kept next to project.toml.
+const LockFileName = "project.lock"
+
+const lockHeader = "# Generated by `pack build --lock`. Run `pack build --update-lock` to resolve the images and buildpacks again.\n\n"
+
+// Lock pins what a build of the project resolved its builder, run image, lifecycle image and buildpacks to.
+type Lock struct {
+	Builder        *LockedImage      `toml:"builder,omitempty"`
+	RunImage       *LockedImage      `toml:"run-image,omitempty"`
+	LifecycleImage *LockedImage      `toml:"lifecycle-image,omitempty"`
+	Buildpacks     []LockedBuildpack `toml:"buildpacks,omitempty"`
+}
+
+// LockedImage is an image repository and the digest of the image used from it.
+type LockedImage struct {
+	Image  string `toml:"image"`
+	Digest string `toml:"digest"`
+}
+
+// LockedBuildpack is a buildpack as it was declared, and what it resolved to. Buildpacks from images, including
+// those from a buildpack registry, are pinned to the digest of the image. Buildpacks downloaded from a URI are
+// pinned to the sha256 digest of the archive.
+type LockedBuildpack struct {
+	URI     string `toml:"uri"`
+	ID      string `toml:"id"`
+	Version string `toml:"version"`
+	Image   string `toml:"image,omitempty"`
+	Digest  string `toml:"digest"`
+}
+
+// FindBuildpack returns the locked buildpack declared as uri.
+func (l Lock) FindBuildpack(uri string) (LockedBuildpack, bool) {
+	for _, bp := range l.Buildpacks {
+		if bp.URI == uri {
+			return bp, true
+		}
+	}
+	return LockedBuildpack{}, false
+}
+
+func ReadLock(pathToFile string) (Lock, error) {
+	contents, err := ioutil.ReadFile(filepath.Clean(pathToFile))
+	if err != nil {
+		return Lock{}, err
+	}
+
+	var lock Lock
+	if _, err := toml.Decode(string(contents), &lock); err != nil {
+		return Lock{}, errors.Wrap(err, "project.lock")
+	}
+
+	for _, img := range []*LockedImage{lock.Builder, lock.RunImage, lock.LifecycleImage} {
+		if img != nil && (img.Image == "" || img.Digest == "") {
+			return Lock{}, errors.New("project.lock: images must have an image and digest defined")
+		}
+	}
+	for _, bp := range lock.Buildpacks {
+		if bp.URI == "" || bp.Digest == "" {
+			return Lock{}, errors.New("project.lock: buildpacks must have a uri and digest defined")
+		}
+	}
+
+	return lock, nil
+}
+
+func WriteLock(pathToFile string, lock Lock) error {
+	buf := bytes.NewBufferString(lockHeader)
+	if err := toml.NewEncoder(buf).Encode(lock); err != nil {
+		return errors.Wrap(err, "encoding project.lock")
+	}
+	return ioutil.WriteFile(pathToFile, buf.Bytes(), 0644)
+}
@@ -0,0 +1,98 @@
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestLock(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "Lock", testLock, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testLock(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir string
+		path   string
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "project-lock-test")
+		h.AssertNil(t, err)
+		path = filepath.Join(tmpDir, LockFileName)
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	when("#WriteLock", func() {
+		it("writes a lock that reads back the same", func() {
+			lock := Lock{
+				Builder:  &LockedImage{Image: "index.docker.io/some/builder", Digest: "sha256:builder"},
+				RunImage: &LockedImage{Image: "index.docker.io/some/run", Digest: "sha256:run"},
+				Buildpacks: []LockedBuildpack{
+					{URI: "example/bp@1.0.0", ID: "example/bp", Version: "1.0.0", Image: "index.docker.io/example/bp", Digest: "sha256:bp"},
+					{URI: "https://example.com/bp.tgz", ID: "example/archive", Version: "2.0.0", Digest: "sha256:archive"},
+				},
+			}
+			h.AssertNil(t, WriteLock(path, lock))
+
+			contents, err := ioutil.ReadFile(path)
+			h.AssertNil(t, err)
+			h.AssertTrue(t, strings.HasPrefix(string(contents), "# Generated by `pack build --lock`"))
+			h.AssertNotContains(t, string(contents), "lifecycle-image")
+
+			read, err := ReadLock(path)
+			h.AssertNil(t, err)
+			h.AssertEq(t, read, lock)
+		})
+	})
+
+	when("#ReadLock", func() {
+		it("returns a not exist error for missing files", func() {
+			_, err := ReadLock(path)
+			h.AssertTrue(t, os.IsNotExist(err))
+		})
+
+		it("errors for images without a digest", func() {
+			h.AssertNil(t, ioutil.WriteFile(path, []byte(`[builder]
+image = "index.docker.io/some/builder"`), 0644))
+
+			_, err := ReadLock(path)
+			h.AssertError(t, err, "project.lock: images must have an image and digest defined")
+		})
+
+		it("errors for buildpacks without a digest", func() {
+			h.AssertNil(t, ioutil.WriteFile(path, []byte(`[[buildpacks]]
+uri = "example/bp@1.0.0"`), 0644))
+
+			_, err := ReadLock(path)
+			h.AssertError(t, err, "project.lock: buildpacks must have a uri and digest defined")
+		})
+	})
+
+	when("#FindBuildpack", func() {
+		it("finds buildpacks by the uri they were declared as", func() {
+			lock := Lock{Buildpacks: []LockedBuildpack{{URI: "example/bp@1.0.0", Digest: "sha256:bp"}}}
+
+			bp, ok := lock.FindBuildpack("example/bp@1.0.0")
+			h.AssertTrue(t, ok)
+			h.AssertEq(t, bp.Digest, "sha256:bp")
+
+			_, ok = lock.FindBuildpack("example/other@1.0.0")
+			h.AssertFalse(t, ok)
+		})
+	})
+}
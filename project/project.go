@@ -28,19 +28,37 @@ type EnvVar struct {
 	Value string `toml:"value"`
 }
 
+type Secret struct {
+	ID     string `toml:"id"`
+	Source string `toml:"src"`
+}
+
+type Cache struct {
+	Type   string `toml:"type"`
+	Format string `toml:"format"`
+	Name   string `toml:"name"`
+	Source string `toml:"source"`
+}
+
 type Build struct {
-	Include    []string    `toml:"include"`
-	Exclude    []string    `toml:"exclude"`
-	Buildpacks []Buildpack `toml:"buildpacks"`
-	Env        []EnvVar    `toml:"env"`
-	Builder    string      `toml:"builder"`
+	Include    []string          `toml:"include"`
+	Exclude    []string          `toml:"exclude"`
+	Buildpacks []Buildpack       `toml:"buildpacks"`
+	Env        []EnvVar          `toml:"env"`
+	Builder    string            `toml:"builder"`
+	Secrets    []Secret          `toml:"secrets"`
+	Caches     []Cache           `toml:"cache"`
+	Labels     map[string]string `toml:"labels"`
 }
 
 type Project struct {
-	Name      string         `toml:"name"`
-	Version   string         `toml:"version"`
-	SourceURL string         `toml:"source-url"`
-	Licenses  []dist.License `toml:"licenses"`
+	Name         string         `toml:"name"`
+	Version      string         `toml:"version"`
+	SourceURL    string         `toml:"source-url"`
+	SourceCommit string         `toml:"source-commit"`
+	SourceBranch string         `toml:"source-branch"`
+	SourceDirty  *bool          `toml:"source-dirty"`
+	Licenses     []dist.License `toml:"licenses"`
 }
 
 type Descriptor struct {
@@ -88,5 +106,23 @@ func validate(p Descriptor) error {
 		}
 	}
 
+	for _, secret := range p.Build.Secrets {
+		if secret.ID == "" || secret.Source == "" {
+			return errors.New("project.toml: secrets must have an id and src defined")
+		}
+	}
+
+	for key := range p.Build.Labels {
+		if key == "" {
+			return errors.New("project.toml: labels must have a key defined")
+		}
+	}
+
+	for _, cache := range p.Build.Caches {
+		if cache.Type == "" {
+			return errors.New("project.toml: caches must have a type defined")
+		}
+	}
+
 	return nil
 }
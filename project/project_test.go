@@ -5,6 +5,7 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -214,6 +215,183 @@ version = "1.2.3"
 			}
 		})
 
+		it("should parse secrets", func() {
+			projectToml := `
+[project]
+name = "secrets"
+
+[[build.secrets]]
+id = "npmrc"
+src = "./.npmrc"
+`
+			tmpProjectToml, err := createTmpProjectTomlFile(projectToml)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			projectDescriptor, err := ReadProjectDescriptor(tmpProjectToml.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []Secret{{ID: "npmrc", Source: "./.npmrc"}}
+			if !reflect.DeepEqual(projectDescriptor.Build.Secrets, expected) {
+				t.Fatalf("Expected\n-----\n%#v\n-----\nbut got\n-----\n%#v\n",
+					expected, projectDescriptor.Build.Secrets)
+			}
+		})
+
+		it("should require an id and src for secrets", func() {
+			projectToml := `
+[project]
+name = "secrets should have an id and src defined"
+
+[[build.secrets]]
+id = "npmrc"
+`
+			tmpProjectToml, err := createTmpProjectTomlFile(projectToml)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = ReadProjectDescriptor(tmpProjectToml.Name())
+			if err == nil {
+				t.Fatal("Expected error for having no src defined for a secret")
+			}
+		})
+
+		it("should parse caches", func() {
+			projectToml := `
+[project]
+name = "caches"
+
+[[build.cache]]
+type = "build"
+format = "bind"
+source = "./.cache"
+
+[[build.cache]]
+type = "launch"
+format = "volume"
+name = "shared-launch-cache"
+`
+			tmpProjectToml, err := createTmpProjectTomlFile(projectToml)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			projectDescriptor, err := ReadProjectDescriptor(tmpProjectToml.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []Cache{
+				{Type: "build", Format: "bind", Source: "./.cache"},
+				{Type: "launch", Format: "volume", Name: "shared-launch-cache"},
+			}
+			if !reflect.DeepEqual(projectDescriptor.Build.Caches, expected) {
+				t.Fatalf("Expected\n-----\n%#v\n-----\nbut got\n-----\n%#v\n",
+					expected, projectDescriptor.Build.Caches)
+			}
+		})
+
+		it("should require a type for caches", func() {
+			projectToml := `
+[project]
+name = "caches should have a type defined"
+
+[[build.cache]]
+format = "volume"
+`
+			tmpProjectToml, err := createTmpProjectTomlFile(projectToml)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = ReadProjectDescriptor(tmpProjectToml.Name())
+			if err == nil {
+				t.Fatal("Expected error for having no type defined for a cache")
+			}
+		})
+
+		it("should parse the source of the project", func() {
+			projectToml := `
+[project]
+name = "source"
+source-url = "https://github.com/example/app.git"
+source-commit = "0123456789abcdef0123456789abcdef01234567"
+source-branch = "main"
+source-dirty = false
+`
+			tmpProjectToml, err := createTmpProjectTomlFile(projectToml)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			projectDescriptor, err := ReadProjectDescriptor(tmpProjectToml.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if projectDescriptor.Project.SourceCommit != "0123456789abcdef0123456789abcdef01234567" {
+				t.Fatalf("Expected source commit to be %s, but got %s", "0123456789abcdef0123456789abcdef01234567", projectDescriptor.Project.SourceCommit)
+			}
+			if projectDescriptor.Project.SourceBranch != "main" {
+				t.Fatalf("Expected source branch to be %s, but got %s", "main", projectDescriptor.Project.SourceBranch)
+			}
+			if projectDescriptor.Project.SourceDirty == nil || *projectDescriptor.Project.SourceDirty {
+				t.Fatalf("Expected source dirty to be declared as false, but got %v", projectDescriptor.Project.SourceDirty)
+			}
+		})
+
+		it("should parse labels", func() {
+			projectToml := `
+[project]
+name = "labels"
+
+[build.labels]
+"com.example.team" = "payments"
+"com.example.cost-center" = "1234"
+`
+			tmpProjectToml, err := createTmpProjectTomlFile(projectToml)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			projectDescriptor, err := ReadProjectDescriptor(tmpProjectToml.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := map[string]string{
+				"com.example.team":        "payments",
+				"com.example.cost-center": "1234",
+			}
+			if !reflect.DeepEqual(projectDescriptor.Build.Labels, expected) {
+				t.Fatalf("Expected\n-----\n%#v\n-----\nbut got\n-----\n%#v\n",
+					expected, projectDescriptor.Build.Labels)
+			}
+		})
+
+		it("should require a key for labels", func() {
+			projectToml := `
+[project]
+name = "labels should have a key defined"
+
+[build.labels]
+"" = "some-value"
+`
+			tmpProjectToml, err := createTmpProjectTomlFile(projectToml)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = ReadProjectDescriptor(tmpProjectToml.Name())
+			if err == nil {
+				t.Fatal("Expected error for having no key defined for a label")
+			}
+		})
+
 		it("should require either a type or uri for licenses", func() {
 			projectToml := `
 [project]
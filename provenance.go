@@ -0,0 +1,148 @@
+package pack
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/buildpacks/lifecycle/platform"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/builder"
+	"github.com/buildpacks/pack/internal/provenance"
+	"github.com/buildpacks/pack/internal/sign"
+	"github.com/buildpacks/pack/internal/style"
+)
+
+// ProvenanceOptions configures the in-toto provenance statement recorded for a build, in the SLSA provenance format.
+// The statement records the builder and its digest, the lifecycle version, the buildpack order with versions,
+// the git commit of the app, the names (but not the values) of the build environment variables and the invoker.
+type ProvenanceOptions struct {
+	// File is the path the statement is written to. It is relative to RelativeBaseDir.
+	File string
+
+	// Attach pushes the statement as a DSSE envelope to the repository of the image, under the tag cosign uses
+	// for attestations. The envelope is signed with SignKey when it is set. Requires Publish.
+	Attach bool
+
+	// Invoker identifies who invoked the build. It defaults to the current user.
+	Invoker string
+}
+
+func (o ProvenanceOptions) requested() bool {
+	return o.File != "" || o.Attach
+}
+
+func validateProvenance(opts BuildOptions) error {
+	if opts.Provenance.Attach && !opts.Publish {
+		return errors.New("attaching provenance requires publishing the image")
+	}
+	if opts.Provenance.Attach && len(opts.Platforms) > 1 {
+		return errors.New("attaching provenance does not support building for multiple platforms")
+	}
+	if opts.Provenance.File != "" && len(opts.Platforms) > 1 {
+		return errors.New("provenance file does not support building for multiple platforms")
+	}
+	return nil
+}
+
+// recordProvenance writes or attaches the provenance statement of the image built as imageRef and exported as exportRef,
+// as requested by opts. The statement is about the image with labelledDigest, when the image was saved again with labels.
+func (c *Client) recordProvenance(ctx context.Context, opts BuildOptions, imageRef, exportRef name.Reference, labelledDigest string, inputs provenance.Inputs) error {
+	if !opts.Provenance.requested() {
+		return nil
+	}
+
+	inputs.Digest = labelledDigest
+	if inputs.Digest == "" {
+		var err error
+		if inputs.Digest, err = c.builtImageDigest(ctx, opts.Publish, exportRef); err != nil {
+			return err
+		}
+	}
+	inputs.FinishedOn = time.Now()
+	statement := provenance.New(inputs)
+
+	if opts.Provenance.File != "" {
+		path := opts.Provenance.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(opts.RelativeBaseDir, path)
+		}
+		if err := provenance.WriteFile(path, statement); err != nil {
+			return err
+		}
+		c.logger.Infof("Wrote provenance of %s to %s", style.Symbol(imageRef.Name()), style.Symbol(path))
+	}
+
+	if opts.Provenance.Attach {
+		payload, err := json.Marshal(statement)
+		if err != nil {
+			return errors.Wrap(err, "marshalling provenance")
+		}
+		key, err := loadSignKey(opts.SignKey, opts.SignKeyPassword, opts.Publish)
+		if err != nil {
+			return err
+		}
+		digest := imageRef.Context().Digest(inputs.Digest)
+		tag, err := sign.Attest(ctx, authn.DefaultKeychain, digest, provenance.PayloadType, payload, key)
+		if err != nil {
+			return errors.Wrapf(err, "attaching provenance to %s", style.Symbol(digest.Name()))
+		}
+		c.logger.Infof("Pushed provenance of %s to %s", style.Symbol(digest.Name()), style.Symbol(tag.Name()))
+	}
+	return nil
+}
+
+// orderForProvenance returns the buildpack order of bldr, with the version of each buildpack the builder holds.
+func orderForProvenance(bldr *builder.Builder) [][]provenance.Buildpack {
+	versions := map[string]string{}
+	for _, bp := range bldr.Buildpacks() {
+		versions[bp.ID] = bp.Version
+	}
+
+	var order [][]provenance.Buildpack
+	for _, entry := range bldr.Order() {
+		var group []provenance.Buildpack
+		for _, bp := range entry.Group {
+			version := bp.Version
+			if version == "" {
+				version = versions[bp.ID]
+			}
+			group = append(group, provenance.Buildpack{ID: bp.ID, Version: version, Optional: bp.Optional})
+		}
+		order = append(order, group)
+	}
+	return order
+}
+
+// sourceForProvenance returns the git repository and commit recorded in the project metadata, if any.
+func sourceForProvenance(md platform.ProjectMetadata) *provenance.Source {
+	if md.Source == nil || md.Source.Type != "git" {
+		return nil
+	}
+	commit, _ := md.Source.Version["commit"].(string)
+	url, _ := md.Source.Metadata["url"].(string)
+	return &provenance.Source{URL: url, Commit: commit}
+}
+
+func envKeys(env map[string]string) []string {
+	var keys []string
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func currentInvoker() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -35,6 +35,12 @@ type RebaseOptions struct {
 	// AdditionalMirrors gives us inputs to recalculate the 'best' run image
 	// based on the registry we are publishing to.
 	AdditionalMirrors map[string][]string
+
+	// SignKey is the path to a cosign private key the rebased image is signed with. Requires Publish.
+	SignKey string
+
+	// SignKeyPassword decrypts SignKey when it is an encrypted cosign key.
+	SignKeyPassword string
 }
 
 // Rebase updates the run image layers in an app image.
@@ -45,6 +51,18 @@ func (c *Client) Rebase(ctx context.Context, opts RebaseOptions) error {
 		return errors.Wrapf(err, "invalid image name '%s'", opts.RepoName)
 	}
 
+	signKey, err := loadSignKey(opts.SignKey, opts.SignKeyPassword, opts.Publish)
+	if err != nil {
+		return err
+	}
+
+	if err := c.loadPolicy(); err != nil {
+		return err
+	}
+	if err := c.policy.CheckPublish(opts.Publish, opts.RepoName); err != nil {
+		return err
+	}
+
 	appImage, err := c.imageFetcher.Fetch(ctx, opts.RepoName, image.FetchOptions{Daemon: !opts.Publish, PullPolicy: opts.PullPolicy})
 	if err != nil {
 		return err
@@ -79,6 +97,10 @@ func (c *Client) Rebase(ctx context.Context, opts RebaseOptions) error {
 		return err
 	}
 
+	if err := c.checkRunImagePolicy(ctx, runImageName, baseImage); err != nil {
+		return err
+	}
+
 	c.logger.Infof("Rebasing %s on run image %s", style.Symbol(appImage.Name()), style.Symbol(baseImage.Name()))
 	rebaser := &lifecycle.Rebaser{Logger: c.logger, PlatformAPI: build.SupportedPlatformAPIVersions.Latest()}
 	_, err = rebaser.Rebase(appImage, baseImage, nil)
@@ -92,5 +114,9 @@ func (c *Client) Rebase(ctx context.Context, opts RebaseOptions) error {
 	}
 
 	c.logger.Infof("Rebased Image: %s", style.Symbol(appImageIdentifier.String()))
-	return nil
+
+	if signKey == nil {
+		return nil
+	}
+	return c.signImage(ctx, signKey, opts.RepoName, parseDigestFromImageID(appImageIdentifier), nil)
 }
@@ -3,6 +3,8 @@ package pack
 import (
 	"bytes"
 	"context"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/buildpacks/pack/config"
@@ -160,6 +162,52 @@ func testRebase(t *testing.T, when spec.G, it spec.S) {
 				})
 			})
 
+			when("a sign key is provided without publishing", func() {
+				it("returns an error", func() {
+					err := subject.Rebase(context.TODO(), RebaseOptions{
+						RepoName: "some/app",
+						SignKey:  "cosign.key",
+					})
+					h.AssertError(t, err, "signing requires publishing the image")
+				})
+			})
+
+			when("a policy is set", func() {
+				var tmpDir string
+
+				it.Before(func() {
+					var err error
+					tmpDir, err = ioutil.TempDir("", "rebase-policy")
+					h.AssertNil(t, err)
+				})
+
+				it.After(func() {
+					h.AssertNil(t, os.RemoveAll(tmpDir))
+				})
+
+				it("errors for run images not in the allowed run images", func() {
+					subject.policy = readPolicy(t, tmpDir, `[run-images]
+allowed = ["registry.example.com/run/base"]`)
+
+					err := subject.Rebase(context.TODO(), RebaseOptions{
+						RepoName:   "some/app",
+						PullPolicy: config.PullNever,
+					})
+					h.AssertError(t, err, "run image 'some/run' is not in the allowed run images")
+					h.AssertEq(t, fakeAppImage.Base(), "")
+				})
+
+				it("errors when not publishing to the required registry", func() {
+					subject.policy = readPolicy(t, tmpDir, `[publish]
+registry = "registry.example.com"`)
+
+					err := subject.Rebase(context.TODO(), RebaseOptions{
+						RepoName: "some/app",
+					})
+					h.AssertError(t, err, "images must be published to 'registry.example.com'")
+				})
+			})
+
 			when("publish", func() {
 				var (
 					fakeRemoteRunImage *fakes.Image
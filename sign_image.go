@@ -0,0 +1,96 @@
+package pack
+
+import (
+	"context"
+	"crypto/ecdsa"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/image"
+	"github.com/buildpacks/pack/internal/sign"
+	"github.com/buildpacks/pack/internal/style"
+)
+
+// VerifyImageOptions configures the verification of the signature of an image.
+type VerifyImageOptions struct {
+	// Image is the name of the image in a registry.
+	Image string
+
+	// Key is the path to the cosign public key the image must be signed with.
+	Key string
+}
+
+// VerifyImage checks that the image is signed with the key, in the layout cosign pushes signatures in.
+// It returns the digest of the image verified.
+func (c *Client) VerifyImage(ctx context.Context, opts VerifyImageOptions) (name.Digest, error) {
+	ref, err := name.ParseReference(opts.Image, name.WeakValidation)
+	if err != nil {
+		return name.Digest{}, errors.Wrapf(err, "invalid image name %s", style.Symbol(opts.Image))
+	}
+
+	key, err := sign.LoadPublicKey(opts.Key)
+	if err != nil {
+		return name.Digest{}, err
+	}
+
+	digest, err := image.ResolveDigest(ctx, authn.DefaultKeychain, ref)
+	if err != nil {
+		return name.Digest{}, err
+	}
+
+	if err := sign.Verify(ctx, authn.DefaultKeychain, digest, key); err != nil {
+		return name.Digest{}, err
+	}
+	return digest, nil
+}
+
+// loadSignKey returns the private key at path, or nil when no path is given. Signing requires publishing.
+func loadSignKey(path, password string, publish bool) (*ecdsa.PrivateKey, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !publish {
+		return nil, errors.New("signing requires publishing the image")
+	}
+	return sign.LoadPrivateKey(path, []byte(password))
+}
+
+// signImage signs the image published as imageName with key. The digest is the one the image was exported with,
+// rather than the one imageName points to now, which may have been pushed since. The signature is pushed to the
+// repository of imageName and to the repository of each additional tag.
+func (c *Client) signImage(ctx context.Context, key *ecdsa.PrivateKey, imageName, imageDigest string, additionalTags []string) error {
+	ref, err := name.ParseReference(imageName, name.WeakValidation)
+	if err != nil {
+		return err
+	}
+	if imageDigest == "" {
+		return errors.Errorf("the digest of image %s is unknown", style.Symbol(imageName))
+	}
+	digest := ref.Context().Digest(imageDigest)
+
+	repos := []name.Repository{ref.Context()}
+	for _, tag := range additionalTags {
+		tagRef, err := name.ParseReference(tag, name.WeakValidation)
+		if err != nil {
+			return err
+		}
+		repos = append(repos, tagRef.Context())
+	}
+
+	signed := map[string]bool{}
+	for _, repo := range repos {
+		if signed[repo.Name()] {
+			continue
+		}
+		signed[repo.Name()] = true
+
+		sigTag, err := sign.Sign(ctx, authn.DefaultKeychain, repo.Digest(digest.DigestStr()), key)
+		if err != nil {
+			return errors.Wrapf(err, "signing image %s", style.Symbol(imageName))
+		}
+		c.logger.Infof("Pushed signature of %s to %s", style.Symbol(repo.Name()+"@"+digest.DigestStr()), style.Symbol(sigTag.Name()))
+	}
+	return nil
+}
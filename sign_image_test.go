@@ -0,0 +1,172 @@
+package pack
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/logging"
+	"github.com/buildpacks/pack/internal/sign"
+	h "github.com/buildpacks/pack/testhelpers"
+)
+
+func TestSignImage(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "SignImage", testSignImage, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testSignImage(t *testing.T, when spec.G, it spec.S) {
+	var (
+		subject    *Client
+		out        bytes.Buffer
+		server     *httptest.Server
+		tmpDir     string
+		keyPath    string
+		pubKeyPath string
+		imageName  string
+	)
+
+	it.Before(func() {
+		subject = &Client{logger: logging.NewLogWithWriters(&out, &out)}
+		server = httptest.NewServer(registry.New())
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "sign-image-test")
+		h.AssertNil(t, err)
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		h.AssertNil(t, err)
+		der, err := x509.MarshalECPrivateKey(key)
+		h.AssertNil(t, err)
+		keyPath = filepath.Join(tmpDir, "cosign.key")
+		h.AssertNil(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+		pubDer, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		h.AssertNil(t, err)
+		pubKeyPath = filepath.Join(tmpDir, "cosign.pub")
+		h.AssertNil(t, ioutil.WriteFile(pubKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}), 0644))
+
+		imageName = strings.TrimPrefix(server.URL, "http://") + "/some/app:latest"
+		img, err := random.Image(10, 1)
+		h.AssertNil(t, err)
+		ref, err := name.ParseReference(imageName)
+		h.AssertNil(t, err)
+		h.AssertNil(t, remote.Write(ref, img))
+	})
+
+	it.After(func() {
+		server.Close()
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	when("#signImage", func() {
+		var imageDigest string
+
+		it.Before(func() {
+			ref, err := name.ParseReference(imageName)
+			h.AssertNil(t, err)
+			desc, err := remote.Head(ref)
+			h.AssertNil(t, err)
+			imageDigest = desc.Digest.String()
+		})
+
+		it("pushes a signature which verifies with the public key", func() {
+			key, err := loadSignKey(keyPath, "", true)
+			h.AssertNil(t, err)
+			h.AssertNil(t, subject.signImage(context.TODO(), key, imageName, imageDigest, nil))
+			h.AssertContains(t, out.String(), "Pushed signature of")
+
+			digest, err := subject.VerifyImage(context.TODO(), VerifyImageOptions{Image: imageName, Key: pubKeyPath})
+			h.AssertNil(t, err)
+			h.AssertEq(t, digest.Context().Name(), strings.TrimSuffix(imageName, ":latest"))
+			h.AssertEq(t, sign.SignatureTag(digest).TagStr(), strings.Replace(digest.DigestStr(), ":", "-", 1)+".sig")
+		})
+
+		it("signs in the repository of each additional tag", func() {
+			otherName := strings.TrimPrefix(server.URL, "http://") + "/other/app:latest"
+			ref, err := name.ParseReference(imageName)
+			h.AssertNil(t, err)
+			img, err := remote.Image(ref)
+			h.AssertNil(t, err)
+			otherRef, err := name.ParseReference(otherName)
+			h.AssertNil(t, err)
+			h.AssertNil(t, remote.Write(otherRef, img))
+
+			key, err := loadSignKey(keyPath, "", true)
+			h.AssertNil(t, err)
+			h.AssertNil(t, subject.signImage(context.TODO(), key, imageName, imageDigest, []string{otherName, imageName}))
+
+			_, err = subject.VerifyImage(context.TODO(), VerifyImageOptions{Image: otherName, Key: pubKeyPath})
+			h.AssertNil(t, err)
+		})
+
+		it("signs the digest given rather than the one the image name points to", func() {
+			ref, err := name.ParseReference(imageName)
+			h.AssertNil(t, err)
+			other, err := random.Image(10, 1)
+			h.AssertNil(t, err)
+			h.AssertNil(t, remote.Write(ref, other))
+
+			key, err := loadSignKey(keyPath, "", true)
+			h.AssertNil(t, err)
+			h.AssertNil(t, subject.signImage(context.TODO(), key, imageName, imageDigest, nil))
+
+			digest, err := subject.VerifyImage(context.TODO(), VerifyImageOptions{Image: ref.Context().Digest(imageDigest).Name(), Key: pubKeyPath})
+			h.AssertNil(t, err)
+			h.AssertEq(t, digest.DigestStr(), imageDigest)
+
+			_, err = subject.VerifyImage(context.TODO(), VerifyImageOptions{Image: imageName, Key: pubKeyPath})
+			h.AssertError(t, err, "no signatures found for")
+		})
+
+		it("errors without a digest", func() {
+			key, err := loadSignKey(keyPath, "", true)
+			h.AssertNil(t, err)
+			h.AssertError(t, subject.signImage(context.TODO(), key, imageName, "", nil), "the digest of image")
+		})
+	})
+
+	when("#VerifyImage", func() {
+		it("errors when the image is not signed", func() {
+			_, err := subject.VerifyImage(context.TODO(), VerifyImageOptions{Image: imageName, Key: pubKeyPath})
+			h.AssertError(t, err, "no signatures found for")
+		})
+
+		it("errors for an invalid public key", func() {
+			_, err := subject.VerifyImage(context.TODO(), VerifyImageOptions{Image: imageName, Key: keyPath})
+			h.AssertError(t, err, "unsupported PEM type")
+		})
+	})
+
+	when("#loadSignKey", func() {
+		it("returns no key without a path", func() {
+			key, err := loadSignKey("", "", false)
+			h.AssertNil(t, err)
+			h.AssertTrue(t, key == nil)
+		})
+
+		it("errors when the image is not published", func() {
+			_, err := loadSignKey(keyPath, "", false)
+			h.AssertError(t, err, "signing requires publishing the image")
+		})
+	})
+}
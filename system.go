@@ -0,0 +1,140 @@
+package pack
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/ephemeral"
+	"github.com/buildpacks/pack/internal/style"
+)
+
+// PrunedResources lists the resources removed by PruneSystem.
+type PrunedResources struct {
+	// IDs of the containers removed.
+	Containers []string
+
+	// Names of the volumes removed.
+	Volumes []string
+
+	// Names of the images removed, or their IDs when untagged.
+	Images []string
+}
+
+// PruneSystem removes the containers, volumes and images that pack created for builds
+// and which were left behind because pack was killed before it could remove them.
+// Resources of builds that are still running are kept, as are resources created by pack on another host.
+// Debug shell containers are kept along with the volumes they mount.
+// Ephemeral builders are kept for later builds until their base builder is removed or replaced by another image.
+// The resources pruned before an error occurred are returned along with it.
+func (c *Client) PruneSystem(ctx context.Context) (PrunedResources, error) {
+	var pruned PrunedResources
+	labelFilter := filters.NewArgs(filters.Arg("label", ephemeral.Filter()))
+
+	// containers are removed first as they prevent the removal of the volumes they mount
+	ctrs, err := c.docker.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: labelFilter})
+	if err != nil {
+		return pruned, errors.Wrap(err, "listing containers")
+	}
+	for _, ctr := range ctrs {
+		if !ephemeral.Orphaned(ctr.Labels) {
+			continue
+		}
+		if err := c.docker.ContainerRemove(ctx, ctr.ID, types.ContainerRemoveOptions{Force: true}); err != nil {
+			return pruned, errors.Wrapf(err, "removing container %s", style.Symbol(ctr.ID))
+		}
+		pruned.Containers = append(pruned.Containers, ctr.ID)
+	}
+
+	keptVolumes, err := c.keptVolumes(ctx)
+	if err != nil {
+		return pruned, err
+	}
+
+	volumes, err := c.docker.VolumeList(ctx, labelFilter)
+	if err != nil {
+		return pruned, errors.Wrap(err, "listing volumes")
+	}
+	for _, volume := range volumes.Volumes {
+		if !ephemeral.Orphaned(volume.Labels) || keptVolumes[volume.Name] {
+			continue
+		}
+		if err := c.docker.VolumeRemove(ctx, volume.Name, false); err != nil {
+			return pruned, errors.Wrapf(err, "removing volume %s", style.Symbol(volume.Name))
+		}
+		pruned.Volumes = append(pruned.Volumes, volume.Name)
+	}
+
+	images, err := c.docker.ImageList(ctx, types.ImageListOptions{All: true, Filters: labelFilter})
+	if err != nil {
+		return pruned, errors.Wrap(err, "listing images")
+	}
+	for _, img := range images {
+		if !ephemeral.Orphaned(img.Labels) {
+			continue
+		}
+
+		name := img.ID
+		if len(img.RepoTags) > 0 {
+			name = img.RepoTags[0]
+		}
+		if _, err := c.docker.ImageRemove(ctx, img.ID, types.ImageRemoveOptions{Force: true, PruneChildren: true}); err != nil {
+			return pruned, errors.Wrapf(err, "removing image %s", style.Symbol(name))
+		}
+		pruned.Images = append(pruned.Images, name)
+	}
+
+	return pruned, c.pruneStaleBuilders(ctx, &pruned)
+}
+
+// keptVolumes returns the names of the volumes mounted by kept containers, such as debug shells.
+func (c *Client) keptVolumes(ctx context.Context) (map[string]bool, error) {
+	keepFilter := filters.NewArgs(filters.Arg("label", ephemeral.KeepFilter()))
+	ctrs, err := c.docker.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: keepFilter})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing kept containers")
+	}
+
+	volumes := map[string]bool{}
+	for _, ctr := range ctrs {
+		for _, m := range ctr.Mounts {
+			if m.Type == mount.TypeVolume {
+				volumes[m.Name] = true
+			}
+		}
+	}
+	return volumes, nil
+}
+
+// pruneStaleBuilders removes the ephemeral builders whose base builder has since been removed or replaced by another image.
+func (c *Client) pruneStaleBuilders(ctx context.Context, pruned *PrunedResources) error {
+	builderFilter := filters.NewArgs(filters.Arg("label", baseBuilderIDLabel))
+	builders, err := c.docker.ImageList(ctx, types.ImageListOptions{Filters: builderFilter})
+	if err != nil {
+		return errors.Wrap(err, "listing ephemeral builders")
+	}
+
+	for _, img := range builders {
+		base, _, err := c.docker.ImageInspectWithRaw(ctx, img.Labels[baseBuilderNameLabel])
+		if err != nil && !dockerClient.IsErrNotFound(err) {
+			return errors.Wrapf(err, "inspecting builder %s", style.Symbol(img.Labels[baseBuilderNameLabel]))
+		}
+		if err == nil && base.ID == img.Labels[baseBuilderIDLabel] {
+			continue
+		}
+
+		name := img.ID
+		if len(img.RepoTags) > 0 {
+			name = img.RepoTags[0]
+		}
+		if _, err := c.docker.ImageRemove(ctx, img.ID, types.ImageRemoveOptions{Force: true, PruneChildren: true}); err != nil {
+			return errors.Wrapf(err, "removing image %s", style.Symbol(name))
+		}
+		pruned.Images = append(pruned.Images, name)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package pack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"github.com/golang/mock/gomock"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/pack/internal/ephemeral"
+	ilogging "github.com/buildpacks/pack/internal/logging"
+	h "github.com/buildpacks/pack/testhelpers"
+	"github.com/buildpacks/pack/testmocks"
+)
+
+func TestSystem(t *testing.T) {
+	color.Disable(true)
+	defer color.Disable(false)
+	spec.Run(t, "System", testSystem, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testSystem(t *testing.T, when spec.G, it spec.S) {
+	var (
+		subject        *Client
+		mockController *gomock.Controller
+		mockDocker     *testmocks.MockCommonAPIClient
+		out            bytes.Buffer
+		orphaned       map[string]string
+		running        map[string]string
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		mockDocker = testmocks.NewMockCommonAPIClient(mockController)
+
+		var err error
+		subject, err = NewClient(WithLogger(ilogging.NewLogWithWriters(&out, &out)), WithDockerClient(mockDocker))
+		h.AssertNil(t, err)
+
+		hostname, err := os.Hostname()
+		h.AssertNil(t, err)
+		orphaned = map[string]string{ephemeral.Label: "true", ephemeral.OwnerLabel: fmt.Sprintf("%d@%s", 999999999, hostname)}
+		running = ephemeral.Labels()
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	expectBuilders := func(builders ...types.ImageSummary) {
+		mockDocker.EXPECT().ImageList(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, opts types.ImageListOptions) ([]types.ImageSummary, error) {
+				h.AssertEq(t, opts.Filters.Get("label"), []string{baseBuilderIDLabel})
+				return builders, nil
+			})
+	}
+
+	expectKept := func(ctrs ...types.Container) {
+		mockDocker.EXPECT().ContainerList(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, opts types.ContainerListOptions) ([]types.Container, error) {
+				h.AssertEq(t, opts.Filters.Get("label"), []string{ephemeral.KeepFilter()})
+				return ctrs, nil
+			})
+	}
+
+	when("#PruneSystem", func() {
+		it("removes the resources of builds which are no longer running", func() {
+			mockDocker.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return([]types.Container{
+				{ID: "orphaned-container", Labels: orphaned},
+				{ID: "running-container", Labels: running},
+			}, nil)
+			mockDocker.EXPECT().ContainerRemove(gomock.Any(), "orphaned-container", types.ContainerRemoveOptions{Force: true}).Return(nil)
+			expectKept()
+
+			mockDocker.EXPECT().VolumeList(gomock.Any(), gomock.Any()).Return(volume.VolumeListOKBody{Volumes: []*types.Volume{
+				{Name: "orphaned-volume", Labels: orphaned},
+				{Name: "running-volume", Labels: running},
+			}}, nil)
+			mockDocker.EXPECT().VolumeRemove(gomock.Any(), "orphaned-volume", false).Return(nil)
+
+			mockDocker.EXPECT().ImageList(gomock.Any(), gomock.Any()).Return([]types.ImageSummary{
+				{ID: "sha256:orphaned", RepoTags: []string{"pack.local/builder/orphaned:latest"}, Labels: orphaned},
+				{ID: "sha256:untagged", Labels: orphaned},
+				{ID: "sha256:running", RepoTags: []string{"pack.local/builder/running:latest"}, Labels: running},
+			}, nil)
+			mockDocker.EXPECT().ImageRemove(gomock.Any(), "sha256:orphaned", types.ImageRemoveOptions{Force: true, PruneChildren: true}).Return(nil, nil)
+			mockDocker.EXPECT().ImageRemove(gomock.Any(), "sha256:untagged", types.ImageRemoveOptions{Force: true, PruneChildren: true}).Return(nil, nil)
+			expectBuilders()
+
+			pruned, err := subject.PruneSystem(context.TODO())
+			h.AssertNil(t, err)
+			h.AssertEq(t, pruned, PrunedResources{
+				Containers: []string{"orphaned-container"},
+				Volumes:    []string{"orphaned-volume"},
+				Images:     []string{"pack.local/builder/orphaned:latest", "sha256:untagged"},
+			})
+		})
+
+		it("only lists ephemeral resources", func() {
+			labelFilter := gomock.AssignableToTypeOf(types.ContainerListOptions{})
+			mockDocker.EXPECT().ContainerList(gomock.Any(), labelFilter).DoAndReturn(
+				func(_ context.Context, opts types.ContainerListOptions) ([]types.Container, error) {
+					h.AssertEq(t, opts.All, true)
+					h.AssertEq(t, opts.Filters.Get("label"), []string{ephemeral.Filter()})
+					return nil, nil
+				})
+			expectKept()
+			mockDocker.EXPECT().VolumeList(gomock.Any(), gomock.Any()).Return(volume.VolumeListOKBody{}, nil)
+			mockDocker.EXPECT().ImageList(gomock.Any(), gomock.Any()).Return(nil, nil)
+			expectBuilders()
+
+			pruned, err := subject.PruneSystem(context.TODO())
+			h.AssertNil(t, err)
+			h.AssertEq(t, pruned, PrunedResources{})
+		})
+
+		it("keeps the volumes mounted by kept containers", func() {
+			mockDocker.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return(nil, nil)
+			expectKept(types.Container{ID: "debug-container", Mounts: []types.MountPoint{
+				{Type: mount.TypeVolume, Name: "debug-volume"},
+				{Type: mount.TypeBind, Source: "/some/path"},
+			}})
+			mockDocker.EXPECT().VolumeList(gomock.Any(), gomock.Any()).Return(volume.VolumeListOKBody{Volumes: []*types.Volume{
+				{Name: "orphaned-volume", Labels: orphaned},
+				{Name: "debug-volume", Labels: orphaned},
+			}}, nil)
+			mockDocker.EXPECT().VolumeRemove(gomock.Any(), "orphaned-volume", false).Return(nil)
+			mockDocker.EXPECT().ImageList(gomock.Any(), gomock.Any()).Return(nil, nil)
+			expectBuilders()
+
+			pruned, err := subject.PruneSystem(context.TODO())
+			h.AssertNil(t, err)
+			h.AssertEq(t, pruned.Volumes, []string{"orphaned-volume"})
+		})
+
+		when("there are ephemeral builders", func() {
+			builderLabels := func(id string) map[string]string {
+				return map[string]string{baseBuilderNameLabel: "some/builder", baseBuilderIDLabel: id}
+			}
+
+			it.Before(func() {
+				mockDocker.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return(nil, nil)
+				expectKept()
+				mockDocker.EXPECT().VolumeList(gomock.Any(), gomock.Any()).Return(volume.VolumeListOKBody{}, nil)
+				mockDocker.EXPECT().ImageList(gomock.Any(), gomock.Any()).Return(nil, nil)
+			})
+
+			it("removes the builders whose base builder has been replaced", func() {
+				expectBuilders(
+					types.ImageSummary{ID: "sha256:current", RepoTags: []string{"pack.local/builder/current:latest"}, Labels: builderLabels("sha256:base")},
+					types.ImageSummary{ID: "sha256:stale", RepoTags: []string{"pack.local/builder/stale:latest"}, Labels: builderLabels("sha256:old-base")},
+				)
+				mockDocker.EXPECT().ImageInspectWithRaw(gomock.Any(), "some/builder").Return(types.ImageInspect{ID: "sha256:base"}, nil, nil).Times(2)
+				mockDocker.EXPECT().ImageRemove(gomock.Any(), "sha256:stale", types.ImageRemoveOptions{Force: true, PruneChildren: true}).Return(nil, nil)
+
+				pruned, err := subject.PruneSystem(context.TODO())
+				h.AssertNil(t, err)
+				h.AssertEq(t, pruned.Images, []string{"pack.local/builder/stale:latest"})
+			})
+
+			it("removes the builders whose base builder has been removed", func() {
+				expectBuilders(
+					types.ImageSummary{ID: "sha256:stale", RepoTags: []string{"pack.local/builder/stale:latest"}, Labels: builderLabels("sha256:base")},
+				)
+				mockDocker.EXPECT().ImageInspectWithRaw(gomock.Any(), "some/builder").Return(types.ImageInspect{}, nil, errdefs.NotFound(errors.New("no such image")))
+				mockDocker.EXPECT().ImageRemove(gomock.Any(), "sha256:stale", gomock.Any()).Return(nil, nil)
+
+				pruned, err := subject.PruneSystem(context.TODO())
+				h.AssertNil(t, err)
+				h.AssertEq(t, pruned.Images, []string{"pack.local/builder/stale:latest"})
+			})
+
+			it("errors when the base builder cannot be inspected", func() {
+				expectBuilders(
+					types.ImageSummary{ID: "sha256:current", Labels: builderLabels("sha256:base")},
+				)
+				mockDocker.EXPECT().ImageInspectWithRaw(gomock.Any(), "some/builder").Return(types.ImageInspect{}, nil, errors.New("daemon unavailable"))
+
+				_, err := subject.PruneSystem(context.TODO())
+				h.AssertError(t, err, "inspecting builder 'some/builder': daemon unavailable")
+			})
+		})
+
+		it("returns the resources removed before an error", func() {
+			mockDocker.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return([]types.Container{
+				{ID: "orphaned-container", Labels: orphaned},
+			}, nil)
+			mockDocker.EXPECT().ContainerRemove(gomock.Any(), "orphaned-container", gomock.Any()).Return(nil)
+			expectKept()
+			mockDocker.EXPECT().VolumeList(gomock.Any(), gomock.Any()).Return(volume.VolumeListOKBody{Volumes: []*types.Volume{
+				{Name: "orphaned-volume", Labels: orphaned},
+			}}, nil)
+			mockDocker.EXPECT().VolumeRemove(gomock.Any(), "orphaned-volume", false).Return(errors.New("volume is in use"))
+
+			pruned, err := subject.PruneSystem(context.TODO())
+			h.AssertError(t, err, "removing volume 'orphaned-volume': volume is in use")
+			h.AssertEq(t, pruned.Containers, []string{"orphaned-container"})
+		})
+	})
+}
@@ -0,0 +1,69 @@
+package pack
+
+import (
+	"context"
+	"strings"
+
+	"github.com/buildpacks/imgutil"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/pack/internal/sign"
+	"github.com/buildpacks/pack/internal/style"
+)
+
+// BuilderTrust records which images of a trusted builder are trusted.
+type BuilderTrust struct {
+	// Digests are the digests of the trusted builder images. When set, any other image is untrusted.
+	Digests []string
+
+	// SigningKey is the path to the cosign public key trusted builder images are signed with.
+	// When set, images without a signature by this key are untrusted.
+	SigningKey string
+}
+
+func (t BuilderTrust) pinned() bool {
+	return len(t.Digests) > 0 || t.SigningKey != ""
+}
+
+// isBuilderTrusted returns whether the lifecycle may run in the builder image fetched for builderRef as a
+// trusted builder. When the trust of the builder is pinned to digests or a signing key, the digest the image
+// resolved to must be trusted, regardless of the name the builder was given by.
+func (c *Client) isBuilderTrusted(ctx context.Context, opts BuildOptions, builderRef name.Reference, builderImage imgutil.Image) (bool, error) {
+	if !opts.TrustBuilder || !opts.BuilderTrust.pinned() {
+		return opts.TrustBuilder, nil
+	}
+
+	digests, err := c.imageDigests(ctx, builderImage)()
+	if err != nil {
+		return false, errors.Wrapf(err, "resolving digest of builder %s", style.Symbol(builderRef.String()))
+	}
+
+	for _, digest := range digests {
+		if contains(opts.BuilderTrust.Digests, digest) {
+			c.logger.Debugf("Builder %s is trusted by digest %s", style.Symbol(builderRef.String()), style.Symbol(digest))
+			return true, nil
+		}
+	}
+
+	if opts.BuilderTrust.SigningKey != "" {
+		key, err := sign.LoadPublicKey(opts.BuilderTrust.SigningKey)
+		if err != nil {
+			return false, err
+		}
+		for _, digest := range digests {
+			if err := sign.Verify(ctx, authn.DefaultKeychain, builderRef.Context().Digest(digest), key); err == nil {
+				c.logger.Debugf("Builder %s is trusted by its signature on %s", style.Symbol(builderRef.String()), style.Symbol(digest))
+				return true, nil
+			}
+		}
+	}
+
+	if len(digests) == 0 {
+		c.logger.Warnf("Not trusting builder %s: its digest could not be determined", style.Symbol(builderRef.String()))
+	} else {
+		c.logger.Warnf("Not trusting builder %s: it resolved to %s, which is not trusted", style.Symbol(builderRef.String()), style.Symbol(strings.Join(digests, ", ")))
+	}
+	return false, nil
+}